@@ -6,7 +6,9 @@ require (
 	github.com/antonfisher/nested-logrus-formatter v1.0.3
 	github.com/edwarnicke/exechelper v1.0.1
 	github.com/edwarnicke/grpcfd v0.0.0-20200920223154-d5b6e1f19bd0
+	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/golang/protobuf v1.4.2
+	github.com/google/uuid v1.1.1
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/networkservicemesh/api v0.0.0-20200915182332-e5aee3ba99ef
 	github.com/networkservicemesh/sdk v0.0.0-20200921122707-638c8c26fa46
@@ -20,6 +22,7 @@ require (
 	go.ligato.io/vpp-agent/v3 v3.1.0
 	golang.org/x/sys v0.0.0-20200916084744-dbad9cb7cb7a
 	golang.org/x/text v0.3.3 // indirect
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.32.0
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
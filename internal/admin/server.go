@@ -0,0 +1,44 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin - http server for the forwarder's admin api (metrics, diagnostics) kept off the main grpc server
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Serve - serves handler on ln until ctx is done.  Returns a channel that receives any error and is closed once
+// serving stops.
+func Serve(ctx context.Context, ln net.Listener, handler http.Handler) <-chan error {
+	errCh := make(chan error, 1)
+	server := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		defer close(errCh)
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			errCh <- errors.WithStack(err)
+		}
+	}()
+	return errCh
+}
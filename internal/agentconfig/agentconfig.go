@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agentconfig - parses vpp-agent configs written by operators, in YAML or JSON with the field names of the
+// json mapping of configurator.Config, e.g.
+//
+//	vppConfig:
+//	  routes:
+//	    - dstNetwork: 10.0.0.0/8
+//	      nextHopAddr: 192.168.0.1
+package agentconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"gopkg.in/yaml.v2"
+)
+
+// Parse - returns the vpp-agent config in data, YAML or JSON
+func Parse(data []byte) (*configurator.Config, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "invalid vpp-agent config")
+	}
+	conf := &configurator.Config{}
+	if doc == nil {
+		return conf, nil
+	}
+	jsonData, err := json.Marshal(jsonCompatible(doc))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid vpp-agent config")
+	}
+	if err = jsonpb.Unmarshal(bytes.NewReader(jsonData), conf); err != nil {
+		return nil, errors.Wrap(err, "invalid vpp-agent config")
+	}
+	return conf, nil
+}
+
+// jsonCompatible - returns v with the maps yaml decodes into, keyed by interface{}, keyed by string instead
+func jsonCompatible(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		rv := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			rv[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return rv
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonCompatible(item)
+		}
+	}
+	return v
+}
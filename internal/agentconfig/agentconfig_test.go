@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agentconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/agentconfig"
+)
+
+func TestParse(t *testing.T) {
+	for name, data := range map[string]string{
+		"yaml": "vppConfig:\n  routes:\n    - dstNetwork: 10.0.0.0/8\n      nextHopAddr: 192.168.0.1\n      weight: 2\n",
+		"json": `{"vppConfig": {"routes": [{"dstNetwork": "10.0.0.0/8", "nextHopAddr": "192.168.0.1", "weight": 2}]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf, err := agentconfig.Parse([]byte(data))
+			require.NoError(t, err)
+			routes := conf.GetVppConfig().GetRoutes()
+			require.Len(t, routes, 1)
+			require.Equal(t, "10.0.0.0/8", routes[0].GetDstNetwork())
+			require.Equal(t, "192.168.0.1", routes[0].GetNextHopAddr())
+			require.Equal(t, uint32(2), routes[0].GetWeight())
+		})
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	conf, err := agentconfig.Parse(nil)
+	require.NoError(t, err)
+	require.Nil(t, conf.GetVppConfig())
+}
+
+func TestParseInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"syntax":        "vppConfig: [",
+		"unknown field": "vppConfig:\n  routez: []\n",
+		"wrong type":    "vppConfig:\n  routes: 5\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := agentconfig.Parse([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+)
+
+type auditCredentials struct {
+	credentials.TransportCredentials
+	logger *Logger
+}
+
+// TransportCredentials - wraps creds so that every accepted connection is audited by logger
+func TransportCredentials(creds credentials.TransportCredentials, logger *Logger) credentials.TransportCredentials {
+	return &auditCredentials{
+		TransportCredentials: creds,
+		logger:               logger,
+	}
+}
+
+func (a *auditCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, authInfo, err := a.TransportCredentials.ServerHandshake(rawConn)
+	record := &Record{
+		Event:      "connection",
+		RemoteAddr: rawConn.RemoteAddr().String(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.SpiffeID, record.Serial = peerIdentity(authInfo)
+	}
+	a.logger.Write(context.Background(), record)
+	return conn, authInfo, err
+}
+
+func (a *auditCredentials) Clone() credentials.TransportCredentials {
+	return TransportCredentials(a.TransportCredentials.Clone(), a.logger)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit - records the spiffe id and certificate serial of the peer of every new grpc connection and every
+// Request/Close, both as structured log entries and, optionally, as json lines in an append-only audit file
+package audit
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Record - a single audit record
+type Record struct {
+	Time               time.Time `json:"time"`
+	Event              string    `json:"event"`
+	RemoteAddr         string    `json:"remote_addr,omitempty"`
+	SpiffeID           string    `json:"spiffe_id,omitempty"`
+	Serial             string    `json:"serial,omitempty"`
+	ConnectionID       string    `json:"connection_id,omitempty"`
+	ForwardedBy        []string  `json:"forwarded_by,omitempty"`
+	ClaimedForwardedBy []string  `json:"claimed_forwarded_by,omitempty"`
+	Error              string    `json:"error,omitempty"`
+}
+
+// Logger - writes audit records, a nil *Logger discards them
+type Logger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewLogger - returns a Logger appending to the file at filename, or only logging if filename is empty
+func NewLogger(filename string) (*Logger, error) {
+	l := &Logger{}
+	if filename == "" {
+		return l, nil
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open audit file %s", filename)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Write - logs record and appends it to the audit file if there is one
+func (l *Logger) Write(ctx context.Context, record *Record) {
+	if l == nil {
+		return
+	}
+	record.Time = time.Now()
+	fields := logrus.Fields{
+		"audit":         record.Event,
+		"remote_addr":   record.RemoteAddr,
+		"spiffe_id":     record.SpiffeID,
+		"serial":        record.Serial,
+		"connection_id": record.ConnectionID,
+	}
+	if len(record.ClaimedForwardedBy) > 0 {
+		// Forwarded by metadata of a peer not trusted with it, possibly spoofed
+		fields["claimed_forwarded_by"] = record.ClaimedForwardedBy
+	}
+	log.Entry(ctx).WithFields(fields).Info("peer audit")
+	if l.file == nil {
+		return
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Entry(ctx).Errorf("failed to marshal audit record: %+v", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		log.Entry(ctx).Errorf("failed to write audit record: %+v", err)
+	}
+}
+
+// peerIdentity - returns the spiffe id and certificate serial of the peer described by authInfo
+func peerIdentity(authInfo credentials.AuthInfo) (spiffeID, serial string) {
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return "", ""
+	}
+	return certIdentity(tlsInfo.State.PeerCertificates[0])
+}
+
+func certIdentity(cert *x509.Certificate) (spiffeID, serial string) {
+	serial = cert.SerialNumber.String()
+	if id, err := x509svid.IDFromCert(cert); err == nil {
+		spiffeID = id.String()
+	}
+	return spiffeID, serial
+}
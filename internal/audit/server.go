@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarded"
+)
+
+type auditServer struct {
+	logger *Logger
+	trust  forwarded.Trust
+}
+
+// NewServer - returns a server chain element auditing the peer of every Request and Close, and the peers it was
+// forwarded by according to trust
+func NewServer(logger *Logger, trust forwarded.Trust) networkservice.NetworkServiceServer {
+	return &auditServer{logger: logger, trust: trust}
+}
+
+func (a *auditServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	a.logger.Write(ctx, a.newRecord(ctx, "request", request.GetConnection().GetId(), err))
+	return conn, err
+}
+
+func (a *auditServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+	a.logger.Write(ctx, a.newRecord(ctx, "close", conn.GetId(), err))
+	return rv, err
+}
+
+func (a *auditServer) newRecord(ctx context.Context, event, connectionID string, err error) *Record {
+	record := &Record{
+		Event:        event,
+		ConnectionID: connectionID,
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		record.RemoteAddr = p.Addr.String()
+		record.SpiffeID, record.Serial = peerIdentity(p.AuthInfo)
+	}
+	record.ForwardedBy = a.trust.Peers(ctx)
+	record.ClaimedForwardedBy = a.trust.Claimed(ctx)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/audit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarded"
+)
+
+// failingServer - last chain element failing every Request
+type failingServer struct{}
+
+func (failingServer) Request(context.Context, *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return nil, errors.New("no endpoint")
+}
+
+func (failingServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// peerContext - returns a context with a tls peer presenting a certificate of spiffeID with serial
+func peerContext(spiffeID string, serial int64) context.Context {
+	id, _ := url.Parse(spiffeID)
+	cert := &x509.Certificate{SerialNumber: big.NewInt(serial), URIs: []*url.URL{id}}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+}
+
+// records - returns the records of the audit file at filename
+func records(t *testing.T, filename string) []*audit.Record {
+	file, err := os.Open(filename)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	var rv []*audit.Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &audit.Record{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+		rv = append(rv, record)
+	}
+	require.NoError(t, scanner.Err())
+	return rv
+}
+
+func newLogger(t *testing.T) (logger *audit.Logger, filename string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(t, err)
+	filename = filepath.Join(dir, "audit.jsonl")
+	logger, err = audit.NewLogger(filename)
+	require.NoError(t, err)
+	return logger, filename, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestRequestAndCloseAudited(t *testing.T) {
+	logger, filename, cleanup := newLogger(t)
+	defer cleanup()
+	server := chain.NewNetworkServiceServer(audit.NewServer(logger, forwarded.NewTrust(nil)))
+	ctx := peerContext("spiffe://example.org/nsc", 42)
+
+	conn, err := server.Request(ctx, &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: "conn-1"}})
+	require.NoError(t, err)
+	_, err = server.Close(ctx, conn)
+	require.NoError(t, err)
+
+	got := records(t, filename)
+	require.Len(t, got, 2)
+	for i, event := range []string{"request", "close"} {
+		require.Equal(t, event, got[i].Event)
+		require.Equal(t, "conn-1", got[i].ConnectionID)
+		require.Equal(t, "spiffe://example.org/nsc", got[i].SpiffeID)
+		require.Equal(t, "42", got[i].Serial)
+		require.Equal(t, "10.0.0.1:5000", got[i].RemoteAddr)
+		require.False(t, got[i].Time.IsZero())
+	}
+}
+
+func TestFailedRequestAudited(t *testing.T) {
+	logger, filename, cleanup := newLogger(t)
+	defer cleanup()
+	server := chain.NewNetworkServiceServer(audit.NewServer(logger, forwarded.NewTrust(nil)), failingServer{})
+
+	_, err := server.Request(peerContext("spiffe://example.org/nsc", 42), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-1"},
+	})
+	require.Error(t, err)
+	got := records(t, filename)
+	require.Len(t, got, 1)
+	require.Contains(t, got[0].Error, "no endpoint")
+}
+
+func TestAppendsToAuditFile(t *testing.T) {
+	logger, filename, cleanup := newLogger(t)
+	defer cleanup()
+	logger.Write(context.Background(), &audit.Record{Event: "connection"})
+
+	// A restarted forwarder appends to the records of the previous one
+	logger, err := audit.NewLogger(filename)
+	require.NoError(t, err)
+	logger.Write(context.Background(), &audit.Record{Event: "connection"})
+	require.Len(t, records(t, filename), 2)
+}
+
+func TestNilLoggerDiscards(t *testing.T) {
+	var logger *audit.Logger
+	logger.Write(context.Background(), &audit.Record{Event: "connection"})
+}
+
+func TestForwardedByAudited(t *testing.T) {
+	logger, filename, cleanup := newLogger(t)
+	defer cleanup()
+	server := chain.NewNetworkServiceServer(audit.NewServer(logger, forwarded.NewTrust([]string{"spiffe://example.org/nsmgr"})))
+	md := metadata.Pairs(forwarded.Header, "spiffe://example.org/forwarder-a")
+
+	_, err := server.Request(metadata.NewIncomingContext(peerContext("spiffe://example.org/nsmgr", 1), md), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-1"},
+	})
+	require.NoError(t, err)
+	_, err = server.Request(metadata.NewIncomingContext(peerContext("spiffe://example.org/nsc", 2), md), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-2"},
+	})
+	require.NoError(t, err)
+
+	got := records(t, filename)
+	require.Len(t, got, 2)
+	require.Equal(t, []string{"spiffe://example.org/forwarder-a", "spiffe://example.org/nsmgr"}, got[0].ForwardedBy)
+	require.Empty(t, got[0].ClaimedForwardedBy)
+	require.Equal(t, []string{"spiffe://example.org/nsc"}, got[1].ForwardedBy)
+	require.Equal(t, []string{"spiffe://example.org/forwarder-a"}, got[1].ClaimedForwardedBy)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backpressure - tracks the depth of the vpp-agent txn queue and, while it is deep, turns away new Requests
+// with a retryable status and a suggested backoff, so that refreshes of existing connections keep their latency
+// during Request storms.  With a Concurrency set the txns beyond it wait, and are started by the priority class of
+// their connection (the "priority" label, high, normal or low), refreshes of existing connections before new ones.
+package backpressure
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// Queue - the vpp-agent txns in flight or waiting to be, at most Concurrency of them (any number if 0) in flight
+type Queue struct {
+	Concurrency int
+	depth       int64
+
+	mu      sync.Mutex
+	running int
+	waiting waiters
+	seq     uint64
+}
+
+// UnaryClientInterceptor - returns an interceptor counting the vpp-agent calls in flight in q, and holding back
+// those beyond its Concurrency by priority
+func (q *Queue) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		metrics.Int("vppagent_txn_queue_depth").Set(atomic.AddInt64(&q.depth, 1))
+		defer func() {
+			metrics.Int("vppagent_txn_queue_depth").Set(atomic.AddInt64(&q.depth, -1))
+		}()
+		if err := q.acquire(ctx, rankFrom(ctx)); err != nil {
+			return err
+		}
+		defer q.release()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Depth - returns the number of vpp-agent calls in flight or waiting
+func (q *Queue) Depth() int64 {
+	return atomic.LoadInt64(&q.depth)
+}
+
+type backpressureServer struct {
+	queue     *Queue
+	threshold int64
+	backoff   time.Duration
+	known     func(connID string) bool
+}
+
+// NewServer - returns a server chain element rejecting Requests for connections not known to known while more than
+// threshold vpp-agent calls are in flight in queue, suggesting a backoff of backoff per threshold calls in flight.
+// A threshold of 0 disables it.
+func NewServer(queue *Queue, threshold int, backoff time.Duration, known func(connID string) bool) networkservice.NetworkServiceServer {
+	return &backpressureServer{
+		queue:     queue,
+		threshold: int64(threshold),
+		backoff:   backoff,
+		known:     known,
+	}
+}
+
+func (b *backpressureServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	depth := b.queue.Depth()
+	refresh := b.known(request.GetConnection().GetId())
+	if b.threshold == 0 || depth <= b.threshold || refresh {
+		ctx = withRank(ctx, request.GetConnection(), refresh)
+		return next.Server(ctx).Request(ctx, request)
+	}
+	metrics.Int("backpressure_rejected_requests").Add(1)
+	backoff := b.backoff * time.Duration((depth+b.threshold-1)/b.threshold)
+	log.Entry(ctx).Warnf("%d vpp-agent txns in flight, asking for a retry of new connection %s in %s",
+		depth, request.GetConnection().GetId(), backoff)
+	st, err := status.Newf(codes.Unavailable, "forwarder is busy with %d vpp-agent txns, retry in %s", depth, backoff).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(backoff)})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "forwarder is busy with %d vpp-agent txns, retry in %s", depth, backoff)
+	}
+	return nil, st.Err()
+}
+
+func (b *backpressureServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	ctx = withRank(ctx, conn, true)
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backpressure_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/backpressure"
+)
+
+// hold - starts n vpp-agent calls through queue, blocking until the returned func is called
+func hold(t *testing.T, queue *backpressure.Queue, n int) (release func()) {
+	unblock := make(chan struct{})
+	var wg sync.WaitGroup
+	interceptor := queue.UnaryClientInterceptor()
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update", nil, nil, nil,
+				func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+					<-unblock
+					return nil
+				})
+		}()
+	}
+	require.Eventually(t, func() bool { return queue.Depth() == int64(n) }, time.Second, 10*time.Millisecond)
+	return func() {
+		close(unblock)
+		wg.Wait()
+	}
+}
+
+func request(id string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: id}}
+}
+
+func TestRejectsNewRequestsWhileBusy(t *testing.T) {
+	queue := &backpressure.Queue{}
+	release := hold(t, queue, 3)
+	server := chain.NewNetworkServiceServer(backpressure.NewServer(queue, 2, time.Second, func(connID string) bool {
+		return connID == "known"
+	}))
+
+	_, err := server.Request(context.Background(), request("new"))
+	st := status.Convert(err)
+	require.Equal(t, codes.Unavailable, st.Code())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	delay, err := ptypes.Duration(retry.GetRetryDelay())
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, delay, "a backoff per threshold txns in flight")
+
+	_, err = server.Request(context.Background(), request("known"))
+	require.NoError(t, err, "refreshes are let through")
+
+	release()
+	require.Equal(t, int64(0), queue.Depth())
+	_, err = server.Request(context.Background(), request("new"))
+	require.NoError(t, err)
+}
+
+func TestDisabled(t *testing.T) {
+	queue := &backpressure.Queue{}
+	release := hold(t, queue, 3)
+	defer release()
+	server := chain.NewNetworkServiceServer(backpressure.NewServer(queue, 0, time.Second, func(string) bool { return false }))
+	_, err := server.Request(context.Background(), request("new"))
+	require.NoError(t, err)
+}
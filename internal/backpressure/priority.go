@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backpressure
+
+import (
+	"container/heap"
+	"context"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+)
+
+// PriorityLabel - the connection label naming its priority class, High, Low or, if neither, normal
+const PriorityLabel = "priority"
+
+// Priority classes
+const (
+	High = "high"
+	Low  = "low"
+)
+
+type rankKey struct{}
+
+// withRank - returns ctx ranking the vpp-agent txns made for conn by its priority class, refreshes of existing
+// connections above new ones of the same class
+func withRank(ctx context.Context, conn *networkservice.Connection, refresh bool) context.Context {
+	rank := 2
+	switch conn.GetLabels()[PriorityLabel] {
+	case High:
+		rank = 4
+	case Low:
+		rank = 0
+	}
+	if refresh {
+		rank++
+	}
+	return context.WithValue(ctx, rankKey{}, rank)
+}
+
+// rankFrom - returns the rank of the txns made with ctx, those made for no connection rank as normal refreshes
+func rankFrom(ctx context.Context) int {
+	if rank, ok := ctx.Value(rankKey{}).(int); ok {
+		return rank
+	}
+	return 3
+}
+
+type waiter struct {
+	rank  int
+	seq   uint64
+	index int
+	ready chan struct{}
+}
+
+// waiters - a heap of waiters, highest rank then earliest first
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+
+func (w waiters) Less(i, j int) bool {
+	if w[i].rank != w[j].rank {
+		return w[i].rank > w[j].rank
+	}
+	return w[i].seq < w[j].seq
+}
+
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+
+func (w *waiters) Push(x interface{}) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+
+func (w *waiters) Pop() interface{} {
+	old := *w
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*w = old[:len(old)-1]
+	return item
+}
+
+// acquire - waits, unless ctx is done first, for q to have room for one more txn of rank
+func (q *Queue) acquire(ctx context.Context, rank int) error {
+	q.mu.Lock()
+	if q.Concurrency == 0 || (q.running < q.Concurrency && q.waiting.Len() == 0) {
+		q.running++
+		q.mu.Unlock()
+		return nil
+	}
+	q.seq++
+	w := &waiter{rank: rank, seq: q.seq, ready: make(chan struct{})}
+	heap.Push(&q.waiting, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	q.mu.Lock()
+	if w.index >= 0 {
+		heap.Remove(&q.waiting, w.index)
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+	q.mu.Unlock()
+	// Room was made for w as ctx was done, pass it on
+	q.release()
+	return ctx.Err()
+}
+
+// release - hands the room of a finished txn to the highest ranked waiting one
+func (q *Queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.waiting.Len() > 0 {
+		close(heap.Pop(&q.waiting).(*waiter).ready)
+		return
+	}
+	q.running--
+}
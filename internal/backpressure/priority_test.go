@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backpressure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank(t *testing.T) {
+	conn := func(priority string) *networkservice.Connection {
+		return &networkservice.Connection{Labels: map[string]string{PriorityLabel: priority}}
+	}
+	require.Equal(t, 5, rankFrom(withRank(context.Background(), conn(High), true)))
+	require.Equal(t, 4, rankFrom(withRank(context.Background(), conn(High), false)))
+	require.Equal(t, 3, rankFrom(withRank(context.Background(), conn(""), true)))
+	require.Equal(t, 2, rankFrom(withRank(context.Background(), &networkservice.Connection{}, false)))
+	require.Equal(t, 1, rankFrom(withRank(context.Background(), conn(Low), true)))
+	require.Equal(t, 0, rankFrom(withRank(context.Background(), conn(Low), false)))
+	require.Equal(t, 3, rankFrom(context.Background()), "txns made for no connection did not rank as normal refreshes")
+}
+
+// waitFor - returns once q has n waiters
+func waitFor(t *testing.T, q *Queue, n int) {
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.waiting.Len() == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestAcquireByRank(t *testing.T) {
+	q := &Queue{Concurrency: 1}
+	require.NoError(t, q.acquire(context.Background(), 0))
+
+	var mu sync.Mutex
+	var started []string
+	var wg sync.WaitGroup
+	for i, w := range []struct {
+		name string
+		rank int
+	}{
+		{"low", 0},
+		{"normal-1", 2},
+		{"high", 4},
+		{"normal-2", 2},
+		{"refresh", 3},
+	} {
+		wg.Add(1)
+		go func(name string, rank int) {
+			defer wg.Done()
+			if err := q.acquire(context.Background(), rank); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			started = append(started, name)
+			mu.Unlock()
+			q.release()
+		}(w.name, w.rank)
+		waitFor(t, q, i+1)
+	}
+	q.release()
+	wg.Wait()
+	require.Equal(t, []string{"high", "refresh", "normal-1", "normal-2", "low"}, started)
+	require.Equal(t, 0, q.running)
+}
+
+func TestAcquireCancelled(t *testing.T) {
+	q := &Queue{Concurrency: 1}
+	require.NoError(t, q.acquire(context.Background(), 2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- q.acquire(ctx, 4)
+	}()
+	waitFor(t, q, 1)
+	cancel()
+	require.Equal(t, context.Canceled, <-errCh)
+	waitFor(t, q, 0)
+
+	q.release()
+	require.Equal(t, 0, q.running, "the room of the cancelled waiter was not given back")
+	require.NoError(t, q.acquire(context.Background(), 0))
+}
+
+func TestAcquireUnlimited(t *testing.T) {
+	q := &Queue{}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.acquire(context.Background(), 0))
+	}
+	require.Equal(t, 3, q.running)
+}
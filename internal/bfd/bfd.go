@@ -0,0 +1,231 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bfd - runs a BFD session towards the remote end of every vxlan tunnel the forwarder programs, detecting
+// the failure of a remote node or the underlay within a fraction of a second rather than at the next refresh
+package bfd
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+// CLI - runs a vpp cli command, returning its output
+type CLI func(ctx context.Context, command ...string) (string, error)
+
+// DownHandler - called with the peer whose BFD session went down and the vpp-agent names of the tunnels to it
+type DownHandler func(ctx context.Context, peer string, tunnels []string)
+
+// Monitor - tracks the vxlan tunnels passing through its interceptor and, once started, keeps a BFD session to the
+// remote end of each.  The zero value is ready to use.
+type Monitor struct {
+	mu       sync.Mutex
+	peers    probe.Peers
+	sessions map[string]bool
+	states   map[string]string
+	started  bool
+	cli      CLI
+	local    []string
+	timers   []string
+	onDown   DownHandler
+}
+
+// UnaryClientInterceptor - returns an interceptor recording the vxlan tunnels created and deleted by the vpp-agent
+// calls passing through
+func (m *Monitor) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			return err
+		}
+		put, deleted := probe.Tunnels(req)
+		for _, iface := range put {
+			m.add(ctx, iface)
+		}
+		for _, iface := range deleted {
+			m.remove(ctx, iface)
+		}
+		return nil
+	}
+}
+
+// Start - starts BFD sessions from localIP on the vpp interface uplink to the peers of the tunnels seen so far and
+// from then on, sending and expecting a packet every interval and declaring a peer down after detectMult are missed.
+// The session states are polled every interval until ctx is done, calling onDown whenever a session that has been
+// up goes down.  A peer that never answers, such as a forwarder without BFD, is never reported.
+func (m *Monitor) Start(ctx context.Context, cli CLI, uplink string, localIP net.IP, interval time.Duration, detectMult int, onDown DownHandler) {
+	usec := strconv.FormatInt(int64(interval/time.Microsecond), 10)
+	m.mu.Lock()
+	m.cli = cli
+	m.onDown = onDown
+	m.local = []string{"interface", uplink, "local-addr", localIP.String()}
+	m.timers = []string{"desired-min-tx", usec, "required-min-rx", usec, "detect-mult", strconv.Itoa(detectMult)}
+	m.started = true
+	for _, addr := range m.peers.Addrs() {
+		m.startSession(ctx, addr)
+	}
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			output, err := cli(ctx, "show", "bfd", "sessions")
+			if err != nil {
+				log.Entry(ctx).Warnf("failed to read the bfd sessions: %+v", err)
+				continue
+			}
+			m.update(ctx, parseSessions(output))
+		}
+	}()
+}
+
+func (m *Monitor) add(ctx context.Context, iface *vpp_interfaces.Interface) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peers.Put(iface)
+	if addr := iface.GetVxlan().GetDstAddress(); m.started && m.peers.Has(addr) && !m.sessions[addr] {
+		m.startSession(ctx, addr)
+	}
+}
+
+func (m *Monitor) remove(ctx context.Context, iface *vpp_interfaces.Interface) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	addr := iface.GetVxlan().GetDstAddress()
+	if !m.peers.Delete(iface) {
+		return
+	}
+	delete(m.states, addr)
+	if m.sessions[addr] {
+		delete(m.sessions, addr)
+		command := append([]string{"bfd", "udp", "session", "del"}, m.sessionArgs(addr)...)
+		if _, err := m.cli(ctx, command...); err != nil {
+			log.Entry(ctx).Warnf("failed to delete the bfd session to %s: %+v", addr, err)
+		}
+	}
+}
+
+// startSession - adds the vpp bfd session to addr, m.mu held
+func (m *Monitor) startSession(ctx context.Context, addr string) {
+	command := append(append([]string{"bfd", "udp", "session", "add"}, m.sessionArgs(addr)...), m.timers...)
+	if _, err := m.cli(ctx, command...); err != nil {
+		log.Entry(ctx).Warnf("failed to add a bfd session to %s: %+v", addr, err)
+		return
+	}
+	if m.sessions == nil {
+		m.sessions = make(map[string]bool)
+	}
+	m.sessions[addr] = true
+	log.Entry(ctx).Infof("bfd session to tunnel peer %s added", addr)
+}
+
+func (m *Monitor) sessionArgs(addr string) []string {
+	return append(append([]string{}, m.local...), "peer-addr", addr)
+}
+
+// update - records the session states by peer address, reporting the sessions gone down
+func (m *Monitor) update(ctx context.Context, states map[string]string) {
+	type down struct {
+		addr    string
+		tunnels []string
+	}
+	var downs []down
+	m.mu.Lock()
+	var up int64
+	if m.states == nil {
+		m.states = make(map[string]string)
+	}
+	for _, addr := range m.peers.Addrs() {
+		state, ok := states[addr]
+		if !ok {
+			continue
+		}
+		if state == "Up" {
+			up++
+		} else if m.states[addr] == "Up" {
+			downs = append(downs, down{addr: addr, tunnels: m.peers.Names(addr)})
+		}
+		m.states[addr] = state
+	}
+	onDown := m.onDown
+	m.mu.Unlock()
+	metrics.Int("bfd_sessions_up").Set(up)
+	for _, d := range downs {
+		metrics.Int("bfd_session_downs").Add(1)
+		if onDown != nil {
+			onDown(ctx, d.addr, d.tunnels)
+		}
+	}
+}
+
+// parseSessions - returns the local state of each session listed by 'show bfd sessions' by peer address
+func parseSessions(output string) map[string]string {
+	states := make(map[string]string)
+	var current string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) >= 5 && fields[2] == "address":
+			if _, err := strconv.Atoi(fields[0]); err == nil {
+				current = fields[len(fields)-1]
+			}
+		case len(fields) >= 3 && fields[0] == "Session" && fields[1] == "state" && current != "":
+			states[current] = fields[2]
+		}
+	}
+	return states
+}
+
+// Uplink - returns the name vpp gives the af_packet interface on the host interface with tunnelIP
+func Uplink(tunnelIP net.IP) (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(tunnelIP) {
+				return "host-" + ifaces[i].Name, nil
+			}
+		}
+	}
+	return "", errors.Errorf("no interface has the tunnel ip %s", tunnelIP)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sessions - 'show bfd sessions' of vpp with a session up to 10.0.0.2, one down to 10.0.0.3 and an ipv6 one coming up
+const sessions = `Index                 Property                  Local value         Remote value
+0     IPv4 address              10.0.0.1            10.0.0.2
+      Session state             Up                  Up
+      Diagnostic code           No Diagnostic       No Diagnostic
+      Detect multiplier         3                   3
+1     IPv4 address              10.0.0.1            10.0.0.3
+      Session state             Down                Down
+      Diagnostic code           Control Detection Time Expired No Diagnostic
+2     IPv6 address              fd00::1             fd00::2
+      Session state             Init                Down
+`
+
+func TestParseSessions(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"10.0.0.2": "Up",
+		"10.0.0.3": "Down",
+		"fd00::2":  "Init",
+	}, parseSessions(sessions))
+}
+
+func TestParseNoSessions(t *testing.T) {
+	require.Empty(t, parseSessions(""))
+	require.Empty(t, parseSessions("Index                 Property                  Local value         Remote value\n"))
+}
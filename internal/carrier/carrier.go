@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package carrier - tells clients and operators how the forwarder carries a connection, by adding its mechanism,
+// vpp interfaces and tunnel to the extra context of the connection it returns
+package carrier
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+// keyPrefix - prefix of the extra context keys, followed by the name of the forwarder so that those of each
+// forwarder on the path are kept apart
+const keyPrefix = "nsm.forwarder/"
+
+// iface - what the forwarder has vpp-agent create for a vpp interface
+type iface struct {
+	ifType               string
+	tunnelSrc, tunnelDst string
+	vni                  uint32
+}
+
+// Interfaces - the vpp interfaces created through vpp-agent by name, the zero value is empty and ready to use
+type Interfaces struct {
+	mu     sync.RWMutex
+	ifaces map[string]*iface
+}
+
+// UnaryClientInterceptor - returns an interceptor recording the vpp interfaces created and deleted by the vpp-agent
+// calls passing through
+func (i *Interfaces) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			return err
+		}
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			for _, vppIface := range r.GetUpdate().GetVppConfig().GetInterfaces() {
+				if i.ifaces == nil {
+					i.ifaces = make(map[string]*iface)
+				}
+				i.ifaces[vppIface.GetName()] = &iface{
+					ifType:    vppIface.GetType().String(),
+					tunnelSrc: vppIface.GetVxlan().GetSrcAddress(),
+					tunnelDst: vppIface.GetVxlan().GetDstAddress(),
+					vni:       vppIface.GetVxlan().GetVni(),
+				}
+			}
+		case *configurator.DeleteRequest:
+			for _, vppIface := range r.GetDelete().GetVppConfig().GetInterfaces() {
+				delete(i.ifaces, vppIface.GetName())
+			}
+		}
+		return nil
+	}
+}
+
+func (i *Interfaces) get(name string) *iface {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.ifaces[name]
+}
+
+type carrierServer struct {
+	prefix string
+	ifaces *Interfaces
+}
+
+// NewServer - returns a server chain element adding to the extra context of the connections it returns, under
+// nsm.forwarder/<name>/, the mechanism toward the client, the names and types of their vpp interfaces and the
+// addresses and vni of their vxlan tunnel, as recorded by ifaces
+func NewServer(name string, ifaces *Interfaces) networkservice.NetworkServiceServer {
+	return &carrierServer{prefix: keyPrefix + name + "/", ifaces: ifaces}
+}
+
+func (c *carrierServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if conn.GetContext() == nil {
+		conn.Context = &networkservice.ConnectionContext{}
+	}
+	if conn.GetContext().GetExtraContext() == nil {
+		conn.GetContext().ExtraContext = make(map[string]string)
+	}
+	extra := conn.GetContext().GetExtraContext()
+	extra[c.prefix+"mechanism"] = conn.GetMechanism().GetType()
+	for side, name := range map[string]string{
+		"server": vppnames.ServerInterface(conn),
+		"client": vppnames.ClientInterface(conn),
+	} {
+		recorded := c.ifaces.get(name)
+		if recorded == nil {
+			continue
+		}
+		extra[c.prefix+side+"_interface"] = name
+		extra[c.prefix+side+"_interface_type"] = recorded.ifType
+		if recorded.ifType == vpp_interfaces.Interface_VXLAN_TUNNEL.String() {
+			extra[c.prefix+"tunnel_src"] = recorded.tunnelSrc
+			extra[c.prefix+"tunnel_dst"] = recorded.tunnelDst
+			extra[c.prefix+"tunnel_vni"] = strconv.FormatUint(uint64(recorded.vni), 10)
+		}
+	}
+	return conn, nil
+}
+
+func (c *carrierServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
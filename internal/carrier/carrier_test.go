@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package carrier_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/carrier"
+)
+
+// memifServer - returns the connections it is requested with the memif mechanism
+type memifServer struct{}
+
+func (m *memifServer) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn := request.GetConnection()
+	conn.Mechanism = &networkservice.Mechanism{Type: "MEMIF"}
+	return conn, nil
+}
+
+func (m *memifServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// call - passes req through the interceptor of ifaces to a vpp-agent answering with err
+func call(ifaces *carrier.Interfaces, req interface{}, err error) error {
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return err
+	}
+	return ifaces.UnaryClientInterceptor()(context.Background(), "/vpp-agent", req, nil, nil, invoker)
+}
+
+func connection() *networkservice.Connection {
+	return &networkservice.Connection{
+		Id: "conn-1",
+		Path: &networkservice.Path{
+			Index:        1,
+			PathSegments: []*networkservice.PathSegment{{Id: "nsc"}, {Id: "conn-1"}, {Id: "next-1"}},
+		},
+	}
+}
+
+func TestCarrier(t *testing.T) {
+	ifaces := &carrier.Interfaces{}
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: []*vpp_interfaces.Interface{
+		{Name: "server-conn-1", Type: vpp_interfaces.Interface_MEMIF},
+		{
+			Name: "client-next-1",
+			Type: vpp_interfaces.Interface_VXLAN_TUNNEL,
+			Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{
+				SrcAddress: "10.0.0.1",
+				DstAddress: "10.0.0.2",
+				Vni:        42,
+			}},
+		},
+	}}}
+	require.NoError(t, call(ifaces, &configurator.UpdateRequest{Update: conf}, nil))
+	server := chain.NewNetworkServiceServer(carrier.NewServer("forwarder-a", ifaces), &memifServer{})
+
+	conn, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: connection()})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"nsm.forwarder/forwarder-a/mechanism":             "MEMIF",
+		"nsm.forwarder/forwarder-a/server_interface":      "server-conn-1",
+		"nsm.forwarder/forwarder-a/server_interface_type": "MEMIF",
+		"nsm.forwarder/forwarder-a/client_interface":      "client-next-1",
+		"nsm.forwarder/forwarder-a/client_interface_type": "VXLAN_TUNNEL",
+		"nsm.forwarder/forwarder-a/tunnel_src":            "10.0.0.1",
+		"nsm.forwarder/forwarder-a/tunnel_dst":            "10.0.0.2",
+		"nsm.forwarder/forwarder-a/tunnel_vni":            "42",
+	}, conn.GetContext().GetExtraContext())
+
+	// Deleted interfaces, and those vpp-agent failed to create, are not reported
+	require.NoError(t, call(ifaces, &configurator.DeleteRequest{Delete: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: "client-next-1"}},
+	}}}, nil))
+	require.Error(t, call(ifaces, &configurator.UpdateRequest{Update: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: "server-conn-2", Type: vpp_interfaces.Interface_MEMIF}},
+	}}}, errors.New("vpp-agent failed")))
+
+	conn, err = server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: connection()})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"nsm.forwarder/forwarder-a/mechanism":             "MEMIF",
+		"nsm.forwarder/forwarder-a/server_interface":      "server-conn-1",
+		"nsm.forwarder/forwarder-a/server_interface_type": "MEMIF",
+	}, conn.GetContext().GetExtraContext())
+
+	conn = connection()
+	conn.Id = "conn-2"
+	conn.Context = &networkservice.ConnectionContext{ExtraContext: map[string]string{"nsm.forwarder/forwarder-b/mechanism": "VXLAN"}}
+	conn, err = server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: conn})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"nsm.forwarder/forwarder-b/mechanism": "VXLAN",
+		"nsm.forwarder/forwarder-a/mechanism": "MEMIF",
+	}, conn.GetContext().GetExtraContext(), "the keys of the other forwarders on the path were not kept")
+}
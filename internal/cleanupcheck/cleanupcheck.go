@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cleanupcheck - checks that vpp is back to its state after vppinit once all connections are closed, so CI
+// and soak tests catch connections whose Close leaves interfaces, tunnels, cross connects or routes behind
+package cleanupcheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ifpool"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/prewarm"
+)
+
+const pollInterval = time.Second
+
+// Baseline - the vpp objects expected when no connection is served
+type Baseline map[string]bool
+
+// Snapshot - returns the vpp objects vpp-agent dumps through vppagentCC, with those initFunc adds to the first Update
+// of the forwarder (nil if none)
+func Snapshot(ctx context.Context, vppagentCC grpc.ClientConnInterface, initFunc func(conf *configurator.Config) error) (Baseline, error) {
+	dump, err := configurator.NewConfiguratorServiceClient(vppagentCC).Dump(ctx, &configurator.DumpRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dump the vpp state")
+	}
+	b := make(Baseline)
+	for _, key := range keys(dump.GetDump()) {
+		b[key] = true
+	}
+	if initFunc == nil {
+		return b, nil
+	}
+	conf := &configurator.Config{}
+	if err = initFunc(conf); err != nil {
+		return nil, errors.Wrap(err, "failed to compute the initial vpp config")
+	}
+	for _, key := range keys(conf) {
+		b[key] = true
+	}
+	return b, nil
+}
+
+// Leaked - returns the vpp objects vpp-agent dumps through vppagentCC that are not part of b, sorted
+func (b Baseline) Leaked(ctx context.Context, vppagentCC grpc.ClientConnInterface) ([]string, error) {
+	dump, err := configurator.NewConfiguratorServiceClient(vppagentCC).Dump(ctx, &configurator.DumpRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dump the vpp state")
+	}
+	var leaked []string
+	for _, key := range keys(dump.GetDump()) {
+		if !b[key] {
+			leaked = append(leaked, key)
+		}
+	}
+	sort.Strings(leaked)
+	return leaked, nil
+}
+
+// Watch - once connections, having been above 0, stay at 0 for idle, calls done with the vpp objects left behind
+// relative to b, or the error dumping them.  done is called at most once.
+func Watch(ctx context.Context, vppagentCC grpc.ClientConnInterface, b Baseline, connections func() int, idle time.Duration, done func(leaked []string, err error)) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		served := false
+		var idleSince time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if connections() > 0 {
+				served, idleSince = true, time.Time{}
+				continue
+			}
+			if !served {
+				continue
+			}
+			if idleSince.IsZero() {
+				idleSince = time.Now()
+			}
+			if time.Since(idleSince) < idle {
+				continue
+			}
+			done(b.Leaked(ctx, vppagentCC))
+			return
+		}
+	}()
+}
+
+// keys - returns the keys of the interfaces, cross connects and routes of conf
+func keys(conf *configurator.Config) []string {
+	var rv []string
+	for _, iface := range conf.GetVppConfig().GetInterfaces() {
+		// Free pool interfaces and tunnel shells come and go with the pool and the peers, not with connections
+		if ifpool.Pooled(iface.GetName()) || prewarm.Shell(iface.GetName()) {
+			continue
+		}
+		rv = append(rv, fmt.Sprintf("vpp interface %s (%s)", iface.GetName(), iface.GetType()))
+	}
+	for _, xconnect := range conf.GetVppConfig().GetXconnectPairs() {
+		rv = append(rv, fmt.Sprintf("vpp xconnect %s -> %s", xconnect.GetReceiveInterface(), xconnect.GetTransmitInterface()))
+	}
+	for _, route := range conf.GetVppConfig().GetRoutes() {
+		rv = append(rv, fmt.Sprintf("vpp route %s via %s %s vrf %d", route.GetDstNetwork(), route.GetNextHopAddr(), route.GetOutgoingInterface(), route.GetVrfId()))
+	}
+	for _, iface := range conf.GetLinuxConfig().GetInterfaces() {
+		rv = append(rv, fmt.Sprintf("linux interface %s (%s)", iface.GetName(), iface.GetType()))
+	}
+	for _, route := range conf.GetLinuxConfig().GetRoutes() {
+		rv = append(rv, fmt.Sprintf("linux route %s via %s %s", route.GetDstNetwork(), route.GetGwAddr(), route.GetOutgoingInterface()))
+	}
+	return rv
+}
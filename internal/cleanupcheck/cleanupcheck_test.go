@@ -0,0 +1,190 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cleanupcheck_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/linux"
+	linux_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/linux/interfaces"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cleanupcheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ifpool"
+)
+
+// dumpCC - a vpp-agent dumping dump, or failing with err
+type dumpCC struct {
+	mu   sync.Mutex
+	dump *configurator.Config
+	err  error
+}
+
+func (c *dumpCC) set(dump *configurator.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dump = dump
+}
+
+func (c *dumpCC) Invoke(_ context.Context, _ string, args, reply interface{}, _ ...grpc.CallOption) error {
+	if _, ok := args.(*configurator.DumpRequest); !ok {
+		return errors.Errorf("unexpected %T", args)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	proto.Merge(reply.(proto.Message), &configurator.DumpResponse{Dump: c.dump})
+	return nil
+}
+
+func (c *dumpCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+// idle - the vpp state of a forwarder serving no connection, uplink included
+func idle() *configurator.Config {
+	return &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{
+			{Name: "local0"},
+			{Name: "eth0", Type: vpp_interfaces.Interface_AF_PACKET},
+		},
+		Routes: []*vpp.Route{{DstNetwork: "0.0.0.0/0", NextHopAddr: "10.0.0.1", OutgoingInterface: "eth0"}},
+	}}
+}
+
+// serving - the vpp state of a forwarder serving connection 1
+func serving() *configurator.Config {
+	conf := idle()
+	conf.GetVppConfig().Interfaces = append(conf.GetVppConfig().Interfaces,
+		&vpp_interfaces.Interface{Name: "server-1", Type: vpp_interfaces.Interface_MEMIF},
+		&vpp_interfaces.Interface{Name: "client-1", Type: vpp_interfaces.Interface_TAP},
+	)
+	conf.GetVppConfig().XconnectPairs = []*vpp.L2XConnect{{ReceiveInterface: "server-1", TransmitInterface: "client-1"}}
+	conf.LinuxConfig = &linux.ConfigData{Interfaces: []*linux_interfaces.Interface{
+		{Name: "client-1", Type: linux_interfaces.Interface_TAP_TO_VPP},
+	}}
+	return conf
+}
+
+func TestSnapshotAndLeaked(t *testing.T) {
+	// vppinit has yet to configure the uplink when the baseline is taken
+	cc := &dumpCC{dump: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: "local0"}},
+	}}}
+	initFunc := func(conf *configurator.Config) error {
+		proto.Merge(conf, idle())
+		return nil
+	}
+	b, err := cleanupcheck.Snapshot(context.Background(), cc, initFunc)
+	require.NoError(t, err)
+
+	cc.set(idle())
+	leaked, err := b.Leaked(context.Background(), cc)
+	require.NoError(t, err)
+	require.Empty(t, leaked)
+
+	cc.set(serving())
+	leaked, err = b.Leaked(context.Background(), cc)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"linux interface client-1 (TAP_TO_VPP)",
+		"vpp interface client-1 (TAP)",
+		"vpp interface server-1 (MEMIF)",
+		"vpp xconnect server-1 -> client-1",
+	}, leaked)
+
+	cc.err = errors.New("vpp-agent is gone")
+	_, err = b.Leaked(context.Background(), cc)
+	require.Error(t, err)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	_, err := cleanupcheck.Snapshot(context.Background(), &dumpCC{err: errors.New("vpp-agent is gone")}, nil)
+	require.Error(t, err)
+
+	_, err = cleanupcheck.Snapshot(context.Background(), &dumpCC{dump: idle()}, func(*configurator.Config) error {
+		return errors.New("no uplink")
+	})
+	require.Error(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	cc := &dumpCC{dump: idle()}
+	b, err := cleanupcheck.Snapshot(context.Background(), cc, nil)
+	require.NoError(t, err)
+	cc.set(serving())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	polls := 0
+	// No connection yet, then one, then none again
+	connections := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		if polls == 2 {
+			return 1
+		}
+		return 0
+	}
+	done := make(chan error, 2)
+	var leaked []string
+	cleanupcheck.Watch(ctx, cc, b, connections, 0, func(l []string, err error) {
+		leaked = l
+		done <- err
+	})
+	select {
+	case err = <-done:
+		require.NoError(t, err)
+		require.Len(t, leaked, 4)
+		mu.Lock()
+		require.Equal(t, 3, polls, "the check ran before any connection was served")
+		mu.Unlock()
+	case <-time.After(10 * time.Second):
+		t.Fatal("the cleanup check never ran")
+	}
+}
+
+func TestPoolInterfacesAndShellsIgnored(t *testing.T) {
+	cc := &dumpCC{dump: idle()}
+	b, err := cleanupcheck.Snapshot(context.Background(), cc, nil)
+	require.NoError(t, err)
+
+	// The pool is topped up with free interfaces behind the baseline's back
+	conf := idle()
+	conf.GetVppConfig().Interfaces = append(conf.GetVppConfig().Interfaces,
+		&vpp_interfaces.Interface{Name: ifpool.Prefix + "7", Type: vpp_interfaces.Interface_TAP},
+		// and so is a tunnel shell to a newly found peer
+		&vpp_interfaces.Interface{Name: "prewarm-10.0.0.2", Type: vpp_interfaces.Interface_VXLAN_TUNNEL},
+	)
+	cc.set(conf)
+	leaked, err := b.Leaked(context.Background(), cc)
+	require.NoError(t, err)
+	require.Empty(t, leaked)
+}
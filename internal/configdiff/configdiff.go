@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configdiff - logs, at debug level, what each vpp-agent Update and Delete changes of the configuration the
+// forwarder applied: the interfaces, xconnects, routes... added, changed and removed, answering what a Request or
+// Close actually programmed
+package configdiff
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	updateMethod = "/ligato.configurator.ConfiguratorService/Update"
+	deleteMethod = "/ligato.configurator.ConfiguratorService/Delete"
+)
+
+// Tracker - the configuration applied through vpp-agent, the zero value is empty and ready to use
+type Tracker struct {
+	mu    sync.Mutex
+	items map[string]proto.Message
+}
+
+// UnaryClientInterceptor - returns an interceptor recording the items of the Updates and Deletes succeeding through
+// it in t and logging, at debug level, how they changed what t had recorded.  Nothing is recorded while debug logging
+// is off, once it is on again the changes are those from an empty configuration.
+func (t *Tracker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var config *configurator.Config
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			config = r.GetUpdate()
+		case *configurator.DeleteRequest:
+			config = r.GetDelete()
+		}
+		if (method != updateMethod && method != deleteMethod) || config == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+			t.reset()
+			return nil
+		}
+		changes := t.apply(method == deleteMethod, flatten(config))
+		name := method[strings.LastIndex(method, "/")+1:]
+		if len(changes) == 0 {
+			log.Entry(ctx).Debugf("vpp-agent %s changed nothing", name)
+			return nil
+		}
+		log.Entry(ctx).Debugf("vpp-agent %s changed:\n%s", name, strings.Join(changes, "\n"))
+		return nil
+	}
+}
+
+// apply - records items as applied, or removed if remove is set, returning the changes as lines of the form
+// "+ key: item", "~ key: old -> new" or "- key"
+func (t *Tracker) apply(remove bool, items map[string]proto.Message) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.items == nil {
+		t.items = make(map[string]proto.Message)
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var changes []string
+	for _, key := range keys {
+		item := items[key]
+		old, ok := t.items[key]
+		switch {
+		case remove:
+			delete(t.items, key)
+			changes = append(changes, "- "+key)
+			continue
+		case !ok && strings.HasSuffix(key, "}"):
+			changes = append(changes, "+ "+key)
+		case !ok:
+			changes = append(changes, "+ "+key+": "+text(item))
+		case !proto.Equal(old, item):
+			changes = append(changes, "~ "+key+": "+text(old)+" -> "+text(item))
+		default:
+			continue
+		}
+		t.items[key] = proto.Clone(item)
+	}
+	return changes
+}
+
+// reset - drops the items recorded in t
+func (t *Tracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.items = nil
+}
+
+// flatten - returns the items of config keyed by their kind and name, e.g. vpp/Interfaces/server-1234, or by their
+// content if they have no name
+func flatten(config *configurator.Config) map[string]proto.Message {
+	items := make(map[string]proto.Message)
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		section := v.Field(i)
+		if field.PkgPath != "" || section.Kind() != reflect.Ptr || section.IsNil() || section.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		addItems(items, strings.ToLower(strings.TrimSuffix(field.Name, "Config")), section.Elem())
+	}
+	return items
+}
+
+// addItems - adds the message fields, and the elements of the repeated message fields, of section to items
+func addItems(items map[string]proto.Message, prefix string, section reflect.Value) {
+	for i := 0; i < section.NumField(); i++ {
+		field := section.Type().Field(i)
+		value := section.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		kind := prefix + "/" + field.Name
+		switch value.Kind() {
+		case reflect.Ptr:
+			if msg, ok := value.Interface().(proto.Message); ok && !value.IsNil() {
+				items[kind] = msg
+			}
+		case reflect.Slice:
+			for j := 0; j < value.Len(); j++ {
+				if msg, ok := value.Index(j).Interface().(proto.Message); ok && !value.Index(j).IsNil() {
+					items[kind+"/"+name(msg)] = msg
+				}
+			}
+		}
+	}
+}
+
+// name - returns the name of msg, or its content if it has none
+func name(msg proto.Message) string {
+	if named, ok := msg.(interface{ GetName() string }); ok && named.GetName() != "" {
+		return named.GetName()
+	}
+	if xconnect, ok := msg.(interface{ GetReceiveInterface() string }); ok && xconnect.GetReceiveInterface() != "" {
+		return xconnect.GetReceiveInterface()
+	}
+	return "{" + text(msg) + "}"
+}
+
+func text(msg proto.Message) string {
+	return strings.TrimSpace(proto.CompactTextString(msg))
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+)
+
+func config(mtu uint32) *configurator.Config {
+	return &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: "server-1", Type: vpp_interfaces.Interface_MEMIF, Mtu: mtu}},
+		Routes:     []*vpp_l3.Route{{DstNetwork: "10.0.0.2/32", OutgoingInterface: "server-1"}},
+	}}
+}
+
+func TestUpdateChangeDelete(t *testing.T) {
+	tracker := &Tracker{}
+	changes := tracker.apply(false, flatten(config(1500)))
+	require.Len(t, changes, 2)
+	require.Contains(t, changes[0], "+ vpp/Interfaces/server-1: ")
+	require.Contains(t, changes[1], "+ vpp/Routes/{")
+
+	require.Empty(t, tracker.apply(false, flatten(config(1500))))
+
+	changes = tracker.apply(false, flatten(config(1450)))
+	require.Len(t, changes, 1)
+	require.Contains(t, changes[0], "~ vpp/Interfaces/server-1: ")
+	require.Contains(t, changes[0], "1450")
+
+	changes = tracker.apply(true, flatten(config(1450)))
+	require.Len(t, changes, 2)
+	require.Equal(t, "- vpp/Interfaces/server-1", changes[0])
+	require.Empty(t, tracker.items)
+}
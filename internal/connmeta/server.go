@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connmeta
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+// Keys of the metadata recorded for every connection
+const (
+	MechanismKey       = "mechanism"
+	ServerInterfaceKey = "vpp.server_interface"
+	ClientInterfaceKey = "vpp.client_interface"
+)
+
+type connmetaServer struct {
+	store *Store
+}
+
+// NewServer - returns a server chain element recording the mechanism and vpp interface names of every connection in
+// store, touching it on every Request and deleting it on Close
+func NewServer(store *Store) networkservice.NetworkServiceServer {
+	return &connmetaServer{store: store}
+}
+
+func (c *connmetaServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{
+		MechanismKey:       conn.GetMechanism().GetType(),
+		ServerInterfaceKey: vppnames.ServerInterface(conn),
+	}
+	if clientInterface := vppnames.ClientInterface(conn); clientInterface != "" {
+		values[ClientInterfaceKey] = clientInterface
+	}
+	c.store.SetValues(conn.GetId(), values)
+	return conn, nil
+}
+
+func (c *connmetaServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	defer c.store.Delete(conn.GetId())
+	return next.Server(ctx).Close(ctx, conn)
+}
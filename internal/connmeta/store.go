@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connmeta - concurrent-safe store of per connection metadata (mechanism artifacts, vpp names, netns
+// handles...) with TTL based cleanup, persisted so that it survives a forwarder restart.  Changes are written to the
+// file at most every persistInterval, a restart may lose the latest of them.
+package connmeta
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	expiryInterval  = 10 * time.Second
+	persistInterval = time.Second
+)
+
+// ExpireFunc - called with the metadata of a connection that expired
+type ExpireFunc func(ctx context.Context, connID string, values map[string]string)
+
+type entry struct {
+	Values  map[string]string `json:"values"`
+	Expires time.Time         `json:"expires"`
+}
+
+// Store - store of per connection metadata
+type Store struct {
+	mu       sync.Mutex
+	entries  map[string]*entry
+	ttl      time.Duration
+	filename string
+	onExpire []ExpireFunc
+	// changed - whether entries changed since they were last written
+	changed bool
+	// writeMu - serializes the writes of the file
+	writeMu sync.Mutex
+}
+
+// NewStore - returns a store whose connections expire ttl after they were last touched, persisted to filename (not
+// persisted if empty) and restored from it, expiring connections and persisting changes until ctx is done
+func NewStore(ctx context.Context, filename string, ttl time.Duration) (*Store, error) {
+	s := &Store{
+		entries:  make(map[string]*entry),
+		ttl:      ttl,
+		filename: filename,
+	}
+	if filename != "" {
+		data, err := ioutil.ReadFile(filename)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, errors.WithStack(err)
+		default:
+			if err = json.Unmarshal(data, &s.entries); err != nil {
+				return nil, errors.Wrapf(err, "invalid connection metadata %s", filename)
+			}
+			log.Entry(ctx).Infof("restored metadata of %d connections from %s", len(s.entries), filename)
+		}
+	}
+	go s.expire(ctx)
+	if filename != "" {
+		go s.flushChanges(ctx)
+	}
+	return s, nil
+}
+
+// OnExpire - adds f to the functions called when a connection expires
+func (s *Store) OnExpire(f ExpireFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpire = append(s.onExpire, f)
+}
+
+// Get - returns the value of key for connID
+func (s *Store) Get(connID, key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[connID]
+	if !ok {
+		return "", false
+	}
+	value, ok := e.Values[key]
+	return value, ok
+}
+
+// Set - sets key to value for connID and touches it
+func (s *Store) Set(connID, key, value string) {
+	s.SetValues(connID, map[string]string{key: value})
+}
+
+// SetValues - sets the keys of values to their values for connID and touches it
+func (s *Store) SetValues(connID string, values map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.touch(connID)
+	for key, value := range values {
+		e.Values[key] = value
+	}
+	s.persist()
+}
+
+// Touch - extends the lifetime of connID by the ttl
+func (s *Store) Touch(connID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touch(connID)
+	s.persist()
+}
+
+// Delete - deletes the metadata of connID, returning it
+func (s *Store) Delete(connID string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[connID]
+	if !ok {
+		return nil
+	}
+	delete(s.entries, connID)
+	s.persist()
+	return e.Values
+}
+
+func (s *Store) touch(connID string) *entry {
+	e, ok := s.entries[connID]
+	if !ok {
+		e = &entry{Values: make(map[string]string)}
+		s.entries[connID] = e
+	}
+	e.Expires = time.Now().Add(s.ttl)
+	return e
+}
+
+// persist - records that the store changed, to be written to its file by the next Flush
+func (s *Store) persist() {
+	s.changed = s.filename != ""
+}
+
+// Flush - writes the store to its file if it changed since it was last written, errors are logged as the in-memory
+// store stays authoritative
+func (s *Store) Flush() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	if !s.changed {
+		s.mu.Unlock()
+		return
+	}
+	s.changed = false
+	data, err := json.Marshal(s.entries)
+	s.mu.Unlock()
+	if err == nil {
+		tmpFile := s.filename + ".tmp"
+		if err = ioutil.WriteFile(tmpFile, data, 0600); err == nil {
+			err = os.Rename(tmpFile, s.filename)
+		}
+	}
+	if err != nil {
+		log.Entry(context.Background()).Warnf("failed to persist connection metadata to %s: %+v", s.filename, err)
+	}
+}
+
+// flushChanges - flushes the store every persistInterval, and a last time once ctx is done
+func (s *Store) flushChanges(ctx context.Context) {
+	ticker := time.NewTicker(persistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.Flush()
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+func (s *Store) expire(ctx context.Context) {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired := make(map[string]map[string]string)
+			s.mu.Lock()
+			for connID, e := range s.entries {
+				if now.After(e.Expires) {
+					expired[connID] = e.Values
+					delete(s.entries, connID)
+				}
+			}
+			if len(expired) > 0 {
+				s.persist()
+			}
+			onExpire := s.onExpire
+			s.mu.Unlock()
+			for connID, values := range expired {
+				for _, f := range onExpire {
+					f(ctx, connID, values)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connmeta
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "connmeta")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "metadata.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := NewStore(ctx, filename, time.Hour)
+	require.NoError(t, err)
+	store.SetValues("conn-1", map[string]string{ServerInterfaceKey: "server-1", ClientInterfaceKey: "client-1"})
+	store.Set("conn-2", ServerInterfaceKey, "server-2")
+	require.Equal(t, map[string]string{ServerInterfaceKey: "server-2"}, store.Delete("conn-2"))
+	// Changes are batched until the next flush
+	_, err = os.Stat(filename)
+	require.True(t, os.IsNotExist(err))
+	store.Flush()
+
+	restored, err := NewStore(ctx, filename, time.Hour)
+	require.NoError(t, err)
+	value, ok := restored.Get("conn-1", ClientInterfaceKey)
+	require.True(t, ok)
+	require.Equal(t, "client-1", value)
+	_, ok = restored.Get("conn-2", ServerInterfaceKey)
+	require.False(t, ok)
+}
+
+func TestFlushOnDone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "connmeta")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "metadata.json")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store, err := NewStore(ctx, filename, time.Hour)
+	require.NoError(t, err)
+	store.Set("conn-1", ServerInterfaceKey, "server-1")
+	cancel()
+	require.Eventually(t, func() bool {
+		_, statErr := os.Stat(filename)
+		return statErr == nil
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var csvHeader = []string{
+	"id", "network_service", "peer", "mechanism", "server_interface", "client_interface",
+	"created", "refreshed", "refreshes", "age_seconds", "link_state",
+	"server_if_index", "client_if_index",
+}
+
+// WriteJSON - writes entries to w as a json array
+func WriteJSON(w io.Writer, entries []Entry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(entries))
+}
+
+// WriteCSV - writes entries to w as csv with a header line
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write(csvHeader)
+	for i := range entries {
+		e := &entries[i]
+		_ = writer.Write([]string{
+			e.ID, e.NetworkService, e.Peer, e.Mechanism, e.ServerInterface, e.ClientInterface,
+			e.Created.Format(time.RFC3339), e.Refreshed.Format(time.RFC3339),
+			strconv.Itoa(e.Refreshes), strconv.FormatInt(e.AgeSeconds, 10), e.LinkState,
+			strconv.FormatUint(uint64(e.ServerIfIndex), 10), strconv.FormatUint(uint64(e.ClientIfIndex), 10),
+		})
+	}
+	writer.Flush()
+	return errors.WithStack(writer.Error())
+}
+
+// Handler - returns an admin api handler:
+//
+//	GET  /connections[?format=csv] returns the table as json (or csv)
+//	POST /connections/dump writes the table to connections-<time>.json and .csv in dir and returns their paths
+func Handler(table *Table, dir string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/connections", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		write, contentType := WriteJSON, "application/json"
+		if r.URL.Query().Get("format") == "csv" {
+			write, contentType = WriteCSV, "text/csv"
+		}
+		w.Header().Set("Content-Type", contentType)
+		_ = write(w, table.List())
+	})
+	mux.HandleFunc("/connections/dump", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		files, err := Dump(table, dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(files)
+	})
+	return mux
+}
+
+// Dump - writes the table to connections-<time>.json and .csv in dir, returning their paths
+func Dump(table *Table, dir string) ([]string, error) {
+	entries := table.List()
+	base := filepath.Join(dir, "connections-"+time.Now().UTC().Format("20060102T150405Z"))
+	jsonBuf, csvBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := WriteJSON(jsonBuf, entries); err != nil {
+		return nil, err
+	}
+	if err := WriteCSV(csvBuf, entries); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(base+".json", jsonBuf.Bytes(), 0600); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(base+".csv", csvBuf.Bytes(), 0600); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return []string{base + ".json", base + ".csv"}, nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testTable() *Table {
+	table := &Table{}
+	created := time.Now().Add(-time.Minute)
+	table.store(&Entry{ID: "conn-2", NetworkService: "ns", Mechanism: "KERNEL", Created: created.Add(time.Second)})
+	table.store(&Entry{ID: "conn-1", NetworkService: "ns", Mechanism: "MEMIF", ServerInterface: "server-1", Created: created})
+	return table
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSON(buf, testTable().List()))
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	require.Len(t, entries, 2)
+	require.Equal(t, "conn-1", entries[0].ID, "entries are ordered by creation")
+	require.Equal(t, "server-1", entries[0].ServerInterface)
+	require.True(t, entries[0].AgeSeconds >= 59)
+}
+
+func TestWriteCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteCSV(buf, testTable().List()))
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, csvHeader, records[0])
+	require.Equal(t, []string{"conn-1", "ns", "", "MEMIF", "server-1", ""}, records[1][:6])
+	require.Equal(t, "conn-2", records[2][0])
+}
+
+func TestHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conntable")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	server := httptest.NewServer(Handler(testTable(), dir))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/connections?format=csv")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	require.True(t, strings.HasPrefix(string(body), strings.Join(csvHeader, ",")+"\n"))
+
+	resp, err = http.Post(server.URL+"/connections", "application/json", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/connections/dump", "application/json", nil)
+	require.NoError(t, err)
+	var files []string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&files))
+	_ = resp.Body.Close()
+	require.Len(t, files, 2)
+	for _, file := range files {
+		require.True(t, strings.HasPrefix(file, dir), file)
+		_, err = os.Stat(file)
+		require.NoError(t, err)
+	}
+}
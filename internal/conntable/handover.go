@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Save - writes the entries of t to filename, for the next forwarder process to Restore on handoff
+func (t *Table) Save(filename string) error {
+	t.mu.RLock()
+	data, err := json.Marshal(t.entries)
+	t.mu.RUnlock()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tmpFile := filename + ".tmp"
+	if err = ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpFile, filename))
+}
+
+// Restore - adds the entries saved to filename to t and removes filename, so that it is restored once.  Returns the
+// number of entries restored, none if there is no filename.
+func (t *Table) Restore(filename string) (int, error) {
+	data, err := ioutil.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err):
+		return 0, nil
+	case err != nil:
+		return 0, errors.WithStack(err)
+	}
+	var entries map[string]*Entry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return 0, errors.Wrapf(err, "invalid connection table %s", filename)
+	}
+	t.mu.Lock()
+	if t.entries == nil {
+		t.entries = make(map[string]*Entry)
+	}
+	for id, e := range entries {
+		t.entries[id] = e
+	}
+	t.mu.Unlock()
+	return len(entries), errors.WithStack(os.Remove(filename))
+}
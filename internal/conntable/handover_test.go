@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conntable")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "connections.json")
+
+	saved := &Table{}
+	saved.store(&Entry{ID: "conn-1", ServerInterface: "server-1", ClientInterface: "client-1", Created: time.Now()})
+	saved.SetLinkState("server-1", "UP", 7)
+	require.NoError(t, saved.Save(filename))
+
+	restored := &Table{}
+	n, err := restored.Restore(filename)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	list := restored.List()
+	require.Len(t, list, 1)
+	require.Equal(t, "conn-1", list[0].ID)
+	require.Equal(t, "UP", list[0].LinkState)
+	require.Equal(t, uint32(7), list[0].ServerIfIndex)
+	require.Equal(t, "conn-1", restored.Lookup("client-1"))
+
+	// Restored once only
+	_, err = os.Stat(filename)
+	require.True(t, os.IsNotExist(err))
+	n, err = restored.Restore(filename)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
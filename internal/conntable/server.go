@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type conntableServer struct {
+	table *Table
+}
+
+// NewServer - returns a server chain element recording the connections it serves in table
+func NewServer(table *Table) networkservice.NetworkServiceServer {
+	return &conntableServer{table: table}
+}
+
+func (c *conntableServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c.table.store(&Entry{
+		ID:              conn.GetId(),
+		NetworkService:  conn.GetNetworkService(),
+		Peer:            peerID(ctx),
+		Mechanism:       conn.GetMechanism().GetType(),
+		ServerInterface: vppnames.ServerInterface(conn),
+		ClientInterface: vppnames.ClientInterface(conn),
+		Created:         now,
+		Refreshed:       now,
+	})
+	return conn, nil
+}
+
+func (c *conntableServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	c.table.delete(conn.GetId())
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// peerID - returns the spiffe id of the peer of ctx, or "" if it has none
+func peerID(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+)
+
+func withPeer(ctx context.Context, spiffeID string) context.Context {
+	id, _ := url.Parse(spiffeID)
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{id}}},
+	}}})
+}
+
+func request() *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id:             "conn-1",
+			NetworkService: "icmp-responder",
+			Mechanism:      &networkservice.Mechanism{Type: "MEMIF"},
+			Path: &networkservice.Path{
+				PathSegments: []*networkservice.PathSegment{{Id: "conn-1"}, {Id: "next-1"}},
+			},
+		},
+	}
+}
+
+func TestServerRecordsConnections(t *testing.T) {
+	table := &conntable.Table{}
+	server := chain.NewNetworkServiceServer(conntable.NewServer(table))
+	ctx := withPeer(context.Background(), "spiffe://example.org/nsmgr")
+
+	conn, err := server.Request(ctx, request())
+	require.NoError(t, err)
+	list := table.List()
+	require.Len(t, list, 1)
+	require.Equal(t, "conn-1", list[0].ID)
+	require.Equal(t, "icmp-responder", list[0].NetworkService)
+	require.Equal(t, "spiffe://example.org/nsmgr", list[0].Peer)
+	require.Equal(t, "MEMIF", list[0].Mechanism)
+	require.NotEmpty(t, list[0].ServerInterface)
+	require.NotEmpty(t, list[0].ClientInterface)
+	require.Zero(t, list[0].Refreshes)
+	require.True(t, table.Has("conn-1"))
+	require.Equal(t, "conn-1", table.Lookup(list[0].ClientInterface))
+
+	// A refresh keeps the creation time and counts
+	_, err = server.Request(context.Background(), request())
+	require.NoError(t, err)
+	refreshed := table.List()
+	require.Len(t, refreshed, 1)
+	require.Equal(t, 1, refreshed[0].Refreshes)
+	require.Equal(t, list[0].Created, refreshed[0].Created)
+	require.Empty(t, refreshed[0].Peer, "the peer is that of the last Request")
+
+	_, err = server.Close(ctx, conn)
+	require.NoError(t, err)
+	require.Zero(t, table.Len())
+	require.False(t, table.Has("conn-1"))
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conntable - table of the connections the forwarder serves, exportable as json or csv for audits
+package conntable
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry - a connection of the table
+type Entry struct {
+	ID              string    `json:"id"`
+	NetworkService  string    `json:"network_service"`
+	Peer            string    `json:"peer"`
+	Mechanism       string    `json:"mechanism"`
+	ServerInterface string    `json:"server_interface"`
+	ClientInterface string    `json:"client_interface"`
+	Created         time.Time `json:"created"`
+	Refreshed       time.Time `json:"refreshed"`
+	Refreshes       int       `json:"refreshes"`
+	AgeSeconds      int64     `json:"age_seconds"`
+	LinkState       string    `json:"link_state,omitempty"`
+	ServerIfIndex   uint32    `json:"server_if_index,omitempty"`
+	ClientIfIndex   uint32    `json:"client_if_index,omitempty"`
+}
+
+// Table - table of connections, the zero value is empty and ready to use
+type Table struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+}
+
+// List - returns the entries of the table ordered by creation
+func (t *Table) List() []Entry {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	now := time.Now()
+	list := make([]Entry, 0, len(t.entries))
+	for _, e := range t.entries {
+		entry := *e
+		entry.AgeSeconds = int64(now.Sub(entry.Created) / time.Second)
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.Before(list[j].Created) })
+	return list
+}
+
+// Len - returns the number of connections in the table
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.entries)
+}
+
+// Has - returns whether the connection with id is in the table
+func (t *Table) Has(id string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.entries[id]
+	return ok
+}
+
+func (t *Table) store(entry *Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries == nil {
+		t.entries = make(map[string]*Entry)
+	}
+	if existing, ok := t.entries[entry.ID]; ok {
+		entry.Created = existing.Created
+		entry.Refreshes = existing.Refreshes + 1
+		entry.LinkState = existing.LinkState
+		entry.ServerIfIndex, entry.ClientIfIndex = existing.ServerIfIndex, existing.ClientIfIndex
+	}
+	t.entries[entry.ID] = entry
+}
+
+func (t *Table) delete(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, id)
+}
+
+// SetLinkState - records state as the link state, and ifIndex (unless 0) as the vpp interface index, of the
+// connection with the vpp interface ifName, returning its id or "" if there is none
+func (t *Table) SetLinkState(ifName, state string, ifIndex uint32) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, e := range t.entries {
+		switch {
+		case ifName == e.ServerInterface && ifIndex != 0:
+			e.ServerIfIndex = ifIndex
+		case ifName == e.ClientInterface && ifIndex != 0:
+			e.ClientIfIndex = ifIndex
+		case ifName != e.ServerInterface && ifName != e.ClientInterface:
+			continue
+		}
+		e.LinkState = state
+		return id
+	}
+	return ""
+}
+
+// Lookup - returns the id of the connection with the vpp interface ifName, or "" if there is none
+func (t *Table) Lookup(ifName string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for id, e := range t.entries {
+		if ifName == e.ServerInterface || ifName == e.ClientInterface {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLinkState(t *testing.T) {
+	table := &Table{}
+	table.store(&Entry{ID: "conn-1", ServerInterface: "server-1", ClientInterface: "client-1", Created: time.Now()})
+
+	require.Equal(t, "conn-1", table.SetLinkState("server-1", "UP", 7))
+	require.Equal(t, "conn-1", table.SetLinkState("client-1", "DOWN", 9))
+	require.Equal(t, "conn-1", table.SetLinkState("client-1", "BFD_DOWN", 0))
+	require.Equal(t, "", table.SetLinkState("memif-other", "DOWN", 11))
+
+	list := table.List()
+	require.Len(t, list, 1)
+	require.Equal(t, "BFD_DOWN", list[0].LinkState)
+	require.Equal(t, uint32(7), list[0].ServerIfIndex)
+	require.Equal(t, uint32(9), list[0].ClientIfIndex, "an index of 0 leaves the known one")
+}
+
+func TestHas(t *testing.T) {
+	table := &Table{}
+	require.False(t, table.Has("conn-1"))
+	table.store(&Entry{ID: "conn-1", Created: time.Now()})
+	require.True(t, table.Has("conn-1"))
+	require.False(t, table.Has("conn-2"))
+}
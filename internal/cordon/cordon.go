@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cordon - cordons the forwarder ahead of a planned shutdown: Requests for new connections are refused
+// with a retryable status and health checks fail so that they are steered to other forwarders, while the existing
+// connections are served until the cordon's window elapses
+package cordon
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+// Status - the state of a Cordon as served by its Handler
+type Status struct {
+	Cordoned bool      `json:"cordoned"`
+	Since    time.Time `json:"since,omitempty"`
+	Deadline time.Time `json:"deadline,omitempty"`
+}
+
+// Cordon - whether the forwarder is cordoned, the zero value is not
+type Cordon struct {
+	mu     sync.Mutex
+	status Status
+	timer  *time.Timer
+}
+
+// Set - cordons the forwarder, calling onExpire once window has elapsed unless it is 0 or the cordon is cleared
+// before.  Setting an existing cordon restarts its window.
+func (c *Cordon) Set(window time.Duration, onExpire func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	now := time.Now()
+	if !c.status.Cordoned {
+		c.status = Status{Cordoned: true, Since: now}
+	}
+	c.status.Deadline = time.Time{}
+	if window > 0 {
+		c.status.Deadline = now.Add(window)
+		c.timer = time.AfterFunc(window, onExpire)
+	}
+	readiness.Set("cordon", errors.New("cordoned"))
+}
+
+// Clear - lifts the cordon, if any
+func (c *Cordon) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.status = Status{}
+	readiness.Set("cordon", nil)
+}
+
+// Status - returns the state of the cordon
+func (c *Cordon) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// Handler - returns an admin api handler:
+//
+//	GET    /cordon returns the Status of c as json
+//	POST   /cordon[?window=<duration>] cordons the forwarder, calling onExpire after window (by default defaultWindow)
+//	DELETE /cordon lifts the cordon
+func Handler(c *Cordon, defaultWindow time.Duration, onExpire func()) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			window := defaultWindow
+			if value := r.URL.Query().Get("window"); value != "" {
+				var err error
+				if window, err = time.ParseDuration(value); err != nil || window < 0 {
+					http.Error(w, "invalid window "+value, http.StatusBadRequest)
+					return
+				}
+			}
+			c.Set(window, onExpire)
+		case http.MethodDelete:
+			c.Clear()
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Status())
+	})
+}
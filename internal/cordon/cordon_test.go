@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cordon_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cordon"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+func TestSetAndClear(t *testing.T) {
+	c := &cordon.Cordon{}
+	require.False(t, c.Status().Cordoned)
+
+	c.Set(time.Hour, func() { t.Error("the cordon expired early") })
+	status := c.Status()
+	require.True(t, status.Cordoned)
+	require.WithinDuration(t, status.Since.Add(time.Hour), status.Deadline, time.Second)
+	require.EqualError(t, readiness.Err(), "cordon: cordoned")
+
+	// Setting it again restarts the window but keeps when it was cordoned
+	c.Set(0, nil)
+	require.Equal(t, status.Since, c.Status().Since)
+	require.True(t, c.Status().Deadline.IsZero())
+
+	c.Clear()
+	require.Equal(t, cordon.Status{}, c.Status())
+	require.NoError(t, readiness.Err())
+}
+
+func TestExpiry(t *testing.T) {
+	c := &cordon.Cordon{}
+	defer c.Clear()
+	expired := make(chan struct{})
+	c.Set(10*time.Millisecond, func() { close(expired) })
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatal("the cordon did not expire")
+	}
+
+	c.Set(10*time.Millisecond, func() { t.Error("a cleared cordon expired") })
+	c.Clear()
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestHandler(t *testing.T) {
+	c := &cordon.Cordon{}
+	defer c.Clear()
+	handler := cordon.Handler(c, time.Hour, func() {})
+	serve := func(method, target string) (*httptest.ResponseRecorder, cordon.Status) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		var status cordon.Status
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		}
+		return rec, status
+	}
+
+	rec, status := serve(http.MethodGet, "/cordon")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, status.Cordoned)
+
+	rec, status = serve(http.MethodPost, "/cordon")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, status.Cordoned)
+	require.WithinDuration(t, status.Since.Add(time.Hour), status.Deadline, time.Second)
+
+	rec, status = serve(http.MethodPost, "/cordon?window=1m")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.WithinDuration(t, time.Now().Add(time.Minute), status.Deadline, time.Second)
+
+	for _, window := range []string{"soon", "-1m"} {
+		rec, _ = serve(http.MethodPost, "/cordon?window="+window)
+		require.Equal(t, http.StatusBadRequest, rec.Code, window)
+	}
+
+	rec, _ = serve(http.MethodPut, "/cordon")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec, status = serve(http.MethodDelete, "/cordon")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, status.Cordoned)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cordon
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+type cordonServer struct {
+	cordon *Cordon
+	known  func(connID string) bool
+}
+
+// NewServer - returns a server chain element refusing Requests for connections not known to known while c is set
+func NewServer(c *Cordon, known func(connID string) bool) networkservice.NetworkServiceServer {
+	return &cordonServer{
+		cordon: c,
+		known:  known,
+	}
+}
+
+func (c *cordonServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if !c.cordon.Status().Cordoned || c.known(request.GetConnection().GetId()) {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	metrics.Int("cordon_rejected_requests").Add(1)
+	log.Entry(ctx).Infof("forwarder is cordoned, refusing new connection %s", request.GetConnection().GetId())
+	return nil, status.Error(codes.Unavailable, "forwarder is cordoned for maintenance, retry on another forwarder")
+}
+
+func (c *cordonServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
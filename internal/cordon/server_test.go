@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cordon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cordon"
+)
+
+// countingServer - counts the Requests and Closes it passes
+type countingServer struct {
+	requests, closes int
+}
+
+func (c *countingServer) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	c.requests++
+	return request.GetConnection(), nil
+}
+
+func (c *countingServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	c.closes++
+	return &empty.Empty{}, nil
+}
+
+func TestServer(t *testing.T) {
+	c := &cordon.Cordon{}
+	defer c.Clear()
+	next := &countingServer{}
+	known := func(connID string) bool { return connID == "known" }
+	server := chain.NewNetworkServiceServer(cordon.NewServer(c, known), next)
+	request := func(id string) *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: id}}
+	}
+
+	_, err := server.Request(context.Background(), request("new"))
+	require.NoError(t, err)
+	require.Equal(t, 1, next.requests)
+
+	c.Set(time.Hour, func() {})
+	_, err = server.Request(context.Background(), request("new"))
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 1, next.requests, "a new connection was passed on while cordoned")
+
+	// Existing connections are refreshed and closed as usual
+	_, err = server.Request(context.Background(), request("known"))
+	require.NoError(t, err)
+	require.Equal(t, 2, next.requests)
+	_, err = server.Close(context.Background(), request("new").GetConnection())
+	require.NoError(t, err)
+	require.Equal(t, 1, next.closes)
+
+	c.Clear()
+	_, err = server.Request(context.Background(), request("new"))
+	require.NoError(t, err)
+	require.Equal(t, 3, next.requests)
+}
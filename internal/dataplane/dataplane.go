@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dataplane - seam between the forwarder's chain and the backend programming its connections.  The
+// forwarder's own elements (history, audit, cordon, authorization...) are common to every Dataplane, which brings the
+// elements and the endpoint specific to it, so that backends (vpp, kernel, later ovs...) are swappable and the
+// common chain can be exercised without a live vpp.
+package dataplane
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+// Endpoint - the forwarder's endpoint, registered on each of its grpc servers
+type Endpoint interface {
+	Register(s *grpc.Server)
+}
+
+// Dataplane - a backend programming the forwarder's connections
+type Dataplane interface {
+	// Name - returns the name of the dataplane, advertised as the dataplane label
+	Name() string
+	// Outer - returns the elements of the dataplane running ahead of the common elements, before any admission or
+	// authorization
+	Outer() []networkservice.NetworkServiceServer
+	// Inner - returns the elements of the dataplane running once the common elements admitted and authorized a
+	// Request
+	Inner() []networkservice.NetworkServiceServer
+	// NewEndpoint - returns an endpoint named name serving connections through the dataplane, authorizing and
+	// extending its Requests with authzServer and reaching the endpoints of the connections through clientURL
+	NewEndpoint(ctx context.Context, name string, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, clientURL *url.URL, clientDialOptions ...grpc.DialOption) Endpoint
+}
+
+// Elements - returns the elements of dp with the common elements between them, as NewEndpoint chains them
+func Elements(dp Dataplane, common ...networkservice.NetworkServiceServer) []networkservice.NetworkServiceServer {
+	var elements []networkservice.NetworkServiceServer
+	elements = append(elements, dp.Outer()...)
+	elements = append(elements, common...)
+	return append(elements, dp.Inner()...)
+}
+
+// NewEndpoint - returns the endpoint named name of dp, chaining the common elements between those of dp
+func NewEndpoint(ctx context.Context, dp Dataplane, name string, common []networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, clientURL *url.URL, clientDialOptions ...grpc.DialOption) Endpoint {
+	return dp.NewEndpoint(ctx, name, chain.NewNetworkServiceServer(Elements(dp, common...)...), tokenGenerator, clientURL, clientDialOptions...)
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package dataplane_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edwarnicke/grpcfd"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/local"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/client"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/endpoint"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
+	kernelmechanism "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/sendfd"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/ipam/point2pointipam"
+	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cordon"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/fakevppagent"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/history"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/orphanclose"
+)
+
+// forwarder - the common elements of the forwarder around the vpp dataplane on top of a fake vpp-agent, along with an
+// endpoint and a kernel client of its own
+type forwarder struct {
+	vppagent    *fakevppagent.Server
+	connections *conntable.Table
+	recent      *history.Ring
+	client      networkservice.NetworkServiceClient
+}
+
+// newForwarder - serves the forwarder and its endpoint on sockets of dir
+func newForwarder(ctx context.Context, t *testing.T, dir string) *forwarder {
+	f := &forwarder{
+		vppagent:    &fakevppagent.Server{},
+		connections: &conntable.Table{},
+		recent:      history.NewRing(16),
+	}
+	vppagentCC, err := f.vppagent.Dial(ctx)
+	require.NoError(t, err)
+	metadata, err := connmeta.NewStore(ctx, "", time.Hour)
+	require.NoError(t, err)
+
+	nse := endpoint.NewServer(ctx, "endpoint", &allowServer{}, generateToken,
+		mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
+			kernel.MECHANISM: kernelmechanism.NewServer(),
+		}),
+		sendfd.NewServer(),
+		point2pointipam.NewServer(&net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(24, 32)}),
+	)
+	nseURL := &url.URL{Scheme: "unix", Path: filepath.Join(dir, "endpoint.sock")}
+	serve(ctx, t, nseURL, nse.Register)
+
+	dp := &dataplane.VPP{
+		VPPAgentCC: vppagentCC,
+		BaseDir:    dir,
+		TunnelIP:   net.IPv4(127, 0, 0, 1),
+		InitFunc:   func(*configurator.Config) error { return nil },
+		InnerElements: []networkservice.NetworkServiceServer{
+			orphanclose.NewServer(vppagentCC, metadata),
+			connmeta.NewServer(metadata),
+		},
+	}
+	common := []networkservice.NetworkServiceServer{
+		history.NewServer(f.recent),
+		cordon.NewServer(&cordon.Cordon{}, f.connections.Has),
+		conntable.NewServer(f.connections),
+	}
+	ep := dataplane.NewEndpoint(ctx, dp, "forwarder", common, generateToken, nseURL, dialOptions()...)
+	forwarderURL := &url.URL{Scheme: "unix", Path: filepath.Join(dir, "forwarder.sock")}
+	serve(ctx, t, forwarderURL, ep.Register)
+
+	cc, err := grpc.DialContext(ctx, forwarderURL.String(), dialOptions()...)
+	require.NoError(t, err)
+	f.client = client.NewClient(ctx, "client", nil, generateToken, cc,
+		kernelmechanism.NewClient(),
+		sendfd.NewClient(),
+	)
+	return f
+}
+
+// serve - serves the services register registers on listenOn until ctx is done
+func serve(ctx context.Context, t *testing.T, listenOn *url.URL, register func(server *grpc.Server)) {
+	server := grpc.NewServer(grpc.Creds(grpcfd.TransportCredentials(local.NewCredentials())))
+	register(server)
+	select {
+	case err := <-grpcutils.ListenAndServe(ctx, listenOn, server):
+		require.NoError(t, err)
+	default:
+	}
+}
+
+func dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(grpcfd.TransportCredentials(local.NewCredentials())),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+	}
+}
+
+func generateToken(credentials.AuthInfo) (string, time.Time, error) {
+	return "test", time.Now().Add(time.Hour), nil
+}
+
+type allowServer struct{}
+
+func (a *allowServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (a *allowServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// interfaces - returns the names of the vpp interfaces in config
+func interfaces(config *configurator.Config) []string {
+	var names []string
+	for _, iface := range config.GetVppConfig().GetInterfaces() {
+		names = append(names, iface.GetName())
+	}
+	return names
+}
+
+func TestRequestClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataplane")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := newForwarder(ctx, t, dir)
+
+	conn, err := f.client.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{NetworkService: "ns-1"},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, conn.GetContext().GetIpContext().GetSrcIpAddr())
+	entries := f.connections.List()
+	require.Len(t, entries, 1)
+	require.Equal(t, conn.GetId(), entries[0].ID)
+	require.Contains(t, interfaces(f.vppagent.Config()), entries[0].ServerInterface)
+	require.NotEmpty(t, f.vppagent.Config().GetVppConfig().GetXconnectPairs())
+
+	_, err = f.client.Close(ctx, conn)
+	require.NoError(t, err)
+	require.Zero(t, f.connections.Len())
+	require.Empty(t, f.vppagent.Config().GetVppConfig().GetInterfaces())
+	require.Empty(t, f.vppagent.Config().GetVppConfig().GetXconnectPairs())
+	require.Empty(t, f.vppagent.Config().GetLinuxConfig().GetInterfaces())
+
+	txns := f.vppagent.Txns()
+	require.Equal(t, "Update", txns[0].Method)
+	require.Equal(t, "Delete", txns[len(txns)-1].Method)
+
+	records := f.recent.List()
+	require.Len(t, records, 2)
+	require.Equal(t, "Request", records[0].Op)
+	require.Equal(t, "ns-1", records[0].NetworkService)
+	require.Empty(t, records[0].Error)
+	require.Equal(t, "Close", records[1].Op)
+	require.Empty(t, records[1].Error)
+}
+
+func TestCloseFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dataplane")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f := newForwarder(ctx, t, dir)
+
+	conn, err := f.client.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{NetworkService: "ns-1"},
+	})
+	require.NoError(t, err)
+	serverInterface := f.connections.List()[0].ServerInterface
+	f.vppagent.FailDeletes(status.Error(codes.Unavailable, "vpp-agent unavailable"))
+
+	// The forwarder knows the connection, so orphanclose returns the failure rather than cleaning up by name
+	_, err = f.client.Close(ctx, conn)
+	require.Error(t, err)
+	require.Contains(t, interfaces(f.vppagent.Config()), serverInterface)
+
+	records := f.recent.List()
+	require.Len(t, records, 2)
+	require.NotEmpty(t, records[1].Error)
+}
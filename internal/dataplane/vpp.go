@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dataplane
+
+import (
+	"context"
+	"net"
+	"net/url"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk-vppagent/pkg/networkservice/chains/xconnectns"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+// VPPName - name of the vpp dataplane
+const VPPName = "vpp"
+
+// VPP - the vpp dataplane, programming vpp through vpp-agent with the xconnectns chain of sdk-vppagent
+type VPP struct {
+	// VPPAgentCC - connection to vpp-agent
+	VPPAgentCC *grpc.ClientConn
+	// BaseDir - directory of the memif sockets
+	BaseDir string
+	// TunnelIP - local ip of the vxlan tunnels
+	TunnelIP net.IP
+	// InitFunc - adds the initial vpp config, e.g. that of the uplink, to the first vpp-agent Update
+	InitFunc func(conf *configurator.Config) error
+	// OuterElements - the elements Outer returns
+	OuterElements []networkservice.NetworkServiceServer
+	// InnerElements - the elements Inner returns
+	InnerElements []networkservice.NetworkServiceServer
+}
+
+// Name - returns VPPName
+func (v *VPP) Name() string {
+	return VPPName
+}
+
+// Outer - returns v.OuterElements
+func (v *VPP) Outer() []networkservice.NetworkServiceServer {
+	return v.OuterElements
+}
+
+// Inner - returns v.InnerElements
+func (v *VPP) Inner() []networkservice.NetworkServiceServer {
+	return v.InnerElements
+}
+
+// NewEndpoint - returns the xconnectns endpoint of v
+func (v *VPP) NewEndpoint(ctx context.Context, name string, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, clientURL *url.URL, clientDialOptions ...grpc.DialOption) Endpoint {
+	return xconnectns.NewServer(
+		ctx,
+		name,
+		authzServer,
+		tokenGenerator,
+		v.VPPAgentCC,
+		v.BaseDir,
+		v.TunnelIP,
+		v.InitFunc,
+		clientURL,
+		clientDialOptions...,
+	)
+}
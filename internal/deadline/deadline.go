@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deadline - caps the deadlines of the forwarder's grpc calls, so that hung downstream elements can't pin
+// goroutines and vpp-agent txns indefinitely
+package deadline
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// capped - returns ctx with a deadline no later than maxTimeout from now
+func capped(ctx context.Context, maxTimeout time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= maxTimeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, maxTimeout)
+}
+
+// UnaryServerInterceptor - returns an interceptor capping the deadline of incoming calls at maxTimeout, no cap if maxTimeout is 0
+func UnaryServerInterceptor(maxTimeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if maxTimeout <= 0 {
+			return handler(ctx, req)
+		}
+		ctx, cancel := capped(ctx, maxTimeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// UnaryClientInterceptor - returns an interceptor giving outgoing calls an explicit deadline of at most maxTimeout, no cap
+// if maxTimeout is 0
+func UnaryClientInterceptor(maxTimeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if maxTimeout <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx, cancel := capped(ctx, maxTimeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadline_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/deadline"
+)
+
+// remaining - returns the time left until the deadline of ctx, or 0 if it has none
+func remaining(ctx context.Context) time.Duration {
+	if d, ok := ctx.Deadline(); ok {
+		return time.Until(d)
+	}
+	return 0
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var left time.Duration
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		left = remaining(ctx)
+		return nil, nil
+	}
+
+	_, err := deadline.UnaryServerInterceptor(time.Minute)(context.Background(), nil, nil, handler)
+	require.NoError(t, err)
+	require.True(t, left > 0 && left <= time.Minute, left)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err = deadline.UnaryServerInterceptor(time.Minute)(ctx, nil, nil, handler)
+	require.NoError(t, err)
+	require.True(t, left > 0 && left <= time.Minute, left)
+
+	short, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = deadline.UnaryServerInterceptor(time.Minute)(short, nil, nil, handler)
+	require.NoError(t, err)
+	require.True(t, left > 0 && left <= time.Second, left)
+
+	_, err = deadline.UnaryServerInterceptor(0)(context.Background(), nil, nil, handler)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), left)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var left time.Duration
+	invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		left = remaining(ctx)
+		return nil
+	}
+
+	require.NoError(t, deadline.UnaryClientInterceptor(time.Minute)(context.Background(), "/method", nil, nil, nil, invoker))
+	require.True(t, left > 0 && left <= time.Minute, left)
+
+	require.NoError(t, deadline.UnaryClientInterceptor(0)(context.Background(), "/method", nil, nil, nil, invoker))
+	require.Equal(t, time.Duration(0), left)
+}
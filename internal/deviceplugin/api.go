@@ -0,0 +1,178 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deviceplugin
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// The subset of the kubelet device plugin api (k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1) used by the forwarder,
+// wire compatible with its api.proto
+
+const (
+	apiVersion     = "v1beta1"
+	kubeletSocket  = "kubelet.sock"
+	deviceHealthy  = "Healthy"
+	registerMethod = "/v1beta1.Registration/Register"
+)
+
+type empty struct{}
+
+func (m *empty) Reset()         { *m = empty{} }
+func (m *empty) String() string { return proto.CompactTextString(m) }
+func (*empty) ProtoMessage()    {}
+
+type registerRequest struct {
+	Version      string               `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Endpoint     string               `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	ResourceName string               `protobuf:"bytes,3,opt,name=resource_name,json=resourceName,proto3" json:"resource_name,omitempty"`
+	Options      *devicePluginOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+}
+
+func (m *registerRequest) Reset()         { *m = registerRequest{} }
+func (m *registerRequest) String() string { return proto.CompactTextString(m) }
+func (*registerRequest) ProtoMessage()    {}
+
+type devicePluginOptions struct {
+	PreStartRequired                bool `protobuf:"varint,1,opt,name=pre_start_required,json=preStartRequired,proto3" json:"pre_start_required,omitempty"`
+	GetPreferredAllocationAvailable bool `protobuf:"varint,2,opt,name=get_preferred_allocation_available,json=getPreferredAllocationAvailable,proto3" json:"get_preferred_allocation_available,omitempty"`
+}
+
+func (m *devicePluginOptions) Reset()         { *m = devicePluginOptions{} }
+func (m *devicePluginOptions) String() string { return proto.CompactTextString(m) }
+func (*devicePluginOptions) ProtoMessage()    {}
+
+type device struct {
+	ID     string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Health string `protobuf:"bytes,2,opt,name=health,proto3" json:"health,omitempty"`
+}
+
+func (m *device) Reset()         { *m = device{} }
+func (m *device) String() string { return proto.CompactTextString(m) }
+func (*device) ProtoMessage()    {}
+
+type listAndWatchResponse struct {
+	Devices []*device `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+}
+
+func (m *listAndWatchResponse) Reset()         { *m = listAndWatchResponse{} }
+func (m *listAndWatchResponse) String() string { return proto.CompactTextString(m) }
+func (*listAndWatchResponse) ProtoMessage()    {}
+
+type containerAllocateRequest struct {
+	DevicesIDs []string `protobuf:"bytes,1,rep,name=devicesIDs,proto3" json:"devicesIDs,omitempty"`
+}
+
+func (m *containerAllocateRequest) Reset()         { *m = containerAllocateRequest{} }
+func (m *containerAllocateRequest) String() string { return proto.CompactTextString(m) }
+func (*containerAllocateRequest) ProtoMessage()    {}
+
+type allocateRequest struct {
+	ContainerRequests []*containerAllocateRequest `protobuf:"bytes,1,rep,name=container_requests,json=containerRequests,proto3" json:"container_requests,omitempty"`
+}
+
+func (m *allocateRequest) Reset()         { *m = allocateRequest{} }
+func (m *allocateRequest) String() string { return proto.CompactTextString(m) }
+func (*allocateRequest) ProtoMessage()    {}
+
+type mount struct {
+	ContainerPath string `protobuf:"bytes,1,opt,name=container_path,json=containerPath,proto3" json:"container_path,omitempty"`
+	HostPath      string `protobuf:"bytes,2,opt,name=host_path,json=hostPath,proto3" json:"host_path,omitempty"`
+	ReadOnly      bool   `protobuf:"varint,3,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+}
+
+func (m *mount) Reset()         { *m = mount{} }
+func (m *mount) String() string { return proto.CompactTextString(m) }
+func (*mount) ProtoMessage()    {}
+
+type containerAllocateResponse struct {
+	Envs   map[string]string `protobuf:"bytes,1,rep,name=envs,proto3" json:"envs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Mounts []*mount          `protobuf:"bytes,2,rep,name=mounts,proto3" json:"mounts,omitempty"`
+}
+
+func (m *containerAllocateResponse) Reset()         { *m = containerAllocateResponse{} }
+func (m *containerAllocateResponse) String() string { return proto.CompactTextString(m) }
+func (*containerAllocateResponse) ProtoMessage()    {}
+
+type allocateResponse struct {
+	ContainerResponses []*containerAllocateResponse `protobuf:"bytes,1,rep,name=container_responses,json=containerResponses,proto3" json:"container_responses,omitempty"`
+}
+
+func (m *allocateResponse) Reset()         { *m = allocateResponse{} }
+func (m *allocateResponse) String() string { return proto.CompactTextString(m) }
+func (*allocateResponse) ProtoMessage()    {}
+
+// devicePluginServer - the v1beta1.DevicePlugin service, less GetPreferredAllocation which kubelet only calls when
+// advertised in devicePluginOptions
+type devicePluginServer interface {
+	GetDevicePluginOptions(context.Context, *empty) (*devicePluginOptions, error)
+	ListAndWatch(*empty, grpc.ServerStream) error
+	Allocate(context.Context, *allocateRequest) (*allocateResponse, error)
+	PreStartContainer(context.Context, *containerAllocateRequest) (*empty, error)
+}
+
+func unaryHandler(newRequest func() proto.Message, call func(devicePluginServer, context.Context, proto.Message) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		request := newRequest()
+		if err := dec(request); err != nil {
+			return nil, err
+		}
+		return call(srv.(devicePluginServer), ctx, request)
+	}
+}
+
+var devicePluginServiceDesc = grpc.ServiceDesc{
+	ServiceName: "v1beta1.DevicePlugin",
+	HandlerType: (*devicePluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDevicePluginOptions",
+			Handler: unaryHandler(func() proto.Message { return &empty{} }, func(s devicePluginServer, ctx context.Context, m proto.Message) (interface{}, error) {
+				return s.GetDevicePluginOptions(ctx, m.(*empty))
+			}),
+		},
+		{
+			MethodName: "Allocate",
+			Handler: unaryHandler(func() proto.Message { return &allocateRequest{} }, func(s devicePluginServer, ctx context.Context, m proto.Message) (interface{}, error) {
+				return s.Allocate(ctx, m.(*allocateRequest))
+			}),
+		},
+		{
+			// PreStartContainerRequest has the same fields as ContainerAllocateRequest, and its response none
+			MethodName: "PreStartContainer",
+			Handler: unaryHandler(func() proto.Message { return &containerAllocateRequest{} }, func(s devicePluginServer, ctx context.Context, m proto.Message) (interface{}, error) {
+				return s.PreStartContainer(ctx, m.(*containerAllocateRequest))
+			}),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListAndWatch",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				request := &empty{}
+				if err := stream.RecvMsg(request); err != nil {
+					return err
+				}
+				return srv.(devicePluginServer).ListAndWatch(request, stream)
+			},
+		},
+	},
+}
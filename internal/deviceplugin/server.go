@@ -0,0 +1,176 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deviceplugin - kubelet device plugin advertising the networkservicemesh.io/socket resource, so that client
+// pods requesting it get the memif socket directory mounted by kubelet instead of by a hostPath volume
+package deviceplugin
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// ResourceName - name of the resource advertised to kubelet
+const ResourceName = "networkservicemesh.io/socket"
+
+const (
+	pluginSocket        = "forwarder-socket.sock"
+	socketCheckPeriod   = 5 * time.Second
+	registerDialTimeout = 10 * time.Second
+)
+
+// Config - configuration of the device plugin
+type Config struct {
+	Enabled      bool   `default:"false" desc:"serve a kubelet device plugin for the networkservicemesh.io/socket resource"`
+	Dir          string `default:"/var/lib/kubelet/device-plugins" desc:"kubelet device plugin directory"`
+	HostDir      string `desc:"host directory of memif sockets mounted into allocating containers" split_words:"true"`
+	ContainerDir string `default:"/var/lib/networkservicemesh" desc:"path HostDir is mounted at in allocating containers" split_words:"true"`
+	Capacity     int    `default:"100" desc:"number of containers on the node that may be allocated the resource"`
+}
+
+type devicePlugin struct {
+	config *Config
+}
+
+// ListenAndServe - serves the device plugin and registers it with kubelet until ctx is done.  Kubelet removes the
+// plugin socket when it restarts, in which case the plugin is served and registered anew.
+func ListenAndServe(ctx context.Context, config *Config) <-chan error {
+	errCh := make(chan error, 1)
+	if config.HostDir == "" {
+		errCh <- errors.New("device plugin host dir is not set")
+		close(errCh)
+		return errCh
+	}
+	go func() {
+		defer close(errCh)
+		for {
+			server, err := serve(ctx, config)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			waitForSocketRemoval(ctx, filepath.Join(config.Dir, pluginSocket))
+			server.Stop()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Entry(ctx).Infof("device plugin socket removed (kubelet restarted?), registering again")
+		}
+	}()
+	return errCh
+}
+
+func serve(ctx context.Context, config *Config) (*grpc.Server, error) {
+	socket := filepath.Join(config.Dir, pluginSocket)
+	_ = os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", socket)
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&devicePluginServiceDesc, &devicePlugin{config: config})
+	go func() {
+		if serveErr := server.Serve(ln); serveErr != nil {
+			log.Entry(ctx).Errorf("device plugin server: %+v", serveErr)
+		}
+	}()
+	if err = register(ctx, config); err != nil {
+		server.Stop()
+		return nil, err
+	}
+	log.Entry(ctx).Infof("registered device plugin for %s with kubelet", ResourceName)
+	return server, nil
+}
+
+func register(ctx context.Context, config *Config) error {
+	dialCtx, cancel := context.WithTimeout(ctx, registerDialTimeout)
+	defer cancel()
+	cc, err := grpc.DialContext(dialCtx, "unix://"+filepath.Join(config.Dir, kubeletSocket), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return errors.Wrap(err, "failed to dial kubelet")
+	}
+	defer func() { _ = cc.Close() }()
+	request := &registerRequest{
+		Version:      apiVersion,
+		Endpoint:     pluginSocket,
+		ResourceName: ResourceName,
+		Options:      &devicePluginOptions{},
+	}
+	if err = cc.Invoke(ctx, registerMethod, request, &empty{}); err != nil {
+		return errors.Wrap(err, "failed to register with kubelet")
+	}
+	return nil
+}
+
+func waitForSocketRemoval(ctx context.Context, socket string) {
+	ticker := time.NewTicker(socketCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := os.Stat(socket); os.IsNotExist(err) {
+			return
+		}
+	}
+}
+
+func (d *devicePlugin) GetDevicePluginOptions(context.Context, *empty) (*devicePluginOptions, error) {
+	return &devicePluginOptions{}, nil
+}
+
+// ListAndWatch - advertises Capacity healthy devices, which never change
+func (d *devicePlugin) ListAndWatch(_ *empty, stream grpc.ServerStream) error {
+	response := &listAndWatchResponse{}
+	for i := 0; i < d.config.Capacity; i++ {
+		response.Devices = append(response.Devices, &device{ID: "socket-" + strconv.Itoa(i), Health: deviceHealthy})
+	}
+	if err := stream.SendMsg(response); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+// Allocate - mounts the memif socket directory into every allocating container
+func (d *devicePlugin) Allocate(_ context.Context, request *allocateRequest) (*allocateResponse, error) {
+	response := &allocateResponse{}
+	for range request.ContainerRequests {
+		response.ContainerResponses = append(response.ContainerResponses, &containerAllocateResponse{
+			Envs: map[string]string{"NSM_SOCKET_DIR": d.config.ContainerDir},
+			Mounts: []*mount{{
+				ContainerPath: d.config.ContainerDir,
+				HostPath:      d.config.HostDir,
+			}},
+		})
+	}
+	return response, nil
+}
+
+func (d *devicePlugin) PreStartContainer(context.Context, *containerAllocateRequest) (*empty, error) {
+	return &empty{}, nil
+}
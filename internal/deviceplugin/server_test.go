@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deviceplugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// kubelet - serves the kubelet registration service on dir, sending the registration requests it receives
+func kubelet(t *testing.T, dir string) (*grpc.Server, <-chan *registerRequest) {
+	requests := make(chan *registerRequest, 10)
+	ln, err := net.Listen("unix", filepath.Join(dir, kubeletSocket))
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "v1beta1.Registration",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Register",
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				request := &registerRequest{}
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				requests <- request
+				return &empty{}, nil
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = server.Serve(ln) }()
+	return server, requests
+}
+
+func TestRegistersAndAllocates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deviceplugin")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	server, requests := kubelet(t, dir)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := &Config{Dir: dir, HostDir: "/var/lib/networkservicemesh", ContainerDir: "/nsm", Capacity: 3}
+	errCh := ListenAndServe(ctx, config)
+
+	var request *registerRequest
+	select {
+	case request = <-requests:
+	case err = <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the device plugin did not register")
+	}
+	require.Equal(t, apiVersion, request.Version)
+	require.Equal(t, pluginSocket, request.Endpoint)
+	require.Equal(t, ResourceName, request.ResourceName)
+
+	cc, err := grpc.DialContext(ctx, "unix://"+filepath.Join(dir, pluginSocket), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	stream, err := cc.NewStream(ctx, &devicePluginServiceDesc.Streams[0], "/v1beta1.DevicePlugin/ListAndWatch")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&empty{}))
+	require.NoError(t, stream.CloseSend())
+	devices := &listAndWatchResponse{}
+	require.NoError(t, stream.RecvMsg(devices))
+	require.Len(t, devices.Devices, 3)
+	for _, d := range devices.Devices {
+		require.Equal(t, deviceHealthy, d.Health)
+	}
+
+	allocated := &allocateResponse{}
+	require.NoError(t, cc.Invoke(ctx, "/v1beta1.DevicePlugin/Allocate", &allocateRequest{
+		ContainerRequests: []*containerAllocateRequest{{DevicesIDs: []string{"socket-0"}}, {DevicesIDs: []string{"socket-1"}}},
+	}, allocated))
+	require.Len(t, allocated.ContainerResponses, 2)
+	for _, container := range allocated.ContainerResponses {
+		require.Equal(t, map[string]string{"NSM_SOCKET_DIR": "/nsm"}, container.Envs)
+		require.Len(t, container.Mounts, 1)
+		require.Equal(t, "/nsm", container.Mounts[0].ContainerPath)
+		require.Equal(t, "/var/lib/networkservicemesh", container.Mounts[0].HostPath)
+	}
+
+	cancel()
+	for err = range errCh {
+		require.NoError(t, err)
+	}
+}
+
+func TestHostDirRequired(t *testing.T) {
+	err, ok := <-ListenAndServe(context.Background(), &Config{Dir: "/var/lib/kubelet/device-plugins"})
+	require.True(t, ok)
+	require.Error(t, err)
+}
+
+func TestKubeletUnavailable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deviceplugin")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err, ok := <-ListenAndServe(ctx, &Config{Dir: dir, HostDir: "/var/lib/networkservicemesh"})
+	require.True(t, ok)
+	require.Error(t, err)
+}
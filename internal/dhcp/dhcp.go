@@ -0,0 +1,273 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dhcp - answers the DHCP requests of kernel clients with the addresses of their connection's ip context,
+// for clients (such as VM appliances) that insist on DHCP rather than having their addresses injected
+package dhcp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/hostifs"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type dhcpServer struct {
+	hostIfs   *hostifs.Table
+	leaseTime time.Duration
+	mu        sync.Mutex
+	responses map[string]context.CancelFunc
+}
+
+// NewServer - returns a server chain element answering the DHCP requests of kernel clients on the host side of
+// the veth pair of their connection, as recorded in hostIfs, with leases of leaseTime.  It does nothing unless
+// enabled.
+func NewServer(hostIfs *hostifs.Table, enabled bool, leaseTime time.Duration) networkservice.NetworkServiceServer {
+	if !enabled {
+		hostIfs = nil
+	}
+	return &dhcpServer{
+		hostIfs:   hostIfs,
+		leaseTime: leaseTime,
+		responses: make(map[string]context.CancelFunc),
+	}
+}
+
+func (d *dhcpServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || d.hostIfs == nil || conn.GetMechanism().GetType() != kernel.MECHANISM {
+		return conn, err
+	}
+	lease, ok := newLease(conn, d.leaseTime)
+	if !ok {
+		return conn, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, running := d.responses[conn.GetId()]; running {
+		return conn, nil
+	}
+	hostIf := d.hostIfs.Get(vppnames.ServerInterface(conn))
+	if hostIf == "" {
+		log.Entry(ctx).Debugf("connection %s has no veth pair to answer dhcp on", conn.GetId())
+		return conn, nil
+	}
+	// The responder outlives the Request, up to the Close of the connection
+	respondCtx, cancel := context.WithCancel(context.Background())
+	if err := respond(respondCtx, hostIf, lease); err != nil {
+		cancel()
+		log.Entry(ctx).Warnf("failed to answer dhcp for connection %s on %s: %+v", conn.GetId(), hostIf, err)
+		return conn, nil
+	}
+	d.responses[conn.GetId()] = cancel
+	log.Entry(ctx).Infof("answering dhcp for connection %s on %s with %s", conn.GetId(), hostIf, lease.client)
+	return conn, nil
+}
+
+func (d *dhcpServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	d.mu.Lock()
+	if cancel, ok := d.responses[conn.GetId()]; ok {
+		cancel()
+		delete(d.responses, conn.GetId())
+	}
+	d.mu.Unlock()
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// lease - what a client is told
+type lease struct {
+	client net.IP
+	mask   net.IPMask
+	server net.IP
+	router net.IP
+	time   time.Duration
+}
+
+// newLease - returns the lease of the client of conn (its src address), false if it has no ipv4 address
+func newLease(conn *networkservice.Connection, leaseTime time.Duration) (*lease, bool) {
+	ipContext := conn.GetContext().GetIpContext()
+	client, clientNet, err := net.ParseCIDR(ipContext.GetSrcIpAddr())
+	if err != nil || client.To4() == nil {
+		return nil, false
+	}
+	l := &lease{client: client.To4(), mask: clientNet.Mask, server: client.To4(), time: leaseTime}
+	if server, _, err := net.ParseCIDR(ipContext.GetDstIpAddr()); err == nil && server.To4() != nil {
+		l.server = server.To4()
+		if clientNet.Contains(server) && !server.Equal(client) {
+			l.router = l.server
+		}
+	}
+	return l, true
+}
+
+// DHCP message types and options of RFC 2131 and 2132
+const (
+	bootRequest   = 1
+	bootReply     = 2
+	msgDiscover   = 1
+	msgOffer      = 2
+	msgRequest    = 3
+	msgAck        = 5
+	optSubnetMask = 1
+	optRouter     = 3
+	optLeaseTime  = 51
+	optMsgType    = 53
+	optServerID   = 54
+	optEnd        = 255
+	optPad        = 0
+	bootpLen      = 236
+	serverPort    = 67
+	clientPort    = 68
+	flagBroadcast = 0x8000
+)
+
+var magicCookie = []byte{99, 130, 83, 99}
+
+// reply - returns the ethernet frame, sent from srcMAC, answering the DHCP message request, or nil if it needs no
+// answer
+func (l *lease) reply(request []byte, srcMAC net.HardwareAddr) []byte {
+	if len(request) < bootpLen+len(magicCookie) || request[0] != bootRequest || string(request[bootpLen:bootpLen+4]) != string(magicCookie) {
+		return nil
+	}
+	var replyType byte
+	switch messageType(request[bootpLen+4:]) {
+	case msgDiscover:
+		replyType = msgOffer
+	case msgRequest:
+		replyType = msgAck
+	default:
+		return nil
+	}
+	chaddr := net.HardwareAddr(request[28:34])
+	flags := binary.BigEndian.Uint16(request[10:])
+
+	msg := make([]byte, bootpLen, bootpLen+64)
+	msg[0] = bootReply
+	msg[1], msg[2] = 1, 6        // ethernet
+	copy(msg[4:8], request[4:8]) // xid
+	binary.BigEndian.PutUint16(msg[10:], flags)
+	copy(msg[16:20], l.client)       // yiaddr
+	copy(msg[20:24], l.server)       // siaddr
+	copy(msg[24:28], request[24:28]) // giaddr
+	copy(msg[28:44], request[28:44]) // chaddr
+	msg = append(msg, magicCookie...)
+	msg = append(msg, optMsgType, 1, replyType)
+	msg = append(msg, optServerID, 4)
+	msg = append(msg, l.server...)
+	msg = append(msg, optLeaseTime, 4, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(msg[len(msg)-4:], uint32(l.time/time.Second))
+	msg = append(msg, optSubnetMask, 4)
+	msg = append(msg, l.mask...)
+	if l.router != nil {
+		msg = append(msg, optRouter, 4)
+		msg = append(msg, l.router...)
+	}
+	msg = append(msg, optEnd)
+
+	dstMAC, dstIP := chaddr, l.client
+	if flags&flagBroadcast != 0 {
+		dstMAC, dstIP = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, net.IPv4bcast.To4()
+	}
+	return frame(srcMAC, dstMAC, l.server, dstIP, msg)
+}
+
+// messageType - returns the DHCP message type among options, 0 if there is none
+func messageType(options []byte) byte {
+	for i := 0; i < len(options); {
+		switch options[i] {
+		case optPad:
+			i++
+			continue
+		case optEnd:
+			return 0
+		}
+		if i+1 >= len(options) {
+			return 0
+		}
+		length := int(options[i+1])
+		if options[i] == optMsgType && length == 1 && i+2 < len(options) {
+			return options[i+2]
+		}
+		i += 2 + length
+	}
+	return 0
+}
+
+// frame - returns an ethernet frame carrying payload in a udp datagram from the dhcp server to the client port
+func frame(srcMAC, dstMAC net.HardwareAddr, srcIP, dstIP net.IP, payload []byte) []byte {
+	const ethLen, ipLen, udpLen = 14, 20, 8
+	b := make([]byte, ethLen+ipLen+udpLen+len(payload))
+	copy(b[0:6], dstMAC)
+	copy(b[6:12], srcMAC)
+	binary.BigEndian.PutUint16(b[12:], 0x0800)
+
+	ip := b[ethLen : ethLen+ipLen]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(ipLen+udpLen+len(payload)))
+	ip[8] = 64 // ttl
+	ip[9] = 17 // udp
+	copy(ip[12:16], srcIP.To4())
+	copy(ip[16:20], dstIP.To4())
+	binary.BigEndian.PutUint16(ip[10:], checksum(ip))
+
+	udp := b[ethLen+ipLen : ethLen+ipLen+udpLen]
+	binary.BigEndian.PutUint16(udp[0:], serverPort)
+	binary.BigEndian.PutUint16(udp[2:], clientPort)
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpLen+len(payload)))
+	// A udp checksum of 0 means none
+	copy(b[ethLen+ipLen+udpLen:], payload)
+	return b
+}
+
+// dhcpRequest - returns the udp payload of frame if it is a datagram to the dhcp server port, or nil
+func dhcpRequest(frame []byte) []byte {
+	const ethLen = 14
+	if len(frame) < ethLen+20+8 || binary.BigEndian.Uint16(frame[12:]) != 0x0800 {
+		return nil
+	}
+	ip := frame[ethLen:]
+	headerLen := int(ip[0]&0x0f) * 4
+	if ip[9] != 17 || len(ip) < headerLen+8 {
+		return nil
+	}
+	udp := ip[headerLen:]
+	if binary.BigEndian.Uint16(udp[2:]) != serverPort {
+		return nil
+	}
+	return udp[8:]
+}
+
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
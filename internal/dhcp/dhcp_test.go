@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+)
+
+func discover(xid uint32, mac net.HardwareAddr) []byte {
+	msg := make([]byte, bootpLen)
+	msg[0], msg[1], msg[2] = bootRequest, 1, 6
+	binary.BigEndian.PutUint32(msg[4:], xid)
+	copy(msg[28:], mac)
+	msg = append(msg, magicCookie...)
+	return append(msg, optPad, optMsgType, 1, msgDiscover, optEnd)
+}
+
+func TestReplyOffersTheSrcAddress(t *testing.T) {
+	conn := &networkservice.Connection{
+		Context: &networkservice.ConnectionContext{
+			IpContext: &networkservice.IPContext{SrcIpAddr: "10.0.0.2/24", DstIpAddr: "10.0.0.1/24"},
+		},
+	}
+	l, ok := newLease(conn, time.Hour)
+	require.True(t, ok)
+	clientMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+	serverMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 2}
+
+	reply := l.reply(discover(0x1234, clientMAC), serverMAC)
+	require.NotNil(t, reply)
+	require.Equal(t, []byte(clientMAC), reply[0:6])
+	require.Equal(t, []byte(serverMAC), reply[6:12])
+	msg := reply[14+20+8:]
+	require.Equal(t, byte(bootReply), msg[0])
+	require.Equal(t, uint32(0x1234), binary.BigEndian.Uint32(msg[4:]))
+	require.Equal(t, net.ParseIP("10.0.0.2").To4(), net.IP(msg[16:20]))
+	require.Equal(t, byte(msgOffer), messageType(msg[bootpLen+4:]))
+	require.Equal(t, uint16(0), checksum(reply[14:34]))
+}
+
+func TestReplyIgnoresOtherMessages(t *testing.T) {
+	l := &lease{client: net.IPv4(10, 0, 0, 2).To4(), server: net.IPv4(10, 0, 0, 1).To4(), mask: net.CIDRMask(24, 32)}
+	release := discover(1, net.HardwareAddr{0x02, 0, 0, 0, 0, 1})
+	release[bootpLen+7] = 7
+	require.Nil(t, l.reply(release, net.HardwareAddr{0x02, 0, 0, 0, 0, 2}))
+	require.Nil(t, l.reply([]byte{bootRequest}, net.HardwareAddr{0x02, 0, 0, 0, 0, 2}))
+}
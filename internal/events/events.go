@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events - posts significant forwarder events as kubernetes Events on the forwarder's pod, making them
+// visible in kubectl describe, and the critical ones to an alerting webhook.  Events are dropped until Start or
+// StartWebhook is called.
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/k8s"
+)
+
+// Types of events
+const (
+	Normal  = "Normal"
+	Warning = "Warning"
+)
+
+const (
+	queueSize = 64
+	// minInterval - events of the same reason are posted at most once per minInterval, repeats in between are
+	// counted into the next one
+	minInterval = time.Minute
+)
+
+type event struct {
+	eventType, reason, message string
+}
+
+// sink - a destination of events, receiving those it accepts on its queue
+type sink struct {
+	queue  chan *event
+	accept func(e *event) bool
+}
+
+var (
+	mu         sync.Mutex
+	sinks      []*sink
+	lastPosted = make(map[string]time.Time)
+	suppressed = make(map[string]int)
+)
+
+// Pod - the pod events are posted on
+type Pod struct {
+	Name      string
+	Namespace string
+	NodeName  string
+}
+
+// Start - posts events on pod via client until ctx is done
+func Start(ctx context.Context, client *k8s.Client, pod *Pod) {
+	startSink(ctx, func(*event) bool { return true }, func(e *event) error {
+		return post(ctx, client, pod, e)
+	})
+}
+
+// startSink - delivers the events accept accepts to deliver until ctx is done
+func startSink(ctx context.Context, accept func(e *event) bool, deliver func(e *event) error) {
+	s := &sink{queue: make(chan *event, queueSize), accept: accept}
+	mu.Lock()
+	sinks = append(sinks, s)
+	mu.Unlock()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				mu.Lock()
+				for i := range sinks {
+					if sinks[i] == s {
+						sinks = append(sinks[:i], sinks[i+1:]...)
+						break
+					}
+				}
+				mu.Unlock()
+				return
+			case e := <-s.queue:
+				if err := deliver(e); err != nil {
+					log.Entry(ctx).Warnf("failed to post %s event %s: %+v", e.eventType, e.reason, err)
+				}
+			}
+		}
+	}()
+}
+
+// Emit - queues an event of eventType (Normal or Warning) for posting, never blocking.  reason is a short
+// UpperCamelCase identifier of what happened, message a human readable description.
+func Emit(eventType, reason, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sinks) == 0 {
+		return
+	}
+	if time.Since(lastPosted[reason]) < minInterval {
+		suppressed[reason]++
+		return
+	}
+	if n := suppressed[reason]; n > 0 {
+		message = fmt.Sprintf("%s (and %d more since %s)", message, n, lastPosted[reason].Format(time.RFC3339))
+	}
+	e := &event{eventType: eventType, reason: reason, message: message}
+	queued := false
+	for _, s := range sinks {
+		if !s.accept(e) {
+			continue
+		}
+		select {
+		case s.queue <- e:
+			queued = true
+		default:
+		}
+	}
+	if queued {
+		lastPosted[reason] = time.Now()
+		delete(suppressed, reason)
+	}
+}
+
+// Emitf - Emit with a formatted message
+func Emitf(eventType, reason, format string, args ...interface{}) {
+	Emit(eventType, reason, fmt.Sprintf(format, args...))
+}
+
+func post(ctx context.Context, client *k8s.Client, pod *Pod, e *event) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	body := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"generateName": pod.Name + ".",
+			"namespace":    pod.Namespace,
+		},
+		"involvedObject": map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"name":       pod.Name,
+			"namespace":  pod.Namespace,
+		},
+		"reason":         e.reason,
+		"message":        e.message,
+		"type":           e.eventType,
+		"source":         map[string]interface{}{"component": "forwarder", "host": pod.NodeName},
+		"firstTimestamp": now,
+		"lastTimestamp":  now,
+		"count":          1,
+	}
+	return client.Do(ctx, http.MethodPost, "/api/v1/namespaces/"+pod.Namespace+"/events", body, nil)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// webhook - serves an alert webhook, sending the alerts it receives
+func webhook(t *testing.T) (*httptest.Server, <-chan *alert) {
+	alerts := make(chan *alert, queueSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a := &alert{}
+		if err := json.NewDecoder(r.Body).Decode(a); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		alerts <- a
+	}))
+	return server, alerts
+}
+
+func requireAlert(t *testing.T, alerts <-chan *alert) *alert {
+	select {
+	case a := <-alerts:
+		return a
+	case <-time.After(time.Second):
+		require.Fail(t, "no alert")
+		return nil
+	}
+}
+
+func requireNoAlert(t *testing.T, alerts <-chan *alert) {
+	select {
+	case a := <-alerts:
+		require.Fail(t, "unexpected alert", "%+v", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookFiltersAndSuppresses(t *testing.T) {
+	server, alerts := webhook(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	StartWebhook(ctx, server.URL, []string{"VPPRestarted"}, map[string]string{"forwarder": "forwarder-1"})
+
+	Emit(Normal, "VPPRestarted", "normal events are not alerted")
+	Emit(Warning, "SVIDExpiring", "reasons not listed are not alerted")
+	Emitf(Warning, "VPPRestarted", "vpp restarted %d times", 1)
+	a := requireAlert(t, alerts)
+	require.Equal(t, Warning, a.Type)
+	require.Equal(t, "VPPRestarted", a.Reason)
+	require.Equal(t, "vpp restarted 1 times", a.Message)
+	require.Equal(t, map[string]string{"forwarder": "forwarder-1"}, a.Source)
+
+	// Repeats within minInterval are counted into the next event posted
+	Emit(Warning, "VPPRestarted", "vpp restarted 2 times")
+	Emit(Warning, "VPPRestarted", "vpp restarted 3 times")
+	requireNoAlert(t, alerts)
+	mu.Lock()
+	lastPosted["VPPRestarted"] = time.Now().Add(-minInterval)
+	mu.Unlock()
+	Emit(Warning, "VPPRestarted", "vpp restarted 4 times")
+	a = requireAlert(t, alerts)
+	require.True(t, strings.HasPrefix(a.Message, "vpp restarted 4 times (and 2 more since "), a.Message)
+}
+
+func TestEventsDroppedWithoutSinks(t *testing.T) {
+	server, alerts := webhook(t)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	StartWebhook(ctx, server.URL, nil, nil)
+	Emit(Warning, "Dropped", "alerted while the webhook is started")
+	requireAlert(t, alerts)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sinks) == 0
+	}, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	delete(lastPosted, "Dropped")
+	mu.Unlock()
+	Emit(Warning, "Dropped", "dropped once the webhook is stopped")
+	mu.Lock()
+	defer mu.Unlock()
+	require.Zero(t, suppressed["Dropped"])
+	require.True(t, lastPosted["Dropped"].IsZero())
+}
+
+func TestPostWebhookStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	err := postWebhook(context.Background(), server.Client(), server.URL, &alert{Type: Warning, Reason: "VPPRestarted"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "503")
+
+	server.Close()
+	require.Error(t, postWebhook(context.Background(), server.Client(), server.URL, &alert{}))
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// webhookTimeout - time a webhook has to accept an alert
+const webhookTimeout = 10 * time.Second
+
+// alert - the json body POSTed to the webhook per event
+type alert struct {
+	Type    string            `json:"type"`
+	Reason  string            `json:"reason"`
+	Message string            `json:"message"`
+	Time    time.Time         `json:"time"`
+	Source  map[string]string `json:"source,omitempty"`
+}
+
+// StartWebhook - POSTs the Warning events of the given reasons, or all of them if reasons is empty, as json to
+// webhookURL until ctx is done, source identifying the forwarder they come from
+func StartWebhook(ctx context.Context, webhookURL string, reasons []string, source map[string]string) {
+	accepted := make(map[string]bool, len(reasons))
+	for _, reason := range reasons {
+		accepted[reason] = true
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+	startSink(ctx, func(e *event) bool {
+		return e.eventType == Warning && (len(accepted) == 0 || accepted[e.reason])
+	}, func(e *event) error {
+		return postWebhook(ctx, client, webhookURL, &alert{
+			Type:    e.eventType,
+			Reason:  e.reason,
+			Message: e.message,
+			Time:    time.Now().UTC(),
+			Source:  source,
+		})
+	})
+}
+
+func postWebhook(ctx context.Context, client *http.Client, webhookURL string, a *alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "failed to post to the alert webhook")
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("alert webhook answered %s", resp.Status)
+	}
+	return nil
+}
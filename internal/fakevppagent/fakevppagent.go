@@ -0,0 +1,223 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package fakevppagent - in-process vpp-agent configurator service recording the txns it is sent instead of
+// programming vpp.  The chain tests run on it, and forwarders built with the fakevpp tag run on it in place of vpp
+// and vpp-agent if NSM_VPP_FAKE is set; it is not linked into the forwarder otherwise.
+package fakevppagent
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1 << 20
+
+// Txn - an Update or Delete the Server was sent
+type Txn struct {
+	// Method - Update or Delete
+	Method string
+	Config *configurator.Config
+}
+
+// Server - fake vpp-agent configurator service, its Get answering the config its Updates and Deletes resulted in.
+// The zero value is empty and ready to use.
+type Server struct {
+	mu        sync.Mutex
+	txns      []*Txn
+	current   *configurator.Config
+	deleteErr error
+}
+
+// Dial - serves s in process until ctx is done and returns a connection to it, dialed with opts in addition to its
+// own
+func (s *Server) Dial(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	listener := bufconn.Listen(bufSize)
+	server := grpc.NewServer()
+	configurator.RegisterConfiguratorServiceServer(server, s)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	opts = append([]grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+	}, opts...)
+	return grpc.DialContext(ctx, "fake-vpp-agent", opts...)
+}
+
+// Txns - returns the Updates and Deletes s was sent, oldest first
+func (s *Server) Txns() []*Txn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Txn{}, s.txns...)
+}
+
+// Config - returns the config the Updates and Deletes s was sent resulted in
+func (s *Server) Config() *configurator.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clone(s.current)
+}
+
+// FailDeletes - has the Deletes s is sent from now on fail with err, leaving the config as it is
+func (s *Server) FailDeletes(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteErr = err
+}
+
+// Get - returns the current config
+func (s *Server) Get(context.Context, *configurator.GetRequest) (*configurator.GetResponse, error) {
+	return &configurator.GetResponse{Config: s.Config()}, nil
+}
+
+// Update - records the Update and puts its items into the current config, replacing those of the same name
+func (s *Server) Update(_ context.Context, request *configurator.UpdateRequest) (*configurator.UpdateResponse, error) {
+	update := clone(request.GetUpdate())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txns = append(s.txns, &Txn{Method: "Update", Config: update})
+	if s.current == nil || request.GetFullResync() {
+		s.current = &configurator.Config{}
+	}
+	apply(s.current, update, false)
+	return &configurator.UpdateResponse{}, nil
+}
+
+// Delete - records the Delete and removes its items from the current config, unless Deletes are to fail
+func (s *Server) Delete(_ context.Context, request *configurator.DeleteRequest) (*configurator.DeleteResponse, error) {
+	deleted := clone(request.GetDelete())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txns = append(s.txns, &Txn{Method: "Delete", Config: deleted})
+	if s.deleteErr != nil {
+		return nil, s.deleteErr
+	}
+	if s.current != nil {
+		apply(s.current, deleted, true)
+	}
+	return &configurator.DeleteResponse{}, nil
+}
+
+// Dump - returns the current config as the state of vpp
+func (s *Server) Dump(context.Context, *configurator.DumpRequest) (*configurator.DumpResponse, error) {
+	return &configurator.DumpResponse{Dump: s.Config()}, nil
+}
+
+// Notify - sends no notifications, there being no vpp to have any
+func (s *Server) Notify(_ *configurator.NotifyRequest, stream configurator.ConfiguratorService_NotifyServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// clone - returns a deep copy of config, an empty config if nil
+func clone(config *configurator.Config) *configurator.Config {
+	if config == nil {
+		return &configurator.Config{}
+	}
+	return proto.Clone(config).(*configurator.Config)
+}
+
+// apply - puts the items of change into current, or removes them if remove is set.  Items are matched by name, or by
+// content if they have none.
+func apply(current, change *configurator.Config, remove bool) {
+	c, ch := reflect.ValueOf(current).Elem(), reflect.ValueOf(change).Elem()
+	for i := 0; i < ch.NumField(); i++ {
+		if c.Type().Field(i).PkgPath != "" || ch.Field(i).Kind() != reflect.Ptr || ch.Field(i).IsNil() {
+			continue
+		}
+		if _, ok := ch.Field(i).Interface().(proto.Message); !ok {
+			continue
+		}
+		if c.Field(i).IsNil() {
+			c.Field(i).Set(reflect.New(c.Field(i).Type().Elem()))
+		}
+		applySection(c.Field(i).Elem(), ch.Field(i).Elem(), remove)
+	}
+}
+
+func applySection(current, change reflect.Value, remove bool) {
+	for i := 0; i < change.NumField(); i++ {
+		field, value := current.Field(i), change.Field(i)
+		if current.Type().Field(i).PkgPath != "" {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.Ptr:
+			if _, ok := value.Interface().(proto.Message); !ok || value.IsNil() {
+				continue
+			}
+			if remove {
+				field.Set(reflect.Zero(field.Type()))
+			} else {
+				field.Set(value)
+			}
+		case reflect.Slice:
+			for j := 0; j < value.Len(); j++ {
+				item, ok := value.Index(j).Interface().(proto.Message)
+				if !ok {
+					break
+				}
+				field.Set(applyItem(field, item, remove))
+			}
+		}
+	}
+}
+
+// applyItem - returns items with item put in place of the one of the same key, or removed if remove is set
+func applyItem(items reflect.Value, item proto.Message, remove bool) reflect.Value {
+	for k := 0; k < items.Len(); k++ {
+		if key(items.Index(k).Interface().(proto.Message)) != key(item) {
+			continue
+		}
+		if remove {
+			return reflect.AppendSlice(items.Slice(0, k), items.Slice(k+1, items.Len()))
+		}
+		items.Index(k).Set(reflect.ValueOf(item))
+		return items
+	}
+	if remove {
+		return items
+	}
+	return reflect.Append(items, reflect.ValueOf(item))
+}
+
+// key - returns the name of item, or its content if it has none
+func key(item proto.Message) string {
+	if named, ok := item.(interface{ GetName() string }); ok && named.GetName() != "" {
+		return named.GetName()
+	}
+	if xconnect, ok := item.(interface{ GetReceiveInterface() string }); ok && xconnect.GetReceiveInterface() != "" {
+		return xconnect.GetReceiveInterface()
+	}
+	return strings.TrimSpace(proto.CompactTextString(item))
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build faultinject
+
+// Package faultinject - injects faults into the forwarder for resilience testing of NSM healing.  Faults are only
+// compiled into builds with the faultinject build tag.
+package faultinject
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Faults
+const (
+	// VPPAgentFail - fails a vpp-agent txn
+	VPPAgentFail = "vppagent_fail"
+	// VPPAgentDelay - delays a vpp-agent txn
+	VPPAgentDelay = "vppagent_delay"
+	// CloseDrop - drops a Close, leaving the connection's vpp state behind
+	CloseDrop = "close_drop"
+	// SVIDDelay - delays fetching the svid at startup
+	SVIDDelay = "svid_delay"
+)
+
+// txnMethods - vpp-agent methods applying txns
+var txnMethods = map[string]bool{
+	"/ligato.configurator.ConfiguratorService/Update": true,
+	"/ligato.configurator.ConfiguratorService/Delete": true,
+}
+
+type fault struct {
+	probability float64
+	delay       time.Duration
+}
+
+var faults = make(map[string]fault)
+
+// Configure - enables the faults of spec, a comma separated list of fault=probability[:delay], e.g.
+// vppagent_fail=0.1,vppagent_delay=0.5:2s,close_drop=0.05,svid_delay=1:10s
+func Configure(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf("invalid fault %q, use fault=probability[:delay]", item)
+		}
+		switch kv[0] {
+		case VPPAgentFail, VPPAgentDelay, CloseDrop, SVIDDelay:
+		default:
+			return errors.Errorf("unknown fault %q", kv[0])
+		}
+		values := strings.SplitN(kv[1], ":", 2)
+		probability, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || probability < 0 || probability > 1 {
+			return errors.Errorf("invalid probability of fault %q", item)
+		}
+		f := fault{probability: probability}
+		if len(values) == 2 {
+			if f.delay, err = time.ParseDuration(values[1]); err != nil {
+				return errors.Wrapf(err, "invalid delay of fault %q", item)
+			}
+		}
+		faults[kv[0]] = f
+	}
+	return nil
+}
+
+// inject - returns the fault named name if configured and it is to be injected now
+func inject(name string) (fault, bool) {
+	f, ok := faults[name]
+	// #nosec
+	return f, ok && rand.Float64() < f.probability
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// Delay - sleeps for the delay of fault name if it is to be injected now
+func Delay(ctx context.Context, name string) {
+	if f, ok := inject(name); ok {
+		log.Entry(ctx).Warnf("fault injection: %s, sleeping %s", name, f.delay)
+		sleep(ctx, f.delay)
+	}
+}
+
+// UnaryClientInterceptor - returns an interceptor delaying and failing vpp-agent txns
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if txnMethods[method] {
+			Delay(ctx, VPPAgentDelay)
+			if _, ok := inject(VPPAgentFail); ok {
+				log.Entry(ctx).Warnf("fault injection: %s, failing %s", VPPAgentFail, method)
+				return status.Errorf(codes.Unavailable, "fault injection: %s", VPPAgentFail)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+type faultInjectServer struct{}
+
+// NewServer - returns a server chain element dropping Closes
+func NewServer() networkservice.NetworkServiceServer {
+	return &faultInjectServer{}
+}
+
+func (f *faultInjectServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (f *faultInjectServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if _, ok := inject(CloseDrop); ok {
+		log.Entry(ctx).Warnf("fault injection: %s, dropping Close of %s", CloseDrop, conn.GetId())
+		return &empty.Empty{}, nil
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+)
+
+type closeCounter struct {
+	closes int
+}
+
+func (c *closeCounter) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return request.GetConnection(), nil
+}
+
+func (c *closeCounter) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	c.closes++
+	return &empty.Empty{}, nil
+}
+
+func configure(t *testing.T, spec string) {
+	faults = make(map[string]fault)
+	require.NoError(t, Configure(spec))
+}
+
+func TestConfigure(t *testing.T) {
+	configure(t, " vppagent_fail=0.1, vppagent_delay=0.5:2s,,close_drop=1")
+	require.Equal(t, map[string]fault{
+		VPPAgentFail:  {probability: 0.1},
+		VPPAgentDelay: {probability: 0.5, delay: 2 * time.Second},
+		CloseDrop:     {probability: 1},
+	}, faults)
+
+	for _, spec := range []string{
+		"vppagent_fail",
+		"unknown=0.5",
+		"close_drop=two",
+		"close_drop=1.5",
+		"close_drop=-1",
+		"svid_delay=1:soon",
+	} {
+		require.Error(t, Configure(spec), spec)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	configure(t, "vppagent_fail=1")
+	invoked := 0
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked++
+		return nil
+	}
+	interceptor := UnaryClientInterceptor()
+
+	err := interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update", nil, nil, nil, invoker)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Equal(t, 0, invoked)
+
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Dump", nil, nil, nil, invoker))
+	require.Equal(t, 1, invoked)
+
+	configure(t, "vppagent_fail=0")
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Delete", nil, nil, nil, invoker))
+	require.Equal(t, 2, invoked)
+}
+
+func TestDelay(t *testing.T) {
+	configure(t, "svid_delay=1:1h")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	Delay(ctx, SVIDDelay)
+	require.True(t, time.Since(start) < time.Hour)
+	require.Error(t, ctx.Err())
+
+	configure(t, "")
+	start = time.Now()
+	Delay(context.Background(), SVIDDelay)
+	require.True(t, time.Since(start) < time.Second)
+}
+
+func TestServerCloseDrop(t *testing.T) {
+	counter := &closeCounter{}
+	server := chain.NewNetworkServiceServer(NewServer(), counter)
+
+	configure(t, "close_drop=1")
+	_, err := server.Close(context.Background(), &networkservice.Connection{Id: "conn"})
+	require.NoError(t, err)
+	require.Equal(t, 0, counter.closes)
+
+	configure(t, "close_drop=0")
+	_, err = server.Close(context.Background(), &networkservice.Connection{Id: "conn"})
+	require.NoError(t, err)
+	require.Equal(t, 1, counter.closes)
+}
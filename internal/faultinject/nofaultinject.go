@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !faultinject
+
+// Package faultinject - injects faults into the forwarder for resilience testing of NSM healing.  Faults are only
+// compiled into builds with the faultinject build tag, this build leaves everything untouched.
+package faultinject
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+// Faults
+const (
+	VPPAgentFail  = "vppagent_fail"
+	VPPAgentDelay = "vppagent_delay"
+	CloseDrop     = "close_drop"
+	SVIDDelay     = "svid_delay"
+)
+
+// Configure - fails for any spec, faults can't be injected into this build
+func Configure(spec string) error {
+	if spec != "" {
+		return errors.New("fault injection needs a build with the faultinject build tag")
+	}
+	return nil
+}
+
+// Delay - does nothing
+func Delay(context.Context, string) {}
+
+// UnaryClientInterceptor - returns an interceptor passing calls through
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+type faultInjectServer struct{}
+
+// NewServer - returns a server chain element passing calls through
+func NewServer() networkservice.NetworkServiceServer {
+	return &faultInjectServer{}
+}
+
+func (f *faultInjectServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (f *faultInjectServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !faultinject
+
+package faultinject_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/faultinject"
+)
+
+func TestConfigure(t *testing.T) {
+	require.NoError(t, faultinject.Configure(""))
+	require.Error(t, faultinject.Configure("vppagent_fail=1"))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	invoked := 0
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked++
+		return nil
+	}
+	err := faultinject.UnaryClientInterceptor()(context.Background(), "/ligato.configurator.ConfiguratorService/Update", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, 1, invoked)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package featuregate - gates experimental subsystems, so that they ship dark and are enabled per deployment with
+// NSM_FEATURE_GATES=InterfacePool=true,TunnelPrewarm=true.  The state of every gate is logged at startup, exported as
+// the feature_gate.<name> metrics and served by Handler.
+package featuregate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// Gates of experimental subsystems
+const (
+	// InterfacePool - the warm pool of vpp tap interfaces (NSM_INTERFACE_POOL_SIZE)
+	InterfacePool = "InterfacePool"
+	// TunnelPrewarm - the prewarming of the other forwarders as tunnel peers (NSM_TUNNEL_PREWARM)
+	TunnelPrewarm = "TunnelPrewarm"
+	// ConfigSnippets - the vpp-agent config snippets of connections (NSM_VPP_CONFIG_SNIPPETS)
+	ConfigSnippets = "ConfigSnippets"
+)
+
+// defaults - the known gates and whether they are enabled unless set
+var defaults = map[string]bool{
+	InterfacePool:  false,
+	TunnelPrewarm:  false,
+	ConfigSnippets: false,
+}
+
+// Gates - the gates set, decoded from comma separated name=bool pairs
+type Gates map[string]bool
+
+// Decode - decodes value into g, failing on unknown gates
+func (g *Gates) Decode(value string) error {
+	gates := make(Gates)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf("invalid feature gate %q, expected name=true|false", pair)
+		}
+		name := strings.TrimSpace(kv[0])
+		if _, ok := defaults[name]; !ok {
+			return errors.Errorf("unknown feature gate %q, known ones are %s", name, strings.Join(names(), ", "))
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return errors.Wrapf(err, "invalid feature gate %q", pair)
+		}
+		gates[name] = enabled
+	}
+	*g = gates
+	return nil
+}
+
+// Enabled - returns whether the gate name is enabled
+func (g Gates) Enabled(name string) bool {
+	if enabled, ok := g[name]; ok {
+		return enabled
+	}
+	return defaults[name]
+}
+
+// All - returns the state of every known gate
+func (g Gates) All() map[string]bool {
+	rv := make(map[string]bool, len(defaults))
+	for name := range defaults {
+		rv[name] = g.Enabled(name)
+	}
+	return rv
+}
+
+// Report - logs the state of every known gate and exports it as the feature_gate.<name> metrics
+func (g Gates) Report(ctx context.Context) {
+	var states []string
+	for _, name := range names() {
+		enabled := g.Enabled(name)
+		states = append(states, name+"="+strconv.FormatBool(enabled))
+		if enabled {
+			metrics.Int("feature_gate." + name).Set(1)
+		} else {
+			metrics.Int("feature_gate." + name).Set(0)
+		}
+	}
+	log.Entry(ctx).Infof("feature gates: %s", strings.Join(states, ", "))
+}
+
+// Require - returns an error naming what needs the gate name unless it is enabled
+func (g Gates) Require(name, what string) error {
+	if g.Enabled(name) {
+		return nil
+	}
+	return errors.Errorf("%s needs the %s feature gate, enable it with NSM_FEATURE_GATES=%s=true", what, name, name)
+}
+
+// Handler - returns an http handler serving the state of every known gate as json
+func Handler(g Gates) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		w.Header().Set("Content-Type", "application/json")
+		_ = encoder.Encode(g.All())
+	})
+}
+
+func names() []string {
+	rv := make([]string, 0, len(defaults))
+	for name := range defaults {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package featuregate_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/featuregate"
+)
+
+func TestDecode(t *testing.T) {
+	var g featuregate.Gates
+	require.NoError(t, g.Decode(" InterfacePool = true ,, TunnelPrewarm=false"))
+	require.True(t, g.Enabled(featuregate.InterfacePool))
+	require.False(t, g.Enabled(featuregate.TunnelPrewarm))
+	require.False(t, g.Enabled(featuregate.ConfigSnippets), "a gate not set is not at its default")
+
+	require.NoError(t, g.Decode(""))
+	require.Empty(t, g)
+
+	for _, value := range []string{"InterfacePool", "InterfacePool=yes", "Unknown=true"} {
+		g = featuregate.Gates{featuregate.InterfacePool: true}
+		require.Error(t, g.Decode(value), value)
+		require.True(t, g.Enabled(featuregate.InterfacePool), "a failed decode changed the gates")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	g := featuregate.Gates{featuregate.InterfacePool: true}
+	require.NoError(t, g.Require(featuregate.InterfacePool, "NSM_INTERFACE_POOL_SIZE"))
+	err := g.Require(featuregate.TunnelPrewarm, "NSM_TUNNEL_PREWARM")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "NSM_FEATURE_GATES=TunnelPrewarm=true")
+}
+
+func TestHandler(t *testing.T) {
+	handler := featuregate.Handler(featuregate.Gates{featuregate.ConfigSnippets: true})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var gates map[string]bool
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &gates))
+	require.Equal(t, map[string]bool{
+		featuregate.InterfacePool:  false,
+		featuregate.TunnelPrewarm:  false,
+		featuregate.ConfigSnippets: true,
+	}, gates)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
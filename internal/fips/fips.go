@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fips - the FIPS 140-2 mode of the forwarder.
+//
+// Built with a boringcrypto go toolchain (go build -tags boringcrypto), the forwarder's crypto is BoringCrypto's
+// validated module and crypto/tls only negotiates FIPS approved parameters.  The tls parameters configured are
+// then restricted likewise so that a non compliant config fails at startup rather than at the first handshake.
+package fips
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// cipherSuites - the tls 1.2 cipher suites approved for FIPS, also the defaults in FIPS mode
+var cipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// CurvePreferences - the elliptic curves approved for FIPS
+var CurvePreferences = []tls.CurveID{tls.CurveP256, tls.CurveP384}
+
+// Enabled - returns true if the forwarder's crypto is BoringCrypto's
+func Enabled() bool {
+	return enabled()
+}
+
+// Report - logs whether FIPS mode is enabled and exports it as the fips_enabled metric
+func Report(ctx context.Context) {
+	if Enabled() {
+		metrics.Int("fips_enabled").Set(1)
+		log.Entry(ctx).Infof("FIPS mode: enabled, crypto is BoringCrypto")
+		return
+	}
+	metrics.Int("fips_enabled").Set(0)
+	log.Entry(ctx).Infof("FIPS mode: disabled, crypto is go's")
+}
+
+// Require - returns an error unless FIPS mode is enabled
+func Require() error {
+	if !Enabled() {
+		return errors.New("FIPS mode is required but the forwarder was not built with boringcrypto")
+	}
+	return nil
+}
+
+// Restrict - returns suites restricted to the FIPS approved ones, all of them if suites is empty.  Configured suites
+// or a minVersion other than tls 1.2, the only version boringcrypto negotiates, are an error.
+func Restrict(minVersion uint16, suites []uint16) ([]uint16, error) {
+	if minVersion != tls.VersionTLS12 {
+		return nil, errors.Errorf("tls version %#04x is not approved in FIPS mode, only 1.2 is", minVersion)
+	}
+	if len(suites) == 0 {
+		return cipherSuites, nil
+	}
+	for _, suite := range suites {
+		if !approved(suite) {
+			return nil, errors.Errorf("cipher suite %#04x is not approved in FIPS mode", suite)
+		}
+	}
+	return suites, nil
+}
+
+func approved(suite uint16) bool {
+	for _, s := range cipherSuites {
+		if s == suite {
+			return true
+		}
+	}
+	return false
+}
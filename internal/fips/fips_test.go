@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package fips_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/fips"
+)
+
+func TestRestrict(t *testing.T) {
+	suites, err := fips.Restrict(tls.VersionTLS12, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, suites, "no suites are approved by default")
+
+	suites, err = fips.Restrict(tls.VersionTLS12, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256})
+	require.NoError(t, err)
+	require.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, suites)
+
+	_, err = fips.Restrict(tls.VersionTLS12, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305})
+	require.Error(t, err)
+	_, err = fips.Restrict(tls.VersionTLS13, nil)
+	require.Error(t, err)
+	_, err = fips.Restrict(tls.VersionTLS11, nil)
+	require.Error(t, err)
+}
+
+func TestRequire(t *testing.T) {
+	if fips.Enabled() {
+		require.NoError(t, fips.Require())
+		return
+	}
+	require.Error(t, fips.Require(), "FIPS mode was required from a build without boringcrypto")
+}
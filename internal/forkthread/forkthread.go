@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package forkthread - runs funcs on an os thread that never exits.  The parent death signal of a child is sent when
+// the thread that forked it exits, not the process, and goroutines exiting while locked to their thread take it with
+// them, as do the other threads of a process exec'ing.  Children with a parent death signal are forked here, and
+// processes having such children exec from here.
+package forkthread
+
+import (
+	"runtime"
+	"sync"
+)
+
+var (
+	once    sync.Once
+	funcsCh chan func()
+)
+
+// Run - runs f on the os thread of the package, returns once f has returned
+func Run(f func()) {
+	once.Do(func() {
+		funcsCh = make(chan func())
+		go func() {
+			runtime.LockOSThread()
+			for f := range funcsCh {
+				f()
+			}
+		}()
+	})
+	done := make(chan struct{})
+	funcsCh <- func() {
+		defer close(done)
+		f()
+	}
+	<-done
+}
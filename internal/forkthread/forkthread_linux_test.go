@@ -0,0 +1,47 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forkthread_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forkthread"
+)
+
+func TestRunOnOneThread(t *testing.T) {
+	var tid int
+	forkthread.Run(func() { tid = unix.Gettid() })
+	require.NotEqual(t, 0, tid)
+
+	var wg sync.WaitGroup
+	tids := make([]int, 10)
+	for i := range tids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			forkthread.Run(func() { tids[i] = unix.Gettid() })
+		}(i)
+	}
+	wg.Wait()
+	for _, other := range tids {
+		require.Equal(t, tid, other)
+	}
+}
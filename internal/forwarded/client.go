@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarded
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const networkServicePrefix = "/networkservice.NetworkService/"
+
+// UnaryClientInterceptor - returns an interceptor recording, in the path segment of the forwarder named name, when
+// each Request is sent on and appending the peers it was forwarded by, as far as trust has it, to the outgoing
+// metadata
+func UnaryClientInterceptor(name string, trust Trust) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !strings.HasPrefix(method, networkServicePrefix) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if request, ok := req.(*networkservice.NetworkServiceRequest); ok {
+			stampSent(request.GetConnection(), name)
+		}
+		for _, p := range trust.Peers(ctx) {
+			ctx = metadata.AppendToOutgoingContext(ctx, Header, p)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// stampSent - records the send time in the last path segment of conn named name, the one of this forwarder
+func stampSent(conn *networkservice.Connection, name string) {
+	segments := conn.GetPath().GetPathSegments()
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		if segment.GetName() != name || segment.GetMetrics()[ReceivedKey] == "" {
+			continue
+		}
+		now := time.Now().UTC()
+		segment.Metrics[SentKey] = now.Format(time.RFC3339Nano)
+		if received, err := time.Parse(time.RFC3339Nano, segment.Metrics[ReceivedKey]); err == nil {
+			segment.Metrics[ProcessingKey] = now.Sub(received).String()
+		}
+		return
+	}
+}
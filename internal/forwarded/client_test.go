@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarded_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarded"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	received := time.Now().Add(-time.Second).UTC()
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Path: &networkservice.Path{
+				PathSegments: []*networkservice.PathSegment{
+					{Name: "forwarder", Metrics: map[string]string{forwarded.ReceivedKey: received.Format(time.RFC3339Nano)}},
+					{Name: "nsmgr"},
+				},
+			},
+		},
+	}
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	interceptor := forwarded.UnaryClientInterceptor("forwarder", forwarded.NewTrust(nil))
+	ctx := peerContext(context.Background(), "spiffe://example.org/nsc")
+
+	require.NoError(t, interceptor(ctx, "/networkservice.NetworkService/Request", request, nil, nil, invoker))
+	metrics := request.GetConnection().GetPath().GetPathSegments()[0].GetMetrics()
+	_, err := time.Parse(time.RFC3339Nano, metrics[forwarded.SentKey])
+	require.NoError(t, err)
+	processing, err := time.ParseDuration(metrics[forwarded.ProcessingKey])
+	require.NoError(t, err)
+	require.True(t, processing >= time.Second, processing)
+	require.Equal(t, []string{"spiffe://example.org/nsc"}, outgoing.Get(forwarded.Header))
+
+	// Other calls are passed as they are
+	outgoing = nil
+	require.NoError(t, interceptor(ctx, "/registry.NetworkServiceEndpointRegistry/Register", nil, nil, nil, invoker))
+	require.Empty(t, outgoing.Get(forwarded.Header))
+}
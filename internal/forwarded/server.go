@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forwarded - preserves where a Request came from as it is forwarded onward, so that downstream elements and
+// audits can reconstruct its full path, including the time each forwarder took to process it.
+//
+// The forwarder's own path segment records the peer it received the Request from and when it received and sent it
+// on, in its metrics.  The spiffe ids of the peers along the path are carried in the x-forwarded-spiffe-id grpc
+// metadata, appended to by every forwarder.
+package forwarded
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+// Header - grpc metadata carrying the spiffe ids of the peers a Request was forwarded by, in order
+const Header = "x-forwarded-spiffe-id"
+
+// Path segment metrics
+const (
+	// PeerKey - spiffe id of the peer the Request was received from
+	PeerKey = "forwarded.peer"
+	// ReceivedKey - time the Request was received, RFC3339 with nanoseconds
+	ReceivedKey = "forwarded.received"
+	// SentKey - time the Request was sent on, RFC3339 with nanoseconds
+	SentKey = "forwarded.sent"
+	// ProcessingKey - time taken between receiving and sending on the Request, as a duration string
+	ProcessingKey = "forwarded.processing"
+)
+
+type forwardedServer struct{}
+
+// NewServer - returns a server chain element recording the peer and time of every Request in the forwarder's path
+// segment
+func NewServer() networkservice.NetworkServiceServer {
+	return &forwardedServer{}
+}
+
+func (f *forwardedServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if segment := currentSegment(request.GetConnection()); segment != nil {
+		if segment.Metrics == nil {
+			segment.Metrics = make(map[string]string)
+		}
+		segment.Metrics[ReceivedKey] = time.Now().UTC().Format(time.RFC3339Nano)
+		delete(segment.Metrics, SentKey)
+		delete(segment.Metrics, ProcessingKey)
+		if id := peerID(ctx); id != "" {
+			segment.Metrics[PeerKey] = id
+		}
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (f *forwardedServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// Trust - the spiffe ids of the peers, such as NSMgrs and other forwarders, whose Header metadata is taken to be the
+// peers a Request was forwarded by.  Any peer can set the metadata, so that of others is only reported as claimed.
+type Trust map[string]bool
+
+// NewTrust - returns the Trust of the peers with the spiffe ids ids
+func NewTrust(ids []string) Trust {
+	t := make(Trust, len(ids))
+	for _, id := range ids {
+		t[id] = true
+	}
+	return t
+}
+
+// Peers - returns the spiffe ids of the peers the Request of ctx was forwarded by before reaching us, the last being
+// the peer we received it from.  The peers before it are only those of its Header metadata, if it is trusted.
+func (t Trust) Peers(ctx context.Context) []string {
+	id := peerID(ctx)
+	var peers []string
+	if id != "" && t[id] {
+		md, _ := metadata.FromIncomingContext(ctx)
+		peers = append(peers, md.Get(Header)...)
+	}
+	if id != "" {
+		peers = append(peers, id)
+	}
+	return peers
+}
+
+// Claimed - returns the Header metadata of ctx if its peer is not trusted with it, nil otherwise
+func (t Trust) Claimed(ctx context.Context) []string {
+	if id := peerID(ctx); id != "" && t[id] {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return md.Get(Header)
+}
+
+func currentSegment(conn *networkservice.Connection) *networkservice.PathSegment {
+	segments := conn.GetPath().GetPathSegments()
+	index := int(conn.GetPath().GetIndex())
+	if index >= len(segments) {
+		return nil
+	}
+	return segments[index]
+}
+
+// peerID - returns the spiffe id of the peer of ctx, or "" if it has none
+func peerID(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
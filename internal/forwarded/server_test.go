@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarded_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarded"
+)
+
+// peerContext - returns ctx with a tls peer presenting a certificate of spiffeID
+func peerContext(ctx context.Context, spiffeID string) context.Context {
+	id, _ := url.Parse(spiffeID)
+	cert := &x509.Certificate{URIs: []*url.URL{id}}
+	return peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+}
+
+func TestServerRecordsPeer(t *testing.T) {
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "conn-1",
+			Path: &networkservice.Path{
+				Index: 1,
+				PathSegments: []*networkservice.PathSegment{
+					{Name: "nsc"},
+					{Name: "forwarder", Metrics: map[string]string{forwarded.SentKey: "stale", forwarded.ProcessingKey: "stale"}},
+				},
+			},
+		},
+	}
+	before := time.Now()
+	conn, err := chain.NewNetworkServiceServer(forwarded.NewServer()).Request(peerContext(context.Background(), "spiffe://example.org/nsmgr"), request)
+	require.NoError(t, err)
+
+	metrics := conn.GetPath().GetPathSegments()[1].GetMetrics()
+	require.Equal(t, "spiffe://example.org/nsmgr", metrics[forwarded.PeerKey])
+	received, err := time.Parse(time.RFC3339Nano, metrics[forwarded.ReceivedKey])
+	require.NoError(t, err)
+	require.False(t, received.Before(before.Truncate(time.Second)))
+	require.NotContains(t, metrics, forwarded.SentKey)
+	require.NotContains(t, metrics, forwarded.ProcessingKey)
+	require.Empty(t, conn.GetPath().GetPathSegments()[0].GetMetrics())
+}
+
+func TestTrust(t *testing.T) {
+	trust := forwarded.NewTrust([]string{"spiffe://example.org/nsmgr"})
+	md := metadata.Pairs(forwarded.Header, "spiffe://example.org/forwarder-a", forwarded.Header, "spiffe://example.org/nsmgr-a")
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	trusted := peerContext(ctx, "spiffe://example.org/nsmgr")
+	require.Equal(t, []string{
+		"spiffe://example.org/forwarder-a",
+		"spiffe://example.org/nsmgr-a",
+		"spiffe://example.org/nsmgr",
+	}, trust.Peers(trusted))
+	require.Nil(t, trust.Claimed(trusted))
+
+	untrusted := peerContext(ctx, "spiffe://example.org/nsc")
+	require.Equal(t, []string{"spiffe://example.org/nsc"}, trust.Peers(untrusted))
+	require.Equal(t, []string{"spiffe://example.org/forwarder-a", "spiffe://example.org/nsmgr-a"}, trust.Claimed(untrusted))
+
+	require.Empty(t, trust.Peers(ctx), "no peer without tls")
+}
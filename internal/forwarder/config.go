@@ -0,0 +1,233 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/deviceplugin"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/featuregate"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/leakwatch"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/logging"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokengen"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// Config - configuration for cmd-forwarder-vppagent.  The groups are embedded, their fields are processed from the
+// environment with the NSM prefix alone.
+type Config struct {
+	Name              string        `default:"forwarder" desc:"Name of Endpoint"`
+	BaseDir           string        `default:"./" desc:"base directory" split_words:"true"`
+	TunnelIP          net.IP        `desc:"IP to use for tunnels" split_words:"true"`
+	TunnelVRF         uint32        `default:"0" desc:"vrf the uplink, its routes and the vxlan tunnels are put in, 0 for the default table" envconfig:"TUNNEL_VRF"`
+	ManagementVRF     uint32        `default:"0" desc:"vrf created for management and monitoring traffic, 0 for none" envconfig:"MANAGEMENT_VRF"`
+	VPPExtraConfigDir string        `desc:"directory of yaml and json vpp-agent configs applied after the initial vpp configuration, e.g. base acls, spans or loopbacks" envconfig:"VPP_EXTRA_CONFIG_DIR"`
+	ListenOn          []url.URL     `default:"unix:///listen.on.socket" desc:"urls to listen on, unix:@name for an abstract unix socket, ?creds=insecure serves one without tls, the first is registered" split_words:"true"`
+	ConnectTo         url.URL       `default:"unix:///connect.to.socket" desc:"url to connect to" split_words:"true"`
+	MaxTokenLifetime  time.Duration `default:"24h" desc:"maximum lifetime of tokens" split_words:"true"`
+	SecurityConfig
+	AdminConfig
+	RuntimeConfig
+	ClusterConfig
+	LoggingConfig
+	ChainConfig
+	TelemetryConfig
+	TunnelConfig
+	KernelConfig
+	InstanceConfig
+	StartupConfig
+	TestSuiteConfig
+	VPP vppagent.Config
+}
+
+// SecurityConfig - the tls, token and peer policy configuration of the forwarder, along with its privileges
+type SecurityConfig struct {
+	ClockSkew             time.Duration `default:"0" desc:"clock skew between nodes tolerated when validating the tokens and certificate validity windows of peers" split_words:"true"`
+	Token                 tokengen.Config
+	TokenAudiences        []string `desc:"audiences generated tokens are restricted to, the peer spiffe id if empty" split_words:"true"`
+	TLSSessionCacheSize   int      `default:"0" desc:"size of the tls session cache used to resume sessions toward ConnectTo, 0 disables; resumed sessions are not verified again against the svid expiry, allowlist or trust domains" split_words:"true"`
+	TLSMinVersion         string   `default:"1.2" desc:"minimum tls version (1.0, 1.1, 1.2 or 1.3) for the server and clients" split_words:"true"`
+	TLSCipherSuites       []string `desc:"allowed tls 1.0-1.2 cipher suites by name, go defaults if empty" split_words:"true"`
+	FIPS                  bool     `desc:"refuse to start unless built with boringcrypto (go build -tags boringcrypto), tls is restricted to FIPS approved parameters" envconfig:"FIPS"`
+	FederatedBundles      []string `desc:"trust bundles of federated trust domains as trustdomain=file, in addition to those the spire agent federates with" split_words:"true"`
+	TrustDomains          []string `desc:"trust domains peers are accepted from, any trust domain with a known bundle if empty" split_words:"true"`
+	PeerAudit             bool     `default:"false" desc:"log the peer spiffe id and certificate serial of every new connection and Request" split_words:"true"`
+	PeerAuditFile         string   `desc:"append-only file receiving peer audit records as json lines, used if PeerAudit is set" split_words:"true"`
+	PeerAllowlistFile     string   `desc:"file of allowed (and '!' prefixed denied) peer spiffe ids, reloaded on change, any peer is allowed if empty" split_words:"true"`
+	ForwardedTrustedPeers []string `desc:"spiffe ids of the peers trusted with the x-forwarded-spiffe-id metadata, that of other peers is only audited as claimed" split_words:"true"`
+	DropPrivileges        bool     `default:"false" desc:"drop all capabilities but Capabilities by re-executing the forwarder" split_words:"true"`
+	Capabilities          []string `default:"NET_ADMIN,NET_RAW,SYS_ADMIN,SYS_PTRACE,IPC_LOCK" desc:"capabilities the forwarder requires and keeps when dropping privileges" split_words:"true"`
+}
+
+// AdminConfig - the configuration of the admin api and the grpc reflection api
+type AdminConfig struct {
+	AdminListenOn  url.URL       `desc:"url to serve the admin api (metrics at /metrics, connections at /connections) on, disabled if empty" split_words:"true"`
+	HistorySize    int           `default:"256" desc:"number of recent Requests and Closes served at /history of the admin api, 0 keeps none" split_words:"true"`
+	CordonWindow   time.Duration `default:"5m" desc:"time the existing connections of a forwarder cordoned through the admin api are served before it shuts down, 0 serves them until the cordon is lifted" split_words:"true"`
+	GRPCReflection bool          `default:"false" desc:"serve the grpc reflection api on ListenOn, e.g. for grpcurl" split_words:"true"`
+}
+
+// RuntimeConfig - the configuration of the go runtime of the forwarder
+type RuntimeConfig struct {
+	GOMAXPROCS      int           `default:"0" desc:"GOMAXPROCS of the forwarder, following the cpu quota of its container if 0" envconfig:"GOMAXPROCS"`
+	GOGC            int           `default:"100" desc:"gc percent of the forwarder, negative to disable the gc" envconfig:"GOGC"`
+	GCBallast       uint64        `default:"0" desc:"bytes of heap ballast spacing out the gc of a small heap, none if 0" envconfig:"GC_BALLAST"`
+	GoStatsInterval time.Duration `default:"10s" desc:"interval of the go allocation and gc metrics, 0 disables" split_words:"true"`
+}
+
+// ClusterConfig - the configuration of how the forwarder takes part in the cluster: its registration, the labels it
+// advertises, its events and the leader lock of its node
+type ClusterConfig struct {
+	Register         bool                `default:"false" desc:"register the forwarder with the registry served at ConnectTo" split_words:"true"`
+	RegisterLifetime time.Duration       `default:"1m" desc:"lifetime of the registration, capped at MaxTokenLifetime and refreshed at a third of it" split_words:"true"`
+	TunnelPrewarm    bool                `default:"false" desc:"warm the underlay neighbor, bfd session and path mtu and latency probes of each other forwarder in the registry at ConnectTo ahead of any connection to it, through a vxlan tunnel shell connections do not use" split_words:"true"`
+	NodeName         string              `envconfig:"NODE_NAME" desc:"name of the node the forwarder runs on, advertised as the nodeName label"`
+	Zone             string              `desc:"zone advertised as the topology.kubernetes.io/zone label, looked up in the node's labels if empty"`
+	Region           string              `desc:"region advertised as the topology.kubernetes.io/region label, looked up in the node's labels if empty"`
+	DevicePlugin     deviceplugin.Config `split_words:"true"`
+	HeartbeatPeriod  time.Duration       `default:"10s" desc:"period of rewriting BaseDir/alive with the startup phase and readiness, 0 disables" split_words:"true"`
+	PodName          string              `envconfig:"POD_NAME" desc:"name of the forwarder's pod, events are posted on"`
+	PodNamespace     string              `envconfig:"POD_NAMESPACE" desc:"namespace of the forwarder's pod"`
+	KubernetesEvents bool                `default:"false" desc:"post significant events as kubernetes Events on the forwarder's pod" split_words:"true"`
+	AlertWebhookURL  string              `desc:"url receiving the critical events (AlertReasons) as json POSTs, disabled if empty" split_words:"true"`
+	AlertReasons     []string            `default:"ProcessExited,DataplaneFallback,StartupPhaseTimeout,TunnelPeerDown" desc:"reasons of the warning events posted to AlertWebhookURL, all of them if empty" split_words:"true"`
+	LeaderLockFile   string              `desc:"file in a directory shared by the forwarders on the node locked before starting vpp, so only one of them serves at a time, disabled if empty" split_words:"true"`
+}
+
+// LoggingConfig - the configuration of the logs of the forwarder
+type LoggingConfig struct {
+	LogProfile      string                   `default:"dev" desc:"log profile: dev (colored, trace level) or prod (json, info level)" split_words:"true"`
+	LogLevels       []string                 `desc:"per package log level overrides as package=level, e.g. github.com/networkservicemesh/sdk/pkg/networkservice/core/trace=info" split_words:"true"`
+	LogMaxPayload   int                      `default:"16384" desc:"bytes of a log message or field beyond which it is truncated, e.g. trace dumps of large vpp-agent configs, 0 leaves them whole" split_words:"true"`
+	LogDumpDir      string                   `desc:"directory truncated log entries are written to in full, the last 100 being kept, none if empty" split_words:"true"`
+	AccessLog       bool                     `default:"false" desc:"log a line per served call with its method, peer address and spiffe id, deadline, status code and duration" split_words:"true"`
+	TraceSampleRate int                      `default:"1" desc:"log the debug and trace output of 1 in N Requests and Closes, of the others only if they fail" split_words:"true"`
+	LogSink         []string                 `desc:"additional log outputs: syslog://host:port, syslog+tcp://host:port, syslog:// (local) or fluent://host:port, with an optional ?tag=" split_words:"true"`
+	LogEscalation   logging.EscalationConfig `split_words:"true"`
+}
+
+// ChainConfig - the configuration of the chain elements and the vpp-agent txns of the connections
+type ChainConfig struct {
+	FeatureGates          featuregate.Gates `desc:"comma separated name=true|false experimental subsystems are enabled with, e.g. InterfacePool=true" split_words:"true"`
+	SteeringRulesFile     string            `desc:"yaml file of rules restricting the mechanisms of connections by labels and network service, reloaded on change, any mechanism is allowed if empty" split_words:"true"`
+	FaultInjection        string            `desc:"faults to inject as fault=probability[:delay],..., builds with the faultinject tag only" split_words:"true"`
+	MaxRequestTimeout     time.Duration     `default:"1m" desc:"cap on the deadline of incoming and forwarded Requests, vpp-agent txns included, 0 disables" split_words:"true"`
+	Leak                  leakwatch.Config
+	TxnDedupTTL           time.Duration `default:"0" desc:"vpp-agent Updates identical to one applied less than this ago, since vpp last lost state, are skipped, e.g. on refresh, 0 disables" split_words:"true"`
+	IDRanges              []string      `default:"vni=1-16777215,vlan=1-4094,sa=1-4294967295,mpls=16-1048575" desc:"ranges ids of each kind (vni, vlan, sa, mpls) are allocated from as kind=min-max" split_words:"true"`
+	BackpressureThreshold int           `default:"0" desc:"vpp-agent txns in flight above which Requests for new connections are asked to retry, 0 disables" split_words:"true"`
+	BackpressureBackoff   time.Duration `default:"1s" desc:"retry delay suggested to Requests turned away per BackpressureThreshold txns in flight" split_words:"true"`
+	VPPConfigSnippets     string        `desc:"directory of vpp-agent config snippets merged into the txns of the connections labelled vppagent-config=<file>, labels are ignored if empty" envconfig:"VPP_CONFIG_SNIPPETS"`
+	TxnConcurrency        int           `default:"0" desc:"vpp-agent txns in flight beyond which txns wait, started by the priority label of their connection and refreshes first, 0 disables" split_words:"true"`
+	InterfacePoolSize     int           `default:"0" desc:"vpp tap interfaces kept created ahead of the Requests for kernel connections, 0 disables" split_words:"true"`
+	SetupSLO              time.Duration `default:"0" desc:"Request latency target, Requests over it are counted as violations against the chain element they spent most time in, 0 disables" envconfig:"SETUP_SLO"`
+	SetupSLOQuantile      float64       `default:"0.99" desc:"quantile of the Request latency held to SetupSLO" envconfig:"SETUP_SLO_QUANTILE"`
+	PingCheckTimeout      time.Duration `default:"0" desc:"fail the Requests of kernel connections whose client cannot ping the dst address within this, 0 to skip the check" split_words:"true"`
+	MirrorTo              string        `desc:"vpp interface (or host:<name> for a host interface) connections labelled mirror=rx|tx|both are mirrored to, disabled if empty" split_words:"true"`
+	FallbackDataplane     string        `desc:"dataplane to serve local kernel connections with when vpp cannot start: kernel (veth pairs only), exits if empty" split_words:"true"`
+	CleanupCheckIdle      time.Duration `default:"0" desc:"once all connections are closed and none is requested for this long, exit, non-zero if vpp is not back to its state after vppinit, for CI and soak tests, 0 disables" split_words:"true"`
+}
+
+// TelemetryConfig - the configuration of the flow export and packet sampling of the connections
+type TelemetryConfig struct {
+	IPFIXCollector    string `desc:"host[:port] of the IPFIX collector the flows of the connection interfaces are exported to, disabled if empty" split_words:"true"`
+	SFlowCollector    string `desc:"host[:port] of the sFlow collector sampled packets of the connections are sent to, disabled if empty" envconfig:"SFLOW_COLLECTOR"`
+	SFlowSamplingRate uint32 `default:"1000" desc:"1 in this many packets of the connections are sent to SFlowCollector" envconfig:"SFLOW_SAMPLING_RATE"`
+}
+
+// TunnelConfig - the configuration of the monitoring and the mtu of the vxlan tunnels
+type TunnelConfig struct {
+	BFDInterval          time.Duration `default:"0" desc:"interval of the BFD packets exchanged with the remote end of every vxlan tunnel, disabled if 0" split_words:"true"`
+	BFDDetectMult        int           `default:"3" desc:"missed BFD packets after which a tunnel peer is declared down" split_words:"true"`
+	PMTUProbeInterval    time.Duration `default:"0" desc:"interval of the path mtu probes to the remote end of every vxlan tunnel, whose mtu follows the path mtu, disabled if 0" split_words:"true"`
+	OversizePolicy       string        `default:"drop" desc:"packets too large for their tunnel are dropped by vpp (drop) or, for kernel interfaces, answered with icmp frag needed by the client's kernel (icmp), which requires PMTUProbeInterval" split_words:"true"`
+	ClampMSS             bool          `default:"false" desc:"clamp the tcp mss on the kernel interfaces of connections to what fits their tunnel, which requires PMTUProbeInterval and iptables" split_words:"true"`
+	LatencyProbeInterval time.Duration `default:"0" desc:"interval between round trip time measurements to the remote ends of vxlan tunnels, 0 to not measure them" split_words:"true"`
+	LatencySLO           time.Duration `default:"0" desc:"round trip time to a tunnel peer above which the connections through it get a warning event, 0 for none" envconfig:"LATENCY_SLO"`
+}
+
+// KernelConfig - the configuration of the services offered to kernel clients connected by veth pairs
+type KernelConfig struct {
+	DHCPServer           bool          `default:"false" desc:"answer the dhcp requests of kernel clients connected by veth pairs with their ip context addresses" split_words:"true"`
+	DHCPLeaseTime        time.Duration `default:"1h" desc:"lease time given to dhcp clients" split_words:"true"`
+	RouterAdvertisements bool          `default:"false" desc:"advertise the ipv6 prefix of kernel clients connected by veth pairs for them to autoconfigure" split_words:"true"`
+	RAInterval           time.Duration `default:"200s" desc:"interval between unsolicited router advertisements" envconfig:"RA_INTERVAL"`
+	LLDP                 bool          `default:"false" desc:"send lldp frames naming the forwarder and connection to kernel clients connected by veth pairs" envconfig:"LLDP"`
+	LLDPInterval         time.Duration `default:"30s" desc:"interval between lldp frames" envconfig:"LLDP_INTERVAL"`
+}
+
+// InstanceConfig - the configuration of the forwarders sharing a node
+type InstanceConfig struct {
+	InstanceID    int `default:"0" desc:"index of this forwarder among the InstanceCount sharing the node, selecting its vpp-agent ports, vpp shared memory prefix, cpus and ids, see the README for what else each needs of its own" envconfig:"INSTANCE_ID"`
+	InstanceCount int `default:"1" desc:"number of forwarders sharing the node, IDRanges being split between them, above 1 each needs its own TunnelIP on its own host interface" split_words:"true"`
+}
+
+// StartupConfig - the timeouts of the startup phases and the actions taken when they expire
+type StartupConfig struct {
+	Phase1 startup.PhaseConfig
+	Phase2 startup.PhaseConfig
+	Phase3 startup.PhaseConfig
+	Phase4 startup.PhaseConfig
+	Phase5 startup.PhaseConfig
+}
+
+// TestSuiteConfig - the configuration of the test-suite subcommand
+type TestSuiteConfig struct {
+	TestSuiteScenarios []string      `default:"local,remote,refresh,heal" desc:"scenarios run by the test-suite subcommand, in order" split_words:"true"`
+	TestSuiteTimeout   time.Duration `default:"1m" desc:"time each scenario of the test-suite subcommand may take" split_words:"true"`
+}
+
+// Validate - returns an error if config, processed from the environment, is inconsistent
+func (c *Config) Validate() error {
+	if len(c.ListenOn) == 0 {
+		return errors.New("NSM_LISTEN_ON is empty")
+	}
+	if c.RouterAdvertisements && c.RAInterval <= 0 {
+		return errors.Errorf("NSM_RA_INTERVAL must be positive, got %s", c.RAInterval)
+	}
+	if c.LLDP && c.LLDPInterval <= 0 {
+		return errors.Errorf("NSM_LLDP_INTERVAL must be positive, got %s", c.LLDPInterval)
+	}
+	if c.Register && c.RegisterLifetime <= 0 {
+		return errors.Errorf("NSM_REGISTER_LIFETIME must be positive, got %s", c.RegisterLifetime)
+	}
+	if c.InstanceID < 0 || c.InstanceID >= c.InstanceCount {
+		return errors.Errorf("NSM_INSTANCE_ID %d is not below NSM_INSTANCE_COUNT %d", c.InstanceID, c.InstanceCount)
+	}
+	for _, gated := range []struct {
+		gate, what string
+		used       bool
+	}{
+		{featuregate.InterfacePool, "NSM_INTERFACE_POOL_SIZE", c.InterfacePoolSize > 0},
+		{featuregate.TunnelPrewarm, "NSM_TUNNEL_PREWARM", c.TunnelPrewarm},
+		{featuregate.ConfigSnippets, "NSM_VPP_CONFIG_SNIPPETS", c.VPPConfigSnippets != ""},
+	} {
+		if gated.used {
+			if err := c.FeatureGates.Require(gated.gate, gated.what); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
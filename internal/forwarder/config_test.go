@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/featuregate"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarder"
+)
+
+// setenv - sets the environment variables of env, returning the func unsetting them
+func setenv(t *testing.T, env map[string]string) func() {
+	for key, value := range env {
+		require.NoError(t, os.Setenv(key, value))
+	}
+	return func() {
+		for key := range env {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+func TestGroupsKeepEnvNames(t *testing.T) {
+	defer setenv(t, map[string]string{
+		"NSM_NAME":               "forwarder-1",
+		"NSM_TLS_MIN_VERSION":    "1.3",
+		"NSM_ADMIN_LISTEN_ON":    "tcp://127.0.0.1:9090",
+		"NSM_GOMAXPROCS":         "2",
+		"NSM_REGISTER_LIFETIME":  "5m",
+		"NSM_LOG_PROFILE":        "prod",
+		"NSM_TXN_DEDUP_TTL":      "1s",
+		"NSM_SFLOW_COLLECTOR":    "collector:6343",
+		"NSM_BFD_INTERVAL":       "100ms",
+		"NSM_RA_INTERVAL":        "10s",
+		"NSM_INSTANCE_COUNT":     "2",
+		"NSM_PHASE2_TIMEOUT":     "30s",
+		"NSM_TEST_SUITE_TIMEOUT": "2m",
+	})()
+
+	config := &forwarder.Config{}
+	require.NoError(t, envconfig.Process("nsm", config))
+	require.Equal(t, "forwarder-1", config.Name)
+	require.Equal(t, "1.3", config.TLSMinVersion)
+	require.Equal(t, "127.0.0.1:9090", config.AdminListenOn.Host)
+	require.Equal(t, 2, config.GOMAXPROCS)
+	require.Equal(t, 5*time.Minute, config.RegisterLifetime)
+	require.Equal(t, "prod", config.LogProfile)
+	require.Equal(t, time.Second, config.TxnDedupTTL)
+	require.Equal(t, "collector:6343", config.SFlowCollector)
+	require.Equal(t, 100*time.Millisecond, config.BFDInterval)
+	require.Equal(t, 10*time.Second, config.RAInterval)
+	require.Equal(t, 2, config.InstanceCount)
+	require.Equal(t, 30*time.Second, config.Phase2.Timeout)
+	require.Equal(t, 2*time.Minute, config.TestSuiteTimeout)
+	// Defaults of the groups are applied too
+	require.Equal(t, 256, config.HistorySize)
+	require.Equal(t, "drop", config.OversizePolicy)
+}
+
+func TestValidate(t *testing.T) {
+	for name, invalidate := range map[string]func(config *forwarder.Config){
+		"no listen on":        func(config *forwarder.Config) { config.ListenOn = nil },
+		"ra interval":         func(config *forwarder.Config) { config.RouterAdvertisements, config.RAInterval = true, 0 },
+		"lldp interval":       func(config *forwarder.Config) { config.LLDP, config.LLDPInterval = true, 0 },
+		"register lifetime":   func(config *forwarder.Config) { config.Register, config.RegisterLifetime = true, 0 },
+		"instance id":         func(config *forwarder.Config) { config.InstanceID = 1 },
+		"negative instance":   func(config *forwarder.Config) { config.InstanceID = -1 },
+		"interface pool gate": func(config *forwarder.Config) { config.InterfacePoolSize = 4 },
+		"prewarm gate":        func(config *forwarder.Config) { config.TunnelPrewarm = true },
+		"snippets gate":       func(config *forwarder.Config) { config.VPPConfigSnippets = "/etc/snippets" },
+	} {
+		config := &forwarder.Config{}
+		require.NoError(t, envconfig.Process("nsm", config))
+		require.NoError(t, config.Validate(), "the defaults are valid")
+		invalidate(config)
+		require.Error(t, config.Validate(), name)
+	}
+}
+
+func TestValidateGatedEnabled(t *testing.T) {
+	config := &forwarder.Config{}
+	require.NoError(t, envconfig.Process("nsm", config))
+	config.InterfacePoolSize = 4
+	config.FeatureGates = featuregate.Gates{featuregate.InterfacePool: true}
+	require.NoError(t, config.Validate())
+}
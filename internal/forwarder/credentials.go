@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+
+	"github.com/edwarnicke/grpcfd"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/audit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/faultinject"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/fips"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/mtls"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/peerpolicy"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/steering"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/svidrotation"
+)
+
+// RetrieveSVID - the third phase: retrieves the x509 svid of the forwarder from the spire agent and watches its
+// rotation
+func (f *Forwarder) RetrieveSVID(ctx context.Context) error {
+	heartbeat.SetPhase("3")
+	if err := startup.Run(ctx, "3", &f.config.Phase3, startup.Retry, func(phaseCtx context.Context) error {
+		faultinject.Delay(phaseCtx, faultinject.SVIDDelay)
+		x509source, sourceErr := workloadapi.NewX509Source(phaseCtx)
+		if sourceErr != nil {
+			return sourceErr
+		}
+		svid, svidErr := x509source.GetX509SVID()
+		if svidErr != nil {
+			_ = x509source.Close()
+			return svidErr
+		}
+		logrus.Infof("SVID: %q", svid.ID)
+		f.source = x509source
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error getting x509 svid")
+	}
+	svidrotation.Watch(ctx, f.source)
+	return nil
+}
+
+// tlsOptions - returns the mtls options of the tls version, cipher suites and trust domains of the config of f,
+// restricted to FIPS approved parameters by a boringcrypto build
+func (f *Forwarder) tlsOptions(ctx context.Context) ([]mtls.Option, error) {
+	config := f.config
+	tlsMinVersion, err := mtls.ParseVersion(config.TLSMinVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing tls min version")
+	}
+	tlsCipherSuites, err := mtls.ParseCipherSuites(config.TLSCipherSuites)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing tls cipher suites")
+	}
+	fips.Report(ctx)
+	var tlsCurves []tls.CurveID
+	if config.FIPS {
+		if err = fips.Require(); err != nil {
+			return nil, errors.Wrap(err, "error verifying FIPS mode")
+		}
+	}
+	// A boringcrypto build restricts tls anyway, a config it would refuse to handshake with is better caught here
+	if fips.Enabled() {
+		if tlsCipherSuites, err = fips.Restrict(tlsMinVersion, tlsCipherSuites); err != nil {
+			return nil, errors.Wrap(err, "error restricting tls to FIPS approved parameters")
+		}
+		tlsCurves = fips.CurvePreferences
+	}
+	if config.TLSSessionCacheSize > 0 {
+		log.Entry(ctx).Warnf("tls session resumption is enabled, resumed sessions skip the verification of the server svid")
+	}
+	return []mtls.Option{
+		mtls.WithSessionCacheSize(config.TLSSessionCacheSize),
+		mtls.WithMinVersion(tlsMinVersion),
+		mtls.WithCipherSuites(tlsCipherSuites),
+		mtls.WithCurvePreferences(tlsCurves),
+		mtls.WithTrustDomains(config.TrustDomains),
+		mtls.WithClockSkew(config.ClockSkew),
+	}, nil
+}
+
+// loadPolicies - loads the federated trust bundles, the peer allowlist and the steering rules of the config of f,
+// the allowlist authorizing the mtls peers along with the Requests
+func (f *Forwarder) loadPolicies(ctx context.Context) error {
+	config := f.config
+	var err error
+	if f.bundles, err = mtls.FederatedBundles(f.source, config.FederatedBundles); err != nil {
+		return errors.Wrap(err, "error loading federated trust bundles")
+	}
+	if config.PeerAllowlistFile != "" {
+		if f.policy, err = peerpolicy.NewFile(ctx, config.PeerAllowlistFile); err != nil {
+			return errors.Wrap(err, "error loading peer allowlist")
+		}
+		f.mtlsOptions = append(f.mtlsOptions, mtls.WithAuthorizer(f.policy.Authorize))
+		for i := range config.ListenOn {
+			if config.ListenOn[i].Query().Get("creds") == "insecure" {
+				log.Entry(ctx).Warnf("the peer allowlist denies every Request served on insecure listener %s", config.ListenOn[i].String())
+			}
+		}
+	}
+	if config.SteeringRulesFile != "" {
+		if f.steeringPolicy, err = steering.NewFile(ctx, config.SteeringRulesFile); err != nil {
+			return errors.Wrap(err, "error loading steering rules")
+		}
+	}
+	f.dialOptions = []grpc.DialOption{
+		grpc.WithTransportCredentials(grpcfd.TransportCredentials(credentials.NewTLS(mtls.ClientConfig(f.source, f.bundles, f.mtlsOptions...)))),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+	}
+	return nil
+}
+
+// serverCredentials - returns the server options securing listenOn: mtls with tlsConfig and fd passing, audited by
+// auditLogger, unless its creds query parameter is insecure
+func serverCredentials(listenOn *url.URL, tlsConfig *tls.Config, auditLogger *audit.Logger) ([]grpc.ServerOption, error) {
+	switch creds := listenOn.Query().Get("creds"); creds {
+	case "", "mtls":
+		serverCreds := grpcfd.TransportCredentials(credentials.NewTLS(tlsConfig))
+		return []grpc.ServerOption{grpc.Creds(audit.TransportCredentials(serverCreds, auditLogger))}, nil
+	case "insecure":
+		return nil, nil
+	default:
+		return nil, errors.Errorf("unknown creds %q, expected mtls or insecure", creds)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/audit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipfix"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/sflow"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokencheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokengen"
+)
+
+// CreateEndpoint - the fourth phase: takes the state of the forwarder over from the previous forwarder process, if
+// any, and creates the xconnect network service endpoint on the dataplane StartVPP left it with
+func (f *Forwarder) CreateEndpoint(ctx context.Context) error {
+	heartbeat.SetPhase("4")
+	config := f.config
+	phaseDone := startup.Bound(ctx, "4", &config.Phase4)
+	defer phaseDone()
+	var err error
+	if config.PeerAudit {
+		if f.auditLogger, err = audit.NewLogger(config.PeerAuditFile); err != nil {
+			return errors.Wrap(err, "error creating peer audit logger")
+		}
+	}
+	if f.mtlsOptions, err = f.tlsOptions(ctx); err != nil {
+		return err
+	}
+	// Have the process we take over from stop changing the state of the forwarder before restoring it
+	if err = handoff.TakeOver(ctx); err != nil {
+		return errors.Wrap(err, "error taking over from the previous forwarder process")
+	}
+	if err = f.init(ctx); err != nil {
+		return errors.Wrap(err, "error creating the forwarder")
+	}
+	handoff.OnStop(f.handOver)
+	if err = f.loadPolicies(ctx); err != nil {
+		return err
+	}
+	authorizeServer := authorize.NewServer()
+	if config.ClockSkew > 0 {
+		authorizeServer = authorize.NewServer(authorize.WithPolicies(tokencheck.Policies(config.ClockSkew)...))
+	}
+	if err = f.startTelemetry(ctx); err != nil {
+		return err
+	}
+	tokenGenerator, err := tokengen.New(ctx, &config.Token, f.source, config.MaxTokenLifetime, config.TokenAudiences...)
+	if err != nil {
+		return errors.Wrap(err, "error creating token generator")
+	}
+	f.dp = f.dataplane(f.vppagentCC)
+	f.xconnect = f.endpoint(ctx, f.dp, authorizeServer, tokenGenerator, &config.ConnectTo, f.dialOptions...)
+	return f.fillInterfacePool(ctx)
+}
+
+// fillInterfacePool - creates the vpp tap interfaces of the interface pool of f, resuming the pool handed over by the
+// previous forwarder process
+func (f *Forwarder) fillInterfacePool(ctx context.Context) error {
+	config := f.config
+	if config.InterfacePoolSize <= 0 || f.kernelFallback() {
+		return nil
+	}
+	// The pool state is only valid for the vpp it was recorded with
+	poolFile := filepath.Join(config.BaseDir, "ifpool.json")
+	if !handoff.Inherited() {
+		_ = os.Remove(poolFile)
+	}
+	if err := f.interfacePool.Start(ctx, f.vppagentCC, config.InterfacePoolSize, poolFile); err != nil {
+		return errors.Wrap(err, "error filling the interface pool")
+	}
+	return nil
+}
+
+// startTelemetry - starts the ipfix flow export and the sflow sampling of the connections of f, neither of which the
+// kernel dataplane has
+func (f *Forwarder) startTelemetry(ctx context.Context) error {
+	config := f.config
+	if f.kernelFallback() {
+		return nil
+	}
+	var err error
+	if config.IPFIXCollector != "" {
+		if f.flows, err = ipfix.NewExporter(ctx, config.VPP.CLI, config.IPFIXCollector, config.TunnelIP); err != nil {
+			return errors.Wrap(err, "error configuring ipfix export")
+		}
+	}
+	if config.SFlowCollector != "" {
+		if f.sampler, err = sflow.NewSampler(ctx, f.vppagentCC, config.SFlowCollector, config.SFlowSamplingRate, config.TunnelIP, config.InstanceID); err != nil {
+			return errors.Wrap(err, "error configuring sflow sampling")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package forwarder - the forwarder cmd-forwarder-vppagent serves, its Config and the startup phases main takes it
+// through, along with the vpp-init and test-suite subcommands
+package forwarder
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vppinterfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/audit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/backpressure"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/bfd"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/carrier"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/configdiff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cordon"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/deadline"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dhcp"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/faultinject"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarded"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/history"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/hostifs"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/idalloc"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ifevents"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ifpool"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/inflight"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipfix"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipneighbor"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/kernelfwd"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/latency"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/lldp"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/mirror"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/orphanclose"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/payloadcheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/peerpolicy"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pingcheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pmtu"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ra"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ratelimit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/rawconfig"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/remoteswap"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/setupslo"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/sflow"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/steering"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tunnelmtu"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/txndedup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/txnstats"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppinit"
+)
+
+// connectionsFile - the file in BaseDir the connection table is handed over in
+const connectionsFile = "connections.json"
+
+// forwarder - the vpp-agent client interceptors and the chain of a forwarder built from its Config, along with the
+// state they share.  The Forwarder main serves is one, the test-suite subcommand runs two of them.
+type forwarder struct {
+	config *Config
+
+	// State of the vpp-agent client interceptors
+	txnQueue        *backpressure.Queue
+	tunnelPeers     *bfd.Monitor
+	tunnelMTUs      *pmtu.Prober
+	tunnelLatencies *latency.Monitor
+	hostIfs         *hostifs.Table
+	carried         *carrier.Interfaces
+	configChanges   *configdiff.Tracker
+	interfacePool   *ifpool.Pool
+	appliedTxns     *txndedup.Cache
+
+	// State of the chain elements, those set by init restored from BaseDir
+	connections     *conntable.Table
+	recent          *history.Ring
+	cordoned        *cordon.Cordon
+	forwardedTrust  forwarded.Trust
+	metadata        *connmeta.Store
+	ids             *idalloc.Allocator
+	tunnelMTUServer networkservice.NetworkServiceServer
+	setupLatencies  *setupslo.Tracker
+	vppExtraConfig  *configurator.Config
+
+	// Optional parts of the chain, left to the caller: the peer audit logger, the peer allowlist, the steering rules
+	// and the sflow sampler
+	auditLogger    *audit.Logger
+	policy         *peerpolicy.Policy
+	steeringPolicy *steering.Policy
+	sampler        *sflow.Sampler
+}
+
+// newForwarder - returns the forwarder of config, to be init'ed before its endpoint is built
+func newForwarder(config *Config) *forwarder {
+	return &forwarder{
+		config:          config,
+		txnQueue:        &backpressure.Queue{Concurrency: config.TxnConcurrency},
+		tunnelPeers:     &bfd.Monitor{},
+		tunnelMTUs:      &pmtu.Prober{},
+		tunnelLatencies: &latency.Monitor{},
+		hostIfs:         &hostifs.Table{},
+		carried:         &carrier.Interfaces{},
+		configChanges:   &configdiff.Tracker{},
+		interfacePool:   &ifpool.Pool{},
+		appliedTxns:     &txndedup.Cache{TTL: config.TxnDedupTTL},
+		connections:     &conntable.Table{},
+		recent:          history.NewRing(config.HistorySize),
+		cordoned:        &cordon.Cordon{},
+		forwardedTrust:  forwarded.NewTrust(config.ForwardedTrustedPeers),
+		policy:          &peerpolicy.Policy{},
+		steeringPolicy:  &steering.Policy{},
+	}
+}
+
+// init - restores the allocated ids, the connection metadata and any connection table handed over of f from BaseDir,
+// loads its extra vpp-agent configs and creates the chain elements that depend on the config being valid
+func (f *forwarder) init(ctx context.Context) error {
+	config := f.config
+	idRanges, err := idalloc.ParseRanges(config.IDRanges)
+	if err != nil {
+		return errors.Wrap(err, "error parsing id ranges")
+	}
+	if idRanges, err = idalloc.Partition(idRanges, config.InstanceID, config.InstanceCount); err != nil {
+		return errors.Wrap(err, "error partitioning id ranges")
+	}
+	if f.ids, err = idalloc.NewAllocator(ctx, filepath.Join(config.BaseDir, "ids.json"), idRanges); err != nil {
+		return errors.Wrap(err, "error restoring allocated ids")
+	}
+	// Connections not refreshed within the token lifetime are gone
+	if f.metadata, err = connmeta.NewStore(ctx, filepath.Join(config.BaseDir, "metadata.json"), config.MaxTokenLifetime); err != nil {
+		return errors.Wrap(err, "error restoring connection metadata")
+	}
+	f.metadata.OnExpire(func(ctx context.Context, connID string, values map[string]string) {
+		log.Entry(ctx).Warnf("connection %s expired without Close, deleting its vpp interfaces %s %s",
+			connID, values[connmeta.ServerInterfaceKey], values[connmeta.ClientInterfaceKey])
+		events.Emitf(events.Warning, "ConnectionExpired", "connection %s expired without Close", connID)
+	})
+	if (config.OversizePolicy == tunnelmtu.PolicyICMP || config.ClampMSS) && config.PMTUProbeInterval == 0 {
+		return errors.Errorf("oversize policy %s and mss clamping require path mtu probes (PMTUProbeInterval)", tunnelmtu.PolicyICMP)
+	}
+	if f.tunnelMTUServer, err = tunnelmtu.NewServer(f.tunnelMTUs, config.OversizePolicy, config.ClampMSS); err != nil {
+		return errors.Wrap(err, "error creating tunnel mtu server")
+	}
+	if config.SetupSLO > 0 && (config.SetupSLOQuantile <= 0 || config.SetupSLOQuantile > 1) {
+		return errors.Errorf("invalid setup latency quantile %v, expected one in (0, 1]", config.SetupSLOQuantile)
+	}
+	f.setupLatencies = setupslo.NewTracker(ctx, config.SetupSLO, config.SetupSLOQuantile)
+	if f.vppExtraConfig, err = vppinit.LoadExtraConfig(config.VPPExtraConfigDir); err != nil {
+		return errors.Wrap(err, "error loading the extra vpp-agent configs")
+	}
+	n, err := f.connections.Restore(filepath.Join(config.BaseDir, connectionsFile))
+	if err != nil {
+		return errors.Wrap(err, "error restoring the connection table handed over")
+	}
+	if n > 0 {
+		log.Entry(ctx).Infof("restored %d connections handed over by the previous forwarder process", n)
+	}
+	return nil
+}
+
+// handOver - saves the connection table and the connection metadata of f for the next forwarder process to restore
+// in init, to be run once f stopped serving on handoff
+func (f *forwarder) handOver() {
+	f.metadata.Flush()
+	if err := f.connections.Save(filepath.Join(f.config.BaseDir, connectionsFile)); err != nil {
+		log.Entry(context.Background()).Errorf("failed to hand the connection table over: %+v", err)
+	}
+}
+
+// vppInitFunc - returns the function creating the initial vpp configuration of f, none on a fake vpp-agent: there is
+// no vpp to take the uplink over
+func (f *forwarder) vppInitFunc() func(conf *configurator.Config) error {
+	if f.config.VPP.Fake {
+		return func(*configurator.Config) error { return nil }
+	}
+	return vppinit.Func(f.config.TunnelIP, vppInitOptions(f.config, f.vppExtraConfig)...)
+}
+
+// vppagentDialOptions - returns the options to dial vpp-agent with, with the client interceptors of f
+func (f *forwarder) vppagentDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(
+			deadline.UnaryClientInterceptor(f.config.MaxRequestTimeout),
+			f.config.VPP.RxModeInterceptor(),
+			f.config.VPP.MemifQueuesInterceptor(),
+			vppinit.TunnelVRFInterceptor(f.config.TunnelVRF),
+			f.tunnelPeers.UnaryClientInterceptor(),
+			f.tunnelMTUs.UnaryClientInterceptor(),
+			f.tunnelLatencies.UnaryClientInterceptor(),
+			f.hostIfs.UnaryClientInterceptor(),
+			f.carried.UnaryClientInterceptor(),
+			rawconfig.UnaryClientInterceptor(),
+			f.configChanges.UnaryClientInterceptor(),
+			payloadcheck.UnaryClientInterceptor(),
+			f.appliedTxns.UnaryClientInterceptor(),
+			f.txnQueue.UnaryClientInterceptor(),
+			txnstats.UnaryClientInterceptor(),
+			f.interfacePool.UnaryClientInterceptor(),
+			faultinject.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(f.interfacePool.StreamClientInterceptor()),
+	}
+}
+
+// clientDialOptions - returns opts with the client interceptors of the connections of f to their endpoints
+func (f *forwarder) clientDialOptions(opts ...grpc.DialOption) []grpc.DialOption {
+	return append(append([]grpc.DialOption{}, opts...), grpc.WithChainUnaryInterceptor(
+		deadline.UnaryClientInterceptor(f.config.MaxRequestTimeout),
+		forwarded.UnaryClientInterceptor(f.config.Name, f.forwardedTrust),
+		f.steeringPolicy.UnaryClientInterceptor(),
+	))
+}
+
+// dataplane - returns the vpp dataplane of f on vppagentCC, the kernel one if vppagentCC is nil.  The vpp objects of
+// expired connections are deleted through vppagentCC from then on.  To be called once.
+func (f *forwarder) dataplane(vppagentCC *grpc.ClientConn) dataplane.Dataplane {
+	if vppagentCC == nil {
+		return kernelfwd.Dataplane{}
+	}
+	config, metadata := f.config, f.metadata
+	// The ids of an expired connection are programmed into its vpp objects, they are only free once those are gone
+	metadata.OnExpire(func(ctx context.Context, connID string, values map[string]string) {
+		if serverInterface := values[connmeta.ServerInterfaceKey]; serverInterface != "" {
+			if err := orphanclose.Cleanup(ctx, vppagentCC, serverInterface, values[connmeta.ClientInterfaceKey]); err != nil {
+				log.Entry(ctx).Errorf("keeping the ids of expired connection %s reserved: %+v", connID, err)
+				return
+			}
+		}
+		f.ids.Release(connID)
+	})
+	return &dataplane.VPP{
+		VPPAgentCC: vppagentCC,
+		BaseDir:    config.BaseDir,
+		TunnelIP:   config.TunnelIP,
+		InitFunc:   f.vppInitFunc(),
+		InnerElements: []networkservice.NetworkServiceServer{
+			orphanclose.NewServer(vppagentCC, metadata),
+			payloadcheck.NewServer(),
+			rawconfig.NewServer(config.VPPConfigSnippets, metadata),
+			backpressure.NewServer(f.txnQueue, config.BackpressureThreshold, config.BackpressureBackoff, func(connID string) bool {
+				_, ok := metadata.Get(connID, connmeta.ServerInterfaceKey)
+				return ok
+			}),
+			remoteswap.NewServer(vppagentCC, metadata),
+			connmeta.NewServer(metadata),
+			idalloc.NewServer(f.ids),
+			faultinject.NewServer(),
+			ipneighbor.NewServer(vppagentCC),
+			ratelimit.NewServer(),
+			f.tunnelMTUServer,
+			dhcp.NewServer(f.hostIfs, config.DHCPServer, config.DHCPLeaseTime),
+			ra.NewServer(f.hostIfs, config.RouterAdvertisements, config.RAInterval),
+			lldp.NewServer(f.hostIfs, config.LLDP, config.Name, config.LLDPInterval),
+			mirror.NewServer(vppagentCC, config.MirrorTo, metadata),
+			sflow.NewServer(f.sampler),
+			carrier.NewServer(config.Name, f.carried),
+		},
+	}
+}
+
+// endpoint - returns the endpoint of f on dp, authorizing Requests with authzServer and reaching the endpoints of its
+// connections through connectTo, dialed with clientDialOptions in addition to the client interceptors of f.  The
+// elements of dp and the common ones are instrumented together, so what follows the last of them is measured once.
+func (f *forwarder) endpoint(ctx context.Context, dp dataplane.Dataplane, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, connectTo *url.URL, clientDialOptions ...grpc.DialOption) dataplane.Endpoint {
+	elements := f.setupLatencies.Instrument(dataplane.Elements(dp,
+		history.NewServer(f.recent),
+		audit.NewServer(f.auditLogger, f.forwardedTrust),
+		forwarded.NewServer(),
+		cordon.NewServer(f.cordoned, f.connections.Has),
+		conntable.NewServer(f.connections),
+		peerpolicy.NewServer(f.policy),
+		steering.NewServer(f.steeringPolicy),
+		authzServer,
+		inflight.NewServer(),
+		pingcheck.NewServer(f.config.PingCheckTimeout),
+	)...)
+	return dp.NewEndpoint(ctx, f.config.Name, chain.NewNetworkServiceServer(elements...), tokenGenerator, connectTo,
+		f.clientDialOptions(clientDialOptions...)...)
+}
+
+// watchInterfaces - keeps the link states of the connections of f up to date with the vpp interface events of
+// vppagentCC, enabling flow export with flows (nil if none) once they are up
+func (f *forwarder) watchInterfaces(ctx context.Context, vppagentCC *grpc.ClientConn, flows *ipfix.Exporter) {
+	ifevents.Watch(ctx, vppagentCC, func(ctx context.Context, state *vppinterfaces.InterfaceState) {
+		name, status := state.GetName(), state.GetOperStatus().String()
+		connID := f.connections.SetLinkState(name, status, state.GetIfIndex())
+		if status == "DELETED" {
+			// Whatever deleted it, a refresh has to program the interface again
+			f.appliedTxns.Invalidate()
+		}
+		flows.Update(ctx, connID, state)
+		if connID != "" && status != "UP" {
+			log.Entry(ctx).Warnf("vpp interface %s of connection %s is %s", name, connID, status)
+			events.Emitf(events.Warning, "LinkDown", "vpp interface %s of connection %s is %s", name, connID, status)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"net/url"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/bfd"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+)
+
+// claimUplink - makes sure no other forwarder instance on the node uses the uplink of config.  Every instance runs
+// its own vpp, which takes the host interface with the TunnelIP over along with all its addresses, so each of them
+// needs a TunnelIP of its own on a host interface of its own.  The claim is an abstract unix socket named after the
+// uplink, released by the kernel when the process exits and handed on to the next forwarder process on upgrades.
+func claimUplink(config *Config) error {
+	if config.TunnelIP == nil || config.TunnelIP.IsUnspecified() {
+		return errors.Errorf("NSM_TUNNEL_IP is needed with NSM_INSTANCE_COUNT %d", config.InstanceCount)
+	}
+	uplink, err := bfd.Uplink(config.TunnelIP)
+	if err != nil {
+		return err
+	}
+	_, err = handoff.Listen("uplink", &url.URL{Scheme: "unix", Opaque: "@nsm-forwarder-uplink-" + uplink})
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return errors.Errorf("uplink %s of NSM_TUNNEL_IP %s is used by another forwarder instance on the node", uplink, config.TunnelIP)
+	}
+	return err
+}
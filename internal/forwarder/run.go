@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/admin"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cordon"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/faultinject"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/featuregate"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarderid"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/goruntime"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/history"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipfix"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/k8s"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/logging"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/mtls"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/privileges"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+)
+
+// Forwarder - the forwarder main serves, taken through the startup phases by main one method per phase, along with
+// what each phase sets up for the following ones
+type Forwarder struct {
+	*forwarder
+	id     string
+	cancel context.CancelFunc
+
+	// Set up by StartVPP, vppagentCC is nil once the forwarder fell back to the kernel dataplane
+	vppagentCC    *grpc.ClientConn
+	vppagentErrCh <-chan error
+
+	// Set up by RetrieveSVID and CreateEndpoint
+	source      *workloadapi.X509Source
+	bundles     x509bundle.Source
+	mtlsOptions []mtls.Option
+	dialOptions []grpc.DialOption
+	flows       *ipfix.Exporter
+	dp          dataplane.Dataplane
+	xconnect    dataplane.Endpoint
+
+	cleanupCheckErrCh chan error
+}
+
+// New - the first phase, once config has been processed from the environment: validates config, applies its logging
+// and runtime settings and starts what the forwarder reports through (events, heartbeat and the admin api).  Returns
+// ctx with the forwarder id as log field, and the Forwarder to take through the other phases.  cancel is called when
+// the forwarder has to stop.
+func New(ctx context.Context, cancel context.CancelFunc, config *Config) (context.Context, *Forwarder, error) {
+	phaseDone := startup.Bound(ctx, "1", &config.Phase1)
+	defer phaseDone()
+	if err := config.Validate(); err != nil {
+		return ctx, nil, errors.Wrap(err, "error processing config from env")
+	}
+	if err := logging.Apply(config.LogProfile, config.LogLevels); err != nil {
+		return ctx, nil, errors.Wrap(err, "error configuring logging")
+	}
+	logging.Bound(config.LogMaxPayload, config.LogDumpDir)
+	if err := logging.AddSinks(config.LogSink); err != nil {
+		return ctx, nil, errors.Wrap(err, "error configuring log sinks")
+	}
+
+	forwarderID, err := forwarderid.Load(filepath.Join(config.BaseDir, "forwarder-id"))
+	if err != nil {
+		return ctx, nil, errors.Wrap(err, "error loading forwarder id")
+	}
+	ctx = log.WithField(ctx, "forwarder_id", forwarderID)
+	metrics.String("forwarder_id").Set(forwarderID)
+	config.VPP.ForwarderID = forwarderID
+	log.Entry(ctx).Infof("Config: %#v", config)
+	config.FeatureGates.Report(ctx)
+
+	f := &Forwarder{
+		forwarder:         newForwarder(config),
+		id:                forwarderID,
+		cancel:            cancel,
+		cleanupCheckErrCh: make(chan error, 1),
+	}
+	if err = f.setupProcess(ctx); err != nil {
+		return ctx, nil, err
+	}
+	if err = f.startReporting(ctx); err != nil {
+		return ctx, nil, err
+	}
+	return ctx, f, nil
+}
+
+// setupProcess - drops the privileges of the forwarder process, claims its uplink and moves it off the vpp cpus
+func (f *Forwarder) setupProcess(ctx context.Context) error {
+	config := f.config
+	if config.DropPrivileges {
+		if err := privileges.Drop(ctx, config.Capabilities); err != nil {
+			return errors.Wrap(err, "error dropping privileges")
+		}
+	}
+	if err := privileges.Validate(ctx, config.Capabilities); err != nil {
+		log.Entry(ctx).Warnf("startup validation: %+v", err)
+	}
+	if err := faultinject.Configure(config.FaultInjection); err != nil {
+		return errors.Wrap(err, "error configuring fault injection")
+	}
+	config.VPP.Instance = config.InstanceID
+	if config.InstanceCount > 1 {
+		if err := claimUplink(config); err != nil {
+			return errors.Wrap(err, "error processing config from env")
+		}
+	}
+	if err := config.VPP.Validate(); err != nil {
+		return errors.Wrap(err, "error validating vpp config")
+	}
+	// Keep the go runtime off the cpus of the vpp threads before counting the cpus left to it
+	if err := goruntime.AvoidCPUs(config.VPP.PinnedCPUs()); err != nil {
+		return errors.Wrap(err, "error moving the forwarder off the vpp cpus")
+	}
+	log.Entry(ctx).Infof("GOMAXPROCS is %d", goruntime.SetMaxProcs(config.GOMAXPROCS))
+	goruntime.TuneGC(config.GOGC, config.GCBallast)
+	goruntime.WatchGC(ctx, config.GoStatsInterval)
+	return nil
+}
+
+// startReporting - starts posting the events of the forwarder, its heartbeat and its admin api
+func (f *Forwarder) startReporting(ctx context.Context) error {
+	config := f.config
+	if config.KubernetesEvents {
+		k8sClient, err := k8s.InCluster()
+		if err != nil {
+			return errors.Wrap(err, "error creating kubernetes client for events")
+		}
+		events.Start(ctx, k8sClient, &events.Pod{Name: config.PodName, Namespace: config.PodNamespace, NodeName: config.NodeName})
+	}
+	if config.AlertWebhookURL != "" {
+		events.StartWebhook(ctx, config.AlertWebhookURL, config.AlertReasons, map[string]string{
+			"forwarder":   config.Name,
+			"forwarderID": f.id,
+			"nodeName":    config.NodeName,
+		})
+	}
+	if config.HeartbeatPeriod > 0 {
+		heartbeat.SetPhase("1")
+		heartbeat.Start(ctx, filepath.Join(config.BaseDir, "alive"), config.HeartbeatPeriod)
+	}
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", expvar.Handler())
+	connectionsHandler := conntable.Handler(f.connections, config.BaseDir)
+	adminMux.Handle("/connections", connectionsHandler)
+	adminMux.Handle("/connections/", connectionsHandler)
+	adminMux.Handle("/history", history.Handler(f.recent))
+	adminMux.Handle("/featuregates", featuregate.Handler(config.FeatureGates))
+	adminMux.Handle("/cordon", cordon.Handler(f.cordoned, config.CordonWindow, func() {
+		log.Entry(ctx).Warnf("cordon window elapsed with %d connections, shutting down", f.connections.Len())
+		events.Emitf(events.Normal, "CordonExpired", "cordon window elapsed, shutting down with %d connections", f.connections.Len())
+		f.cancel()
+	}))
+	if config.AdminListenOn.String() != "" {
+		adminLn, err := handoff.Listen("admin", &config.AdminListenOn)
+		if err != nil {
+			return errors.Wrapf(err, "error listening on %s", config.AdminListenOn.String())
+		}
+		f.exitOnErr(ctx, admin.Serve(ctx, adminLn, adminMux))
+	}
+	return nil
+}
+
+// Wait - waits for the forwarder to stop once ctx is done, and returns the error of the cleanup check, if it ran
+func (f *Forwarder) Wait(ctx context.Context) error {
+	<-ctx.Done()
+	if !f.kernelFallback() {
+		<-f.vppagentErrCh
+	}
+	select {
+	case err := <-f.cleanupCheckErrCh:
+		return err
+	default:
+		return nil
+	}
+}
+
+// exitOnErr - exits if errCh already has an error, otherwise has the forwarder stop on the first error of errCh
+func (f *Forwarder) exitOnErr(ctx context.Context, errCh <-chan error) {
+	// If we already have an error, log it and exit
+	select {
+	case err := <-errCh:
+		log.Entry(ctx).Fatal(err)
+	default:
+	}
+	// Otherwise wait for an error in the background to log and cancel
+	go func(ctx context.Context, errCh <-chan error) {
+		err := <-errCh
+		log.Entry(ctx).Error(err)
+		f.cancel()
+	}(ctx, errCh)
+}
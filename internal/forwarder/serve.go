@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/deadline"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/deviceplugin"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/logging"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/mtls"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/offload"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/prewarm"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/registration"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/topology"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// Serve - the fifth phase: serves the endpoint CreateEndpoint created on every ListenOn url, handed over on handoff,
+// registers the forwarder and starts watching vpp and the tunnels
+func (f *Forwarder) Serve(ctx context.Context) error {
+	heartbeat.SetPhase("5")
+	config := f.config
+	servers, err := f.servers(ctx)
+	if err != nil {
+		return err
+	}
+	f.exitOnErr(ctx, handoff.ListenAndServe(ctx, servers, vppagent.Processes))
+	if config.Register {
+		// Registering is what phase 5 bounds, the forwarder serves unregistered if it is skipped
+		if err = startup.Run(ctx, "5", &config.Phase5, startup.Skip, f.register); err != nil {
+			return errors.Wrap(err, "error registering forwarder")
+		}
+	}
+	if config.DevicePlugin.Enabled {
+		f.exitOnErr(ctx, deviceplugin.ListenAndServe(ctx, &config.DevicePlugin))
+	}
+	if err = f.watch(ctx); err != nil {
+		return err
+	}
+	heartbeat.SetPhase("running")
+	return nil
+}
+
+// servers - returns the grpc servers of the ListenOn urls of the config of f, each with its credentials and the
+// server interceptors
+func (f *Forwarder) servers(ctx context.Context) ([]*handoff.Server, error) {
+	config := f.config
+	// The access log comes first, to see the calls turned away by readiness and deadline too
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
+	if config.AccessLog {
+		unaryInterceptors = append(unaryInterceptors, logging.AccessLogUnaryServerInterceptor())
+		streamInterceptors = append(streamInterceptors, logging.AccessLogStreamServerInterceptor())
+	}
+	escalateInterceptor, err := logging.EscalateUnaryServerInterceptor(ctx, &config.LogEscalation)
+	if err != nil {
+		return nil, errors.Wrap(err, "error configuring log escalation")
+	}
+	unaryInterceptors = append(unaryInterceptors, escalateInterceptor)
+	var servers []*handoff.Server
+	for i := range config.ListenOn {
+		listenOn := &config.ListenOn[i]
+		serverOptions, optionsErr := serverCredentials(listenOn, mtls.ServerConfig(f.source, f.bundles, f.mtlsOptions...), f.auditLogger)
+		if optionsErr != nil {
+			return nil, errors.Wrapf(optionsErr, "error creating credentials for %s", listenOn.String())
+		}
+		server := grpc.NewServer(append(serverOptions,
+			grpc.ChainUnaryInterceptor(append(unaryInterceptors,
+				readiness.UnaryServerInterceptor(),
+				deadline.UnaryServerInterceptor(config.MaxRequestTimeout),
+				logging.SampleUnaryServerInterceptor(config.TraceSampleRate),
+			)...),
+			grpc.ChainStreamInterceptor(streamInterceptors...),
+		)...)
+		f.xconnect.Register(server)
+		if config.GRPCReflection {
+			reflection.Register(server)
+		}
+		servers = append(servers, &handoff.Server{ListenOn: listenOn, Server: server})
+	}
+	return servers, nil
+}
+
+// register - registers the forwarder with the registry at ConnectTo, advertising its first ListenOn url along with
+// its topology, offload and dataplane labels
+func (f *Forwarder) register(ctx context.Context) error {
+	config := f.config
+	registerLifetime := config.RegisterLifetime
+	if registerLifetime > config.MaxTokenLifetime {
+		registerLifetime = config.MaxTokenLifetime
+	}
+	registerURL := config.ListenOn[0]
+	registerURL.RawQuery = ""
+	registryCC, err := grpc.DialContext(ctx, config.ConnectTo.String(), f.clientDialOptions(f.dialOptions...)...)
+	if err != nil {
+		return err
+	}
+	labels := topology.Labels(ctx, config.NodeName, config.Zone, config.Region)
+	for key, value := range offload.Labels(ctx, config.TunnelIP, config.VPP.CryptoEngine) {
+		labels[key] = value
+	}
+	labels["forwarderID"] = f.id
+	if config.TunnelIP != nil {
+		labels[prewarm.TunnelIPLabel] = config.TunnelIP.String()
+	}
+	// The kernel dataplane has no tunnels and no memif, local kernel connections only
+	labels["dataplane"] = f.dp.Name()
+	nse := &registry.NetworkServiceEndpoint{
+		Name:                config.Name,
+		NetworkServiceNames: []string{"forwarder"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"forwarder": {Labels: labels},
+		},
+		Url: registerURL.String(),
+	}
+	return registration.Register(ctx, registryCC, nse, registerLifetime)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// endpointFunc - a dataplane endpoint registering through the func
+type endpointFunc func(s *grpc.Server)
+
+func (e endpointFunc) Register(s *grpc.Server) {
+	e(s)
+}
+
+func TestServersReflection(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		config := &Config{ListenOn: []url.URL{{Scheme: "unix", Path: "/listen.on.sock", RawQuery: "creds=insecure"}}}
+		config.GRPCReflection = enabled
+		registered := 0
+		f := &Forwarder{forwarder: &forwarder{config: config}, xconnect: endpointFunc(func(*grpc.Server) { registered++ })}
+
+		servers, err := f.servers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, servers, 1)
+		require.Equal(t, 1, registered)
+		reflected := false
+		for name := range servers[0].Server.GetServiceInfo() {
+			reflected = reflected || strings.HasPrefix(name, "grpc.reflection.")
+		}
+		require.Equal(t, enabled, reflected)
+	}
+}
+
+func TestServersCreds(t *testing.T) {
+	config := &Config{ListenOn: []url.URL{{Scheme: "tcp", Host: "127.0.0.1:5001", RawQuery: "creds=plaintext"}}}
+	f := &Forwarder{forwarder: &forwarder{config: config}, xconnect: endpointFunc(func(*grpc.Server) {})}
+	_, err := f.servers(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/testsuite"
+)
+
+// TestSuiteCommand - the subcommand bringing up two forwarders in process, each with a vpp instance of its own, and
+// running cross connect scenarios through them, for operators validating a build on their hardware
+const TestSuiteCommand = "test-suite"
+
+// TestSuite - runs the test suite with the vpp config of config, reporting its results on stdout, and returns
+// whether all scenarios passed
+func TestSuite(ctx context.Context, config *Config) (bool, error) {
+	if err := config.VPP.Validate(); err != nil {
+		return false, errors.Wrap(err, "error validating vpp config")
+	}
+	results, err := testsuite.Run(ctx, &testsuite.Config{
+		VPP: config.VPP,
+		NewForwarder: func(ctx context.Context, name string, tunnelIP net.IP, dir string) (testsuite.Forwarder, error) {
+			f, buildErr := newSuiteForwarder(ctx, config, name, tunnelIP, dir)
+			if buildErr != nil {
+				return nil, buildErr
+			}
+			return f, nil
+		},
+		Dir:       filepath.Join(config.BaseDir, "test-suite"),
+		Scenarios: config.TestSuiteScenarios,
+		Timeout:   config.TestSuiteTimeout,
+	})
+	if err != nil {
+		return false, err
+	}
+	return testsuite.Report(os.Stdout, results), nil
+}
+
+// suiteForwarder - a forwarder of the test suite, built by newForwarder like the Forwarder main serves
+type suiteForwarder struct {
+	*forwarder
+}
+
+// newSuiteForwarder - returns the forwarder named name of the test suite, built from config but with tunnelIP as its
+// tunnel ip and dir, emptied first, as its base dir
+func newSuiteForwarder(ctx context.Context, config *Config, name string, tunnelIP net.IP, dir string) (*suiteForwarder, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	forwarderConfig := *config
+	forwarderConfig.Name = name
+	forwarderConfig.TunnelIP = tunnelIP
+	forwarderConfig.BaseDir = dir
+	f := newForwarder(&forwarderConfig)
+	if err := f.init(ctx); err != nil {
+		return nil, err
+	}
+	return &suiteForwarder{forwarder: f}, nil
+}
+
+// VPPAgentDialOptions - returns the options the Forwarder dials vpp-agent with
+func (s *suiteForwarder) VPPAgentDialOptions() []grpc.DialOption {
+	return s.vppagentDialOptions()
+}
+
+// InitFunc - returns the initial vpp config of the Forwarder
+func (s *suiteForwarder) InitFunc() func(conf *configurator.Config) error {
+	return s.vppInitFunc()
+}
+
+// Connections - returns the connection table of the forwarder
+func (s *suiteForwarder) Connections() *conntable.Table {
+	return s.connections
+}
+
+// Endpoint - returns the endpoint of the vpp dataplane of the forwarder, watching the vpp interfaces as the Forwarder does
+func (s *suiteForwarder) Endpoint(ctx context.Context, vppagentCC *grpc.ClientConn, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, connectTo *url.URL, clientDialOptions ...grpc.DialOption) dataplane.Endpoint {
+	s.watchInterfaces(ctx, vppagentCC, nil)
+	return s.endpoint(ctx, s.dataplane(vppagentCC), authzServer, tokenGenerator, connectTo, clientDialOptions...)
+}
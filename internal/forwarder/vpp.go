@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/kernelfwd"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/leader"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// StartVPP - the second phase: runs vppagent and gets a connection to it, or attaches to the one left running by the
+// process the forwarder took over from.  Once it cannot, the forwarder falls back to the kernel dataplane if
+// configured to.
+func (f *Forwarder) StartVPP(ctx context.Context) error {
+	heartbeat.SetPhase("2")
+	if err := f.lead(ctx); err != nil {
+		return err
+	}
+	config := f.config
+	vppagentDialOptions := f.vppagentDialOptions()
+	if err := startup.Run(ctx, "2", &config.Phase2, startup.Retry, func(phaseCtx context.Context) error {
+		if handoff.Inherited() {
+			log.Entry(ctx).Infof("taking over from a previous forwarder process, attaching to its vppagent")
+			f.vppagentCC, f.vppagentErrCh = vppagent.DialContext(phaseCtx, &config.VPP, vppagentDialOptions...)
+		} else {
+			f.vppagentCC, f.vppagentErrCh = vppagent.StartAndDialContext(phaseCtx, &config.VPP, vppagentDialOptions...)
+		}
+		if f.vppagentCC != nil {
+			return nil
+		}
+		vppagentErr := <-f.vppagentErrCh
+		// Wait for vpp and vpp-agent, killed on failure, to exit before a retry starts them again or the kernel
+		// dataplane runs without them
+		for range f.vppagentErrCh {
+		}
+		return vppagentErr
+	}); err != nil {
+		if config.FallbackDataplane != kernelfwd.Name {
+			return errors.Wrap(err, "error running vppagent")
+		}
+		log.Entry(ctx).Errorf("error running vppagent, falling back to the %s dataplane: %+v", kernelfwd.Name, err)
+		events.Emitf(events.Warning, "DataplaneFallback", "vpp cannot start, serving local kernel connections only: %s", err)
+		return nil
+	}
+	f.exitOnErr(ctx, f.vppagentErrCh)
+	f.appliedTxns.WatchConnection(ctx, f.vppagentCC)
+	if config.VPP.AgentRESTPort != 0 {
+		restListenOn := &url.URL{Scheme: "tcp", Host: net.JoinHostPort("127.0.0.1", strconv.Itoa(config.VPP.AgentRESTPort))}
+		restLn, err := handoff.Listen("rest", restListenOn)
+		if err != nil {
+			return errors.Wrap(err, "error listening for the vpp-agent REST api")
+		}
+		vppagent.ServeReadOnlyREST(ctx, restLn, f.vppagentCC)
+	}
+	return nil
+}
+
+// lead - waits to become the forwarder of the node, a process we took over from has handed us its lock.  The wait is
+// not part of the phase's timeout, standing by is what a forwarder that is not the leader is meant to do.
+func (f *Forwarder) lead(ctx context.Context) error {
+	if lockFile := handoff.File("leader"); lockFile != nil {
+		handoff.Keep("leader", lockFile)
+		return nil
+	}
+	if f.config.LeaderLockFile == "" {
+		return nil
+	}
+	lockFile, err := leader.Acquire(ctx, f.config.LeaderLockFile)
+	if err != nil {
+		return errors.Wrap(err, "error acquiring leader lock")
+	}
+	handoff.Keep("leader", lockFile)
+	return nil
+}
+
+// kernelFallback - returns whether the forwarder fell back to the kernel dataplane, having no vppagent
+func (f *Forwarder) kernelFallback() bool {
+	return f.vppagentCC == nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppinit"
+)
+
+// VPPInitCommand - the subcommand applying the forwarder's initial vpp configuration to an already running vpp-agent
+// and exiting, for operators provisioning vpp in an initContainer with the same config as the forwarder
+const VPPInitCommand = "vpp-init"
+
+// VPPInit - dials the running vpp-agent, within the bounds of phase 2, then applies the output of vppinit.Func to it.
+// Nothing but the connection is set up, vpp-agent is neither watched nor served the way the forwarder does.
+func VPPInit(ctx context.Context, config *Config) error {
+	config.VPP.Instance = config.InstanceID
+	if err := config.VPP.Validate(); err != nil {
+		return errors.Wrap(err, "error validating vpp config")
+	}
+	extraConfig, err := vppinit.LoadExtraConfig(config.VPPExtraConfigDir)
+	if err != nil {
+		return errors.Wrap(err, "error loading the extra vpp-agent configs")
+	}
+	var vppagentCC *grpc.ClientConn
+	if err = startup.Run(ctx, "2", &config.Phase2, startup.Retry, func(phaseCtx context.Context) error {
+		cc, dialErr := vppagent.Dial(phaseCtx, &config.VPP)
+		if dialErr != nil {
+			return dialErr
+		}
+		vppagentCC = cc
+		return nil
+	}); err != nil {
+		return err
+	}
+	defer func() { _ = vppagentCC.Close() }()
+	if err = vppinit.Apply(ctx, vppagentCC, config.TunnelIP, vppInitOptions(config, extraConfig)...); err != nil {
+		return err
+	}
+	log.Entry(ctx).Infof("applied the initial vpp configuration")
+	return nil
+}
+
+// vppInitOptions - returns the vppinit options of config and its extra vpp-agent configs, for the forwarder and
+// vpp-init alike
+func vppInitOptions(config *Config, extraConfig *configurator.Config) []vppinit.Option {
+	opts := []vppinit.Option{
+		vppinit.WithTunnelVRF(config.TunnelVRF),
+		vppinit.WithManagementVRF(config.ManagementVRF),
+		vppinit.WithExtraConfig(extraConfig),
+	}
+	if config.BFDInterval > 0 {
+		opts = append(opts, vppinit.WithBFD())
+	}
+	return opts
+}
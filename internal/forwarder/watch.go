@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package forwarder
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/bfd"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cleanupcheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/leakwatch"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/prewarm"
+)
+
+// watch - starts watching the vpp objects and interfaces of the connections of f and the peers of their tunnels, of
+// which the kernel dataplane has the latency to the peers only
+func (f *Forwarder) watch(ctx context.Context) error {
+	if !f.kernelFallback() {
+		if err := f.watchVPP(ctx); err != nil {
+			return err
+		}
+		if err := f.watchTunnelPeers(ctx); err != nil {
+			return err
+		}
+	}
+	config := f.config
+	if config.LatencyProbeInterval > 0 {
+		f.tunnelLatencies.Start(ctx, config.TunnelIP, config.LatencyProbeInterval, config.LatencySLO, func(ctx context.Context, peer string, rtt time.Duration, tunnels []string) {
+			for _, tunnel := range tunnels {
+				if connID := f.connections.Lookup(tunnel); connID != "" {
+					log.Entry(ctx).Warnf("round trip time to %s is %s, over the slo of %s for tunnel %s of connection %s", peer, rtt, config.LatencySLO, tunnel, connID)
+					events.Emitf(events.Warning, "TunnelLatencySLO", "round trip time to %s is %s, over the slo of %s for tunnel %s of connection %s", peer, rtt, config.LatencySLO, tunnel, connID)
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// watchVPP - watches vpp for leaked objects and the interfaces of the connections of f, prewarms the tunnels to the
+// other forwarders and runs the cleanup check
+func (f *Forwarder) watchVPP(ctx context.Context) error {
+	config := f.config
+	leakwatch.Watch(ctx, &config.Leak, f.vppagentCC, f.connections.Len)
+	if config.TunnelPrewarm {
+		if config.TunnelIP == nil {
+			return errors.New("tunnel shells need a TunnelIP")
+		}
+		registryCC, err := grpc.DialContext(ctx, config.ConnectTo.String(), f.clientDialOptions(f.dialOptions...)...)
+		if err != nil {
+			return errors.Wrap(err, "error dialing the registry for tunnel peers")
+		}
+		prewarm.Watch(ctx, registryCC, f.vppagentCC, f.ids, config.Name, config.TunnelIP)
+	}
+	if config.CleanupCheckIdle > 0 {
+		baseline, err := cleanupcheck.Snapshot(ctx, f.vppagentCC, f.vppInitFunc())
+		if err != nil {
+			return errors.Wrap(err, "error recording the vpp baseline of the cleanup check")
+		}
+		cleanupcheck.Watch(ctx, f.vppagentCC, baseline, f.connections.Len, config.CleanupCheckIdle, func(leaked []string, checkErr error) {
+			if checkErr == nil && len(leaked) > 0 {
+				checkErr = errors.Errorf("%d vpp objects left behind once all connections closed: %s", len(leaked), strings.Join(leaked, ", "))
+			}
+			if checkErr == nil {
+				log.Entry(ctx).Infof("cleanup check passed, vpp is back to its state after vppinit")
+			}
+			f.cleanupCheckErrCh <- checkErr
+			f.cancel()
+		})
+	}
+	f.watchInterfaces(ctx, f.vppagentCC, f.flows)
+	return nil
+}
+
+// watchTunnelPeers - starts the bfd sessions with and the path mtu probes of the peers of the vxlan tunnels of f
+func (f *Forwarder) watchTunnelPeers(ctx context.Context) error {
+	config := f.config
+	if config.BFDInterval > 0 {
+		uplink, err := bfd.Uplink(config.TunnelIP)
+		if err != nil {
+			return errors.Wrap(err, "error finding the uplink for bfd")
+		}
+		f.tunnelPeers.Start(ctx, config.VPP.CLI, uplink, config.TunnelIP, config.BFDInterval, config.BFDDetectMult, func(ctx context.Context, peer string, tunnels []string) {
+			for _, tunnel := range tunnels {
+				if connID := f.connections.SetLinkState(tunnel, "BFD_DOWN", 0); connID != "" {
+					log.Entry(ctx).Warnf("bfd session to %s is down, tunnel %s of connection %s is broken", peer, tunnel, connID)
+					events.Emitf(events.Warning, "TunnelPeerDown", "bfd session to %s is down, tunnel %s of connection %s is broken", peer, tunnel, connID)
+				}
+			}
+		})
+	}
+	if config.PMTUProbeInterval > 0 {
+		if err := f.tunnelMTUs.Start(ctx, f.vppagentCC, config.TunnelIP, config.PMTUProbeInterval); err != nil {
+			return errors.Wrap(err, "error starting path mtu probes")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package forwarderid - uuid of the forwarder persisted across restarts, correlating its registrations, logs,
+// metrics and vpp instance even if its name changes
+package forwarderid
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Load - returns the uuid stored in filename, generating and storing one if filename is missing or invalid
+func Load(filename string) (string, error) {
+	if content, err := ioutil.ReadFile(filename); err == nil {
+		if id, parseErr := uuid.Parse(strings.TrimSpace(string(content))); parseErr == nil {
+			return id.String(), nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to read the forwarder id from %s", filename)
+	}
+	id := uuid.New().String()
+	tmpFile := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, []byte(id+"\n"), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to store the forwarder id in %s", filename)
+	}
+	if err := os.Rename(tmpFile, filename); err != nil {
+		return "", errors.Wrapf(err, "failed to store the forwarder id in %s", filename)
+	}
+	return id, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarderid_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarderid"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forwarderid")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "forwarder-id")
+
+	id, err := forwarderid.Load(filename)
+	require.NoError(t, err)
+	_, err = uuid.Parse(id)
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(filepath.Clean(filename))
+	require.NoError(t, err)
+	require.Equal(t, id+"\n", string(content))
+
+	again, err := forwarderid.Load(filename)
+	require.NoError(t, err)
+	require.Equal(t, id, again, "the stored id was not kept")
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("not a uuid\n"), 0600))
+	replaced, err := forwarderid.Load(filename)
+	require.NoError(t, err)
+	require.NotEqual(t, id, replaced)
+	_, err = uuid.Parse(replaced)
+	require.NoError(t, err, "an invalid id was not replaced")
+}
+
+func TestLoadUnreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forwarderid")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	_, err = forwarderid.Load(dir)
+	require.Error(t, err, "a directory was taken as the forwarder id file")
+}
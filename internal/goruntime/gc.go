@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goruntime
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// ballast - never touched, so it costs address space rather than rss, but counts into the heap the gc paces
+// itself by
+var ballast []byte
+
+// TuneGC - sets the gc percent to gogc, a negative one disabling the gc, and allocates a ballast of ballastBytes.
+// The ballast spaces out the collections of a small heap, e.g. during Request storms.
+func TuneGC(gogc int, ballastBytes uint64) {
+	debug.SetGCPercent(gogc)
+	if ballastBytes > 0 {
+		ballast = make([]byte, ballastBytes)
+	}
+}
+
+// WatchGC - publishes the allocation and gc counters of the go runtime as go_* metrics every interval until ctx is
+// done, 0 disabling them
+func WatchGC(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var stats runtime.MemStats
+		for {
+			runtime.ReadMemStats(&stats)
+			metrics.Int("go_heap_alloc_bytes").Set(int64(stats.HeapAlloc))
+			metrics.Int("go_total_alloc_bytes").Set(int64(stats.TotalAlloc))
+			metrics.Int("go_mallocs").Set(int64(stats.Mallocs))
+			metrics.Int("go_frees").Set(int64(stats.Frees))
+			metrics.Int("go_gc_count").Set(int64(stats.NumGC))
+			metrics.Int("go_gc_pause_total_us").Set(int64(stats.PauseTotalNs / uint64(time.Microsecond)))
+			metrics.Int("go_gc_pause_last_us").Set(int64(stats.PauseNs[(stats.NumGC+255)%256] / uint64(time.Microsecond)))
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goruntime
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+func TestTuneGC(t *testing.T) {
+	defer func() { ballast = nil }()
+	previous := debug.SetGCPercent(100)
+	defer debug.SetGCPercent(previous)
+
+	TuneGC(50, 1<<20)
+	require.Equal(t, 50, debug.SetGCPercent(100))
+	require.Len(t, ballast, 1<<20)
+
+	ballast = nil
+	TuneGC(100, 0)
+	require.Nil(t, ballast, "a ballast of 0 bytes was allocated")
+}
+
+func TestWatchGC(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	WatchGC(ctx, 10*time.Millisecond)
+	runtime.GC()
+	require.Eventually(t, func() bool {
+		return metrics.Int("go_heap_alloc_bytes").Value() > 0 && metrics.Int("go_gc_count").Value() > 0
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, metrics.Int("go_mallocs").Value() >= metrics.Int("go_frees").Value())
+}
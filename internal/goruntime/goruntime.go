@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package goruntime - sizes, places and tunes the go runtime of the forwarder: GOMAXPROCS follows the cpu quota of its
+// container unless set explicitly, its threads stay off the cpus vpp threads are pinned to, so the go scheduler does
+// not add jitter to the dataplane, and its gc may be tuned for latency
+package goruntime
+
+import (
+	"math"
+	"runtime"
+)
+
+// SetMaxProcs - sets GOMAXPROCS to procs or, if 0, to the cpu quota of the forwarder's cgroup rounded up, keeping
+// the go default if there is no quota.  A quota is capped at the cpus the forwarder may run on.  Returns the
+// GOMAXPROCS set.
+func SetMaxProcs(procs int) int {
+	if procs > 0 {
+		runtime.GOMAXPROCS(procs)
+		return procs
+	}
+	quota, ok := cpuQuota()
+	if !ok {
+		return runtime.GOMAXPROCS(0)
+	}
+	procs = int(math.Ceil(quota))
+	if allowed := allowedCPUs(); allowed > 0 && procs > allowed {
+		procs = allowed
+	}
+	if procs < 1 {
+		procs = 1
+	}
+	runtime.GOMAXPROCS(procs)
+	return procs
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package goruntime
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// cgroupRoot - where the cgroup of the forwarder's container is mounted, its own through the cgroup namespace
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cpuQuota - returns the cpu quota of the forwarder's cgroup in cores, read from cpu.max on cgroup v2 and from
+// cpu/cpu.cfs_quota_us on v1, false if it has none
+func cpuQuota() (float64, bool) {
+	if content, err := ioutil.ReadFile(cgroupRoot + "/cpu.max"); err == nil {
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		return ratio(fields[0], fields[1])
+	}
+	quota, err := ioutil.ReadFile(cgroupRoot + "/cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0, false
+	}
+	period, err := ioutil.ReadFile(cgroupRoot + "/cpu/cpu.cfs_period_us")
+	if err != nil {
+		return 0, false
+	}
+	return ratio(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+func ratio(quota, period string) (float64, bool) {
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return float64(q) / float64(p), true
+}
+
+// allowedCPUs - returns the number of cpus the forwarder may run on, 0 if unknown
+func allowedCPUs() int {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return 0
+	}
+	return set.Count()
+}
+
+// AvoidCPUs - removes cpus from the affinity of all threads of the forwarder, and so of the threads and processes
+// they start.  vpp pins its own threads, to whichever cpus of its cgroup it is configured with.
+func AvoidCPUs(cpus []int) error {
+	if len(cpus) == 0 {
+		return nil
+	}
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return errors.Wrap(err, "failed to get the cpu affinity of the forwarder")
+	}
+	for _, cpu := range cpus {
+		set.Clear(cpu)
+	}
+	if set.Count() == 0 {
+		return errors.Errorf("no cpu is left to the forwarder besides %v", cpus)
+	}
+	tasks, err := ioutil.ReadDir("/proc/self/task")
+	if err != nil {
+		return errors.Wrap(err, "failed to list the threads of the forwarder")
+	}
+	for _, task := range tasks {
+		tid, convErr := strconv.Atoi(task.Name())
+		if convErr != nil {
+			continue
+		}
+		// Threads exiting meanwhile are gone, ESRCH
+		if err = unix.SchedSetaffinity(tid, &set); err != nil && err != unix.ESRCH {
+			return errors.Wrapf(err, "failed to set the cpu affinity of thread %d", tid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goruntime
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatio(t *testing.T) {
+	quota, ok := ratio("150000", "100000")
+	require.True(t, ok)
+	require.Equal(t, 1.5, quota)
+
+	for _, sample := range [][2]string{{"-1", "100000"}, {"0", "100000"}, {"max", "100000"}, {"150000", "0"}, {"150000", ""}} {
+		_, ok = ratio(sample[0], sample[1])
+		require.False(t, ok, "%s/%s", sample[0], sample[1])
+	}
+}
+
+func TestMaxProcsFollowsTheQuota(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	quota, ok := cpuQuota()
+	if !ok {
+		t.Skip("the test has no cpu quota")
+	}
+	procs := SetMaxProcs(0)
+	require.True(t, float64(procs) >= quota || procs == allowedCPUs(), "GOMAXPROCS %d for a quota of %v", procs, quota)
+	require.True(t, procs <= allowedCPUs())
+}
+
+func TestAvoidCPUs(t *testing.T) {
+	require.NoError(t, AvoidCPUs(nil))
+
+	var all []int
+	for cpu := 0; cpu < allowedCPUs()+runtime.NumCPU(); cpu++ {
+		all = append(all, cpu)
+	}
+	err := AvoidCPUs(all)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no cpu is left to the forwarder")
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux,!windows
+
+package goruntime
+
+import (
+	"github.com/pkg/errors"
+)
+
+func cpuQuota() (float64, bool) {
+	return 0, false
+}
+
+func allowedCPUs() int {
+	return 0
+}
+
+// AvoidCPUs - removes cpus from the affinity of the forwarder, supported on linux only
+func AvoidCPUs(cpus []int) error {
+	if len(cpus) == 0 {
+		return nil
+	}
+	return errors.New("cpu affinity can only be set on linux")
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package handoff - zero datapath loss upgrades of the forwarder.
+//
+// On SIGUSR2 the running forwarder execs a (possibly new) forwarder binary, handing it every listener opened with
+// Listen and every file given to Keep by name.  The old process keeps serving while the new one starts up, until the
+// new one is about to load the state of connections (see TakeOver): the old process then stops serving without
+// tearing anything down, runs the functions given to OnStop, e.g. to persist what the new process loads, and reports
+// it has stopped.  vpp and vpp-agent are left running for the new process to attach to and keep the vpp state of the
+// connections, which NSMgr refreshes against the new process.  Requests arriving in between wait on the handed off
+// listeners for the new process to serve them.
+//
+// The first forwarder process, usually PID 1 of its container and the parent of vpp and vpp-agent, does not exit: it
+// re-execs itself as a supervisor (see Supervise) that keeps vpp and vpp-agent reaped and watched, forwards signals
+// to the forwarder serving at the time and exits, taking everything down, once that forwarder exits without a
+// successor.  Later forwarder processes report their successor to the supervisor and exit.
+package handoff
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/listen"
+)
+
+const (
+	takeOverFDEnv  = "NSM_HANDOFF_TAKEOVER_FD"
+	stoppedFDEnv   = "NSM_HANDOFF_STOPPED_FD"
+	successorFDEnv = "NSM_HANDOFF_SUCCESSOR_FD"
+	filesEnv       = "NSM_HANDOFF_FILES"
+
+	takeOverTimeout = 2 * time.Minute
+	stopTimeout     = 5 * time.Second
+)
+
+// handed - a listener or file to hand to the next forwarder process
+type handed struct {
+	listener net.Listener
+	file     *os.File
+}
+
+var (
+	mu        sync.Mutex
+	inherited = inheritedFiles(os.Getenv(filesEnv))
+	handOn    = map[string]handed{}
+	onStop    []func()
+	// The pipes to the previous forwarder process, kept from being inherited like the files
+	takeOverPipe = inheritedFD(takeOverFDEnv)
+	stoppedPipe  = inheritedFD(stoppedFDEnv)
+)
+
+// Inherited - returns true if this process takes over from a previous forwarder process
+func Inherited() bool {
+	return os.Getenv(takeOverFDEnv) != ""
+}
+
+// TakeOver - if this process takes over from a previous forwarder process, has it stop serving and run its OnStop
+// functions, waiting until it has.  To be called before the state the previous process keeps changing while
+// serving, e.g. the allocated ids, is loaded.  Does nothing otherwise.
+func TakeOver(ctx context.Context) error {
+	mu.Lock()
+	takeOver, stopped := takeOverPipe, stoppedPipe
+	takeOverPipe, stoppedPipe = nil, nil
+	mu.Unlock()
+	if takeOver == nil {
+		return nil
+	}
+	defer func() { _ = takeOver.Close() }()
+	if stopped == nil {
+		return errors.Errorf("no %s to learn that the previous forwarder process stopped from", stoppedFDEnv)
+	}
+	defer func() { _ = stopped.Close() }()
+	if _, err := takeOver.Write([]byte{1}); err != nil {
+		return errors.Wrap(err, "failed to ask the previous forwarder process to stop")
+	}
+	if _, err := stopped.Read(make([]byte, 1)); err != nil {
+		return errors.Wrap(err, "previous forwarder process exited before it stopped")
+	}
+	log.Entry(ctx).Infof("previous forwarder process stopped, taking over")
+	return nil
+}
+
+// OnStop - adds f to the functions run, in order, once this process has stopped serving on handoff and before the
+// next process takes over
+func OnStop(f func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	onStop = append(onStop, f)
+}
+
+// Listen - returns the listener named name inherited from the previous forwarder process, or listens on listenOn if
+// there is none.  Either way the listener is handed on, under the same name, to the next forwarder process.
+func Listen(name string, listenOn *url.URL) (net.Listener, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	var ln net.Listener
+	if f, ok := inherited[name]; ok {
+		delete(inherited, name)
+		var err error
+		ln, err = net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to use the inherited listener for %s", listenOn.String())
+		}
+	} else {
+		var err error
+		if ln, err = listen.Listen(listenOn); err != nil {
+			return nil, err
+		}
+	}
+	handOn[name] = handed{listener: ln}
+	return ln, nil
+}
+
+// File - returns the file named name inherited from the previous forwarder process, or nil if there is none
+func File(name string) *os.File {
+	mu.Lock()
+	defer mu.Unlock()
+	f := inherited[name]
+	delete(inherited, name)
+	return f
+}
+
+// Keep - hands f on, under name, to the next forwarder process
+func Keep(name string, f *os.File) {
+	mu.Lock()
+	defer mu.Unlock()
+	handOn[name] = handed{file: f}
+}
+
+// Server - a grpc server and the url it serves on
+type Server struct {
+	ListenOn *url.URL
+	Server   *grpc.Server
+}
+
+// ListenAndServe - like grpcutils.ListenAndServe, serves each of servers on its url (or the listener inherited from
+// a previous forwarder process) until ctx is done, and hands off to a new process on SIGUSR2.  processes returns the
+// pids of the processes (vpp, vpp-agent) the supervisor keeps watching once the first forwarder process has handed
+// off.  Servers are named by position, so they have to be given in the same order by every process.
+func ListenAndServe(ctx context.Context, servers []*Server, processes func() []int) <-chan error {
+	errCh := make(chan error, len(servers))
+	var listeners []net.Listener
+	for i, s := range servers {
+		ln, err := Listen("grpc-"+strconv.Itoa(i), s.ListenOn)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			errCh <- err
+			close(errCh)
+			return errCh
+		}
+		listeners = append(listeners, ln)
+	}
+	var wg sync.WaitGroup
+	for i, s := range servers {
+		wg.Add(1)
+		go func(server *grpc.Server, ln net.Listener) {
+			defer wg.Done()
+			if serveErr := server.Serve(ln); serveErr != nil {
+				errCh <- errors.WithStack(serveErr)
+			}
+		}(s.Server, listeners[i])
+	}
+	go func() {
+		wg.Wait()
+		close(errCh)
+	}()
+	go func() {
+		<-ctx.Done()
+		for _, s := range servers {
+			s.Server.Stop()
+		}
+	}()
+	go watch(ctx, servers, processes)
+	return errCh
+}
+
+// inheritedFiles - returns the files listed in value, name:fd comma separated, by name.  They are kept from being
+// inherited by the processes the forwarder starts.
+func inheritedFiles(value string) map[string]*os.File {
+	files := map[string]*os.File{}
+	for _, entry := range strings.Split(value, ",") {
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			continue
+		}
+		fd, err := strconv.Atoi(entry[i+1:])
+		if err != nil || fd < 0 {
+			continue
+		}
+		syscall.CloseOnExec(fd)
+		files[entry[:i]] = os.NewFile(uintptr(fd), entry[:i])
+	}
+	return files
+}
+
+// inheritedFD - returns the file whose descriptor is in env, or nil if there is none
+func inheritedFD(env string) *os.File {
+	fd, err := strconv.Atoi(os.Getenv(env))
+	if err != nil || fd < 0 {
+		return nil
+	}
+	syscall.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), env)
+}
+
+func watch(ctx context.Context, servers []*Server, processes func() []int) {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGUSR2)
+	defer signal.Stop(signalCh)
+	successor := inheritedFD(successorFDEnv)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signalCh:
+		}
+		log.Entry(ctx).Infof("SIGUSR2 received, handing off to a new forwarder process")
+		pid, stopped, supervisorR, err := handoff(successor)
+		if err != nil {
+			log.Entry(ctx).Errorf("handoff failed, continuing to serve: %+v", err)
+			continue
+		}
+		log.Entry(ctx).Infof("new forwarder process %d is taking over, stopping", pid)
+		stop(servers)
+		runOnStop()
+		if _, err = stopped.Write([]byte{1}); err != nil {
+			log.Entry(ctx).Errorf("failed to report to forwarder process %d that this one stopped: %+v", pid, err)
+		}
+		_ = stopped.Close()
+		events.Emit(events.Normal, "Upgraded", "handed off to a new forwarder process")
+		// Neither path cancels ctx, which would tear down vpp and vpp-agent
+		if successor != nil {
+			if _, err = fmt.Fprintf(successor, "%d\n", pid); err != nil {
+				log.Entry(ctx).Errorf("failed to report forwarder process %d to the supervisor: %+v", pid, err)
+			}
+			os.Exit(0)
+		}
+		supervise(ctx, pid, processes(), supervisorR)
+	}
+}
+
+// stop - stops servers, gracefully if they stop within stopTimeout.  The new forwarder process serves on the same
+// sockets, so unix sockets are left in place.
+func stop(servers []*Server) {
+	mu.Lock()
+	for _, h := range handOn {
+		if ul, ok := h.listener.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	mu.Unlock()
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(server *grpc.Server) {
+			defer wg.Done()
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-time.After(stopTimeout):
+				server.Stop()
+			}
+		}(s.Server)
+	}
+	wg.Wait()
+}
+
+// runOnStop - runs the functions given to OnStop
+func runOnStop() {
+	mu.Lock()
+	fs := onStop
+	mu.Unlock()
+	for _, f := range fs {
+		f()
+	}
+}
+
+// handoff - starts a new forwarder process with the listeners and files to hand on and waits for it to take over,
+// returning its pid and where to report to it that this process stopped.  successor is where the new process reports
+// its own successor to the supervisor: inherited if there is a supervisor already, else a new pipe whose read end is
+// returned for this process to supervise with.
+func handoff(successor *os.File) (int, *os.File, *os.File, error) {
+	var supervisorR *os.File
+	if successor == nil {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return 0, nil, nil, errors.WithStack(err)
+		}
+		defer func() { _ = w.Close() }()
+		supervisorR, successor = r, w
+	}
+	pid, stopped, err := start(successor)
+	if err != nil {
+		if supervisorR != nil {
+			_ = supervisorR.Close()
+		}
+		return 0, nil, nil, err
+	}
+	return pid, stopped, supervisorR, nil
+}
+
+// start - starts a new forwarder process and waits for it to ask this one to stop, returning its pid and the write end
+// of the pipe it learns that this process stopped from
+func start(successor *os.File) (int, *os.File, error) {
+	takeOverR, takeOverW, err := os.Pipe()
+	if err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	defer func() { _ = takeOverR.Close() }()
+	stoppedR, stoppedW, err := os.Pipe()
+	if err != nil {
+		_ = takeOverW.Close()
+		return 0, nil, errors.WithStack(err)
+	}
+	defer func() { _ = stoppedR.Close() }()
+	// ExtraFiles start at fd 3: the take over pipe, the stopped pipe, the successor pipe, then the named files
+	extraFiles := []*os.File{takeOverW, stoppedR, successor}
+	var names []string
+	mu.Lock()
+	for name := range handOn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var entries []string
+	for _, name := range names {
+		f := handOn[name].file
+		if ln := handOn[name].listener; ln != nil {
+			filer, ok := ln.(interface{ File() (*os.File, error) })
+			if !ok {
+				err = errors.Errorf("listener %s (%T) can not be handed off", name, ln)
+				break
+			}
+			if f, err = filer.File(); err != nil {
+				err = errors.Wrapf(err, "failed to hand off listener %s", name)
+				break
+			}
+			defer func() { _ = f.Close() }()
+		}
+		entries = append(entries, name+":"+strconv.Itoa(3+len(extraFiles)))
+		extraFiles = append(extraFiles, f)
+	}
+	mu.Unlock()
+	if err != nil {
+		_ = takeOverW.Close()
+		_ = stoppedW.Close()
+		return 0, nil, err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		_ = takeOverW.Close()
+		_ = stoppedW.Close()
+		return 0, nil, errors.WithStack(err)
+	}
+	cmd := exec.Command(executable, os.Args[1:]...) // #nosec
+	cmd.Env = append(os.Environ(), takeOverFDEnv+"=3", stoppedFDEnv+"=4", successorFDEnv+"=5", filesEnv+"="+strings.Join(entries, ","))
+	cmd.ExtraFiles = extraFiles
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Start()
+	_ = takeOverW.Close()
+	if err != nil {
+		_ = stoppedW.Close()
+		return 0, nil, errors.WithStack(err)
+	}
+	takeOverCh := make(chan error, 1)
+	go func() {
+		_, readErr := takeOverR.Read(make([]byte, 1))
+		takeOverCh <- readErr
+	}()
+	select {
+	case err = <-takeOverCh:
+	case <-time.After(takeOverTimeout):
+		err = errors.New("timeout waiting for the new forwarder process")
+	}
+	if err != nil {
+		_ = stoppedW.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, nil, errors.Wrap(err, "new forwarder process did not take over")
+	}
+	// The new process is reaped by the supervisor, which this process or its supervisor is
+	return cmd.Process.Pid, stoppedW, nil
+}
+
+// clearCloseOnExec - lets f be inherited across exec
+func clearCloseOnExec(f *os.File) error {
+	_, err := unix.FcntlInt(f.Fd(), unix.F_SETFD, 0)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package handoff
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	socketEnv = "HANDOFF_TEST_SOCKET"
+	failEnv   = "HANDOFF_TEST_FAIL"
+)
+
+// TestMain - runs the test binary as the new forwarder process of TestHandoff when it is started by start
+func TestMain(m *testing.M) {
+	if Inherited() {
+		os.Exit(successor())
+	}
+	os.Exit(m.Run())
+}
+
+// successor - takes over the socket and the file handed off by TestHandoff, then answers one connection on the
+// socket with the content of the file
+func successor() int {
+	if os.Getenv(failEnv) != "" {
+		return 1
+	}
+	f := File("file")
+	if f == nil {
+		return 2
+	}
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return 3
+	}
+	if _, ok := inherited["socket"]; !ok {
+		return 4
+	}
+	ln, err := Listen("socket", &url.URL{Scheme: "unix", Path: os.Getenv(socketEnv)})
+	if err != nil {
+		return 5
+	}
+	if err = TakeOver(context.Background()); err != nil {
+		return 6
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		return 7
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = conn.Write(append(content, '\n')); err != nil {
+		return 8
+	}
+	return 0
+}
+
+// handOff - listens on a unix socket in dir and keeps a file for the next process, returning the socket listener
+func handOff(t *testing.T, dir string) (net.Listener, string) {
+	mu.Lock()
+	handOn = map[string]handed{}
+	mu.Unlock()
+	socket := filepath.Join(dir, "socket")
+	ln, err := Listen("socket", &url.URL{Scheme: "unix", Path: socket})
+	require.NoError(t, err)
+	f, err := ioutil.TempFile(dir, "file")
+	require.NoError(t, err)
+	_, err = f.WriteString("handed off")
+	require.NoError(t, err)
+	_, err = f.Seek(0, 0)
+	require.NoError(t, err)
+	Keep("file", f)
+	require.NoError(t, os.Setenv(socketEnv, socket))
+	return ln, socket
+}
+
+func TestHandoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "handoff")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	ln, socket := handOff(t, dir)
+	successorR, successorW, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = successorR.Close() }()
+	defer func() { _ = successorW.Close() }()
+
+	pid, stopped, err := start(successorW)
+	require.NoError(t, err)
+	// The new process waits for this one to stop before serving
+	stop(nil)
+	require.NoError(t, ln.Close())
+	_, err = os.Stat(socket)
+	require.NoError(t, err, "the socket is left in place for the new process")
+	_, err = stopped.Write([]byte{1})
+	require.NoError(t, err)
+	require.NoError(t, stopped.Close())
+
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "handed off\n", line)
+
+	var status syscall.WaitStatus
+	_, err = syscall.Wait4(pid, &status, 0, nil)
+	require.NoError(t, err)
+	require.True(t, status.Exited())
+	require.Equal(t, 0, status.ExitStatus())
+}
+
+func TestHandoffFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "handoff")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	ln, socket := handOff(t, dir)
+	defer func() { _ = ln.Close() }()
+	require.NoError(t, os.Setenv(failEnv, "1"))
+	defer func() { _ = os.Unsetenv(failEnv) }()
+
+	_, _, err = start(nil)
+	require.Error(t, err)
+
+	// This process keeps serving
+	go func() {
+		conn, acceptErr := ln.Accept()
+		if acceptErr == nil {
+			_, _ = conn.Write([]byte("still serving\n"))
+			_ = conn.Close()
+		}
+	}()
+	conn, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "still serving\n", line)
+}
+
+func TestInheritedFiles(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+	files := inheritedFiles(fmt.Sprintf("pipe:%d,bogus,negative:-1,nan:x", w.Fd()))
+	require.Len(t, files, 1)
+	require.NotNil(t, files["pipe"])
+	_, err = files["pipe"].Write([]byte{1})
+	require.NoError(t, err)
+	require.NoError(t, files["pipe"].Close())
+	_, err = r.Read(make([]byte, 1))
+	require.NoError(t, err)
+}
+
+func TestTakeOverWithoutPredecessor(t *testing.T) {
+	require.NoError(t, TakeOver(context.Background()))
+}
+
+func startProcess(t *testing.T, name string, args ...string) int {
+	cmd := exec.Command(name, args...)
+	require.NoError(t, cmd.Start())
+	return cmd.Process.Pid
+}
+
+func TestRunFollowsSuccessors(t *testing.T) {
+	watched := startProcess(t, "sleep", "60")
+	first := startProcess(t, "sh", "-c", "sleep 0.5")
+	second := startProcess(t, "sh", "-c", "sleep 1; exit 3")
+	successorR, successorW, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = successorW.Close() }()
+	_, err = successorW.WriteString(strconv.Itoa(second) + "\n")
+	require.NoError(t, err)
+
+	require.Equal(t, 3, run(context.Background(), first, []int{watched}, successorR))
+	require.Equal(t, syscall.ESRCH, syscall.Kill(watched, 0), "the watched process is terminated with the last forwarder")
+}
+
+func TestRunStopsForwarderWhenProcessExits(t *testing.T) {
+	watched := startProcess(t, "sh", "-c", "sleep 0.5")
+	forwarder := startProcess(t, "sleep", "60")
+
+	require.Equal(t, 1, run(context.Background(), forwarder, []int{watched}, nil))
+	require.Equal(t, syscall.ESRCH, syscall.Kill(forwarder, 0), "the forwarder is terminated once a watched process exits")
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package handoff
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forkthread"
+)
+
+const (
+	superviseEnv    = "NSM_HANDOFF_SUPERVISE"
+	processesEnv    = "NSM_HANDOFF_PROCESSES"
+	supervisorFDEnv = "NSM_HANDOFF_SUPERVISOR_FD"
+)
+
+// Supervise - if this process was re-exec'd as the supervisor by the first forwarder process on handoff, supervises
+// and never returns.  Does nothing otherwise, to be called first thing by main.
+func Supervise(ctx context.Context) {
+	forwarder, err := strconv.Atoi(os.Getenv(superviseEnv))
+	if err != nil {
+		return
+	}
+	var processes []int
+	for _, field := range strings.Split(os.Getenv(processesEnv), ",") {
+		if pid, atoiErr := strconv.Atoi(field); atoiErr == nil {
+			processes = append(processes, pid)
+		}
+	}
+	os.Exit(run(ctx, forwarder, processes, inheritedFD(supervisorFDEnv)))
+}
+
+// supervise - turns this, the first forwarder process, into the supervisor of forwarder, the process it has handed
+// off to, and of processes.  Re-execs to leave the forwarder's goroutines and state behind, supervises in process if
+// that fails.  Never returns.
+func supervise(ctx context.Context, forwarder int, processes []int, successors *os.File) {
+	var pids []string
+	for _, pid := range processes {
+		pids = append(pids, strconv.Itoa(pid))
+	}
+	err := clearCloseOnExec(successors)
+	if err == nil {
+		var executable string
+		if executable, err = os.Executable(); err == nil {
+			env := append(os.Environ(),
+				superviseEnv+"="+strconv.Itoa(forwarder),
+				processesEnv+"="+strings.Join(pids, ","),
+				supervisorFDEnv+"="+strconv.Itoa(int(successors.Fd())))
+			// vpp and vpp-agent die with the thread that forked them, the only one surviving the exec
+			forkthread.Run(func() { err = syscall.Exec(executable, os.Args, env) }) // #nosec
+		}
+	}
+	log.Entry(ctx).Errorf("failed to re-exec as supervisor, supervising in process: %+v", err)
+	os.Exit(run(ctx, forwarder, processes, successors))
+}
+
+// run - supervises forwarder, its successors as read from successors, and processes: forwards SIGTERM, SIGINT and
+// SIGUSR2 to the forwarder serving at the time, terminates it if one of processes exits, and once it exits without
+// a successor terminates processes.  Returns the exit code of the last forwarder, 1 if one of processes has exited.
+func run(ctx context.Context, forwarder int, processes []int, successors *os.File) int {
+	if err := setSubreaper(); err != nil {
+		log.Entry(ctx).Warnf("failed to become the subreaper of the forwarder processes: %+v", err)
+	}
+	log.Entry(ctx).Infof("supervising forwarder process %d and processes %v", forwarder, processes)
+	var mu sync.Mutex
+	current := forwarder
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGUSR2)
+	go func() {
+		for sig := range signalCh {
+			mu.Lock()
+			_ = syscall.Kill(current, sig.(syscall.Signal))
+			mu.Unlock()
+		}
+	}()
+	successorCh := make(chan int, 1)
+	if successors != nil {
+		go func() {
+			scanner := bufio.NewScanner(successors)
+			for scanner.Scan() {
+				if pid, err := strconv.Atoi(scanner.Text()); err == nil {
+					successorCh <- pid
+				}
+			}
+		}()
+	}
+	watched := map[int]bool{}
+	for _, pid := range processes {
+		watched[pid] = true
+	}
+	failed := false
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, 0, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			log.Entry(ctx).Errorf("lost track of the supervised processes: %+v", err)
+			return 1
+		}
+		switch {
+		case watched[pid]:
+			delete(watched, pid)
+			if !failed {
+				log.Entry(ctx).Errorf("process %d exited (%s), stopping forwarder process %d", pid, describe(status), current)
+				failed = true
+				_ = syscall.Kill(current, syscall.SIGTERM)
+			}
+		case pid == current:
+			select {
+			case next := <-successorCh:
+				log.Entry(ctx).Infof("forwarder process %d handed off to %d", pid, next)
+				mu.Lock()
+				current = next
+				mu.Unlock()
+				continue
+			case <-time.After(stopTimeout):
+			}
+			log.Entry(ctx).Infof("forwarder process %d exited (%s), stopping", pid, describe(status))
+			terminate(watched)
+			if failed || !status.Exited() {
+				return 1
+			}
+			return status.ExitStatus()
+		}
+	}
+}
+
+// terminate - terminates and reaps processes, killing those still running after stopTimeout
+func terminate(processes map[int]bool) {
+	for pid := range processes {
+		_ = syscall.Kill(pid, syscall.SIGTERM)
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for len(processes) > 0 {
+		if time.Now().After(deadline) {
+			for pid := range processes {
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+			}
+			deadline = time.Now().Add(stopTimeout)
+		}
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err != nil && err != syscall.EINTR {
+			return
+		}
+		if pid <= 0 {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		delete(processes, pid)
+	}
+}
+
+func describe(status syscall.WaitStatus) string {
+	if status.Signaled() {
+		return "signal " + status.Signal().String()
+	}
+	return "exit code " + strconv.Itoa(status.ExitStatus())
+}
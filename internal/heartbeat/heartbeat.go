@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heartbeat - periodically rewrites a file with the forwarder's startup phase and readiness, for
+// environments that only support exec or file based probes
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+var phase atomic.Value
+
+// SetPhase - records the phase the forwarder is in, reported by the next heartbeat
+func SetPhase(p string) {
+	phase.Store(p)
+}
+
+// Start - rewrites filename every period until ctx is done.  Its modification time shows the forwarder is alive and
+// its content reads:
+//
+//	phase: <phase>
+//	status: SERVING or NOT_SERVING
+//	error: <why not serving>
+//	time: <RFC3339 time of the heartbeat>
+func Start(ctx context.Context, filename string, period time.Duration) {
+	ticker := time.NewTicker(period)
+	go func() {
+		defer ticker.Stop()
+		for {
+			if err := write(filename); err != nil {
+				log.Entry(ctx).Warnf("failed to write heartbeat %s: %+v", filename, err)
+			}
+			select {
+			case <-ctx.Done():
+				_ = os.Remove(filename)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func write(filename string) error {
+	p, _ := phase.Load().(string)
+	status, errMsg := "SERVING", ""
+	if err := readiness.Err(); err != nil {
+		status, errMsg = "NOT_SERVING", err.Error()
+	}
+	content := fmt.Sprintf("phase: %s\nstatus: %s\nerror: %s\ntime: %s\n", p, status, errMsg, time.Now().Format(time.RFC3339))
+	// Written aside and renamed so probes never read a partial heartbeat
+	tmpFile := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, []byte(content), 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpFile, filename))
+}
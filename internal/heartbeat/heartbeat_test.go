@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeat_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/heartbeat"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+func TestHeartbeat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "heartbeat")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "heartbeat")
+	heartbeatContains := func(lines ...string) func() bool {
+		return func() bool {
+			data, readErr := ioutil.ReadFile(filepath.Clean(filename))
+			if readErr != nil {
+				return false
+			}
+			for _, line := range lines {
+				if !strings.Contains(string(data), line+"\n") {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	heartbeat.SetPhase("2")
+	heartbeat.Start(ctx, filename, 10*time.Millisecond)
+	require.Eventually(t, heartbeatContains("phase: 2", "status: SERVING", "error: "), time.Second, 10*time.Millisecond)
+
+	heartbeat.SetPhase("5")
+	readiness.Set("vpp", errors.New("not connected"))
+	defer readiness.Set("vpp", nil)
+	require.Eventually(t, heartbeatContains("phase: 5", "status: NOT_SERVING", "error: vpp: not connected"), time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, statErr := os.Stat(filename)
+		return os.IsNotExist(statErr)
+	}, time.Second, 10*time.Millisecond, "the heartbeat is removed when the forwarder stops")
+}
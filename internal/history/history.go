@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history - ring buffer of the last Requests and Closes served by the forwarder, with their parameters,
+// results, durations and errors, kept for investigating transient failures that already scrolled out of the logs
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record - a Request or Close of the history
+type Record struct {
+	Time           time.Time         `json:"time"`
+	Op             string            `json:"op"`
+	ID             string            `json:"id"`
+	NetworkService string            `json:"network_service"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Mechanisms     []string          `json:"mechanisms,omitempty"`
+	Mechanism      string            `json:"mechanism,omitempty"`
+	SrcIP          string            `json:"src_ip,omitempty"`
+	DstIP          string            `json:"dst_ip,omitempty"`
+	DurationMs     float64           `json:"duration_ms"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// Ring - the last records, oldest first once full
+type Ring struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewRing - returns a Ring keeping the last size records, nil (keeping none) if size is 0
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		return nil
+	}
+	return &Ring{records: make([]Record, size)}
+}
+
+// Add - adds record, overwriting the oldest one once r is full
+func (r *Ring) Add(record *Record) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[r.next] = *record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// List - returns the records of r, oldest first
+func (r *Ring) List() []Record {
+	if r == nil {
+		return []Record{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Record{}, r.records[:r.next]...)
+	}
+	return append(append([]Record{}, r.records[r.next:]...), r.records[:r.next]...)
+}
+
+// Handler - returns an admin api handler:
+//
+//	GET /history[?errors=true] returns the records of ring (only the failed ones), oldest first, as json
+func Handler(ring *Ring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		records := ring.List()
+		if r.URL.Query().Get("errors") == "true" {
+			failed := records[:0]
+			for i := range records {
+				if records[i].Error != "" {
+					failed = append(failed, records[i])
+				}
+			}
+			records = failed
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		w.Header().Set("Content-Type", "application/json")
+		_ = encoder.Encode(records)
+	})
+}
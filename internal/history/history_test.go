@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/history"
+)
+
+func ids(records []history.Record) []string {
+	rv := []string{}
+	for i := range records {
+		rv = append(rv, records[i].ID)
+	}
+	return rv
+}
+
+func TestRing(t *testing.T) {
+	ring := history.NewRing(3)
+	require.Empty(t, ring.List())
+	ring.Add(&history.Record{ID: "conn-1"})
+	ring.Add(&history.Record{ID: "conn-2"})
+	require.Equal(t, []string{"conn-1", "conn-2"}, ids(ring.List()))
+
+	ring.Add(&history.Record{ID: "conn-3"})
+	ring.Add(&history.Record{ID: "conn-4"})
+	ring.Add(&history.Record{ID: "conn-5"})
+	require.Equal(t, []string{"conn-3", "conn-4", "conn-5"}, ids(ring.List()), "the oldest records were not overwritten")
+
+	// A ring of size 0 keeps nothing
+	ring = history.NewRing(0)
+	require.Nil(t, ring)
+	ring.Add(&history.Record{ID: "conn-1"})
+	require.Empty(t, ring.List())
+}
+
+func TestHandler(t *testing.T) {
+	ring := history.NewRing(4)
+	ring.Add(&history.Record{ID: "conn-1"})
+	ring.Add(&history.Record{ID: "conn-2", Error: "no route"})
+	ring.Add(&history.Record{ID: "conn-3"})
+	handler := history.Handler(ring)
+	get := func(target string) []history.Record {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var records []history.Record
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+		return records
+	}
+
+	require.Equal(t, []string{"conn-1", "conn-2", "conn-3"}, ids(get("/history")))
+	require.Equal(t, []string{"conn-2"}, ids(get("/history?errors=true")))
+	require.Equal(t, []string{"conn-1", "conn-2", "conn-3"}, ids(ring.List()), "filtering the errors changed the ring")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/history", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
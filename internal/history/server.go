@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+type historyServer struct {
+	ring *Ring
+}
+
+// NewServer - returns a server chain element adding the Requests and Closes passing through it to ring, if not nil
+func NewServer(ring *Ring) networkservice.NetworkServiceServer {
+	return &historyServer{ring: ring}
+}
+
+func (h *historyServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if h.ring == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	start := time.Now()
+	conn, err := next.Server(ctx).Request(ctx, request)
+	record := &Record{
+		Time:           start,
+		Op:             "Request",
+		ID:             request.GetConnection().GetId(),
+		NetworkService: request.GetConnection().GetNetworkService(),
+		Labels:         request.GetConnection().GetLabels(),
+		DurationMs:     float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	for _, mechanism := range request.GetMechanismPreferences() {
+		record.Mechanisms = append(record.Mechanisms, mechanism.GetType())
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Mechanism = conn.GetMechanism().GetType()
+		record.SrcIP = conn.GetContext().GetIpContext().GetSrcIpAddr()
+		record.DstIP = conn.GetContext().GetIpContext().GetDstIpAddr()
+	}
+	h.ring.Add(record)
+	return conn, err
+}
+
+func (h *historyServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if h.ring == nil {
+		return next.Server(ctx).Close(ctx, conn)
+	}
+	start := time.Now()
+	rv, err := next.Server(ctx).Close(ctx, conn)
+	record := &Record{
+		Time:           start,
+		Op:             "Close",
+		ID:             conn.GetId(),
+		NetworkService: conn.GetNetworkService(),
+		Labels:         conn.GetLabels(),
+		Mechanism:      conn.GetMechanism().GetType(),
+		DurationMs:     float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	h.ring.Add(record)
+	return rv, err
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/history"
+)
+
+// endpointServer - answers Requests with a kernel connection of the ip context 10.0.0.1/32 - 10.0.0.2/32, failing
+// them and Closes with err
+type endpointServer struct {
+	err error
+}
+
+func (e *endpointServer) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	conn := request.GetConnection()
+	conn.Mechanism = &networkservice.Mechanism{Type: "KERNEL"}
+	conn.Context = &networkservice.ConnectionContext{IpContext: &networkservice.IPContext{
+		SrcIpAddr: "10.0.0.1/32",
+		DstIpAddr: "10.0.0.2/32",
+	}}
+	return conn, nil
+}
+
+func (e *endpointServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &empty.Empty{}, nil
+}
+
+func TestServer(t *testing.T) {
+	ring := history.NewRing(10)
+	next := &endpointServer{}
+	server := chain.NewNetworkServiceServer(history.NewServer(ring), next)
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id:             "conn-1",
+			NetworkService: "ns-1",
+			Labels:         map[string]string{"app": "client"},
+		},
+		MechanismPreferences: []*networkservice.Mechanism{{Type: "MEMIF"}, {Type: "KERNEL"}},
+	}
+
+	conn, err := server.Request(context.Background(), request)
+	require.NoError(t, err)
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+
+	next.err = errors.New("no route")
+	_, err = server.Request(context.Background(), request)
+	require.Error(t, err)
+
+	records := ring.List()
+	require.Len(t, records, 3)
+	require.Equal(t, "Request", records[0].Op)
+	require.Equal(t, "conn-1", records[0].ID)
+	require.Equal(t, "ns-1", records[0].NetworkService)
+	require.Equal(t, map[string]string{"app": "client"}, records[0].Labels)
+	require.Equal(t, []string{"MEMIF", "KERNEL"}, records[0].Mechanisms)
+	require.Equal(t, "KERNEL", records[0].Mechanism)
+	require.Equal(t, "10.0.0.1/32", records[0].SrcIP)
+	require.Equal(t, "10.0.0.2/32", records[0].DstIP)
+	require.Empty(t, records[0].Error)
+
+	require.Equal(t, "Close", records[1].Op)
+	require.Equal(t, "KERNEL", records[1].Mechanism)
+
+	require.Equal(t, "Request", records[2].Op)
+	require.Equal(t, "no route", records[2].Error)
+	require.Empty(t, records[2].Mechanism)
+}
+
+func TestServerWithoutRing(t *testing.T) {
+	server := chain.NewNetworkServiceServer(history.NewServer(nil), &endpointServer{})
+	_, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: "conn-1"}})
+	require.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hostifs - records the host interfaces of the af_packet interfaces the forwarder has vpp-agent create, such
+// as the forwarder's end of the veth pair of a kernel connection, so that the forwarder can itself talk to the client
+// on the other end
+package hostifs
+
+import (
+	"context"
+	"sync"
+
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+)
+
+// Table - the host interface of each af_packet interface by vpp-agent name, the zero value is empty and ready to use
+type Table struct {
+	mu      sync.RWMutex
+	hostIfs map[string]string
+}
+
+// UnaryClientInterceptor - returns an interceptor recording the af_packet interfaces created and deleted by the
+// vpp-agent calls passing through
+func (t *Table) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			return err
+		}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			for _, iface := range r.GetUpdate().GetVppConfig().GetInterfaces() {
+				if iface.GetType() == vpp_interfaces.Interface_AF_PACKET {
+					if t.hostIfs == nil {
+						t.hostIfs = make(map[string]string)
+					}
+					t.hostIfs[iface.GetName()] = iface.GetAfpacket().GetHostIfName()
+				}
+			}
+		case *configurator.DeleteRequest:
+			for _, iface := range r.GetDelete().GetVppConfig().GetInterfaces() {
+				delete(t.hostIfs, iface.GetName())
+			}
+		}
+		return nil
+	}
+}
+
+// Get - returns the host interface of the af_packet interface with the vpp-agent name name, "" if there is none
+func (t *Table) Get(name string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.hostIfs[name]
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostifs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/hostifs"
+)
+
+func TestTable(t *testing.T) {
+	table := &hostifs.Table{}
+	var invokeErr error
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return invokeErr
+	}
+	interceptor := table.UnaryClientInterceptor()
+	config := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: []*vpp_interfaces.Interface{
+		{
+			Name: "server-conn-1",
+			Type: vpp_interfaces.Interface_AF_PACKET,
+			Link: &vpp_interfaces.Interface_Afpacket{Afpacket: &vpp_interfaces.AfpacketLink{HostIfName: "veth-conn-1"}},
+		},
+		{Name: "memif-conn-2", Type: vpp_interfaces.Interface_MEMIF},
+	}}}
+
+	invokeErr = errors.New("vpp-agent is gone")
+	require.Error(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update",
+		&configurator.UpdateRequest{Update: config}, nil, nil, invoker))
+	require.Empty(t, table.Get("server-conn-1"), "failed Updates are not recorded")
+
+	invokeErr = nil
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update",
+		&configurator.UpdateRequest{Update: config}, nil, nil, invoker))
+	require.Equal(t, "veth-conn-1", table.Get("server-conn-1"))
+	require.Empty(t, table.Get("memif-conn-2"))
+
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Delete",
+		&configurator.DeleteRequest{Delete: config}, nil, nil, invoker))
+	require.Empty(t, table.Get("server-conn-1"))
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idalloc - allocates the numeric identifiers programmed into vpp (vxlan vnis, vlan ids, ipsec sa ids, mpls
+// labels) from configured ranges, persisted so that a restarted forwarder doesn't hand out ids still programmed in
+// vpp for connections it kept.
+//
+// Allocation is deterministic: an owner, e.g. a connection id, is hashed into the range of the kind and the next
+// free id from there is taken, so the same owner tends to get the same id even if the persisted state is lost.
+package idalloc
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Kinds of ids
+const (
+	VNI  = "vni"
+	VLAN = "vlan"
+	SA   = "sa"
+	MPLS = "mpls"
+)
+
+// Range - inclusive range of ids of a kind
+type Range struct {
+	Min uint32
+	Max uint32
+}
+
+// ParseRanges - parses ranges given as kind=min-max
+func ParseRanges(ranges []string) (map[string]Range, error) {
+	rv := make(map[string]Range)
+	for _, r := range ranges {
+		kv := strings.SplitN(r, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid id range %q, expected kind=min-max", r)
+		}
+		bounds := strings.SplitN(kv[1], "-", 2)
+		if len(bounds) != 2 {
+			return nil, errors.Errorf("invalid id range %q, expected kind=min-max", r)
+		}
+		min, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid id range %q", r)
+		}
+		max, err := strconv.ParseUint(bounds[1], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid id range %q", r)
+		}
+		if min > max {
+			return nil, errors.Errorf("invalid id range %q, min exceeds max", r)
+		}
+		rv[kv[0]] = Range{Min: uint32(min), Max: uint32(max)}
+	}
+	return rv, nil
+}
+
+// Partition - splits each of ranges into count equal parts and returns part index of each, so forwarders
+// sharing a node allocate disjoint ids
+func Partition(ranges map[string]Range, index, count int) (map[string]Range, error) {
+	if count < 1 || index < 0 || index >= count {
+		return nil, errors.Errorf("invalid partition %d of %d", index, count)
+	}
+	rv := make(map[string]Range, len(ranges))
+	for kind, r := range ranges {
+		size := (uint64(r.Max) - uint64(r.Min) + 1) / uint64(count)
+		if size == 0 {
+			return nil, errors.Errorf("%s range %d-%d is too small for %d partitions", kind, r.Min, r.Max, count)
+		}
+		min := uint64(r.Min) + uint64(index)*size
+		rv[kind] = Range{Min: uint32(min), Max: uint32(min + size - 1)}
+	}
+	return rv, nil
+}
+
+// Allocator - allocates ids of several kinds
+type Allocator struct {
+	mu       sync.Mutex
+	filename string
+	ranges   map[string]Range
+	// ids of each kind by owner, and owners by id
+	ids    map[string]map[string]uint32
+	owners map[string]map[uint32]string
+}
+
+// NewAllocator - returns an allocator handing out ids from ranges, persisted to filename (not persisted if empty) and
+// restored from it.  Restored ids of unknown kinds or outside of their range are dropped.
+func NewAllocator(ctx context.Context, filename string, ranges map[string]Range) (*Allocator, error) {
+	a := &Allocator{
+		filename: filename,
+		ranges:   ranges,
+		ids:      make(map[string]map[string]uint32),
+		owners:   make(map[string]map[uint32]string),
+	}
+	for kind := range ranges {
+		a.ids[kind] = make(map[string]uint32)
+		a.owners[kind] = make(map[uint32]string)
+	}
+	if filename == "" {
+		return a, nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err):
+		return a, nil
+	case err != nil:
+		return nil, errors.WithStack(err)
+	}
+	restored := make(map[string]map[string]uint32)
+	if err = json.Unmarshal(data, &restored); err != nil {
+		return nil, errors.Wrapf(err, "invalid ids %s", filename)
+	}
+	n := 0
+	for kind, ids := range restored {
+		for owner, id := range ids {
+			if r, ok := ranges[kind]; !ok || id < r.Min || id > r.Max {
+				log.Entry(ctx).Warnf("dropping restored %s %d of %s outside of the configured ranges", kind, id, owner)
+				continue
+			}
+			a.ids[kind][owner] = id
+			a.owners[kind][id] = owner
+			n++
+		}
+	}
+	log.Entry(ctx).Infof("restored %d ids from %s", n, filename)
+	return a, nil
+}
+
+// Allocate - returns the id of kind allocated to owner, allocating one if it has none
+func (a *Allocator) Allocate(kind, owner string) (uint32, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r, ok := a.ranges[kind]
+	if !ok {
+		return 0, errors.Errorf("no range configured for %s ids", kind)
+	}
+	if id, ok := a.ids[kind][owner]; ok {
+		return id, nil
+	}
+	size := uint64(r.Max) - uint64(r.Min) + 1
+	if uint64(len(a.owners[kind])) >= size {
+		return 0, errors.Errorf("all %d %s ids are allocated", size, kind)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(owner))
+	offset := h.Sum64() % size
+	for {
+		id := r.Min + uint32(offset)
+		if _, used := a.owners[kind][id]; !used {
+			a.ids[kind][owner] = id
+			a.owners[kind][id] = owner
+			a.persist()
+			return id, nil
+		}
+		offset = (offset + 1) % size
+	}
+}
+
+// Lookup - returns the id of kind allocated to owner, false if it has none
+func (a *Allocator) Lookup(kind, owner string) (uint32, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	id, ok := a.ids[kind][owner]
+	return id, ok
+}
+
+// Reserve - records id of kind, e.g. one chosen by a peer, as used by owner
+func (a *Allocator) Reserve(kind string, id uint32, owner string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	r, ok := a.ranges[kind]
+	if !ok || id < r.Min || id > r.Max {
+		// Ids outside of the ranges can't collide with allocated ones
+		return nil
+	}
+	if current, ok := a.owners[kind][id]; ok {
+		if current == owner {
+			return nil
+		}
+		return errors.Errorf("%s %d of %s is allocated to %s", kind, id, owner, current)
+	}
+	if previous, ok := a.ids[kind][owner]; ok {
+		delete(a.owners[kind], previous)
+	}
+	a.ids[kind][owner] = id
+	a.owners[kind][id] = owner
+	a.persist()
+	return nil
+}
+
+// Release - releases the ids of all kinds of owner
+func (a *Allocator) Release(owner string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	released := false
+	for kind, ids := range a.ids {
+		if id, ok := ids[owner]; ok {
+			delete(ids, owner)
+			delete(a.owners[kind], id)
+			released = true
+		}
+	}
+	if released {
+		a.persist()
+	}
+}
+
+// ReleaseKind - releases the id of kind of owner, leaving its ids of other kinds
+func (a *Allocator) ReleaseKind(kind, owner string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if id, ok := a.ids[kind][owner]; ok {
+		delete(a.ids[kind], owner)
+		delete(a.owners[kind], id)
+		a.persist()
+	}
+}
+
+// persist - writes the allocated ids to the allocator's file, errors are logged as the in-memory state stays
+// authoritative
+func (a *Allocator) persist() {
+	if a.filename == "" {
+		return
+	}
+	data, err := json.Marshal(a.ids)
+	if err == nil {
+		tmpFile := a.filename + ".tmp"
+		if err = ioutil.WriteFile(tmpFile, data, 0600); err == nil {
+			err = os.Rename(tmpFile, a.filename)
+		}
+	}
+	if err != nil {
+		log.Entry(context.Background()).Warnf("failed to persist ids to %s: %+v", a.filename, err)
+	}
+}
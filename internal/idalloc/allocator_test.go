@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idalloc_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/idalloc"
+)
+
+func TestAllocator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idalloc")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "ids.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ranges, err := idalloc.ParseRanges([]string{"vni=10-12"})
+	require.NoError(t, err)
+	allocator, err := idalloc.NewAllocator(ctx, filename, ranges)
+	require.NoError(t, err)
+
+	a, err := allocator.Allocate(idalloc.VNI, "a")
+	require.NoError(t, err)
+	again, err := allocator.Allocate(idalloc.VNI, "a")
+	require.NoError(t, err)
+	require.Equal(t, a, again, "an owner keeps its id")
+	b, err := allocator.Allocate(idalloc.VNI, "b")
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+	require.Error(t, allocator.Reserve(idalloc.VNI, a, "b"), "an id can't be reserved by two owners")
+	_, err = allocator.Allocate(idalloc.VLAN, "a")
+	require.Error(t, err, "kinds without a range can't be allocated")
+
+	restored, err := idalloc.NewAllocator(ctx, filename, ranges)
+	require.NoError(t, err)
+	c, err := restored.Allocate(idalloc.VNI, "c")
+	require.NoError(t, err)
+	require.NotContains(t, []uint32{a, b}, c, "restored ids are not handed out again")
+	_, err = restored.Allocate(idalloc.VNI, "d")
+	require.Error(t, err, "the range is exhausted")
+
+	restored.Release("a")
+	d, err := restored.Allocate(idalloc.VNI, "d")
+	require.NoError(t, err)
+	require.Equal(t, a, d, "released ids are handed out again")
+
+	id, ok := restored.Lookup(idalloc.VNI, "d")
+	require.True(t, ok)
+	require.Equal(t, d, id)
+	restored.ReleaseKind(idalloc.VLAN, "d")
+	_, ok = restored.Lookup(idalloc.VNI, "d")
+	require.True(t, ok, "releasing another kind keeps the id")
+	restored.ReleaseKind(idalloc.VNI, "d")
+	_, ok = restored.Lookup(idalloc.VNI, "d")
+	require.False(t, ok)
+}
+
+func TestPartition(t *testing.T) {
+	ranges, err := idalloc.ParseRanges([]string{"vni=100-199", "vlan=1-10"})
+	require.NoError(t, err)
+
+	first, err := idalloc.Partition(ranges, 0, 3)
+	require.NoError(t, err)
+	last, err := idalloc.Partition(ranges, 2, 3)
+	require.NoError(t, err)
+	require.Equal(t, idalloc.Range{Min: 100, Max: 132}, first[idalloc.VNI])
+	require.Equal(t, idalloc.Range{Min: 166, Max: 198}, last[idalloc.VNI])
+	require.Equal(t, idalloc.Range{Min: 1, Max: 3}, first[idalloc.VLAN])
+	require.Equal(t, idalloc.Range{Min: 7, Max: 9}, last[idalloc.VLAN])
+
+	for _, sample := range [][2]int{{3, 3}, {-1, 3}, {0, 0}} {
+		_, err = idalloc.Partition(ranges, sample[0], sample[1])
+		require.Error(t, err, "partition %d of %d", sample[0], sample[1])
+	}
+	_, err = idalloc.Partition(ranges, 0, 11)
+	require.Error(t, err, "the vlan range can't be split in 11")
+}
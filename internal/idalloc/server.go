@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idalloc
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+type idallocServer struct {
+	allocator *Allocator
+}
+
+// NewServer - returns a server chain element allocating the vni of vxlan mechanisms lacking one from allocator, and
+// reserving those chosen elsewhere, for the lifetime of the connection.  The other kinds are allocated by the
+// mechanisms using them.
+func NewServer(allocator *Allocator) networkservice.NetworkServiceServer {
+	return &idallocServer{allocator: allocator}
+}
+
+func (i *idallocServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	connID := request.GetConnection().GetId()
+	mechanisms := append([]*networkservice.Mechanism{request.GetConnection().GetMechanism()}, request.GetMechanismPreferences()...)
+	// allocated - whether this Request, rather than an earlier one of the connection, allocated its vni
+	allocated := false
+	for _, mechanism := range mechanisms {
+		if mechanism.GetType() != vxlan.MECHANISM || mechanism.GetParameters()[vxlan.VNI] != "" {
+			continue
+		}
+		if _, ok := i.allocator.Lookup(VNI, connID); !ok {
+			allocated = true
+		}
+		vni, err := i.allocator.Allocate(VNI, connID)
+		if err != nil {
+			return nil, err
+		}
+		if mechanism.Parameters == nil {
+			mechanism.Parameters = make(map[string]string)
+		}
+		mechanism.Parameters[vxlan.VNI] = strconv.FormatUint(uint64(vni), 10)
+	}
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		// A connection that failed to come up has no Close to release its vni
+		if allocated {
+			i.allocator.ReleaseKind(VNI, connID)
+		}
+		return nil, err
+	}
+	if conn.GetMechanism().GetType() == vxlan.MECHANISM {
+		if vni, parseErr := strconv.ParseUint(conn.GetMechanism().GetParameters()[vxlan.VNI], 10, 32); parseErr == nil {
+			if err = i.allocator.Reserve(VNI, uint32(vni), conn.GetId()); err != nil {
+				log.Entry(ctx).Warnf("vni collision: %+v", err)
+			}
+		}
+	}
+	return conn, nil
+}
+
+func (i *idallocServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	rv, err := next.Server(ctx).Close(ctx, conn)
+	i.allocator.Release(conn.GetId())
+	return rv, err
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ifevents - subscribes to the link state notifications vpp-agent relays from vpp, so that interfaces going
+// down or away are noticed within milliseconds rather than at the next dump
+package ifevents
+
+import (
+	"context"
+	"time"
+
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vppinterfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const resubscribeDelay = time.Second
+
+// Handler - called with the state of a vpp interface whose link changed, its oper status being UP, DOWN or DELETED
+type Handler func(ctx context.Context, state *vppinterfaces.InterfaceState)
+
+// Watch - calls handler for every link state notification of vpp-agent at cc until ctx is done, resubscribing from
+// the last notification seen if the stream breaks
+func Watch(ctx context.Context, cc grpc.ClientConnInterface, handler Handler) {
+	client := configurator.NewConfiguratorServiceClient(cc)
+	go func() {
+		var idx uint32
+		for {
+			var err error
+			idx, err = watch(ctx, client, idx, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Entry(ctx).Warnf("vpp interface notifications interrupted, resubscribing: %+v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+		}
+	}()
+}
+
+// watch - subscribes to notifications from idx on, returning the index to resubscribe from once the stream breaks
+func watch(ctx context.Context, client configurator.ConfiguratorServiceClient, idx uint32, handler Handler) (uint32, error) {
+	stream, err := client.Notify(ctx, &configurator.NotifyRequest{Idx: idx})
+	if err != nil {
+		return idx, err
+	}
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr != nil {
+			return idx, recvErr
+		}
+		idx = resp.GetNextIdx()
+		notification := resp.GetNotification().GetVppNotification().GetInterface()
+		if notification.GetType() != vppinterfaces.InterfaceNotification_UPDOWN {
+			continue
+		}
+		handler(ctx, notification.GetState())
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ifevents_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ifevents"
+)
+
+// notifyCC - answers each Notify with a stream of the responses of the next batch, broken once they are received,
+// recording the indexes subscribed from
+type notifyCC struct {
+	mu      sync.Mutex
+	batches [][]*configurator.NotifyResponse
+	idxs    []uint32
+}
+
+func (c *notifyCC) subscribedFrom() []uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]uint32(nil), c.idxs...)
+}
+
+func (c *notifyCC) exhausted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.batches) == 0
+}
+
+func (c *notifyCC) Invoke(context.Context, string, interface{}, interface{}, ...grpc.CallOption) error {
+	return errors.New("no unary calls")
+}
+
+func (c *notifyCC) NewStream(ctx context.Context, _ *grpc.StreamDesc, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &notifyStream{ctx: ctx, cc: c}
+	if len(c.batches) > 0 {
+		s.responses, c.batches = c.batches[0], c.batches[1:]
+	}
+	return s, nil
+}
+
+type notifyStream struct {
+	ctx       context.Context
+	cc        *notifyCC
+	responses []*configurator.NotifyResponse
+}
+
+func (s *notifyStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *notifyStream) Trailer() metadata.MD         { return nil }
+func (s *notifyStream) CloseSend() error             { return nil }
+func (s *notifyStream) Context() context.Context     { return s.ctx }
+
+func (s *notifyStream) SendMsg(m interface{}) error {
+	s.cc.mu.Lock()
+	defer s.cc.mu.Unlock()
+	s.cc.idxs = append(s.cc.idxs, m.(*configurator.NotifyRequest).GetIdx())
+	return nil
+}
+
+func (s *notifyStream) RecvMsg(m interface{}) error {
+	if len(s.responses) == 0 {
+		if s.cc.exhausted() {
+			<-s.ctx.Done()
+			return s.ctx.Err()
+		}
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), s.responses[0])
+	s.responses = s.responses[1:]
+	return nil
+}
+
+func notification(nextIdx uint32, notificationType vpp_interfaces.InterfaceNotification_NotifType, name string) *configurator.NotifyResponse {
+	return &configurator.NotifyResponse{
+		NextIdx: nextIdx,
+		Notification: &configurator.Notification{
+			Notification: &configurator.Notification_VppNotification{
+				VppNotification: &vpp.Notification{
+					Interface: &vpp_interfaces.InterfaceNotification{
+						Type: notificationType,
+						State: &vpp_interfaces.InterfaceState{
+							Name:       name,
+							OperStatus: vpp_interfaces.InterfaceState_DOWN,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestWatch(t *testing.T) {
+	cc := &notifyCC{batches: [][]*configurator.NotifyResponse{
+		{
+			notification(1, vpp_interfaces.InterfaceNotification_UPDOWN, "memif-1"),
+			notification(2, vpp_interfaces.InterfaceNotification_COUNTERS, "memif-1"),
+		},
+		{
+			notification(3, vpp_interfaces.InterfaceNotification_UPDOWN, "tap-1"),
+		},
+	}}
+	var mu sync.Mutex
+	var names []string
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ifevents.Watch(ctx, cc, func(_ context.Context, state *vpp_interfaces.InterfaceState) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, state.GetName())
+	})
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(names) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"memif-1", "tap-1"}, names, "link state changes only")
+	require.Equal(t, []uint32{0, 2}, cc.subscribedFrom(), "resubscribed from the last notification seen")
+}
@@ -0,0 +1,421 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ifpool - a warm pool of vpp tap interfaces created ahead of the Requests needing them, taking interface
+// creation off their path.
+//
+// Its interceptor, at the bottom of the vpp-agent chain, renames a tap interface an Update creates to a free pool
+// interface, and every reference to it in the txns and replies, so that vpp-agent modifies the pooled tap into the
+// one asked for rather than creating it, while the chain above keeps seeing the names sdk-vppagent gives.  Pooled
+// taps are not reused: they are deleted with their connection and the pool topped up again.
+//
+// vpp recreates a tap whose link changes, its host_if_name in particular, so a tap is only handed a pool interface
+// whose link is the very one asked for, and the pool is kept of taps linked like the last one asked for.  Memif
+// interfaces are not pooled: their socket is per connection and vpp cannot move a memif to another socket, a pooled
+// memif would be recreated anyway.
+package ifpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	linux_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/linux/interfaces"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_acl "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/acl"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l2 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l2"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// Prefix - the prefix of the vpp-agent names of pool interfaces
+const Prefix = "pool-"
+
+// Pooled - returns whether name is the vpp-agent name of a free pool interface
+func Pooled(name string) bool {
+	return strings.HasPrefix(name, Prefix)
+}
+
+// ownUpdate - context key marking the calls of the Pool itself, which must not be renamed
+type ownUpdate struct{}
+
+type state struct {
+	Free     []string          `json:"free"`
+	Assigned map[string]string `json:"assigned"`
+}
+
+// Pool - the free pool interfaces and those handed to connections, by the name sdk-vppagent gave them.  The zero
+// value hands out nothing until started.
+type Pool struct {
+	mu    sync.Mutex
+	state state
+	// links - the links of the free pool interfaces
+	links    map[string]*vpp_interfaces.TapLink
+	template *vpp_interfaces.TapLink
+	filename string
+	size     int
+	refillCh chan struct{}
+}
+
+// UnaryClientInterceptor - returns an interceptor handing free pool interfaces to the tap interfaces Updates create
+// and renaming them in the vpp-agent calls passing through
+func (p *Pool) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if ctx.Value(ownUpdate{}) != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			p.assign(ctx, r.GetUpdate().GetVppConfig().GetInterfaces())
+			req = p.rename(r)
+		case *configurator.DeleteRequest:
+			req = p.rename(r)
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		if r, ok := req.(*configurator.DeleteRequest); ok {
+			p.release(r.GetDelete().GetVppConfig().GetInterfaces())
+		}
+		p.restore(reply)
+		return nil
+	}
+}
+
+// StreamClientInterceptor - returns an interceptor renaming pool interfaces back in the vpp-agent notifications
+func (p *Pool) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &restoringStream{ClientStream: stream, pool: p}, nil
+	}
+}
+
+type restoringStream struct {
+	grpc.ClientStream
+	pool *Pool
+}
+
+func (r *restoringStream) RecvMsg(m interface{}) error {
+	if err := r.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	r.pool.restore(m)
+	return nil
+}
+
+// Start - loads the pool state recorded in filename, if any, adopts the pool interfaces vpp-agent has, and keeps
+// size free pool interfaces created through vppagentCC until ctx is done.  It returns once the pool is full.
+func (p *Pool) Start(ctx context.Context, vppagentCC grpc.ClientConnInterface, size int, filename string) error {
+	client := configurator.NewConfiguratorServiceClient(vppagentCC)
+	ctx = context.WithValue(ctx, ownUpdate{}, true)
+	dump, err := client.Dump(ctx, &configurator.DumpRequest{})
+	if err != nil {
+		return errors.Wrap(err, "failed to dump the vpp-agent config for the interface pool")
+	}
+	loaded := state{}
+	if data, readErr := ioutil.ReadFile(filename); readErr == nil {
+		if err = json.Unmarshal(data, &loaded); err != nil {
+			log.Entry(ctx).Warnf("ignoring invalid interface pool state in %s: %+v", filename, err)
+		}
+	}
+	assigned := make(map[string]bool)
+	for _, name := range loaded.Assigned {
+		assigned[name] = true
+	}
+
+	p.mu.Lock()
+	p.filename, p.size, p.refillCh = filename, size, make(chan struct{}, 1)
+	p.state = state{Assigned: loaded.Assigned}
+	if p.state.Assigned == nil {
+		p.state.Assigned = make(map[string]string)
+	}
+	p.links = make(map[string]*vpp_interfaces.TapLink)
+	for _, iface := range dump.GetDump().GetVppConfig().GetInterfaces() {
+		if Pooled(iface.GetName()) && !assigned[iface.GetName()] && iface.GetType() == vpp_interfaces.Interface_TAP {
+			p.state.Free = append(p.state.Free, iface.GetName())
+			p.links[iface.GetName()] = iface.GetTap()
+		}
+	}
+	p.persist(ctx)
+	p.mu.Unlock()
+
+	if err = p.refill(ctx, client); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.refillCh:
+			}
+			if refillErr := p.refill(ctx, client); refillErr != nil {
+				log.Entry(ctx).Warnf("failed to top up the interface pool: %+v", refillErr)
+			}
+		}
+	}()
+	return nil
+}
+
+// refill - deletes the free pool interfaces not linked like the template and creates pool interfaces until size of
+// them are free
+func (p *Pool) refill(ctx context.Context, client configurator.ConfiguratorServiceClient) error {
+	if err := p.prune(ctx, client); err != nil {
+		return err
+	}
+	for {
+		p.mu.Lock()
+		if len(p.state.Free) >= p.size {
+			p.mu.Unlock()
+			return nil
+		}
+		link := &vpp_interfaces.TapLink{Version: 2}
+		if p.template != nil {
+			link = proto.Clone(p.template).(*vpp_interfaces.TapLink)
+		}
+		p.mu.Unlock()
+		iface := &vpp_interfaces.Interface{
+			Name: fmt.Sprintf("%s%s", Prefix, uuid.New().String()[:8]),
+			Type: vpp_interfaces.Interface_TAP,
+			Link: &vpp_interfaces.Interface_Tap{Tap: link},
+		}
+		if _, err := client.Update(ctx, &configurator.UpdateRequest{
+			Update: &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: []*vpp_interfaces.Interface{iface}}},
+		}); err != nil {
+			return errors.Wrapf(err, "failed to create pool interface %s", iface.GetName())
+		}
+		p.mu.Lock()
+		p.state.Free = append(p.state.Free, iface.GetName())
+		p.links[iface.GetName()] = link
+		metrics.Int("interface_pool_free").Set(int64(len(p.state.Free)))
+		p.persist(ctx)
+		p.mu.Unlock()
+	}
+}
+
+// prune - deletes the free pool interfaces not linked like the template, which no tap asked for now can take
+func (p *Pool) prune(ctx context.Context, client configurator.ConfiguratorServiceClient) error {
+	p.mu.Lock()
+	var stale []*vpp_interfaces.Interface
+	var free []string
+	for _, name := range p.state.Free {
+		if p.template == nil || proto.Equal(p.links[name], p.template) {
+			free = append(free, name)
+			continue
+		}
+		stale = append(stale, &vpp_interfaces.Interface{
+			Name: name,
+			Type: vpp_interfaces.Interface_TAP,
+			Link: &vpp_interfaces.Interface_Tap{Tap: p.links[name]},
+		})
+	}
+	p.mu.Unlock()
+	if len(stale) == 0 {
+		return nil
+	}
+	if _, err := client.Delete(ctx, &configurator.DeleteRequest{
+		Delete: &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: stale}},
+	}); err != nil {
+		return errors.Wrap(err, "failed to delete the pool interfaces of a previous link")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Free = p.state.Free[:0]
+	for _, name := range free {
+		// Interfaces may have been handed out meanwhile
+		if _, ok := p.links[name]; ok {
+			p.state.Free = append(p.state.Free, name)
+		}
+	}
+	for _, iface := range stale {
+		delete(p.links, iface.GetName())
+	}
+	metrics.Int("interface_pool_free").Set(int64(len(p.state.Free)))
+	p.persist(ctx)
+	return nil
+}
+
+// assign - hands a free pool interface linked the same way to each new tap interface among ifaces, and learns the
+// link of the taps to create further pool interfaces like them
+func (p *Pool) assign(ctx context.Context, ifaces []*vpp_interfaces.Interface) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, iface := range ifaces {
+		if iface.GetType() != vpp_interfaces.Interface_TAP || Pooled(iface.GetName()) {
+			continue
+		}
+		if _, ok := p.state.Assigned[iface.GetName()]; ok {
+			continue
+		}
+		if p.template == nil || !proto.Equal(p.template, iface.GetTap()) {
+			p.template = proto.Clone(iface.GetTap()).(*vpp_interfaces.TapLink)
+			p.topUp()
+		}
+		i := p.free(iface.GetTap())
+		if i < 0 {
+			metrics.Int("interface_pool_misses").Add(1)
+			continue
+		}
+		pooled := p.state.Free[i]
+		p.state.Free = append(p.state.Free[:i], p.state.Free[i+1:]...)
+		delete(p.links, pooled)
+		p.state.Assigned[iface.GetName()] = pooled
+		metrics.Int("interface_pool_free").Set(int64(len(p.state.Free)))
+		log.Entry(ctx).Debugf("handing pool interface %s to %s", pooled, iface.GetName())
+		p.persist(ctx)
+		p.topUp()
+	}
+}
+
+// free - returns the index of a free pool interface with link, -1 if there is none
+func (p *Pool) free(link *vpp_interfaces.TapLink) int {
+	for i, name := range p.state.Free {
+		if proto.Equal(p.links[name], link) {
+			return i
+		}
+	}
+	return -1
+}
+
+// release - forgets the pool interfaces deleted along with ifaces
+func (p *Pool) release(ifaces []*vpp_interfaces.Interface) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, iface := range ifaces {
+		if name, ok := p.original(iface.GetName()); ok {
+			delete(p.state.Assigned, name)
+		}
+	}
+}
+
+func (p *Pool) original(pooled string) (string, bool) {
+	for name, candidate := range p.state.Assigned {
+		if candidate == pooled {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (p *Pool) topUp() {
+	if p.refillCh == nil {
+		return
+	}
+	select {
+	case p.refillCh <- struct{}{}:
+	default:
+	}
+}
+
+// rename - returns a copy of req with the interfaces handed pool interfaces renamed to them
+func (p *Pool) rename(req proto.Message) proto.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.state.Assigned) == 0 {
+		return req
+	}
+	req = proto.Clone(req)
+	replace(reflect.ValueOf(req), p.state.Assigned)
+	return req
+}
+
+// restore - renames the pool interfaces in reply back to the names they were handed to
+func (p *Pool) restore(reply interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.state.Assigned) == 0 {
+		return
+	}
+	names := make(map[string]string, len(p.state.Assigned))
+	for name, pooled := range p.state.Assigned {
+		names[pooled] = name
+	}
+	replace(reflect.ValueOf(reply), names)
+}
+
+// referencing - the fields, besides those named after interfaces, holding the vpp-agent names of vpp interfaces
+var referencing = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(vpp_interfaces.Interface{}):      {"Name": true},
+	reflect.TypeOf(vpp_interfaces.InterfaceState{}): {"Name": true},
+	reflect.TypeOf(vpp_l2.BridgeDomain_Interface{}): {"Name": true},
+	reflect.TypeOf(vpp_acl.ACL_Interfaces{}):        {"Ingress": true, "Egress": true},
+	reflect.TypeOf(linux_interfaces.TapLink{}):      {"VppTapIfName": true},
+}
+
+// replace - replaces, in place, each vpp interface name of v, or reachable from it through its exported fields,
+// that is a key of names by its value.  Other strings, such as the host names of interfaces or the names of acls,
+// are left alone even if they match.
+func replace(v reflect.Value, names map[string]string) {
+	replaceReferences(v, names, false)
+}
+
+// replaceReferences - replace, with the strings of v being interface names if reference
+func replaceReferences(v reflect.Value, names map[string]string, reference bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			replaceReferences(v.Elem(), names, reference)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath == "" {
+				replaceReferences(v.Field(i), names, strings.Contains(field.Name, "Interface") || referencing[v.Type()][field.Name])
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			replaceReferences(v.Index(i), names, reference)
+		}
+	case reflect.String:
+		if name, ok := names[v.String()]; ok && reference && v.CanSet() {
+			v.SetString(name)
+		}
+	}
+}
+
+// persist - records the pool state in its file, so that a forwarder taking over keeps the assignments
+func (p *Pool) persist(ctx context.Context) {
+	if p.filename == "" {
+		return
+	}
+	data, err := json.Marshal(&p.state)
+	if err == nil {
+		err = ioutil.WriteFile(p.filename+".tmp", data, 0600)
+	}
+	if err == nil {
+		err = os.Rename(p.filename+".tmp", p.filename)
+	}
+	if err != nil {
+		log.Entry(ctx).Warnf("failed to record the interface pool state in %s: %+v", p.filename, err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ifpool
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/linux"
+	linux_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/linux/interfaces"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_acl "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/acl"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+)
+
+func tap(name, hostIfName string) *vpp_interfaces.Interface {
+	return &vpp_interfaces.Interface{
+		Name: name,
+		Type: vpp_interfaces.Interface_TAP,
+		Link: &vpp_interfaces.Interface_Tap{Tap: &vpp_interfaces.TapLink{Version: 2, HostIfName: hostIfName}},
+	}
+}
+
+// newPool - returns a pool with a free pool interface linked like tap(_, hostIfName)
+func newPool(hostIfName string) *Pool {
+	return &Pool{
+		state: state{Free: []string{Prefix + "1"}, Assigned: map[string]string{}},
+		links: map[string]*vpp_interfaces.TapLink{Prefix + "1": tap("", hostIfName).GetTap()},
+	}
+}
+
+func TestRenameOnlyInterfaceReferences(t *testing.T) {
+	p := newPool("")
+	update := &configurator.UpdateRequest{Update: &configurator.Config{
+		VppConfig: &vpp.ConfigData{
+			Interfaces: []*vpp_interfaces.Interface{tap("server-1", "")},
+			Routes:     []*vpp_l3.Route{{DstNetwork: "10.0.0.0/24", OutgoingInterface: "server-1"}},
+			Acls: []*vpp_acl.ACL{{
+				Name:       "server-1",
+				Interfaces: &vpp_acl.ACL_Interfaces{Ingress: []string{"server-1"}},
+			}},
+		},
+		LinuxConfig: &linux.ConfigData{
+			Interfaces: []*linux_interfaces.Interface{{
+				Name:       "server-1",
+				Type:       linux_interfaces.Interface_TAP_TO_VPP,
+				HostIfName: "server-1",
+				Link:       &linux_interfaces.Interface_Tap{Tap: &linux_interfaces.TapLink{VppTapIfName: "server-1"}},
+			}},
+		},
+	}}
+	p.assign(context.Background(), update.GetUpdate().GetVppConfig().GetInterfaces())
+	renamed := p.rename(update).(*configurator.UpdateRequest).GetUpdate()
+
+	require.Equal(t, Prefix+"1", renamed.GetVppConfig().GetInterfaces()[0].GetName())
+	require.Equal(t, Prefix+"1", renamed.GetVppConfig().GetRoutes()[0].GetOutgoingInterface())
+	require.Equal(t, []string{Prefix + "1"}, renamed.GetVppConfig().GetAcls()[0].GetInterfaces().GetIngress())
+	require.Equal(t, Prefix+"1", renamed.GetLinuxConfig().GetInterfaces()[0].GetTap().GetVppTapIfName())
+	// Names that are not vpp interface names are left alone
+	require.Equal(t, "server-1", renamed.GetVppConfig().GetAcls()[0].GetName())
+	require.Equal(t, "server-1", renamed.GetLinuxConfig().GetInterfaces()[0].GetName())
+	require.Equal(t, "server-1", renamed.GetLinuxConfig().GetInterfaces()[0].GetHostIfName())
+	// The caller's request is not modified
+	require.Equal(t, "server-1", update.GetUpdate().GetVppConfig().GetInterfaces()[0].GetName())
+
+	reply := &vpp_interfaces.InterfaceNotification{State: &vpp_interfaces.InterfaceState{Name: Prefix + "1"}}
+	p.restore(reply)
+	require.Equal(t, "server-1", reply.GetState().GetName())
+}
+
+func TestAssignOnlySameLink(t *testing.T) {
+	p := newPool("")
+	p.assign(context.Background(), []*vpp_interfaces.Interface{tap("server-1", "nsm-1")})
+	require.Empty(t, p.state.Assigned, "a pool interface with another host_if_name was handed out")
+	require.Equal(t, []string{Prefix + "1"}, p.state.Free)
+
+	p.assign(context.Background(), []*vpp_interfaces.Interface{tap("server-2", "")})
+	require.Equal(t, map[string]string{"server-2": Prefix + "1"}, p.state.Assigned)
+	require.Empty(t, p.state.Free)
+}
+
+func TestReleaseForgetsAssignment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ifpool")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	p := newPool("")
+	p.filename = filepath.Join(dir, "pool.json")
+	p.assign(context.Background(), []*vpp_interfaces.Interface{tap("server-1", "")})
+
+	data, err := ioutil.ReadFile(p.filename)
+	require.NoError(t, err)
+	recorded := state{}
+	require.NoError(t, json.Unmarshal(data, &recorded))
+	require.Equal(t, map[string]string{"server-1": Prefix + "1"}, recorded.Assigned, "the assignment was not recorded")
+
+	// Only deleting the pool interface handed out releases its assignment
+	p.release([]*vpp_interfaces.Interface{tap("server-2", "")})
+	require.Len(t, p.state.Assigned, 1, "an unrelated delete released the assignment")
+	p.release([]*vpp_interfaces.Interface{tap(Prefix+"1", "")})
+	require.Empty(t, p.state.Assigned)
+}
@@ -3,24 +3,51 @@ package imports
 
 import (
 	_ "bufio"
+	_ "bytes"
+	_ "container/heap"
 	_ "context"
+	_ "crypto/ecdsa"
+	_ "crypto/elliptic"
+	_ "crypto/rand"
+	_ "crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/tls"
+	_ "crypto/x509"
+	_ "crypto/x509/pkix"
+	_ "encoding/binary"
+	_ "encoding/csv"
+	_ "encoding/json"
+	_ "encoding/pem"
+	_ "expvar"
 	_ "fmt"
 	_ "github.com/antonfisher/nested-logrus-formatter"
 	_ "github.com/edwarnicke/exechelper"
 	_ "github.com/edwarnicke/grpcfd"
+	_ "github.com/golang-jwt/jwt"
+	_ "github.com/golang/protobuf/jsonpb"
+	_ "github.com/golang/protobuf/proto"
+	_ "github.com/golang/protobuf/ptypes"
 	_ "github.com/golang/protobuf/ptypes/empty"
+	_ "github.com/google/uuid"
 	_ "github.com/kelseyhightower/envconfig"
 	_ "github.com/networkservicemesh/api/pkg/api/networkservice"
 	_ "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
 	_ "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	_ "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/memif"
+	_ "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	_ "github.com/networkservicemesh/api/pkg/api/registry"
 	_ "github.com/networkservicemesh/sdk-vppagent/pkg/networkservice/chains/xconnectns"
 	_ "github.com/networkservicemesh/sdk-vppagent/pkg/tools/vppagent"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/chains/client"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/chains/endpoint"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
+	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/clienturl"
+	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/connect"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/kernel"
+	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/recvfd"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/sendfd"
+	_ "github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
 	_ "github.com/networkservicemesh/sdk/pkg/networkservice/ipam/point2pointipam"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/debug"
@@ -29,9 +56,12 @@ import (
 	_ "github.com/networkservicemesh/sdk/pkg/tools/signalctx"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/spiffejwt"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/spire"
+	_ "github.com/networkservicemesh/sdk/pkg/tools/token"
 	_ "github.com/pkg/errors"
 	_ "github.com/sirupsen/logrus"
+	_ "github.com/sirupsen/logrus/hooks/syslog"
 	_ "github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	_ "github.com/spiffe/go-spiffe/v2/spiffeid"
 	_ "github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	_ "github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	_ "github.com/spiffe/go-spiffe/v2/workloadapi"
@@ -40,22 +70,59 @@ import (
 	_ "github.com/vishvananda/netlink"
 	_ "github.com/vishvananda/netns"
 	_ "go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	_ "go.ligato.io/vpp-agent/v3/proto/ligato/linux"
+	_ "go.ligato.io/vpp-agent/v3/proto/ligato/linux/interfaces"
 	_ "go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
 	_ "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/acl"
 	_ "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	_ "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l2"
 	_ "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
 	_ "golang.org/x/sys/unix"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
 	_ "google.golang.org/grpc"
+	_ "google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/connectivity"
 	_ "google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/credentials/local"
 	_ "google.golang.org/grpc/health/grpc_health_v1"
+	_ "google.golang.org/grpc/metadata"
+	_ "google.golang.org/grpc/peer"
+	_ "google.golang.org/grpc/reflection"
+	_ "google.golang.org/grpc/status"
+	_ "google.golang.org/grpc/test/bufconn"
+	_ "gopkg.in/yaml.v2"
+	_ "hash/crc32"
+	_ "hash/fnv"
 	_ "io"
+	_ "io/ioutil"
+	_ "log/syslog"
+	_ "math"
+	_ "math/big"
+	_ "math/rand"
 	_ "net"
+	_ "net/http"
+	_ "net/http/httptest"
+	_ "net/http/httputil"
 	_ "net/url"
 	_ "os"
+	_ "os/exec"
+	_ "os/signal"
+	_ "path"
 	_ "path/filepath"
+	_ "reflect"
+	_ "regexp"
+	_ "runtime"
+	_ "runtime/debug"
+	_ "sort"
 	_ "strconv"
 	_ "strings"
+	_ "sync"
+	_ "sync/atomic"
 	_ "syscall"
 	_ "testing"
+	_ "text/template"
+	_ "text/template/parse"
 	_ "time"
+	_ "unicode/utf8"
+	_ "unsafe"
 )
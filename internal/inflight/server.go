@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inflight - collapses retransmitted Requests into the one already in flight.
+//
+// Upstream retries may send a Request again while the forwarder is still processing the first one.  Rather than
+// running the chain (and its vpp-agent txns) twice for the same connection, the retransmission waits for the Request
+// in flight and gets the same result.  Upstream signs the path of every retry anew, so Requests are compared without
+// their path tokens and expiry.
+package inflight
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+type call struct {
+	key      [sha256.Size]byte
+	done     chan struct{}
+	conn     *networkservice.Connection
+	err      error
+	canceled bool
+}
+
+type inflightServer struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// NewServer - returns a server chain element collapsing concurrent identical Requests for a connection into one.
+// Requests with different parameters for a connection are run one after another.  It must run after authorization,
+// as a collapsed Request is answered with the result of the one it waited for.
+func NewServer() networkservice.NetworkServiceServer {
+	return &inflightServer{
+		calls: make(map[string]*call),
+	}
+}
+
+func (s *inflightServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	connID := request.GetConnection().GetId()
+	key, err := requestKey(request)
+	if connID == "" || err != nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	c := &call{
+		key:  key,
+		done: make(chan struct{}),
+	}
+	for {
+		s.mu.Lock()
+		running, ok := s.calls[connID]
+		if !ok {
+			s.calls[connID] = c
+			s.mu.Unlock()
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-running.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// A Request given up by its caller is no answer for the retransmission, which runs it again
+		if running.key == c.key && !running.canceled {
+			metrics.Int("duplicate_requests").Add(1)
+			log.Entry(ctx).Infof("collapsed duplicate Request for connection %s", connID)
+			if running.err != nil {
+				return nil, running.err
+			}
+			return proto.Clone(running.conn).(*networkservice.Connection), nil
+		}
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err == nil {
+		// The waiting retransmissions get copies, as the callers are free to modify what they are returned
+		c.conn = proto.Clone(conn).(*networkservice.Connection)
+	}
+	c.err = err
+	c.canceled = err != nil && ctx.Err() != nil
+	s.mu.Lock()
+	delete(s.calls, connID)
+	s.mu.Unlock()
+	close(c.done)
+	return conn, err
+}
+
+// requestKey - returns the hash of request without the tokens and expiry of its path
+func requestKey(request *networkservice.NetworkServiceRequest) ([sha256.Size]byte, error) {
+	request = proto.Clone(request).(*networkservice.NetworkServiceRequest)
+	for _, segment := range request.GetConnection().GetPath().GetPathSegments() {
+		segment.Token = ""
+		segment.Expires = nil
+	}
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(request); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(buf.Bytes()), nil
+}
+
+func (s *inflightServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inflight_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/inflight"
+)
+
+// blockingServer - counts the Requests reaching it and holds them until released or their ctx is done
+type blockingServer struct {
+	mu      sync.Mutex
+	calls   int
+	entered chan string
+	release chan struct{}
+}
+
+func newBlockingServer() *blockingServer {
+	return &blockingServer{
+		entered: make(chan string, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (b *blockingServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	b.mu.Lock()
+	b.calls++
+	b.mu.Unlock()
+	b.entered <- request.GetConnection().GetNetworkService()
+	select {
+	case <-b.release:
+		return request.GetConnection(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *blockingServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+func (b *blockingServer) Calls() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls
+}
+
+type result struct {
+	conn *networkservice.Connection
+	err  error
+}
+
+func request(ctx context.Context, server networkservice.NetworkServiceServer, networkService string) <-chan result {
+	return signedRequest(ctx, server, networkService, "")
+}
+
+// signedRequest - like request, with token and an expiry after now on the path of the Request
+func signedRequest(ctx context.Context, server networkservice.NetworkServiceServer, networkService, token string) <-chan result {
+	expires, _ := ptypes.TimestampProto(time.Now().Add(time.Minute))
+	rv := make(chan result, 1)
+	go func() {
+		conn, err := server.Request(ctx, &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{
+				Id:             "conn-1",
+				NetworkService: networkService,
+				Path: &networkservice.Path{
+					PathSegments: []*networkservice.PathSegment{{Name: "nsc", Id: "conn-1", Token: token, Expires: expires}},
+				},
+			},
+		})
+		rv <- result{conn: conn, err: err}
+	}()
+	return rv
+}
+
+// settle - gives the Requests started time to reach the inflight server
+func settle() {
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestCollapse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	blocking := newBlockingServer()
+	server := chain.NewNetworkServiceServer(inflight.NewServer(), blocking)
+
+	first := request(ctx, server, "ns-1")
+	require.Equal(t, "ns-1", <-blocking.entered)
+	second := request(ctx, server, "ns-1")
+	settle()
+	close(blocking.release)
+
+	firstResult, secondResult := <-first, <-second
+	require.NoError(t, firstResult.err)
+	require.NoError(t, secondResult.err)
+	require.Equal(t, 1, blocking.Calls())
+	require.Equal(t, firstResult.conn.String(), secondResult.conn.String())
+	require.True(t, firstResult.conn != secondResult.conn, "the duplicate must get a copy")
+}
+
+func TestCollapseRetryWithNewToken(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	blocking := newBlockingServer()
+	server := chain.NewNetworkServiceServer(inflight.NewServer(), blocking)
+
+	first := signedRequest(ctx, server, "ns-1", "token-1")
+	require.Equal(t, "ns-1", <-blocking.entered)
+	// The retry is signed anew, only its token and expiry differ
+	time.Sleep(time.Millisecond)
+	second := signedRequest(ctx, server, "ns-1", "token-2")
+	settle()
+	close(blocking.release)
+
+	firstResult, secondResult := <-first, <-second
+	require.NoError(t, firstResult.err)
+	require.NoError(t, secondResult.err)
+	require.Equal(t, 1, blocking.Calls())
+}
+
+func TestCanceledFirstCaller(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	blocking := newBlockingServer()
+	server := chain.NewNetworkServiceServer(inflight.NewServer(), blocking)
+
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+	first := request(firstCtx, server, "ns-1")
+	<-blocking.entered
+	second := request(ctx, server, "ns-1")
+	settle()
+	cancelFirst()
+	require.Error(t, (<-first).err)
+
+	// The retransmission runs the Request itself rather than inheriting the cancellation
+	<-blocking.entered
+	close(blocking.release)
+	secondResult := <-second
+	require.NoError(t, secondResult.err)
+	require.Equal(t, 2, blocking.Calls())
+}
+
+func TestDifferentParametersSerialized(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	blocking := newBlockingServer()
+	server := chain.NewNetworkServiceServer(inflight.NewServer(), blocking)
+
+	first := request(ctx, server, "ns-1")
+	require.Equal(t, "ns-1", <-blocking.entered)
+	second := request(ctx, server, "ns-2")
+	settle()
+	require.Equal(t, 1, blocking.Calls(), "a Request with other parameters must wait for the one in flight")
+
+	blocking.release <- struct{}{}
+	require.NoError(t, (<-first).err)
+	require.Equal(t, "ns-2", <-blocking.entered)
+	blocking.release <- struct{}{}
+	secondResult := <-second
+	require.NoError(t, secondResult.err)
+	require.Equal(t, "ns-2", secondResult.conn.GetNetworkService())
+	require.Equal(t, 2, blocking.Calls())
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipfix - exports the flows of the vpp interfaces of the forwarder's connections to an IPFIX collector
+// through vpp's flowprobe plugin
+package ipfix
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const defaultPort = "4739"
+
+// CLI - runs a vpp cli command, returning its output
+type CLI func(ctx context.Context, command ...string) (string, error)
+
+// Exporter - enables flow export on vpp interfaces, the zero value exports nothing
+type Exporter struct {
+	cli     CLI
+	mu      sync.Mutex
+	enabled map[string]string
+}
+
+// NewExporter - points the ipfix exporter of vpp at collector (host[:port]) with the source address src and
+// configures flowprobe to record l2, l3 and l4 fields, returning an Exporter to enable export on interfaces with
+func NewExporter(ctx context.Context, cli CLI, collector string, src net.IP) (*Exporter, error) {
+	host, port, err := net.SplitHostPort(collector)
+	if err != nil {
+		host, port = collector, defaultPort
+	}
+	addr, err := net.ResolveIPAddr("ip4", host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve ipfix collector %s", collector)
+	}
+	if _, err = strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, errors.Errorf("invalid port of ipfix collector %s", collector)
+	}
+	if src.To4() == nil {
+		return nil, errors.Errorf("ipfix export requires an ipv4 source address, got %s", src)
+	}
+	commands := [][]string{
+		{"set", "ipfix", "exporter", "collector", addr.IP.String(), "port", port, "src", src.String()},
+		{"flowprobe", "params", "record", "l2", "l3", "l4"},
+	}
+	for _, command := range commands {
+		if _, err = cli(ctx, command...); err != nil {
+			return nil, err
+		}
+	}
+	log.Entry(ctx).Infof("exporting connection flows to ipfix collector %s:%s", addr.IP, port)
+	return &Exporter{
+		cli:     cli,
+		enabled: make(map[string]string),
+	}, nil
+}
+
+// Update - enables flow export on the vpp interface of state once it is up, if it is an interface of connection
+// connID, and forgets about it once it is deleted, taking its flowprobe feature along.  Collectors tell the
+// connections apart by the ingress and egress interface index of the flow records, which conntable lists for every
+// connection.
+func (e *Exporter) Update(ctx context.Context, connID string, state *vpp_interfaces.InterfaceState) {
+	if e == nil || e.cli == nil || state.GetInternalName() == "" {
+		return
+	}
+	switch state.GetOperStatus() {
+	case vpp_interfaces.InterfaceState_DELETED:
+		e.mu.Lock()
+		delete(e.enabled, state.GetInternalName())
+		e.mu.Unlock()
+	case vpp_interfaces.InterfaceState_UP:
+		if connID != "" {
+			e.enable(ctx, connID, state.GetInternalName(), state.GetIfIndex())
+		}
+	}
+}
+
+// enable - enables flow export on the vpp interface internalName of connection connID, unless already enabled
+func (e *Exporter) enable(ctx context.Context, connID, internalName string, ifIndex uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.enabled[internalName]; ok {
+		return
+	}
+	if _, err := e.cli(ctx, "flowprobe", "feature", "add-del", internalName, "l2"); err != nil {
+		log.Entry(ctx).Warnf("failed to enable flow export on %s of connection %s: %+v", internalName, connID, err)
+		return
+	}
+	e.enabled[internalName] = connID
+	log.Entry(ctx).Infof("exporting flows of connection %s with interface index %d (%s)", connID, ifIndex, internalName)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipfix_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipfix"
+)
+
+func TestUpdate(t *testing.T) {
+	var commands []string
+	cli := func(_ context.Context, command ...string) (string, error) {
+		commands = append(commands, strings.Join(command, " "))
+		return "", nil
+	}
+	ctx := context.Background()
+	exporter, err := ipfix.NewExporter(ctx, cli, "127.0.0.1", net.ParseIP("10.0.0.1"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"set ipfix exporter collector 127.0.0.1 port 4739 src 10.0.0.1",
+		"flowprobe params record l2 l3 l4",
+	}, commands)
+	commands = nil
+
+	state := func(status vpp_interfaces.InterfaceState_Status) *vpp_interfaces.InterfaceState {
+		return &vpp_interfaces.InterfaceState{InternalName: "tap0", IfIndex: 3, OperStatus: status}
+	}
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_DOWN))
+	require.Empty(t, commands, "interfaces not up are left alone")
+	exporter.Update(ctx, "", state(vpp_interfaces.InterfaceState_UP))
+	require.Empty(t, commands, "interfaces of no connection are left alone")
+
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_UP))
+	require.Equal(t, []string{"flowprobe feature add-del tap0 l2"}, commands)
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_DOWN))
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_UP))
+	require.Len(t, commands, 1, "export is enabled once")
+
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_DELETED))
+	exporter.Update(ctx, "conn-1", state(vpp_interfaces.InterfaceState_UP))
+	require.Len(t, commands, 2, "a recreated interface gets export enabled again")
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipneighbor - chain element that programs static ip neighbor (ARP/ND) entries for the connection endpoints
+// onto the vpp interfaces of the cross connect, removing first packet ARP latency and supporting clients that
+// do not answer ARP
+package ipneighbor
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type ipNeighborServer struct {
+	client configurator.ConfiguratorServiceClient
+}
+
+// NewServer - returns a server chain element programming static neighbors for src and dst of the connection
+// using vppagentCC
+func NewServer(vppagentCC grpc.ClientConnInterface) networkservice.NetworkServiceServer {
+	return &ipNeighborServer{
+		client: configurator.NewConfiguratorServiceClient(vppagentCC),
+	}
+}
+
+func (i *ipNeighborServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	conf := neighborsConfig(conn)
+	if conf == nil {
+		return conn, nil
+	}
+	if _, err := i.client.Update(ctx, &configurator.UpdateRequest{Update: conf}); err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(err, "failed to program static neighbors for connection %s", conn.GetId())
+	}
+	return conn, nil
+}
+
+func (i *ipNeighborServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if conf := neighborsConfig(conn); conf != nil {
+		if _, err := i.client.Delete(ctx, &configurator.DeleteRequest{Delete: conf}); err != nil {
+			log.Entry(ctx).Warnf("failed to remove static neighbors for connection %s: %+v", conn.GetId(), err)
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// neighborsConfig - returns the vppagent config containing the static neighbors for conn, or nil if there are none
+func neighborsConfig(conn *networkservice.Connection) *configurator.Config {
+	ipContext := conn.GetContext().GetIpContext()
+	ethernetContext := conn.GetContext().GetEthernetContext()
+	var arps []*vpp.ARPEntry
+	if arp := arpEntry(vppnames.ServerInterface(conn), ipContext.GetSrcIpAddr(), ethernetContext.GetSrcMac()); arp != nil {
+		arps = append(arps, arp)
+	}
+	if arp := arpEntry(vppnames.ClientInterface(conn), ipContext.GetDstIpAddr(), ethernetContext.GetDstMac()); arp != nil {
+		arps = append(arps, arp)
+	}
+	if len(arps) == 0 {
+		return nil
+	}
+	return &configurator.Config{
+		VppConfig: &vpp.ConfigData{
+			Arps: arps,
+		},
+	}
+}
+
+func arpEntry(ifaceName, ipAddr, macAddr string) *vpp.ARPEntry {
+	if ifaceName == "" || ipAddr == "" || macAddr == "" {
+		return nil
+	}
+	ip, _, err := net.ParseCIDR(ipAddr)
+	if err != nil {
+		return nil
+	}
+	if _, err := net.ParseMAC(macAddr); err != nil {
+		return nil
+	}
+	return &vpp.ARPEntry{
+		Interface:   ifaceName,
+		IpAddress:   ip.String(),
+		PhysAddress: macAddr,
+	}
+}
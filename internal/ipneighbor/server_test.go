@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipneighbor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ipneighbor"
+)
+
+// vppAgent - keeps the arp entries updated and deleted through it, failing Updates with updateErr
+type vppAgent struct {
+	updateErr error
+	updated   []*vpp.ARPEntry
+	deleted   []*vpp.ARPEntry
+}
+
+func (v *vppAgent) Invoke(_ context.Context, _ string, args, _ interface{}, _ ...grpc.CallOption) error {
+	switch r := args.(type) {
+	case *configurator.UpdateRequest:
+		if v.updateErr != nil {
+			return v.updateErr
+		}
+		v.updated = append(v.updated, r.GetUpdate().GetVppConfig().GetArps()...)
+	case *configurator.DeleteRequest:
+		v.deleted = append(v.deleted, r.GetDelete().GetVppConfig().GetArps()...)
+	}
+	return nil
+}
+
+func (v *vppAgent) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+// closeCounter - last chain element, returning the connection of the Request and counting Closes
+type closeCounter struct {
+	closed int
+}
+
+func (c *closeCounter) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return request.GetConnection(), nil
+}
+
+func (c *closeCounter) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	c.closed++
+	return &empty.Empty{}, nil
+}
+
+func request(dstMac string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "conn-1",
+			Path: &networkservice.Path{
+				PathSegments: []*networkservice.PathSegment{{Id: "conn-1"}, {Id: "next-1"}},
+			},
+			Context: &networkservice.ConnectionContext{
+				IpContext: &networkservice.IPContext{SrcIpAddr: "10.0.0.1/32", DstIpAddr: "10.0.0.2/32"},
+				EthernetContext: &networkservice.EthernetContext{
+					SrcMac: "02:fe:00:00:00:01",
+					DstMac: dstMac,
+				},
+			},
+		},
+	}
+}
+
+func requireARPs(t *testing.T, want, got []*vpp.ARPEntry) {
+	require.Len(t, got, len(want))
+	for i := range want {
+		require.True(t, proto.Equal(want[i], got[i]), "%v != %v", want[i], got[i])
+	}
+}
+
+func TestNeighborsOfBothEnds(t *testing.T) {
+	agent := &vppAgent{}
+	server := chain.NewNetworkServiceServer(ipneighbor.NewServer(agent))
+
+	conn, err := server.Request(context.Background(), request("02:fe:00:00:00:02"))
+	require.NoError(t, err)
+	want := []*vpp.ARPEntry{
+		{Interface: "server-conn-1", IpAddress: "10.0.0.1", PhysAddress: "02:fe:00:00:00:01"},
+		{Interface: "client-next-1", IpAddress: "10.0.0.2", PhysAddress: "02:fe:00:00:00:02"},
+	}
+	requireARPs(t, want, agent.updated)
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	requireARPs(t, want, agent.deleted)
+}
+
+func TestInvalidMACSkipped(t *testing.T) {
+	agent := &vppAgent{}
+	server := chain.NewNetworkServiceServer(ipneighbor.NewServer(agent))
+
+	_, err := server.Request(context.Background(), request("not-a-mac"))
+	require.NoError(t, err)
+	require.Len(t, agent.updated, 1)
+	require.Equal(t, "server-conn-1", agent.updated[0].GetInterface())
+}
+
+func TestNoNeighbors(t *testing.T) {
+	agent := &vppAgent{}
+	server := chain.NewNetworkServiceServer(ipneighbor.NewServer(agent))
+
+	conn, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-1"},
+	})
+	require.NoError(t, err)
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Empty(t, agent.updated)
+	require.Empty(t, agent.deleted)
+}
+
+func TestUpdateFailureClosesConnection(t *testing.T) {
+	agent := &vppAgent{updateErr: errors.New("vpp-agent is down")}
+	tail := &closeCounter{}
+	server := chain.NewNetworkServiceServer(ipneighbor.NewServer(agent), tail)
+
+	_, err := server.Request(context.Background(), request("02:fe:00:00:00:02"))
+	require.Error(t, err)
+	require.Equal(t, 1, tail.closed, "the connection set up by the rest of the chain is closed")
+}
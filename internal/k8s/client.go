@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8s - minimal client of the kubernetes api server for a forwarder running in a pod, authenticated by the
+// pod's service account
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const requestTimeout = 10 * time.Second
+
+// serviceAccountDir - directory of the ca.crt and token of the pod's service account
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Client - client of the kubernetes api server
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// InCluster - returns a client of the api server of the cluster the forwarder runs in
+func InCluster() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a kubernetes cluster")
+	}
+	caPEM, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no certificates in the service account ca.crt")
+	}
+	return &Client{
+		baseURL: "https://" + net.JoinHostPort(host, port),
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}},
+		},
+	}, nil
+}
+
+// Do - sends a request with in, if not nil, as json body to the api server path and decodes the json response into
+// out, if not nil
+func (c *Client) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	// The token is read for every request as projected service account tokens are rotated
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var body io.Reader
+	if in != nil {
+		data, marshalErr := json.Marshal(in)
+		if marshalErr != nil {
+			return errors.WithStack(marshalErr)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decoding %s %s", method, path)
+}
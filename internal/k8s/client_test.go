@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// apiServer - serves handler over tls as the api server of the cluster of a pod with a service account whose token
+// is token, returning the func stopping it
+func apiServer(t *testing.T, token string, handler http.HandlerFunc) func() {
+	server := httptest.NewTLSServer(handler)
+	dir, err := ioutil.TempDir("", "k8s")
+	require.NoError(t, err)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.crt"), caPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte(token+"\n"), 0600))
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, os.Setenv("KUBERNETES_SERVICE_HOST", host))
+	require.NoError(t, os.Setenv("KUBERNETES_SERVICE_PORT", port))
+	saDir := serviceAccountDir
+	serviceAccountDir = dir
+	return func() {
+		serviceAccountDir = saDir
+		_ = os.Unsetenv("KUBERNETES_SERVICE_HOST")
+		_ = os.Unsetenv("KUBERNETES_SERVICE_PORT")
+		_ = os.RemoveAll(dir)
+		server.Close()
+	}
+}
+
+func TestDo(t *testing.T) {
+	defer apiServer(t, "sa-token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/nodes/node-1":
+			_, _ = w.Write([]byte(`{"metadata":{"labels":{"topology.kubernetes.io/zone":"zone-a"}}}`))
+		case "POST /api/v1/namespaces/nsm/events":
+			var body map[string]string
+			if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&body) != nil || body["reason"] != "Started" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})()
+
+	client, err := InCluster()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var node struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	require.NoError(t, client.Do(ctx, http.MethodGet, "/api/v1/nodes/node-1", nil, &node))
+	require.Equal(t, map[string]string{"topology.kubernetes.io/zone": "zone-a"}, node.Metadata.Labels)
+
+	require.NoError(t, client.Do(ctx, http.MethodPost, "/api/v1/namespaces/nsm/events", map[string]string{"reason": "Started"}, nil))
+
+	err = client.Do(ctx, http.MethodGet, "/api/v1/nodes/node-2", nil, &node)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404")
+}
+
+func TestDoRereadsToken(t *testing.T) {
+	var authorization string
+	defer apiServer(t, "token-1", func(_ http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	})()
+	client, err := InCluster()
+	require.NoError(t, err)
+
+	// Projected service account tokens are rotated under a running forwarder
+	require.NoError(t, ioutil.WriteFile(filepath.Join(serviceAccountDir, "token"), []byte("token-2"), 0600))
+	require.NoError(t, client.Do(context.Background(), http.MethodGet, "/version", nil, nil))
+	require.Equal(t, "Bearer token-2", authorization)
+}
+
+func TestInClusterOutsideCluster(t *testing.T) {
+	if host, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		defer func() { _ = os.Setenv("KUBERNETES_SERVICE_HOST", host) }()
+	}
+	_ = os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	_, err := InCluster()
+	require.Error(t, err)
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package kernelfwd - a degraded forwarder for nodes where vpp cannot run, serving local connections between kernel
+// clients and kernel endpoints by connecting them with veth pairs only
+package kernelfwd
+
+import (
+	"context"
+	"hash/crc32"
+	"net/url"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/client"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/endpoint"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/clienturl"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/connect"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
+	kernelmechanism "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/recvfd"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+)
+
+// Name - name of the kernel dataplane, the value of the forwarder's FallbackDataplane selecting it
+const Name = "kernel"
+
+// Dataplane - the kernel dataplane, whose endpoint is that of NewServer
+type Dataplane struct{}
+
+// Name - returns Name
+func (Dataplane) Name() string {
+	return Name
+}
+
+// Outer - returns no elements
+func (Dataplane) Outer() []networkservice.NetworkServiceServer {
+	return nil
+}
+
+// Inner - returns no elements
+func (Dataplane) Inner() []networkservice.NetworkServiceServer {
+	return nil
+}
+
+// NewEndpoint - returns NewServer(ctx, name, authzServer, tokenGenerator, clientURL, clientDialOptions...)
+func (Dataplane) NewEndpoint(ctx context.Context, name string, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, clientURL *url.URL, clientDialOptions ...grpc.DialOption) dataplane.Endpoint {
+	return NewServer(ctx, name, authzServer, tokenGenerator, clientURL, clientDialOptions...)
+}
+
+// NewServer - returns an endpoint named name connecting the kernel clients it is requested by to the kernel endpoints
+// it reaches through clientURL, authorizing and extending its Requests with authzServer like xconnectns does
+func NewServer(ctx context.Context, name string, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, clientURL *url.URL, clientDialOptions ...grpc.DialOption) endpoint.Endpoint {
+	return endpoint.NewServer(ctx, name, authzServer, tokenGenerator,
+		recvfd.NewServer(),
+		clienturl.NewServer(clientURL),
+		mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
+			kernel.MECHANISM: &vethServer{pairs: make(map[string]*pair)},
+		}),
+		connect.NewServer(ctx,
+			client.NewClientFactory(name, nil, tokenGenerator,
+				&endpointClient{},
+				kernelmechanism.NewClient(),
+				recvfd.NewClient(),
+			),
+			clientDialOptions...,
+		),
+	)
+}
+
+// pair - a veth pair between a client and an endpoint
+type pair struct {
+	client, endpoint *side
+}
+
+type pairKey struct{}
+
+// endpointClient - records the endpoint's side of the pair of the Request it passes, as returned by the endpoint
+type endpointClient struct{}
+
+func (e *endpointClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	conn, err := next.Client(ctx).Request(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := ctx.Value(pairKey{}).(*pair); ok && conn.GetMechanism().GetType() == kernel.MECHANISM {
+		p.endpoint = &side{
+			netnsURL: conn.GetMechanism().GetParameters()[kernel.NetNSURL],
+			ifName:   ifName(conn),
+			addr:     conn.GetContext().GetIpContext().GetDstIpAddr(),
+		}
+	}
+	return conn, nil
+}
+
+func (e *endpointClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
+
+// vethServer - connects the client of the Requests it passes to their endpoint with a veth pair
+type vethServer struct {
+	mu    sync.Mutex
+	pairs map[string]*pair
+}
+
+func (v *vethServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	p := &pair{}
+	ctx = context.WithValue(ctx, pairKey{}, p)
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.pairs[conn.GetId()]; ok {
+		return conn, nil
+	}
+	if p.endpoint == nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Errorf("the endpoint of connection %s is not a kernel endpoint, the kernel dataplane only connects local kernel endpoints", conn.GetId())
+	}
+	p.client = &side{
+		netnsURL: conn.GetMechanism().GetParameters()[kernel.NetNSURL],
+		ifName:   ifName(conn),
+		addr:     conn.GetContext().GetIpContext().GetSrcIpAddr(),
+	}
+	if err := plumb(shortName("kfw", conn.GetId()), p.client, p.endpoint); err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(err, "failed to connect connection %s with a veth pair", conn.GetId())
+	}
+	v.pairs[conn.GetId()] = p
+	log.Entry(ctx).Infof("connected %s to %s for connection %s", p.client.ifName, p.endpoint.ifName, conn.GetId())
+	return conn, nil
+}
+
+func (v *vethServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	v.mu.Lock()
+	p, ok := v.pairs[conn.GetId()]
+	delete(v.pairs, conn.GetId())
+	v.mu.Unlock()
+	if ok {
+		if err := unplumb(p.client); err != nil {
+			log.Entry(ctx).Warnf("failed to remove the veth pair of connection %s: %+v", conn.GetId(), err)
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// side - one end of a veth pair
+type side struct {
+	netnsURL string
+	ifName   string
+	addr     string
+}
+
+// ifName - returns the kernel interface name asked for by the mechanism of conn, or one derived from its id
+func ifName(conn *networkservice.Connection) string {
+	if name := conn.GetMechanism().GetParameters()[kernel.InterfaceNameKey]; name != "" {
+		return name
+	}
+	return shortName("nsm", conn.GetId())
+}
+
+// shortName - returns an interface name starting with prefix unique to connID, short enough for the kernel
+func shortName(prefix, connID string) string {
+	const hex = "0123456789abcdef"
+	sum := crc32.ChecksumIEEE([]byte(connID))
+	name := []byte(prefix)
+	for shift := 28; shift >= 0; shift -= 4 {
+		name = append(name, hex[sum>>uint(shift)&0xf])
+	}
+	return string(name)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package kernelfwd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+)
+
+// endpointServer - returns the connections it is requested, as reached through endpointClient from an endpoint side
+// of endpoint, or from no kernel endpoint when it is nil, and counts the Closes it gets
+type endpointServer struct {
+	endpoint *side
+	closes   int
+}
+
+func (e *endpointServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if p, ok := ctx.Value(pairKey{}).(*pair); ok && e.endpoint != nil {
+		p.endpoint = e.endpoint
+	}
+	return request.GetConnection(), nil
+}
+
+func (e *endpointServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	e.closes++
+	return &empty.Empty{}, nil
+}
+
+// kernelEndpoint - returns the connection it is requested with the kernel mechanism of an endpoint in netns
+type kernelEndpoint struct {
+	netnsURL string
+}
+
+func (k *kernelEndpoint) Request(_ context.Context, request *networkservice.NetworkServiceRequest, _ ...grpc.CallOption) (*networkservice.Connection, error) {
+	conn := request.GetConnection()
+	conn.Mechanism = &networkservice.Mechanism{
+		Type:       kernel.MECHANISM,
+		Parameters: map[string]string{kernel.NetNSURL: k.netnsURL, kernel.InterfaceNameKey: "nse0"},
+	}
+	conn.Context = &networkservice.ConnectionContext{IpContext: &networkservice.IPContext{DstIpAddr: "10.0.0.2/32"}}
+	return conn, nil
+}
+
+func (k *kernelEndpoint) Close(context.Context, *networkservice.Connection, ...grpc.CallOption) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+func request(id string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{
+		Id: id,
+		Mechanism: &networkservice.Mechanism{
+			Type:       kernel.MECHANISM,
+			Parameters: map[string]string{kernel.NetNSURL: "inode://4/4026531992"},
+		},
+	}}
+}
+
+func TestShortName(t *testing.T) {
+	name := shortName("kfw", "conn-1")
+	require.Len(t, name, len("kfw")+8)
+	require.LessOrEqual(t, len(shortName("nsm", "a connection id longer than any kernel interface name")), 15)
+	require.Equal(t, name, shortName("kfw", "conn-1"))
+	require.NotEqual(t, name, shortName("kfw", "conn-2"))
+}
+
+func TestIfName(t *testing.T) {
+	conn := request("conn-1").GetConnection()
+	require.Equal(t, shortName("nsm", "conn-1"), ifName(conn))
+	conn.GetMechanism().GetParameters()[kernel.InterfaceNameKey] = "nsm0"
+	require.Equal(t, "nsm0", ifName(conn))
+}
+
+func TestEndpointClientRecordsTheEndpoint(t *testing.T) {
+	p := &pair{}
+	ctx := context.WithValue(context.Background(), pairKey{}, p)
+	c := chain.NewNetworkServiceClient(&endpointClient{}, &kernelEndpoint{netnsURL: "file:///proc/self/ns/net"})
+	_, err := c.Request(ctx, request("conn-1"))
+	require.NoError(t, err)
+	require.Equal(t, &side{netnsURL: "file:///proc/self/ns/net", ifName: "nse0", addr: "10.0.0.2/32"}, p.endpoint)
+}
+
+func TestRequestNotAKernelEndpoint(t *testing.T) {
+	next := &endpointServer{}
+	v := &vethServer{pairs: make(map[string]*pair)}
+	_, err := chain.NewNetworkServiceServer(v, next).Request(context.Background(), request("conn-1"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a kernel endpoint")
+	require.Equal(t, 1, next.closes, "the connection to the endpoint was not closed")
+	require.Empty(t, v.pairs)
+}
+
+func TestRequestPlumbFailure(t *testing.T) {
+	next := &endpointServer{endpoint: &side{netnsURL: "inode://4/4026531992", ifName: "nse0"}}
+	v := &vethServer{pairs: make(map[string]*pair)}
+	_, err := chain.NewNetworkServiceServer(v, next).Request(context.Background(), request("conn-1"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to connect connection conn-1")
+	require.Equal(t, 1, next.closes, "the connection to the endpoint was not closed")
+	require.Empty(t, v.pairs)
+}
+
+func TestRefreshAndClose(t *testing.T) {
+	next := &endpointServer{}
+	p := &pair{client: &side{netnsURL: "file:///proc/self/ns/net", ifName: "kfw-test-gone"}}
+	v := &vethServer{pairs: map[string]*pair{"conn-1": p}}
+	server := chain.NewNetworkServiceServer(v, next)
+
+	// A refresh of a connected connection is passed on without plumbing it again
+	_, err := server.Request(context.Background(), request("conn-1"))
+	require.NoError(t, err)
+	require.Equal(t, 0, next.closes)
+	require.True(t, v.pairs["conn-1"] == p)
+
+	_, err = server.Close(context.Background(), request("conn-1").GetConnection())
+	require.NoError(t, err)
+	require.Equal(t, 1, next.closes)
+	require.Empty(t, v.pairs)
+}
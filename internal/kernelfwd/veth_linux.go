@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package kernelfwd
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// plumb - creates a veth pair named name in the forwarder's netns, then moves each end to the netns of its side,
+// renamed, addressed and up
+func plumb(name string, client, endpoint *side) error {
+	peerName := name + "p"
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: name}, PeerName: peerName}
+	if err := netlink.LinkAdd(veth); err != nil {
+		return errors.Wrapf(err, "failed to create veth pair %s", name)
+	}
+	for linkName, s := range map[string]*side{name: client, peerName: endpoint} {
+		if err := move(linkName, s); err != nil {
+			// Deleting either end deletes the pair, wherever the other end is
+			if link, linkErr := netlink.LinkByName(linkName); linkErr == nil {
+				_ = netlink.LinkDel(link)
+			}
+			_ = unplumb(client)
+			return err
+		}
+	}
+	return nil
+}
+
+// move - moves the link linkName to the netns of s, renamed, addressed and up
+func move(linkName string, s *side) error {
+	nsHandle, err := open(s.netnsURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = nsHandle.Close() }()
+	link, err := netlink.LinkByName(linkName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = netlink.LinkSetNsFd(link, int(nsHandle)); err != nil {
+		return errors.Wrapf(err, "failed to move %s to %s", linkName, s.netnsURL)
+	}
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Delete()
+	if link, err = handle.LinkByName(linkName); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = handle.LinkSetName(link, s.ifName); err != nil {
+		return errors.Wrapf(err, "failed to rename %s to %s", linkName, s.ifName)
+	}
+	if s.addr != "" {
+		addr, addrErr := netlink.ParseAddr(s.addr)
+		if addrErr != nil {
+			return errors.WithStack(addrErr)
+		}
+		if err = handle.AddrAdd(link, addr); err != nil {
+			return errors.Wrapf(err, "failed to add %s to %s", s.addr, s.ifName)
+		}
+	}
+	return errors.WithStack(handle.LinkSetUp(link))
+}
+
+// unplumb - deletes the veth pair of s, if it is still there
+func unplumb(s *side) error {
+	nsHandle, err := open(s.netnsURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = nsHandle.Close() }()
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Delete()
+	link, err := handle.LinkByName(s.ifName)
+	if err != nil {
+		// Gone with the client's netns already
+		return nil
+	}
+	return errors.WithStack(handle.LinkDel(link))
+}
+
+func open(netnsURL string) (netns.NsHandle, error) {
+	u, err := url.Parse(netnsURL)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if u.Scheme != "file" {
+		return 0, errors.Errorf("unsupported netns url: %q", netnsURL)
+	}
+	nsHandle, err := netns.GetFromPath(u.Path)
+	return nsHandle, errors.WithStack(err)
+}
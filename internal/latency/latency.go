@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package latency - measures the round trip time to the remote end of every vxlan tunnel the forwarder programs,
+// giving operators a network quality signal per tunnel, and reports the peers whose latency exceeds an SLO
+package latency
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+// BreachHandler - called with the peer whose round trip time rtt exceeds the SLO, and the vpp-agent names of the
+// tunnels to it
+type BreachHandler func(ctx context.Context, peer string, rtt time.Duration, tunnels []string)
+
+// Monitor - tracks the vxlan tunnels passing through its interceptor and, once started, measures the round trip time
+// to the remote end of each.  The zero value is ready to use.
+type Monitor struct {
+	mu       sync.Mutex
+	peers    probe.Peers
+	breached map[string]bool
+}
+
+// UnaryClientInterceptor - returns an interceptor recording the vxlan tunnels created and deleted by the vpp-agent
+// calls passing through
+func (m *Monitor) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			return err
+		}
+		put, deleted := probe.Tunnels(req)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, iface := range put {
+			m.peers.Put(iface)
+		}
+		for _, iface := range deleted {
+			if addr := iface.GetVxlan().GetDstAddress(); m.peers.Delete(iface) {
+				delete(m.breached, addr)
+				metrics.Int("tunnel_rtt_us." + addr).Set(0)
+			}
+		}
+		return nil
+	}
+}
+
+// Start - measures the round trip time from localIP to the peers of the tunnels every interval until ctx is done,
+// exporting it as tunnel_rtt_us.<peer>.  With a non zero slo, onBreach is called whenever the round trip time to a
+// peer goes over slo, and again only once it has come back under it.
+func (m *Monitor) Start(ctx context.Context, localIP net.IP, interval, slo time.Duration, onBreach BreachHandler) {
+	timeout := interval
+	if timeout > time.Second {
+		timeout = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, addr := range m.addrs() {
+				rtt, err := measure(localIP, net.ParseIP(addr), timeout)
+				if err != nil {
+					metrics.Int("tunnel_rtt_losses").Add(1)
+					log.Entry(ctx).Debugf("failed to measure the round trip time to %s: %+v", addr, err)
+					continue
+				}
+				metrics.Int("tunnel_rtt_us." + addr).Set(int64(rtt / time.Microsecond))
+				if slo > 0 {
+					m.check(ctx, addr, rtt, slo, onBreach)
+				}
+			}
+		}
+	}()
+}
+
+func (m *Monitor) addrs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.peers.Addrs()
+}
+
+func (m *Monitor) check(ctx context.Context, addr string, rtt, slo time.Duration, onBreach BreachHandler) {
+	breached := rtt > slo
+	m.mu.Lock()
+	if !m.peers.Has(addr) || m.breached[addr] == breached {
+		m.mu.Unlock()
+		return
+	}
+	if m.breached == nil {
+		m.breached = make(map[string]bool)
+	}
+	m.breached[addr] = breached
+	tunnels := m.peers.Names(addr)
+	m.mu.Unlock()
+
+	if !breached {
+		log.Entry(ctx).Infof("round trip time to %s is back within its slo of %s: %s", addr, slo, rtt)
+		return
+	}
+	metrics.Int("tunnel_rtt_slo_breaches").Add(1)
+	onBreach(ctx, addr, rtt, tunnels)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+)
+
+func tunnels(names ...string) *configurator.Config {
+	var ifaces []*vpp_interfaces.Interface
+	for _, name := range names {
+		ifaces = append(ifaces, &vpp_interfaces.Interface{
+			Name: name,
+			Type: vpp_interfaces.Interface_VXLAN_TUNNEL,
+			Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{DstAddress: "10.0.0.2"}},
+		})
+	}
+	return &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: ifaces}}
+}
+
+func TestSLOBreaches(t *testing.T) {
+	m := &Monitor{}
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return nil
+	}
+	interceptor := m.UnaryClientInterceptor()
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update",
+		&configurator.UpdateRequest{Update: tunnels("vxlan-1", "vxlan-2")}, nil, nil, invoker))
+	require.Equal(t, []string{"10.0.0.2"}, m.addrs())
+
+	var breaches []time.Duration
+	var breachedTunnels []string
+	onBreach := func(_ context.Context, peer string, rtt time.Duration, tunnels []string) {
+		require.Equal(t, "10.0.0.2", peer)
+		breaches = append(breaches, rtt)
+		breachedTunnels = tunnels
+	}
+	slo := 10 * time.Millisecond
+	for _, rtt := range []time.Duration{
+		5 * time.Millisecond,
+		20 * time.Millisecond,
+		30 * time.Millisecond,
+		5 * time.Millisecond,
+		40 * time.Millisecond,
+	} {
+		m.check(context.Background(), "10.0.0.2", rtt, slo, onBreach)
+	}
+	require.Equal(t, []time.Duration{20 * time.Millisecond, 40 * time.Millisecond}, breaches, "once per breach")
+	require.ElementsMatch(t, []string{"vxlan-1", "vxlan-2"}, breachedTunnels)
+
+	// Peers gone are no longer checked
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Delete",
+		&configurator.DeleteRequest{Delete: tunnels("vxlan-1", "vxlan-2")}, nil, nil, invoker))
+	require.Empty(t, m.addrs())
+	m.check(context.Background(), "10.0.0.2", time.Second, slo, onBreach)
+	require.Len(t, breaches, 2)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package latency
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+// measure - returns the round trip time of an icmp echo from src to dst, failing if no reply arrives within timeout
+func measure(src, dst net.IP, timeout time.Duration) (time.Duration, error) {
+	if dst.To4() == nil || src.To4() == nil {
+		return 0, errors.Errorf("round trip times are measured between ipv4 addresses, got %s to %s", src, dst)
+	}
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open an icmp socket")
+	}
+	defer func() { _ = unix.Close(fd) }()
+	local := &unix.SockaddrInet4{}
+	copy(local.Addr[:], src.To4())
+	if err = unix.Bind(fd, local); err != nil {
+		return 0, errors.Wrapf(err, "failed to bind to %s", src)
+	}
+	remote := &unix.SockaddrInet4{}
+	copy(remote.Addr[:], dst.To4())
+
+	id, seq := uint16(os.Getpid()), uint16(time.Now().UnixNano())
+	start := time.Now()
+	if err = unix.Sendto(fd, probe.EchoRequest(true, id, seq, 0), 0, remote); err != nil {
+		return 0, errors.Wrapf(err, "failed to send echo request to %s", dst)
+	}
+	buf := make([]byte, 1500)
+	for {
+		remaining := timeout - time.Since(start)
+		if remaining <= 0 {
+			return 0, errors.Errorf("no echo reply from %s within %s", dst, timeout)
+		}
+		if err = probe.SetReceiveTimeout(fd, remaining); err != nil {
+			return 0, err
+		}
+		n, _, recvErr := unix.Recvfrom(fd, buf, 0)
+		if recvErr == unix.EINTR {
+			continue
+		}
+		if recvErr != nil {
+			return 0, errors.Errorf("no echo reply from %s within %s", dst, timeout)
+		}
+		if probe.IsEchoReply(true, buf[:n], id, seq) {
+			return time.Since(start), nil
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latency
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestMeasureLoopback(t *testing.T) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+	if err != nil {
+		t.Skip("icmp sockets need CAP_NET_RAW")
+	}
+	_ = unix.Close(fd)
+
+	loopback := net.IPv4(127, 0, 0, 1)
+	rtt, err := measure(loopback, loopback, time.Second)
+	require.NoError(t, err)
+	require.True(t, rtt > 0 && rtt < time.Second, rtt)
+
+	_, err = measure(net.ParseIP("::1"), net.ParseIP("::1"), time.Second)
+	require.Error(t, err, "ipv4 only")
+}
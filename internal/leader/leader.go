@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package leader - elects a single forwarder among those sharing a node, e.g. the old and new pod of a DaemonSet
+// surge upgrade, by an exclusive lock on a file in a directory they share
+package leader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const retryInterval = time.Second
+
+// Acquire - blocks until this process holds the exclusive lock on filename or ctx is done.  The lock is held as long
+// as the returned file, or a descriptor inherited from it, is open, and is released by the kernel when the process
+// exits however it does.
+func Acquire(ctx context.Context, filename string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Clean(filename), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for waiting := false; ; waiting = true {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			log.Entry(ctx).Infof("acquired leader lock %s", filename)
+			return f, nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			_ = f.Close()
+			return nil, errors.Wrapf(err, "failed to lock %s", filename)
+		}
+		if !waiting {
+			log.Entry(ctx).Infof("leader lock %s is held by another forwarder, waiting for it to exit", filename)
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, errors.Wrapf(ctx.Err(), "waiting for leader lock %s", filename)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package leader_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/leader"
+)
+
+func TestAcquire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "leader")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "leader.lock")
+
+	leading, err := leader.Acquire(context.Background(), filename)
+	require.NoError(t, err)
+
+	// Locks are per open file, so a second open in this process competes like another forwarder would
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = leader.Acquire(ctx, filename)
+	require.Error(t, err, "the lock is held")
+
+	acquired := make(chan *os.File, 1)
+	go func() {
+		f, acquireErr := leader.Acquire(context.Background(), filename)
+		require.NoError(t, acquireErr)
+		acquired <- f
+	}()
+	require.NoError(t, leading.Close())
+	select {
+	case f := <-acquired:
+		require.NoError(t, f.Close())
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "the lock was not acquired once released")
+	}
+}
+
+func TestAcquireError(t *testing.T) {
+	_, err := leader.Acquire(context.Background(), "/nonexistent/leader.lock")
+	require.Error(t, err)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leakwatch - watches goroutines, open fds and vpp interfaces against the number of connections, so leaks
+// show up long before they end in an OOM
+package leakwatch
+
+import (
+	"context"
+	"expvar"
+	"io/ioutil"
+	"runtime"
+	"time"
+
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/prewarm"
+)
+
+// Config - configuration of the watchdog
+type Config struct {
+	Interval                time.Duration `default:"1m" desc:"interval of leak checks, 0 disables"`
+	GoroutinesPerConnection int           `default:"50" desc:"goroutines a connection may account for" split_words:"true"`
+	OpenFilesPerConnection  int           `default:"10" desc:"open fds a connection may account for" split_words:"true"`
+	InterfacesPerConnection int           `default:"4" desc:"vpp interfaces a connection may account for" split_words:"true"`
+}
+
+type resource struct {
+	name          string
+	perConnection int
+	count         func(ctx context.Context) (int, error)
+	baseline      int
+	gauge, leaked *expvar.Int
+}
+
+// Watch - records a baseline of each resource at startup and then, every Interval until ctx is done, logs and flags
+// in the leak_suspected_<resource> metric each resource whose count exceeds its baseline plus its per connection
+// allowance for one more than the connections currently served
+func Watch(ctx context.Context, config *Config, vppagentCC grpc.ClientConnInterface, connections func() int) {
+	if config.Interval <= 0 {
+		return
+	}
+	resources := []*resource{
+		{name: "goroutines", perConnection: config.GoroutinesPerConnection, count: goroutines},
+		{name: "open_fds", perConnection: config.OpenFilesPerConnection, count: openFDs},
+		{name: "vpp_interfaces", perConnection: config.InterfacesPerConnection, count: vppInterfaces(vppagentCC)},
+	}
+	for _, r := range resources {
+		r.gauge = metrics.Int(r.name)
+		r.leaked = metrics.Int("leak_suspected_" + r.name)
+		r.baseline, _ = r.count(ctx)
+	}
+	go func() {
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			n := connections()
+			for _, r := range resources {
+				count, err := r.count(ctx)
+				if err != nil {
+					log.Entry(ctx).Debugf("leak watchdog: failed to count %s: %+v", r.name, err)
+					continue
+				}
+				r.gauge.Set(int64(count))
+				allowed := r.baseline + r.perConnection*(n+1)
+				if count <= allowed {
+					r.leaked.Set(0)
+					continue
+				}
+				r.leaked.Set(1)
+				log.Entry(ctx).Warnf("leak watchdog: %d %s exceed the %d allowed for %d connections (baseline %d), suspecting a leak",
+					count, r.name, allowed, n, r.baseline)
+			}
+		}
+	}()
+}
+
+func goroutines(context.Context) (int, error) {
+	return runtime.NumGoroutine(), nil
+}
+
+func openFDs(context.Context) (int, error) {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	return len(fds), err
+}
+
+func vppInterfaces(cc grpc.ClientConnInterface) func(ctx context.Context) (int, error) {
+	client := configurator.NewConfiguratorServiceClient(cc)
+	return func(ctx context.Context) (int, error) {
+		resp, err := client.Dump(ctx, &configurator.DumpRequest{})
+		if err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, iface := range resp.GetDump().GetVppConfig().GetInterfaces() {
+			// Tunnel shells come and go with the peers, not with connections
+			if !prewarm.Shell(iface.GetName()) {
+				count++
+			}
+		}
+		return count, nil
+	}
+}
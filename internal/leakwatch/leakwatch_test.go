@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leakwatch_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/leakwatch"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// interfacesCC - answers Dumps with the interfaces set by set
+type interfacesCC struct {
+	mu         sync.Mutex
+	interfaces []*vpp_interfaces.Interface
+}
+
+func (c *interfacesCC) set(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interfaces = nil
+	for _, name := range names {
+		c.interfaces = append(c.interfaces, &vpp_interfaces.Interface{Name: name})
+	}
+}
+
+func (c *interfacesCC) Invoke(_ context.Context, _ string, args, reply interface{}, _ ...grpc.CallOption) error {
+	if _, ok := args.(*configurator.DumpRequest); !ok {
+		return errors.Errorf("unexpected %T", args)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	proto.Merge(reply.(proto.Message), &configurator.DumpResponse{
+		Dump: &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: c.interfaces}},
+	})
+	return nil
+}
+
+func (c *interfacesCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func TestWatchInterfaces(t *testing.T) {
+	cc := &interfacesCC{}
+	cc.set("loop0")
+	var mu sync.Mutex
+	connections := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leakwatch.Watch(ctx, &leakwatch.Config{
+		Interval:                10 * time.Millisecond,
+		GoroutinesPerConnection: 1000,
+		OpenFilesPerConnection:  1000,
+		InterfacesPerConnection: 2,
+	}, cc, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return connections
+	})
+	gauge := metrics.Int("vpp_interfaces")
+	leaked := metrics.Int("leak_suspected_vpp_interfaces")
+
+	// The allowance of one more connection than served covers a connection being set up
+	cc.set("loop0", "memif-1", "tap-1")
+	require.Eventually(t, func() bool { return gauge.Value() == 3 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(0), leaked.Value())
+
+	cc.set("loop0", "memif-1", "tap-1", "memif-2", "tap-2")
+	require.Eventually(t, func() bool { return leaked.Value() == 1 }, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	connections = 1
+	mu.Unlock()
+	require.Eventually(t, func() bool { return leaked.Value() == 0 }, time.Second, 10*time.Millisecond)
+
+	// Prewarmed tunnel shells don't count against the connections
+	var shells []string
+	for i := 0; i < 10; i++ {
+		shells = append(shells, fmt.Sprintf("prewarm-%d", i))
+	}
+	cc.set(append([]string{"loop0", "memif-1", "tap-1", "memif-2", "tap-2"}, shells...)...)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int64(5), gauge.Value())
+	require.Equal(t, int64(0), leaked.Value())
+}
+
+func TestWatchDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leakwatch.Watch(ctx, &leakwatch.Config{}, nil, func() int {
+		t.Fatal("a disabled watchdog counts nothing")
+		return 0
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package listen - listens on the urls the forwarder serves on
+package listen
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Listen - listens on u, which is either tcp://host:port, unix:///path or, on linux, unix:@name for the abstract
+// unix socket name, which needs no writable shared directory.  A stale unix socket left at the path by a previous
+// run is removed.
+func Listen(u *url.URL) (net.Listener, error) {
+	network, address := u.Scheme, u.Host
+	if network == "unix" {
+		address = u.Path
+		if strings.HasPrefix(u.Opaque, "@") {
+			address = u.Opaque
+		} else {
+			_ = os.Remove(address)
+		}
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", u.String())
+	}
+	return ln, nil
+}
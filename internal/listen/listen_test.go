@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listen_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/listen"
+)
+
+func parse(t *testing.T, rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	require.NoError(t, err)
+	return u
+}
+
+// requireAccepts - requires a connection dialed to network, address to be accepted by ln
+func requireAccepts(t *testing.T, ln net.Listener, network, address string) {
+	conn, err := net.Dial(network, address)
+	require.NoError(t, err)
+	_ = conn.Close()
+	accepted, err := ln.Accept()
+	require.NoError(t, err)
+	_ = accepted.Close()
+}
+
+func TestListenTCP(t *testing.T) {
+	ln, err := listen.Listen(parse(t, "tcp://127.0.0.1:0"))
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	requireAccepts(t, ln, "tcp", ln.Addr().String())
+}
+
+func TestListenUnixRemovesStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listen")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	socket := filepath.Join(dir, "listen.on.sock")
+	require.NoError(t, ioutil.WriteFile(socket, nil, 0600))
+
+	ln, err := listen.Listen(parse(t, "unix://"+socket))
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	requireAccepts(t, ln, "unix", socket)
+}
+
+func TestListenAbstractUnix(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are linux only")
+	}
+	name := "@forwarder-listen-test-" + strconv.Itoa(os.Getpid())
+	ln, err := listen.Listen(parse(t, "unix:"+name))
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	requireAccepts(t, ln, "unix", name)
+}
+
+func TestListenError(t *testing.T) {
+	_, err := listen.Listen(parse(t, "udp://127.0.0.1:0"))
+	require.Error(t, err)
+}
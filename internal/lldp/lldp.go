@@ -0,0 +1,155 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lldp - emits LLDP frames naming the forwarder and the connection to kernel clients, so that debugging tools
+// in the client's pod (lldpd, tcpdump) can tell which forwarder and connection an interface belongs to
+package lldp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/hostifs"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type lldpServer struct {
+	hostIfs  *hostifs.Table
+	name     string
+	interval time.Duration
+	mu       sync.Mutex
+	adverts  map[string]context.CancelFunc
+}
+
+// NewServer - returns a server chain element sending an LLDP frame every interval to kernel clients, from the host
+// side of the veth pair of their connection as recorded in hostIfs, with name as the system name.  It does nothing
+// unless enabled.
+func NewServer(hostIfs *hostifs.Table, enabled bool, name string, interval time.Duration) networkservice.NetworkServiceServer {
+	if !enabled {
+		hostIfs = nil
+	}
+	return &lldpServer{
+		hostIfs:  hostIfs,
+		name:     name,
+		interval: interval,
+		adverts:  make(map[string]context.CancelFunc),
+	}
+}
+
+func (l *lldpServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || l.hostIfs == nil || conn.GetMechanism().GetType() != kernel.MECHANISM {
+		return conn, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, running := l.adverts[conn.GetId()]; running {
+		return conn, nil
+	}
+	hostIf := l.hostIfs.Get(vppnames.ServerInterface(conn))
+	if hostIf == "" {
+		log.Entry(ctx).Debugf("connection %s has no veth pair to send lldp on", conn.GetId())
+		return conn, nil
+	}
+	// The advertiser outlives the Request, up to the Close of the connection
+	advertiseCtx, cancel := context.WithCancel(context.Background())
+	if err := advertise(advertiseCtx, hostIf, l.neighbor(conn), l.interval); err != nil {
+		cancel()
+		log.Entry(ctx).Warnf("failed to send lldp for connection %s on %s: %+v", conn.GetId(), hostIf, err)
+		return conn, nil
+	}
+	l.adverts[conn.GetId()] = cancel
+	return conn, nil
+}
+
+func (l *lldpServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	l.mu.Lock()
+	if cancel, ok := l.adverts[conn.GetId()]; ok {
+		cancel()
+		delete(l.adverts, conn.GetId())
+	}
+	l.mu.Unlock()
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// neighbor - what a client is told
+type neighbor struct {
+	system      string
+	port        string
+	description string
+	ttl         time.Duration
+}
+
+// neighbor - returns the neighbor the forwarder is on conn.  Its ttl lets a client forget it a few frames after the
+// connection is gone.
+func (l *lldpServer) neighbor(conn *networkservice.Connection) *neighbor {
+	return &neighbor{
+		system:      l.name,
+		port:        conn.GetId(),
+		description: "network service " + conn.GetNetworkService(),
+		ttl:         4 * l.interval,
+	}
+}
+
+// LLDP TLV types and subtypes of IEEE 802.1AB
+const (
+	tlvEnd             = 0
+	tlvChassisID       = 1
+	tlvPortID          = 2
+	tlvTTL             = 3
+	tlvPortDescription = 4
+	tlvSystemName      = 5
+	subtypeLocal       = 7
+	ethLLDP            = 0x88cc
+	maxTLVLen          = 511
+)
+
+var nearestBridge = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// frame - returns the LLDP frame of n sent from mac
+func (n *neighbor) frame(mac net.HardwareAddr) []byte {
+	f := make([]byte, 14, 128)
+	copy(f, nearestBridge)
+	copy(f[6:], mac)
+	binary.BigEndian.PutUint16(f[12:], ethLLDP)
+	f = tlv(f, tlvChassisID, append([]byte{subtypeLocal}, n.system...))
+	f = tlv(f, tlvPortID, append([]byte{subtypeLocal}, n.port...))
+	ttl := make([]byte, 2)
+	binary.BigEndian.PutUint16(ttl, uint16(n.ttl/time.Second))
+	f = tlv(f, tlvTTL, ttl)
+	f = tlv(f, tlvPortDescription, []byte(n.description))
+	f = tlv(f, tlvSystemName, []byte(n.system))
+	return tlv(f, tlvEnd, nil)
+}
+
+// tlv - appends the TLV of type t with value v to f, truncating v to the longest value a TLV can carry
+func tlv(f []byte, t byte, v []byte) []byte {
+	if len(v) > maxTLVLen {
+		v = v[:maxTLVLen]
+	}
+	f = append(f, t<<1|byte(len(v)>>8), byte(len(v)))
+	return append(f, v...)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lldp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+)
+
+type tlvValue struct {
+	t byte
+	v []byte
+}
+
+// tlvs - returns the TLVs of the LLDP frame f, up to and including the end TLV
+func tlvs(t *testing.T, f []byte) []tlvValue {
+	var rv []tlvValue
+	for b := f[14:]; ; {
+		require.True(t, len(b) >= 2, "truncated TLV")
+		header := binary.BigEndian.Uint16(b)
+		typ, length := byte(header>>9), int(header&0x1ff)
+		require.True(t, len(b) >= 2+length, "truncated TLV value")
+		rv = append(rv, tlvValue{t: typ, v: b[2 : 2+length]})
+		if typ == tlvEnd {
+			return rv
+		}
+		b = b[2+length:]
+	}
+}
+
+func TestFrame(t *testing.T) {
+	server := NewServer(nil, true, "forwarder-1", 30*time.Second).(*lldpServer)
+	n := server.neighbor(&networkservice.Connection{Id: "conn-1", NetworkService: "icmp-responder"})
+	require.Equal(t, 2*time.Minute, n.ttl)
+	mac, err := net.ParseMAC("52:54:00:12:34:56")
+	require.NoError(t, err)
+	f := n.frame(mac)
+
+	require.Equal(t, nearestBridge, net.HardwareAddr(f[:6]))
+	require.Equal(t, mac, net.HardwareAddr(f[6:12]))
+	require.Equal(t, uint16(ethLLDP), binary.BigEndian.Uint16(f[12:]))
+	ttl := make([]byte, 2)
+	binary.BigEndian.PutUint16(ttl, 120)
+	require.Equal(t, []tlvValue{
+		{tlvChassisID, append([]byte{subtypeLocal}, "forwarder-1"...)},
+		{tlvPortID, append([]byte{subtypeLocal}, "conn-1"...)},
+		{tlvTTL, ttl},
+		{tlvPortDescription, []byte("network service icmp-responder")},
+		{tlvSystemName, []byte("forwarder-1")},
+		{tlvEnd, []byte{}},
+	}, tlvs(t, f))
+}
+
+func TestTLVTruncated(t *testing.T) {
+	f := tlv(make([]byte, 14), tlvPortDescription, bytes.Repeat([]byte("x"), 600))
+	f = tlv(f, tlvEnd, nil)
+	got := tlvs(t, f)
+	require.Len(t, got, 2)
+	require.Len(t, got[0].v, maxTLVLen)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package lldp
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// advertise - sends the LLDP frame of n on the host interface ifName every interval until ctx is done
+func advertise(ctx context.Context, ifName string, n *neighbor, interval time.Duration) error {
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	protocol := htons(ethLLDP)
+	// A zero protocol keeps the socket from receiving anything, it only sends
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return errors.Wrap(err, "failed to open a packet socket")
+	}
+	addr := &unix.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index}
+	frame := n.frame(iface.HardwareAddr)
+	go func() {
+		defer func() { _ = unix.Close(fd) }()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if sendErr := unix.Sendto(fd, frame, 0, addr); sendErr != nil {
+				log.Entry(ctx).Warnf("failed to send lldp on %s: %+v", ifName, sendErr)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// AccessLogUnaryServerInterceptor - returns an interceptor logging a line at info level per call: its method, the
+// address and spiffe id of its peer, the time left to its deadline when it arrived, its status code and duration
+func AccessLogUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		fields := accessFields(ctx, info.FullMethod)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logAccess(ctx, fields, start, err)
+		return resp, err
+	}
+}
+
+// AccessLogStreamServerInterceptor - like AccessLogUnaryServerInterceptor for streams, logging once they end
+func AccessLogStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		fields := accessFields(ss.Context(), info.FullMethod)
+		start := time.Now()
+		err := handler(srv, ss)
+		logAccess(ss.Context(), fields, start, err)
+		return err
+	}
+}
+
+func accessFields(ctx context.Context, method string) logrus.Fields {
+	fields := logrus.Fields{"method": method, "peer": "", "spiffe_id": "", "deadline": "none"}
+	if deadline, ok := ctx.Deadline(); ok {
+		fields["deadline"] = time.Until(deadline).Round(time.Millisecond).String()
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return fields
+	}
+	if p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+		if id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0]); err == nil {
+			fields["spiffe_id"] = id.String()
+		}
+	}
+	return fields
+}
+
+func logAccess(ctx context.Context, fields logrus.Fields, start time.Time, err error) {
+	fields["code"] = status.Code(err).String()
+	fields["duration"] = time.Since(start).Round(time.Microsecond).String()
+	log.Entry(ctx).WithFields(fields).Info("access")
+}
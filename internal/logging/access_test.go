@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccessFields(t *testing.T) {
+	require.Equal(t, logrus.Fields{"method": "/m", "peer": "", "spiffe_id": "", "deadline": "none"},
+		accessFields(context.Background(), "/m"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	id, err := url.Parse("spiffe://example.org/ns/default/sa/nsmgr")
+	require.NoError(t, err)
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		Addr: &net.UnixAddr{Name: "/var/lib/networkservicemesh/nsm.io.sock", Net: "unix"},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{id}}},
+		}},
+	})
+	fields := accessFields(ctx, "/m")
+	require.Equal(t, "/var/lib/networkservicemesh/nsm.io.sock", fields["peer"])
+	require.Equal(t, "spiffe://example.org/ns/default/sa/nsmgr", fields["spiffe_id"])
+	deadline, err := time.ParseDuration(fields["deadline"].(string))
+	require.NoError(t, err)
+	require.True(t, deadline > 59*time.Second && deadline <= time.Minute, "deadline %s", deadline)
+}
+
+func TestAccessLogInterceptors(t *testing.T) {
+	defer saveLogger()()
+	out := &bytes.Buffer{}
+	logrus.SetOutput(out)
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logrus.SetLevel(logrus.InfoLevel)
+
+	_, err := AccessLogUnaryServerInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/unary"},
+		func(context.Context, interface{}) (interface{}, error) {
+			return nil, status.Error(codes.Unavailable, "cordoned")
+		})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	require.Contains(t, out.String(), "method=/unary")
+	require.Contains(t, out.String(), "code=Unavailable")
+	require.Contains(t, out.String(), "msg=access")
+
+	out.Reset()
+	err = AccessLogStreamServerInterceptor()(nil, &stream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/stream"},
+		func(interface{}, grpc.ServerStream) error {
+			return nil
+		})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "method=/stream")
+	require.Contains(t, out.String(), "code=OK")
+}
+
+// stream - a server stream of context ctx
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxDumps - number of full dumps of truncated entries kept, the oldest being overwritten
+const maxDumps = 100
+
+// Bound - truncates the messages and fields of the standard logger's entries longer than maxBytes, such as the trace
+// dumps of large vpp-agent configs, writing the truncated entries in full to files of dumpDir unless it is empty.
+// A maxBytes of 0 leaves entries whole.  Has to be called after Apply.
+func Bound(maxBytes int, dumpDir string) {
+	if maxBytes <= 0 {
+		return
+	}
+	logger := logrus.StandardLogger()
+	logger.SetFormatter(&boundedFormatter{Formatter: logger.Formatter, max: maxBytes, dir: dumpDir})
+}
+
+// boundedFormatter - truncates messages and fields longer than max before formatting entries
+type boundedFormatter struct {
+	logrus.Formatter
+	max   int
+	dir   string
+	dumps uint64
+}
+
+func (f *boundedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	long := len(entry.Message) > f.max
+	for _, value := range entry.Data {
+		if s, ok := value.(string); ok && len(s) > f.max {
+			long = true
+			break
+		}
+	}
+	if !long {
+		return f.Formatter.Format(entry)
+	}
+	note := "truncated"
+	if f.dir != "" {
+		if full, err := f.Formatter.Format(entry); err == nil && len(full) > 0 {
+			filename := filepath.Join(f.dir, fmt.Sprintf("log-dump-%02d.txt", atomic.AddUint64(&f.dumps, 1)%maxDumps))
+			if err = ioutil.WriteFile(filename, full, 0600); err == nil {
+				note = "truncated, in full in " + filename
+			}
+		}
+	}
+	bounded := *entry
+	bounded.Message = f.truncate(entry.Message, note)
+	bounded.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		if s, ok := value.(string); ok {
+			value = f.truncate(s, note)
+		}
+		bounded.Data[key] = value
+	}
+	return f.Formatter.Format(&bounded)
+}
+
+// truncate - returns s cut to at most max bytes, on a rune boundary, and noted as truncated if longer than max
+func (f *boundedFormatter) truncate(s, note string) string {
+	if len(s) <= f.max {
+		return s
+	}
+	end := f.max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return fmt.Sprintf("%s... (%d bytes %s)", s[:end], len(s), note)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBound(t *testing.T) {
+	defer saveLogger()()
+	formatter := logrus.StandardLogger().Formatter
+
+	Bound(0, "")
+	require.True(t, formatter == logrus.StandardLogger().Formatter, "a max of 0 bounded the entries")
+
+	Bound(16, "")
+	require.IsType(t, &boundedFormatter{}, logrus.StandardLogger().Formatter)
+}
+
+func TestTruncate(t *testing.T) {
+	f := &boundedFormatter{max: 2}
+	require.Equal(t, "hé", f.truncate("hé", "truncated"))
+	require.Equal(t, "h... (6 bytes truncated)", f.truncate("héllo", "truncated"), "a rune was cut")
+}
+
+func TestBoundedFormatter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	f := &boundedFormatter{
+		Formatter: &logrus.TextFormatter{DisableTimestamp: true, DisableColors: true},
+		max:       8,
+		dir:       dir,
+	}
+	logger := logrus.New()
+
+	out, err := f.Format(&logrus.Entry{Logger: logger, Message: "short", Data: logrus.Fields{"key": "value", "n": 12345678910}})
+	require.NoError(t, err)
+	require.Equal(t, "level=panic msg=short key=value n=12345678910\n", string(out))
+
+	long := strings.Repeat("a", 20)
+	out, err = f.Format(&logrus.Entry{Logger: logger, Message: "short", Data: logrus.Fields{"config": long}})
+	require.NoError(t, err)
+	filename := filepath.Join(dir, "log-dump-01.txt")
+	require.Contains(t, string(out), "aaaaaaaa... (20 bytes truncated, in full in "+filename+")")
+	require.NotContains(t, string(out), long)
+	full, err := ioutil.ReadFile(filepath.Clean(filename))
+	require.NoError(t, err)
+	require.Contains(t, string(full), "config="+long)
+
+	// Without a dump directory the entries are only truncated
+	f.dir = ""
+	out, err = f.Format(&logrus.Entry{Logger: logger, Message: long})
+	require.NoError(t, err)
+	require.Contains(t, string(out), "aaaaaaaa... (20 bytes truncated)")
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// EscalationConfig - configuration of the automatic log level escalation
+type EscalationConfig struct {
+	Threshold float64       `default:"0" desc:"fraction of failed Requests and Closes over a Window above which the log level is raised, 0 disables"`
+	Window    time.Duration `default:"1m" desc:"window the failure rate is measured over"`
+	MinCalls  int           `default:"10" desc:"calls a Window needs for its failure rate to count" split_words:"true"`
+	Duration  time.Duration `default:"5m" desc:"how long the log level stays raised once the failure rate is back under Threshold"`
+	Level     string        `default:"debug" desc:"log level raised to"`
+}
+
+// escalated - 1 + the level logging is escalated to, 0 if it is not
+var escalated uint32
+
+// escalatedLevel - returns the level logging is escalated to, and whether it is
+func escalatedLevel() (logrus.Level, bool) {
+	v := atomic.LoadUint32(&escalated)
+	return logrus.Level(v) - 1, v != 0
+}
+
+type escalation struct {
+	config   *EscalationConfig
+	level    logrus.Level
+	calls    uint64
+	failures uint64
+
+	mu       sync.Mutex
+	previous logrus.Level
+	until    time.Time
+}
+
+// EscalateUnaryServerInterceptor - returns an interceptor raising the log level to config.Level, until ctx is done,
+// whenever more than config.Threshold of the Requests and Closes over a config.Window failed, and lowering it back
+// config.Duration after the failure rate is back under it.  Sampled out debug and trace output is logged while it is
+// raised.
+func EscalateUnaryServerInterceptor(ctx context.Context, config *EscalationConfig) (grpc.UnaryServerInterceptor, error) {
+	if config.Threshold <= 0 {
+		return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}, nil
+	}
+	level, err := logrus.ParseLevel(config.Level)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid log escalation level")
+	}
+	if config.Window <= 0 {
+		return nil, errors.Errorf("invalid log escalation window %s", config.Window)
+	}
+	e := &escalation{config: config, level: level}
+	go e.watch(ctx)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if strings.HasPrefix(info.FullMethod, networkServicePrefix) {
+			atomic.AddUint64(&e.calls, 1)
+			if err != nil {
+				atomic.AddUint64(&e.failures, 1)
+			}
+		}
+		return resp, err
+	}, nil
+}
+
+func (e *escalation) watch(ctx context.Context) {
+	ticker := time.NewTicker(e.config.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			e.lower(ctx)
+			return
+		case <-ticker.C:
+		}
+		calls, failures := atomic.SwapUint64(&e.calls, 0), atomic.SwapUint64(&e.failures, 0)
+		if calls >= uint64(e.config.MinCalls) && calls > 0 && float64(failures)/float64(calls) > e.config.Threshold {
+			e.raise(ctx, calls, failures)
+			continue
+		}
+		e.mu.Lock()
+		expired := !e.until.IsZero() && time.Now().After(e.until)
+		e.mu.Unlock()
+		if expired {
+			e.lower(ctx)
+		}
+	}
+}
+
+func (e *escalation) raise(ctx context.Context, calls, failures uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	raised := !e.until.IsZero()
+	e.until = time.Now().Add(e.config.Duration)
+	if raised {
+		return
+	}
+	e.previous = logrus.GetLevel()
+	if e.level > e.previous {
+		logrus.SetLevel(e.level)
+	}
+	atomic.StoreUint32(&escalated, uint32(e.level)+1)
+	metrics.Int("log_escalations").Add(1)
+	metrics.Int("log_escalated").Set(1)
+	log.Entry(ctx).Warnf("%d of %d calls failed over the last %s, raising the log level to %s for at least %s",
+		failures, calls, e.config.Window, e.level, e.config.Duration)
+}
+
+func (e *escalation) lower(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.until.IsZero() {
+		return
+	}
+	e.until = time.Time{}
+	atomic.StoreUint32(&escalated, 0)
+	logrus.SetLevel(e.previous)
+	metrics.Int("log_escalated").Set(0)
+	log.Entry(ctx).Infof("failure rate back under %v for %s, lowering the log level", e.config.Threshold, e.config.Duration)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package logging
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestEscalateConfig(t *testing.T) {
+	interceptor, err := EscalateUnaryServerInterceptor(context.Background(), &EscalationConfig{})
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: networkServicePrefix + "Request"},
+		func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("failed") })
+	require.Error(t, err, "a disabled escalation swallowed the error")
+
+	_, err = EscalateUnaryServerInterceptor(context.Background(), &EscalationConfig{Threshold: 0.5, Window: time.Minute, Level: "loud"})
+	require.Error(t, err)
+	_, err = EscalateUnaryServerInterceptor(context.Background(), &EscalationConfig{Threshold: 0.5, Level: "debug"})
+	require.Error(t, err)
+}
+
+func TestRaiseAndLower(t *testing.T) {
+	defer saveLogger()()
+	logrus.SetOutput(ioutil.Discard)
+	logrus.SetLevel(logrus.InfoLevel)
+
+	e := &escalation{config: &EscalationConfig{Duration: time.Minute}, level: logrus.DebugLevel}
+	e.raise(context.Background(), 10, 9)
+	require.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	level, ok := escalatedLevel()
+	require.True(t, ok)
+	require.Equal(t, logrus.DebugLevel, level)
+
+	// Raising again only extends the escalation, the level to lower back to is kept
+	e.raise(context.Background(), 10, 9)
+	require.Equal(t, logrus.InfoLevel, e.previous)
+
+	e.lower(context.Background())
+	require.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	_, ok = escalatedLevel()
+	require.False(t, ok)
+
+	// Escalating never lowers the level
+	logrus.SetLevel(logrus.TraceLevel)
+	e.raise(context.Background(), 10, 9)
+	require.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+	e.lower(context.Background())
+}
+
+func TestEscalateUnaryServerInterceptor(t *testing.T) {
+	defer saveLogger()()
+	logrus.SetOutput(ioutil.Discard)
+	logrus.SetLevel(logrus.InfoLevel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interceptor, err := EscalateUnaryServerInterceptor(ctx, &EscalationConfig{
+		Threshold: 0.5,
+		Window:    10 * time.Millisecond,
+		MinCalls:  2,
+		Level:     "trace",
+	})
+	require.NoError(t, err)
+	call := func(method string, err error) {
+		_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+	escalatedNow := func() bool {
+		_, ok := escalatedLevel()
+		return ok
+	}
+
+	// Failures of calls other than Requests and Closes do not count
+	for i := 0; i < 10; i++ {
+		call("/grpc.health.v1.Health/Check", errors.New("failed"))
+	}
+	time.Sleep(30 * time.Millisecond)
+	require.False(t, escalatedNow())
+
+	require.Eventually(t, func() bool {
+		call(networkServicePrefix+"Request", errors.New("failed"))
+		call(networkServicePrefix+"Close", errors.New("failed"))
+		return escalatedNow()
+	}, time.Second, 5*time.Millisecond, "failing Requests did not raise the log level")
+	require.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+
+	// With no failures for Duration, here 0, the level is lowered back
+	require.Eventually(t, func() bool { return !escalatedNow() }, time.Second, 5*time.Millisecond)
+	require.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fluentQueueSize   = 1024
+	fluentDialTimeout = 5 * time.Second
+	fluentRetryPeriod = 5 * time.Second
+)
+
+// fluentHook - sends entries to a fluentd forward input in message mode, [tag, time, record], encoded as msgpack.
+// Entries are queued without blocking the logger and dropped while the queue is full.
+type fluentHook struct {
+	address string
+	tag     string
+	queue   chan []byte
+}
+
+func newFluentHook(address, tag string) (logrus.Hook, error) {
+	if address == "" {
+		return nil, errors.New("fluent sink needs a host:port")
+	}
+	h := &fluentHook{address: address, tag: tag, queue: make(chan []byte, fluentQueueSize)}
+	go h.send()
+	return h, nil
+}
+
+func (h *fluentHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *fluentHook) Fire(entry *logrus.Entry) error {
+	record := map[string]string{
+		"level":   entry.Level.String(),
+		"message": entry.Message,
+	}
+	for key, value := range entry.Data {
+		record[key] = fmt.Sprint(value)
+	}
+	msg := &bytes.Buffer{}
+	msg.WriteByte(0x93)
+	writeString(msg, h.tag)
+	msg.WriteByte(0xce)
+	_ = binary.Write(msg, binary.BigEndian, uint32(entry.Time.Unix()))
+	writeMap(msg, record)
+	select {
+	case h.queue <- msg.Bytes():
+	default:
+	}
+	return nil
+}
+
+func (h *fluentHook) send() {
+	var conn net.Conn
+	for msg := range h.queue {
+		for conn == nil {
+			var err error
+			if conn, err = net.DialTimeout("tcp", h.address, fluentDialTimeout); err != nil {
+				// Not logged through logrus, which would feed the failure back into this hook
+				_, _ = fmt.Fprintf(os.Stderr, "fluent log sink %s: %v\n", h.address, err)
+				conn = nil
+				time.Sleep(fluentRetryPeriod)
+			}
+		}
+		if _, err := conn.Write(msg); err != nil {
+			_ = conn.Close()
+			conn = nil
+		}
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n < 1<<8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n < 1<<16:
+		buf.WriteByte(0xda)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+func writeMap(buf *bytes.Buffer, m map[string]string) {
+	if n := len(m); n < 16 {
+		buf.WriteByte(0x80 | byte(n))
+	} else {
+		buf.WriteByte(0xdf)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	for key, value := range m {
+		writeString(buf, key)
+		writeString(buf, value)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// readString - decodes a msgpack string of r
+func readString(t *testing.T, r *bufio.Reader) string {
+	b, err := r.ReadByte()
+	require.NoError(t, err)
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9:
+		l, lErr := r.ReadByte()
+		require.NoError(t, lErr)
+		n = int(l)
+	case b == 0xda:
+		var l uint16
+		require.NoError(t, binary.Read(r, binary.BigEndian, &l))
+		n = int(l)
+	case b == 0xdb:
+		var l uint32
+		require.NoError(t, binary.Read(r, binary.BigEndian, &l))
+		n = int(l)
+	default:
+		require.Fail(t, "no msgpack string", "type byte %#x", b)
+	}
+	s := make([]byte, n)
+	_, err = io.ReadFull(r, s)
+	require.NoError(t, err)
+	return string(s)
+}
+
+// readMessage - decodes a fluentd forward message of r, returning its tag, time and record
+func readMessage(t *testing.T, r *bufio.Reader) (tag string, sec uint32, record map[string]string) {
+	b, err := r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte(0x93), b, "a message is an array of 3")
+	tag = readString(t, r)
+	b, err = r.ReadByte()
+	require.NoError(t, err)
+	require.Equal(t, byte(0xce), b, "the time is an uint32")
+	require.NoError(t, binary.Read(r, binary.BigEndian, &sec))
+	b, err = r.ReadByte()
+	require.NoError(t, err)
+	var n int
+	if b == 0xdf {
+		var l uint32
+		require.NoError(t, binary.Read(r, binary.BigEndian, &l))
+		n = int(l)
+	} else {
+		require.Equal(t, byte(0x80), b&0xf0, "the record is a map")
+		n = int(b & 0x0f)
+	}
+	record = make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key := readString(t, r)
+		record[key] = readString(t, r)
+	}
+	return tag, sec, record
+}
+
+func TestWriteString(t *testing.T) {
+	for _, n := range []int{0, 31, 32, 255, 256, 1 << 16} {
+		s := strings.Repeat("x", n)
+		buf := &bytes.Buffer{}
+		writeString(buf, s)
+		require.Equal(t, s, readString(t, bufio.NewReader(buf)), n)
+	}
+}
+
+func TestFluentHook(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	hook, err := newFluentHook(ln.Addr().String(), "nsm.forwarder")
+	require.NoError(t, err)
+	now := time.Now()
+	fields := logrus.Fields{"cmd": "forwarder"}
+	for i := 0; i < 20; i++ {
+		fields[strings.Repeat("k", i+1)] = i
+	}
+	require.NoError(t, hook.Fire(&logrus.Entry{Level: logrus.WarnLevel, Message: "vpp restarted", Time: now, Data: fields}))
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	tag, sec, record := readMessage(t, bufio.NewReader(conn))
+	require.Equal(t, "nsm.forwarder", tag)
+	require.Equal(t, uint32(now.Unix()), sec)
+	require.Len(t, record, 23)
+	require.Equal(t, "warning", record["level"])
+	require.Equal(t, "vpp restarted", record["message"])
+	require.Equal(t, "forwarder", record["cmd"])
+	require.Equal(t, "19", record[strings.Repeat("k", 20)])
+
+	_, err = newFluentHook("", "nsm.forwarder")
+	require.Error(t, err)
+}
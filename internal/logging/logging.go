@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging - log profiles of the forwarder and per package log level overrides
+package logging
+
+import (
+	"sort"
+	"strings"
+
+	nested "github.com/antonfisher/nested-logrus-formatter"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Profiles
+const (
+	// DevProfile - human readable, colored logs at trace level
+	DevProfile = "dev"
+	// ProdProfile - json logs at info level for machine consumption
+	ProdProfile = "prod"
+)
+
+type override struct {
+	pkg   string
+	level logrus.Level
+}
+
+// Apply - configures the standard logger for profile, and overrides its level for the packages in levels, given as
+// package=level and matched against the package path of the logging function, e.g.
+// github.com/networkservicemesh/sdk/pkg/networkservice/core/trace=info
+func Apply(profile string, levels []string) error {
+	var formatter logrus.Formatter
+	level := logrus.TraceLevel
+	switch profile {
+	case DevProfile:
+		formatter = &nested.Formatter{}
+	case ProdProfile:
+		formatter = &logrus.JSONFormatter{}
+		level = logrus.InfoLevel
+	default:
+		return errors.Errorf("unknown log profile %q, use %s or %s", profile, DevProfile, ProdProfile)
+	}
+	var overrides []override
+	maxLevel := level
+	for _, pkgLevel := range levels {
+		pkg, levelName := pkgLevel, ""
+		if i := strings.LastIndex(pkgLevel, "="); i >= 0 {
+			pkg, levelName = pkgLevel[:i], pkgLevel[i+1:]
+		}
+		l, err := logrus.ParseLevel(levelName)
+		if err != nil || pkg == "" {
+			return errors.Errorf("invalid log level override %q, use package=level", pkgLevel)
+		}
+		overrides = append(overrides, override{pkg: pkg, level: l})
+		if l > maxLevel {
+			maxLevel = l
+		}
+	}
+	if len(overrides) > 0 {
+		// The most specific package wins
+		sort.Slice(overrides, func(i, j int) bool { return len(overrides[i].pkg) > len(overrides[j].pkg) })
+		formatter = &levelFilter{Formatter: formatter, level: level, overrides: overrides}
+		logrus.SetReportCaller(true)
+	}
+	logrus.SetFormatter(formatter)
+	logrus.SetLevel(maxLevel)
+	return nil
+}
+
+// levelFilter - drops entries above the level of the package logging them.  The logger's level is the most verbose
+// of all, so filtering is left to the formatter, which sees the caller of every entry.
+type levelFilter struct {
+	logrus.Formatter
+	level     logrus.Level
+	overrides []override
+}
+
+func (f *levelFilter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.allows(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// allows - returns true if entry is within the level of the package logging it
+func (f *levelFilter) allows(entry *logrus.Entry) bool {
+	level := f.level
+	if entry.Caller != nil {
+		for _, o := range f.overrides {
+			if strings.HasPrefix(entry.Caller.Function, o.pkg) {
+				level = o.level
+				break
+			}
+		}
+	}
+	// An escalation raises the overrides too
+	if escalatedTo, ok := escalatedLevel(); ok && escalatedTo > level {
+		level = escalatedTo
+	}
+	return entry.Level <= level
+}
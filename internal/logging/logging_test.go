@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// saveLogger - returns the func restoring the standard logger as it is
+func saveLogger() func() {
+	logger := logrus.StandardLogger()
+	formatter, level, reportCaller, out := logger.Formatter, logger.GetLevel(), logger.ReportCaller, logger.Out
+	// Hooks are added to the map in place
+	hooks := make(logrus.LevelHooks)
+	for l, levelHooks := range logger.Hooks {
+		hooks[l] = append([]logrus.Hook(nil), levelHooks...)
+	}
+	return func() {
+		logger.SetFormatter(formatter)
+		logger.SetLevel(level)
+		logger.SetReportCaller(reportCaller)
+		logger.SetOutput(out)
+		logger.ReplaceHooks(hooks)
+	}
+}
+
+func TestApplyProfiles(t *testing.T) {
+	defer saveLogger()()
+
+	require.NoError(t, Apply(ProdProfile, nil))
+	require.IsType(t, &logrus.JSONFormatter{}, logrus.StandardLogger().Formatter)
+	require.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+
+	require.NoError(t, Apply(DevProfile, nil))
+	require.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+
+	require.Error(t, Apply("verbose", nil))
+	require.Error(t, Apply(ProdProfile, []string{"github.com/networkservicemesh/sdk"}))
+	require.Error(t, Apply(ProdProfile, []string{"=debug"}))
+	require.Error(t, Apply(ProdProfile, []string{"github.com/networkservicemesh/sdk=loud"}))
+}
+
+func TestApplyOverrides(t *testing.T) {
+	defer saveLogger()()
+
+	require.NoError(t, Apply(ProdProfile, []string{
+		"github.com/networkservicemesh/sdk=debug",
+		"github.com/networkservicemesh/sdk/pkg/networkservice/core/trace=warn",
+	}))
+	// The logger logs as verbosely as the most verbose package, the formatter filters the others
+	require.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	filter, ok := logrus.StandardLogger().Formatter.(*levelFilter)
+	require.True(t, ok)
+
+	entry := func(level logrus.Level, function string) *logrus.Entry {
+		return &logrus.Entry{Level: level, Caller: &runtime.Frame{Function: function}}
+	}
+	require.True(t, filter.allows(entry(logrus.DebugLevel, "github.com/networkservicemesh/sdk/pkg/tools/log.Entry")))
+	require.False(t, filter.allows(entry(logrus.InfoLevel, "github.com/networkservicemesh/sdk/pkg/networkservice/core/trace.(*traceServer).Request")),
+		"the most specific package wins")
+	require.True(t, filter.allows(entry(logrus.WarnLevel, "github.com/networkservicemesh/sdk/pkg/networkservice/core/trace.(*traceServer).Request")))
+	require.False(t, filter.allows(entry(logrus.DebugLevel, "main.main")), "other packages log at the level of the profile")
+	require.True(t, filter.allows(&logrus.Entry{Level: logrus.InfoLevel}))
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	networkServicePrefix = "/networkservice.NetworkService/"
+	sampleField          = "sample"
+	// maxBufferSize - debug and trace output buffered per unsampled call, kept in case it fails
+	maxBufferSize = 1 << 20
+)
+
+// sampleBuffer - debug and trace output of an unsampled call, written out only if it fails
+type sampleBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+	truncated bool
+}
+
+// String - keeps the buffer readable in the entry's fields
+func (b *sampleBuffer) String() string {
+	return "buffered"
+}
+
+// SampleUnaryServerInterceptor - returns an interceptor logging the debug and trace output of only 1 in rate
+// Requests and Closes, while that of the others is buffered and logged only if they fail.  It relies on the chain
+// logging through log.Entry of the call's ctx.
+func SampleUnaryServerInterceptor(rate int) grpc.UnaryServerInterceptor {
+	if rate <= 1 {
+		return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}
+	}
+	logger := logrus.StandardLogger()
+	logger.SetFormatter(&sampler{Formatter: logger.Formatter})
+	var calls uint64
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, networkServicePrefix) || atomic.AddUint64(&calls, 1)%uint64(rate) == 0 {
+			return handler(ctx, req)
+		}
+		buffer := &sampleBuffer{}
+		resp, err := handler(log.WithField(ctx, sampleField, buffer), req)
+		if err != nil {
+			buffer.mu.Lock()
+			_, _ = logger.Out.Write(buffer.Bytes())
+			if buffer.truncated {
+				log.Entry(ctx).Warnf("debug and trace output of failed %s truncated at %d bytes", info.FullMethod, maxBufferSize)
+			}
+			buffer.mu.Unlock()
+		}
+		return resp, err
+	}
+}
+
+// sampler - diverts debug and trace entries of unsampled calls to their buffer
+type sampler struct {
+	logrus.Formatter
+}
+
+func (s *sampler) Format(entry *logrus.Entry) ([]byte, error) {
+	buffer, ok := entry.Data[sampleField].(*sampleBuffer)
+	if !ok {
+		return s.Formatter.Format(entry)
+	}
+	diverted := diverts(entry)
+	entry = unsampled(entry)
+	if !diverted {
+		return s.Formatter.Format(entry)
+	}
+	serialized, err := s.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	if buffer.Len()+len(serialized) > maxBufferSize {
+		buffer.truncated = true
+		return nil, nil
+	}
+	_, _ = buffer.Write(serialized)
+	return nil, nil
+}
+
+// diverts - returns true if entry is debug or trace output of an unsampled call, to be buffered rather than logged
+func diverts(entry *logrus.Entry) bool {
+	if _, ok := entry.Data[sampleField].(*sampleBuffer); !ok {
+		return false
+	}
+	_, escalated := escalatedLevel()
+	return !escalated && entry.Level >= logrus.DebugLevel
+}
+
+// unsampled - returns entry without the buffer of its call
+func unsampled(entry *logrus.Entry) *logrus.Entry {
+	if _, ok := entry.Data[sampleField]; !ok {
+		return entry
+	}
+	copied := *entry
+	copied.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		if key != sampleField {
+			copied.Data[key] = value
+		}
+	}
+	return &copied
+}
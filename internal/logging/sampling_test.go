@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+func TestSampleUnaryServerInterceptor(t *testing.T) {
+	defer saveLogger()()
+	out := &bytes.Buffer{}
+	logrus.SetOutput(out)
+	logrus.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logrus.SetLevel(logrus.TraceLevel)
+
+	interceptor := SampleUnaryServerInterceptor(2)
+	call := func(method, msg string, err error) {
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			log.Entry(ctx).Debugf("debug of %s", msg)
+			log.Entry(ctx).Infof("info of %s", msg)
+			return nil, err
+		})
+	}
+
+	call(networkServicePrefix+"Request", "unsampled", nil)
+	require.NotContains(t, out.String(), "debug of unsampled")
+	require.Contains(t, out.String(), "info of unsampled")
+	require.NotContains(t, out.String(), sampleField+"=")
+
+	call(networkServicePrefix+"Request", "sampled", nil)
+	require.Contains(t, out.String(), "debug of sampled")
+
+	call(networkServicePrefix+"Close", "failed", errors.New("failed"))
+	require.Contains(t, out.String(), "debug of failed", "the output of failed calls is logged")
+
+	call("/grpc.health.v1.Health/Check", "health check", nil)
+	require.Contains(t, out.String(), "debug of health check", "only Requests and Closes are sampled")
+}
+
+func TestSampleRateOne(t *testing.T) {
+	defer saveLogger()()
+	formatter := logrus.StandardLogger().Formatter
+	SampleUnaryServerInterceptor(1)
+	require.Equal(t, formatter, logrus.StandardLogger().Formatter, "every call is logged in full")
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package logging
+
+import (
+	"log/syslog"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+const defaultTag = "forwarder"
+
+// AddSinks - additionally sends the logs of the standard logger to sinks, given as urls:
+//
+//	syslog://host:port (udp), syslog+tcp://host:port or syslog:// for the local syslog daemon
+//	fluent://host:port for a fluentd forward input
+//
+// The tag defaults to forwarder and is set by a tag query parameter, e.g. fluent://fluentd:24224?tag=nsm.forwarder
+func AddSinks(sinks []string) error {
+	for _, sink := range sinks {
+		u, err := url.Parse(sink)
+		if err != nil {
+			return errors.Wrapf(err, "invalid log sink %q", sink)
+		}
+		tag := u.Query().Get("tag")
+		if tag == "" {
+			tag = defaultTag
+		}
+		var hook logrus.Hook
+		switch u.Scheme {
+		case "syslog", "syslog+udp", "syslog+tcp":
+			network := strings.TrimPrefix(strings.TrimPrefix(u.Scheme, "syslog"), "+")
+			if network == "" && u.Host != "" {
+				network = "udp"
+			}
+			hook, err = lsyslog.NewSyslogHook(network, u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+		case "fluent":
+			hook, err = newFluentHook(u.Host, tag)
+		default:
+			err = errors.Errorf("unknown scheme %q, use syslog, syslog+tcp or fluent", u.Scheme)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to add log sink %q", sink)
+		}
+		logrus.AddHook(&filteredHook{Hook: hook})
+	}
+	return nil
+}
+
+// filteredHook - fires its hook with only the entries the formatters of the logger keep: hooks see every entry
+// within the logger's level, the most verbose of all the package levels, and of unsampled calls too
+type filteredHook struct {
+	logrus.Hook
+}
+
+// Fire - called by logrus with the logger locked, so its formatter is stable
+func (h *filteredHook) Fire(entry *logrus.Entry) error {
+	if dropped(entry.Logger.Formatter, entry) {
+		return nil
+	}
+	return h.Hook.Fire(unsampled(entry))
+}
+
+// dropped - returns true if formatter, or one of those it wraps, drops entry or diverts it to a sample buffer
+func dropped(formatter logrus.Formatter, entry *logrus.Entry) bool {
+	for {
+		switch f := formatter.(type) {
+		case *levelFilter:
+			if !f.allows(entry) {
+				return true
+			}
+			formatter = f.Formatter
+		case *sampler:
+			if diverts(entry) {
+				return true
+			}
+			formatter = f.Formatter
+		case *boundedFormatter:
+			formatter = f.Formatter
+		default:
+			return false
+		}
+	}
+}
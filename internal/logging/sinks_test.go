@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package logging
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHook - keeps the entries it is fired with
+type recordingHook struct {
+	entries []*logrus.Entry
+}
+
+func (h *recordingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *recordingHook) Fire(entry *logrus.Entry) error {
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func TestAddSinksErrors(t *testing.T) {
+	defer saveLogger()()
+	require.Error(t, AddSinks([]string{"kafka://broker:9092"}))
+	require.Error(t, AddSinks([]string{"fluent://"}))
+	require.Error(t, AddSinks([]string{"://"}))
+}
+
+func TestAddSyslogSink(t *testing.T) {
+	defer saveLogger()()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+
+	require.NoError(t, AddSinks([]string{"syslog+tcp://" + ln.Addr().String() + "?tag=nsm.forwarder"}))
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	logrus.Warn("vpp restarted")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Contains(t, line, "nsm.forwarder")
+	require.True(t, strings.Contains(line, "vpp restarted"), line)
+}
+
+func TestFilteredHook(t *testing.T) {
+	recorder := &recordingHook{}
+	hook := &filteredHook{Hook: recorder}
+	logger := &logrus.Logger{Formatter: &sampler{Formatter: &levelFilter{Formatter: &logrus.TextFormatter{}, level: logrus.InfoLevel}}}
+
+	require.NoError(t, hook.Fire(&logrus.Entry{Logger: logger, Level: logrus.DebugLevel, Data: logrus.Fields{}}))
+	require.Empty(t, recorder.entries, "the entries the level filter drops are not sent")
+
+	require.NoError(t, hook.Fire(&logrus.Entry{Logger: logger, Level: logrus.InfoLevel, Data: logrus.Fields{sampleField: &sampleBuffer{}, "cmd": "forwarder"}}))
+	require.Len(t, recorder.entries, 1)
+	require.Equal(t, logrus.Fields{"cmd": "forwarder"}, recorder.entries[0].Data, "the sample buffer is not sent")
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+// HistogramVar - an expvar histogram in the shape of a prometheus one: cumulative counts of the observations up to
+// each bound, "+Inf" counting all of them, and their count and sum
+type HistogramVar struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram - returns the histogram metric named name with the ascending bucket bounds, creating it if needed.  The
+// bounds of an existing one are kept.
+func Histogram(name string, bounds ...float64) *HistogramVar {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := forwarder.Get(name).(*HistogramVar); ok {
+		return v
+	}
+	v := &HistogramVar{bounds: bounds, counts: make([]uint64, len(bounds))}
+	forwarder.Set(name, v)
+	return v
+}
+
+// Observe - records the observation value
+func (h *HistogramVar) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// String - returns the histogram as json, as expvar.Var requires
+func (h *HistogramVar) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets := make(map[string]uint64, len(h.bounds)+1)
+	for i, bound := range h.bounds {
+		buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = h.counts[i]
+	}
+	buckets["+Inf"] = h.count
+	data, _ := json.Marshal(&struct {
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+	}{buckets, h.count, h.sum})
+	return string(data)
+}
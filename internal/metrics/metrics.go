@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics - process wide forwarder metrics, published through expvar under the "forwarder" key
+package metrics
+
+import (
+	"expvar"
+	"sync"
+)
+
+var (
+	mu        sync.Mutex
+	forwarder = expvar.NewMap("forwarder")
+)
+
+// Int - returns the integer metric named name, creating it if needed
+func Int(name string) *expvar.Int {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := forwarder.Get(name).(*expvar.Int); ok {
+		return v
+	}
+	v := new(expvar.Int)
+	forwarder.Set(name, v)
+	return v
+}
+
+// Float - returns the float metric named name, creating it if needed
+func Float(name string) *expvar.Float {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := forwarder.Get(name).(*expvar.Float); ok {
+		return v
+	}
+	v := new(expvar.Float)
+	forwarder.Set(name, v)
+	return v
+}
+
+// String - returns the string metric named name, creating it if needed
+func String(name string) *expvar.String {
+	mu.Lock()
+	defer mu.Unlock()
+	if v, ok := forwarder.Get(name).(*expvar.String); ok {
+		return v
+	}
+	v := new(expvar.String)
+	forwarder.Set(name, v)
+	return v
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+func TestPublishedUnderForwarder(t *testing.T) {
+	metrics.Int("test_int").Add(2)
+	metrics.Int("test_int").Add(3)
+	metrics.String("test_string").Set("value")
+
+	published := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("forwarder").String()), &published))
+	require.Equal(t, float64(5), published["test_int"])
+	require.Equal(t, "value", published["test_string"])
+}
+
+func TestHistogram(t *testing.T) {
+	h := metrics.Histogram("test_histogram", 0.1, 1)
+	for _, value := range []float64{0.05, 0.5, 0.5, 2} {
+		h.Observe(value)
+	}
+	require.True(t, h == metrics.Histogram("test_histogram", 5), "the existing histogram is returned, bounds and all")
+
+	var published struct {
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(h.String()), &published))
+	require.Equal(t, map[string]uint64{"0.1": 1, "1": 3, "+Inf": 4}, published.Buckets)
+	require.Equal(t, uint64(4), published.Count)
+	require.Equal(t, 3.05, published.Sum)
+}
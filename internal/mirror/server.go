@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mirror - chain element mirroring (SPAN) the traffic of connections labelled for it to a monitoring
+// interface, for IDS and analytics taps that the client need not know about
+package mirror
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+// Label - connection label requesting the traffic of the connection be mirrored, valued rx, tx or both (received
+// and sent as seen from the client)
+const Label = "mirror"
+
+// SpanKey - the connmeta key of the span of a connection, in the json mapping of vpp_interfaces.Span
+const SpanKey = "vpp.mirror_span"
+
+const (
+	hostPrefix      = "host:"
+	hostIfacePrefix = "mirror-"
+)
+
+var directions = map[string]vpp_interfaces.Span_Direction{
+	"rx":   vpp_interfaces.Span_RX,
+	"tx":   vpp_interfaces.Span_TX,
+	"both": vpp_interfaces.Span_BOTH,
+	"true": vpp_interfaces.Span_BOTH,
+}
+
+type mirrorServer struct {
+	client    configurator.ConfiguratorServiceClient
+	to        string
+	hostIface *vpp_interfaces.Interface
+	metadata  *connmeta.Store
+}
+
+// NewServer - returns a server chain element mirroring labelled connections to the vpp interface named to using
+// vppagentCC.  A to of host:<name> creates an af_packet interface on the host interface name to mirror to.  The span
+// of each connection is recorded in metadata, and exactly that span is removed with the connection or its label.  The
+// element does nothing if to is empty.
+func NewServer(vppagentCC grpc.ClientConnInterface, to string, metadata *connmeta.Store) networkservice.NetworkServiceServer {
+	m := &mirrorServer{
+		client:   configurator.NewConfiguratorServiceClient(vppagentCC),
+		to:       to,
+		metadata: metadata,
+	}
+	if strings.HasPrefix(to, hostPrefix) {
+		hostIfName := strings.TrimPrefix(to, hostPrefix)
+		m.to = hostIfacePrefix + hostIfName
+		m.hostIface = &vpp_interfaces.Interface{
+			Name:    m.to,
+			Type:    vpp_interfaces.Interface_AF_PACKET,
+			Enabled: true,
+			Link: &vpp_interfaces.Interface_Afpacket{
+				Afpacket: &vpp_interfaces.AfpacketLink{
+					HostIfName: hostIfName,
+				},
+			},
+		}
+	}
+	return m
+}
+
+func (m *mirrorServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	span, err := m.span(conn)
+	if err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, err
+	}
+	previous := m.recorded(ctx, conn.GetId())
+	if previous != nil && (span == nil || previous.GetInterfaceTo() != span.GetInterfaceTo()) {
+		// No longer mirrored, or mirrored elsewhere since a restart
+		m.stop(ctx, conn.GetId(), previous)
+	}
+	if span == nil {
+		return conn, nil
+	}
+	conf := &vpp.ConfigData{Spans: []*vpp_interfaces.Span{span}}
+	if m.hostIface != nil {
+		// vpp-agent leaves the interface as is when it is already configured
+		conf.Interfaces = []*vpp_interfaces.Interface{m.hostIface}
+	}
+	if _, err := m.client.Update(ctx, &configurator.UpdateRequest{Update: &configurator.Config{VppConfig: conf}}); err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(err, "failed to mirror connection %s to %s", conn.GetId(), m.to)
+	}
+	data, err := (&jsonpb.Marshaler{}).MarshalToString(span)
+	if err != nil {
+		log.Entry(ctx).Warnf("span of connection %s cannot be recorded to be removed with it: %+v", conn.GetId(), err)
+		return conn, nil
+	}
+	m.metadata.Set(conn.GetId(), SpanKey, data)
+	return conn, nil
+}
+
+func (m *mirrorServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	span := m.recorded(ctx, conn.GetId())
+	if span == nil {
+		// Mirrored by a forwarder that did not record its spans
+		span, _ = m.span(conn)
+	}
+	if span != nil {
+		m.stop(ctx, conn.GetId(), span)
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// recorded - returns the span recorded for connection connID, nil if there is none
+func (m *mirrorServer) recorded(ctx context.Context, connID string) *vpp_interfaces.Span {
+	data, ok := m.metadata.Get(connID, SpanKey)
+	if !ok || data == "" {
+		return nil
+	}
+	span := &vpp_interfaces.Span{}
+	if err := jsonpb.UnmarshalString(data, span); err != nil {
+		log.Entry(ctx).Warnf("span of connection %s cannot be removed: %+v", connID, err)
+		return nil
+	}
+	return span
+}
+
+// stop - removes span of connection connID and its record
+func (m *mirrorServer) stop(ctx context.Context, connID string, span *vpp_interfaces.Span) {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{Spans: []*vpp_interfaces.Span{span}}}
+	if _, err := m.client.Delete(ctx, &configurator.DeleteRequest{Delete: conf}); err != nil {
+		log.Entry(ctx).Warnf("failed to stop mirroring connection %s: %+v", connID, err)
+		return
+	}
+	m.metadata.Set(connID, SpanKey, "")
+}
+
+// span - returns the span mirroring conn, or nil if conn is not to be mirrored
+func (m *mirrorServer) span(conn *networkservice.Connection) (*vpp_interfaces.Span, error) {
+	value, ok := conn.GetLabels()[Label]
+	if !ok || m.to == "" {
+		return nil, nil
+	}
+	direction, ok := directions[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		return nil, errors.Errorf("invalid %s label value %q, expected rx, tx or both", Label, value)
+	}
+	return &vpp_interfaces.Span{
+		InterfaceFrom: vppnames.ServerInterface(conn),
+		InterfaceTo:   m.to,
+		Direction:     direction,
+	}, nil
+}
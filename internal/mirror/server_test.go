@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/mirror"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+// vppAgent - keeps the spans updated and deleted through it
+type vppAgent struct {
+	updated []*vpp_interfaces.Span
+	deleted []*vpp_interfaces.Span
+}
+
+func (v *vppAgent) Invoke(_ context.Context, _ string, args, _ interface{}, _ ...grpc.CallOption) error {
+	switch r := args.(type) {
+	case *configurator.UpdateRequest:
+		v.updated = append(v.updated, r.GetUpdate().GetVppConfig().GetSpans()...)
+	case *configurator.DeleteRequest:
+		v.deleted = append(v.deleted, r.GetDelete().GetVppConfig().GetSpans()...)
+	}
+	return nil
+}
+
+func (v *vppAgent) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func newServer(t *testing.T) (networkservice.NetworkServiceServer, *vppAgent, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	metadata, err := connmeta.NewStore(ctx, "", time.Minute)
+	require.NoError(t, err)
+	agent := &vppAgent{}
+	return chain.NewNetworkServiceServer(mirror.NewServer(agent, "mon0", metadata)), agent, cancel
+}
+
+func request(labels map[string]string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-1", Labels: labels},
+	}
+}
+
+func TestCloseRemovesSpan(t *testing.T) {
+	server, agent, cancel := newServer(t)
+	defer cancel()
+
+	conn, err := server.Request(context.Background(), request(map[string]string{mirror.Label: "rx"}))
+	require.NoError(t, err)
+	want := &vpp_interfaces.Span{
+		InterfaceFrom: vppnames.ServerInterface(conn),
+		InterfaceTo:   "mon0",
+		Direction:     vpp_interfaces.Span_RX,
+	}
+	require.Len(t, agent.updated, 1)
+	require.True(t, proto.Equal(want, agent.updated[0]))
+
+	// The span goes with the connection, whatever its labels on Close
+	_, err = server.Close(context.Background(), &networkservice.Connection{Id: conn.GetId()})
+	require.NoError(t, err)
+	require.Len(t, agent.deleted, 1)
+	require.True(t, proto.Equal(want, agent.deleted[0]))
+}
+
+func TestLabelRemoved(t *testing.T) {
+	server, agent, cancel := newServer(t)
+	defer cancel()
+
+	_, err := server.Request(context.Background(), request(map[string]string{mirror.Label: "both"}))
+	require.NoError(t, err)
+	require.Len(t, agent.updated, 1)
+
+	conn, err := server.Request(context.Background(), request(nil))
+	require.NoError(t, err)
+	require.Len(t, agent.updated, 1)
+	require.Len(t, agent.deleted, 1, "a refresh without the label stops mirroring")
+	require.True(t, proto.Equal(agent.updated[0], agent.deleted[0]))
+
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, agent.deleted, 1)
+}
+
+func TestInvalidLabel(t *testing.T) {
+	server, agent, cancel := newServer(t)
+	defer cancel()
+
+	_, err := server.Request(context.Background(), request(map[string]string{mirror.Label: "sideways"}))
+	require.Error(t, err)
+	require.Empty(t, agent.updated)
+}
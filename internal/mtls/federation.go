@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+)
+
+type bundleFile struct {
+	filename string
+	modTime  time.Time
+	bundle   *x509bundle.Bundle
+}
+
+type federatedSource struct {
+	source x509bundle.Source
+	mu     sync.Mutex
+	files  map[spiffeid.TrustDomain]*bundleFile
+}
+
+// FederatedBundles - returns a bundle source serving the trust bundles of federated trust domains from files, given
+// as trustdomain=file and reloaded on change, and those of any other trust domain from source.  The workload api
+// source already serves the bundles of the trust domains the spire agent federates with, files add the others.
+func FederatedBundles(source x509bundle.Source, files []string) (x509bundle.Source, error) {
+	f := &federatedSource{
+		source: source,
+		files:  make(map[spiffeid.TrustDomain]*bundleFile),
+	}
+	for _, file := range files {
+		kv := strings.SplitN(file, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid federated bundle %q, expected trustdomain=file", file)
+		}
+		td, err := spiffeid.TrustDomainFromString(kv[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trust domain of federated bundle %q", file)
+		}
+		f.files[td] = &bundleFile{filename: kv[1]}
+		if _, err = f.load(td); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *federatedSource) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	f.mu.Lock()
+	_, ok := f.files[td]
+	f.mu.Unlock()
+	if !ok {
+		return f.source.GetX509BundleForTrustDomain(td)
+	}
+	return f.load(td)
+}
+
+// load - returns the bundle of td, reloading its file if it changed
+func (f *federatedSource) load(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file := f.files[td]
+	info, err := os.Stat(file.filename)
+	if err != nil {
+		if file.bundle != nil {
+			return file.bundle, nil
+		}
+		return nil, errors.Wrapf(err, "error loading the bundle of %s", td.String())
+	}
+	if file.bundle != nil && info.ModTime().Equal(file.modTime) {
+		return file.bundle, nil
+	}
+	bundle, err := x509bundle.Load(td, file.filename)
+	if err != nil {
+		if file.bundle != nil {
+			return file.bundle, nil
+		}
+		return nil, errors.Wrapf(err, "error loading the bundle of %s from %s", td.String(), file.filename)
+	}
+	file.bundle, file.modTime = bundle, info.ModTime()
+	return bundle, nil
+}
+
+// WithTrustDomains - accepts peers of trustDomains only, in addition to what the authorizer accepts
+func WithTrustDomains(trustDomains []string) Option {
+	return func(o *options) {
+		o.trustDomains = append(o.trustDomains, trustDomains...)
+	}
+}
+
+// authorizeTrustDomains - returns an authorizer accepting peers of trustDomains that authorizer accepts
+func authorizeTrustDomains(trustDomains []string, authorizer tlsconfig.Authorizer) tlsconfig.Authorizer {
+	allowed := make(map[string]bool)
+	for _, td := range trustDomains {
+		allowed[strings.TrimPrefix(td, "spiffe://")] = true
+	}
+	return func(id spiffeid.ID, verifiedChains [][]*x509.Certificate) error {
+		if !allowed[id.TrustDomain().String()] {
+			return errors.Errorf("peer %s is not of an accepted trust domain", id.String())
+		}
+		return authorizer(id, verifiedChains)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+)
+
+// workloadSource - serves the bundle of its trust domain only
+type workloadSource struct {
+	bundle *x509bundle.Bundle
+}
+
+func (w *workloadSource) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if td != w.bundle.TrustDomain() {
+		return nil, errors.Errorf("no bundle of %s", td.String())
+	}
+	return w.bundle, nil
+}
+
+func newCA(t *testing.T, name string) *x509.Certificate {
+	now := time.Now()
+	ca, _ := newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	return ca
+}
+
+func writeBundle(t *testing.T, filename string, ca *x509.Certificate) {
+	require.NoError(t, ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600))
+}
+
+func TestFederatedBundles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "federation")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "other.org.pem")
+	first := newCA(t, "first")
+	writeBundle(t, filename, first)
+
+	local, err := spiffeid.TrustDomainFromString("example.org")
+	require.NoError(t, err)
+	other, err := spiffeid.TrustDomainFromString("other.org")
+	require.NoError(t, err)
+	localCA := newCA(t, "local")
+	source, err := FederatedBundles(&workloadSource{bundle: x509bundle.FromX509Authorities(local, []*x509.Certificate{localCA})},
+		[]string{"other.org=" + filename})
+	require.NoError(t, err)
+
+	bundle, err := source.GetX509BundleForTrustDomain(local)
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(localCA), "other trust domains are served by the workload api")
+
+	bundle, err = source.GetX509BundleForTrustDomain(other)
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(first))
+
+	// A changed file is reloaded
+	second := newCA(t, "second")
+	writeBundle(t, filename, second)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filename, later, later))
+	bundle, err = source.GetX509BundleForTrustDomain(other)
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(second))
+
+	// A file gone keeps the last bundle loaded
+	require.NoError(t, os.Remove(filename))
+	bundle, err = source.GetX509BundleForTrustDomain(other)
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(second))
+}
+
+func TestFederatedBundlesInvalid(t *testing.T) {
+	for _, files := range [][]string{
+		{"other.org"},
+		{"=/bundle.pem"},
+		{"other.org=/does/not/exist.pem"},
+	} {
+		_, err := FederatedBundles(nil, files)
+		require.Error(t, err, files)
+	}
+}
+
+func TestAuthorizeTrustDomains(t *testing.T) {
+	authorizedAny := 0
+	authorize := authorizeTrustDomains([]string{"spiffe://example.org", "other.org"}, func(spiffeid.ID, [][]*x509.Certificate) error {
+		authorizedAny++
+		return nil
+	})
+	for _, sample := range []struct {
+		id       string
+		accepted bool
+	}{
+		{"spiffe://example.org/nsmgr", true},
+		{"spiffe://other.org/forwarder", true},
+		{"spiffe://intruder.org/nsmgr", false},
+	} {
+		id, err := spiffeid.FromString(sample.id)
+		require.NoError(t, err)
+		if sample.accepted {
+			require.NoError(t, authorize(id, nil), sample.id)
+		} else {
+			require.Error(t, authorize(id, nil), sample.id)
+		}
+	}
+	require.Equal(t, 2, authorizedAny, "the wrapped authorizer still decides")
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mtls - builds the spiffe based mTLS configs used by the forwarder's grpc server and clients
+package mtls
+
+import (
+	"crypto/tls"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+)
+
+// ClientConfig - returns a mTLS config for dialing other NSM components using svidSource and bundleSource
+func ClientConfig(svidSource x509svid.Source, bundleSource x509bundle.Source, opts ...Option) *tls.Config {
+	o := newOptions(opts...)
+	tlsConfig := tlsconfig.MTLSClientConfig(svidSource, bundleSource, o.authorizer)
+	o.apply(tlsConfig, bundleSource)
+	if o.sessionCacheSize > 0 {
+		// VerifyPeerCertificate is not called for resumed sessions: a cached session outlives the svid it was verified
+		// with and any later change of the authorizer, which is why resumption is opt in
+		tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(o.sessionCacheSize)
+	}
+	return tlsConfig
+}
+
+// ServerConfig - returns a mTLS config for serving other NSM components using svidSource and bundleSource
+func ServerConfig(svidSource x509svid.Source, bundleSource x509bundle.Source, opts ...Option) *tls.Config {
+	o := newOptions(opts...)
+	tlsConfig := tlsconfig.MTLSServerConfig(svidSource, bundleSource, o.authorizer)
+	o.apply(tlsConfig, bundleSource)
+	return tlsConfig
+}
+
+func (o *options) apply(tlsConfig *tls.Config, bundleSource x509bundle.Source) {
+	if o.minVersion != 0 {
+		tlsConfig.MinVersion = o.minVersion
+	}
+	if len(o.cipherSuites) > 0 {
+		tlsConfig.CipherSuites = o.cipherSuites
+	}
+	if len(o.curvePreferences) > 0 {
+		tlsConfig.CurvePreferences = o.curvePreferences
+	}
+	if o.clockSkew > 0 {
+		tlsConfig.VerifyPeerCertificate = verifyPeerCertificate(bundleSource, o.authorizer, o.clockSkew)
+	}
+}
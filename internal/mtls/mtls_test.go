@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientConfigSessionResumptionOptIn(t *testing.T) {
+	require.Nil(t, ClientConfig(nil, nil).ClientSessionCache, "sessions are not resumed by default")
+	require.NotNil(t, ClientConfig(nil, nil, WithSessionCacheSize(16)).ClientSessionCache)
+	require.Nil(t, ServerConfig(nil, nil, WithSessionCacheSize(16)).ClientSessionCache)
+}
+
+func TestConfigOptions(t *testing.T) {
+	opts := []Option{
+		WithMinVersion(tls.VersionTLS13),
+		WithCipherSuites([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}),
+		WithCurvePreferences([]tls.CurveID{tls.X25519}),
+	}
+	for _, tlsConfig := range []*tls.Config{ClientConfig(nil, nil, opts...), ServerConfig(nil, nil, opts...)} {
+		require.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+		require.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+		require.Equal(t, []tls.CurveID{tls.X25519}, tlsConfig.CurvePreferences)
+	}
+
+	// Without options the defaults of go-spiffe are kept
+	server := ServerConfig(nil, nil)
+	require.Equal(t, tls.RequireAnyClientCert, server.ClientAuth)
+	require.Empty(t, server.CipherSuites)
+	require.Empty(t, server.CurvePreferences)
+}
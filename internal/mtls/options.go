@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+)
+
+type options struct {
+	sessionCacheSize int
+	authorizer       tlsconfig.Authorizer
+	minVersion       uint16
+	cipherSuites     []uint16
+	curvePreferences []tls.CurveID
+	trustDomains     []string
+	clockSkew        time.Duration
+}
+
+// Option - option for ClientConfig and ServerConfig
+type Option func(o *options)
+
+// WithSessionCacheSize - enables tls session resumption for clients with a session cache of size entries.  Resumed
+// sessions are not verified again.
+func WithSessionCacheSize(size int) Option {
+	return func(o *options) {
+		o.sessionCacheSize = size
+	}
+}
+
+// WithAuthorizer - authorizes peers with authorizer instead of accepting any peer
+func WithAuthorizer(authorizer tlsconfig.Authorizer) Option {
+	return func(o *options) {
+		o.authorizer = authorizer
+	}
+}
+
+// WithMinVersion - sets the minimum accepted tls version
+func WithMinVersion(version uint16) Option {
+	return func(o *options) {
+		o.minVersion = version
+	}
+}
+
+// WithCipherSuites - restricts the tls 1.0-1.2 cipher suites to suites
+func WithCipherSuites(suites []uint16) Option {
+	return func(o *options) {
+		o.cipherSuites = suites
+	}
+}
+
+// WithCurvePreferences - restricts the elliptic curves used in handshakes to curves
+func WithCurvePreferences(curves []tls.CurveID) Option {
+	return func(o *options) {
+		o.curvePreferences = curves
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		authorizer: tlsconfig.AuthorizeAny(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if len(o.trustDomains) > 0 {
+		o.authorizer = authorizeTrustDomains(o.trustDomains, o.authorizer)
+	}
+	return o
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/x509"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// WithClockSkew - accepts peer certificates expired or not yet valid by up to skew, for peers whose clocks are not
+// quite in sync with the forwarder's
+func WithClockSkew(skew time.Duration) Option {
+	return func(o *options) {
+		o.clockSkew = skew
+	}
+}
+
+// verifyPeerCertificate - returns a tls.Config VerifyPeerCertificate verifying the peer svid against bundleSource
+// like tlsconfig does, but tolerating a validity window off by up to skew, and authorizing it with authorizer
+func verifyPeerCertificate(bundleSource x509bundle.Source, authorizer tlsconfig.Authorizer, skew time.Duration) func([][]byte, [][]*x509.Certificate) error {
+	tolerated := metrics.Int("clock_skew_tolerated.certificates")
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("peer presented no certificate")
+		}
+		intermediates := x509.NewCertPool()
+		var leaf *x509.Certificate
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return errors.Wrap(err, "error parsing peer certificate")
+			}
+			if leaf == nil {
+				leaf = cert
+				continue
+			}
+			intermediates.AddCert(cert)
+		}
+		id, err := x509svid.IDFromCert(leaf)
+		if err != nil {
+			return errors.Wrap(err, "peer certificate is no svid")
+		}
+		bundle, err := bundleSource.GetX509BundleForTrustDomain(id.TrustDomain())
+		if err != nil {
+			return errors.Wrapf(err, "no trust bundle for peer %s", id.String())
+		}
+		roots := x509.NewCertPool()
+		for _, authority := range bundle.X509Authorities() {
+			roots.AddCert(authority)
+		}
+		verifyOptions := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}
+		chains, err := leaf.Verify(verifyOptions)
+		var invalid x509.CertificateInvalidError
+		if err != nil && errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+			// x509 reports certificates not valid yet as expired too, so both ends of the window are tried
+			now := time.Now()
+			for _, at := range []time.Time{now.Add(-skew), now.Add(skew)} {
+				verifyOptions.CurrentTime = at
+				if skewedChains, skewedErr := leaf.Verify(verifyOptions); skewedErr == nil {
+					chains, err = skewedChains, nil
+					tolerated.Add(1)
+					break
+				}
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error verifying the svid of peer %s", id.String())
+		}
+		return authorizer(id, chains)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/require"
+)
+
+func newCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestVerifyPeerCertificateSkew(t *testing.T) {
+	now := time.Now()
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	td, err := spiffeid.TrustDomainFromString("example.org")
+	require.NoError(t, err)
+	bundle := x509bundle.FromX509Authorities(td, []*x509.Certificate{ca})
+
+	for _, sample := range []struct {
+		name      string
+		notBefore time.Duration
+		notAfter  time.Duration
+		skew      time.Duration
+		valid     bool
+	}{
+		{"valid", -time.Hour, time.Hour, time.Minute, true},
+		{"expired within skew", -2 * time.Hour, -time.Minute, 5 * time.Minute, true},
+		{"expired outside skew", -2 * time.Hour, -10 * time.Minute, 5 * time.Minute, false},
+		{"not yet valid within skew", time.Minute, time.Hour, 5 * time.Minute, true},
+		{"not yet valid outside skew", 10 * time.Minute, time.Hour, 5 * time.Minute, false},
+	} {
+		leaf, _ := newCertificate(t, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			NotBefore:    now.Add(sample.notBefore),
+			NotAfter:     now.Add(sample.notAfter),
+			URIs:         []*url.URL{{Scheme: "spiffe", Host: "example.org", Path: "/nsmgr"}},
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+		}, ca, caKey)
+		verify := verifyPeerCertificate(bundle, tlsconfig.AuthorizeAny(), sample.skew)
+		err := verify([][]byte{leaf.Raw}, nil)
+		if sample.valid {
+			require.NoError(t, err, sample.name)
+		} else {
+			require.Error(t, err, sample.name)
+		}
+	}
+}
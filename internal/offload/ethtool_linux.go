@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package offload
+
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// ethtool commands and constants of linux/ethtool.h
+const (
+	ethtoolGStrings  = 0x1b
+	ethtoolGSSetInfo = 0x37
+	ethtoolGFeatures = 0x3a
+	ethSSFeatures    = 4
+	ethGStringLen    = 32
+)
+
+type ifreq struct {
+	name [unix.IFNAMSIZ]byte
+	data uintptr
+	_    [16]byte
+}
+
+type ssetInfo struct {
+	cmd      uint32
+	reserved uint32
+	mask     uint64
+	count    uint32
+}
+
+// features - returns whether each ethtool feature of interface ifName is active
+func features(ifName string) (map[string]bool, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	info := ssetInfo{cmd: ethtoolGSSetInfo, mask: 1 << ethSSFeatures}
+	if err = ethtool(fd, ifName, unsafe.Pointer(&info)); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the number of features of %s", ifName)
+	}
+	n := int(info.count)
+
+	// struct ethtool_gstrings: cmd, string_set, len, then len strings
+	stringsBuf := make([]uint32, 3+n*ethGStringLen/4)
+	stringsBuf[0], stringsBuf[1], stringsBuf[2] = ethtoolGStrings, ethSSFeatures, uint32(n)
+	if err = ethtool(fd, ifName, unsafe.Pointer(&stringsBuf[0])); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the feature names of %s", ifName)
+	}
+	names := (*[1 << 20]byte)(unsafe.Pointer(&stringsBuf[3]))[: n*ethGStringLen : n*ethGStringLen]
+
+	// struct ethtool_gfeatures: cmd, size, then size blocks of available, requested, active and never_changed
+	blocks := (n + 31) / 32
+	featuresBuf := make([]uint32, 2+blocks*4)
+	featuresBuf[0], featuresBuf[1] = ethtoolGFeatures, uint32(blocks)
+	if err = ethtool(fd, ifName, unsafe.Pointer(&featuresBuf[0])); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the features of %s", ifName)
+	}
+
+	active := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		name := names[i*ethGStringLen : (i+1)*ethGStringLen]
+		for j, c := range name {
+			if c == 0 {
+				name = name[:j]
+				break
+			}
+		}
+		activeBits := featuresBuf[2+(i/32)*4+2]
+		active[string(name)] = activeBits&(1<<uint(i%32)) != 0
+	}
+	runtime.KeepAlive(stringsBuf)
+	return active, nil
+}
+
+func ethtool(fd int, ifName string, data unsafe.Pointer) error {
+	var ifr ifreq
+	copy(ifr.name[:unix.IFNAMSIZ-1], ifName)
+	ifr.data = uintptr(data)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr)))
+	runtime.KeepAlive(data)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offload
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	ifName, err := interfaceOf(net.IPv4(127, 0, 0, 1))
+	require.NoError(t, err)
+	active, err := features(ifName)
+	require.NoError(t, err)
+	require.True(t, active["tx-checksum-ip-generic"], "loopback checksums are always offloaded")
+
+	_, err = features("does-not-exist")
+	require.Error(t, err)
+}
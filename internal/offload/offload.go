@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offload - probes the hardware acceleration available to the forwarder's tunnels (nic offloads of the
+// tunnel interface, crypto instructions of the cpu) so it can be advertised and NSMgr can prefer forwarders whose
+// mechanisms will be accelerated
+package offload
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// LabelPrefix - prefix of the labels advertising capabilities
+const LabelPrefix = "offload."
+
+// nicFeatures - ethtool features of the tunnel interface advertised, by label
+var nicFeatures = map[string]string{
+	"tx-checksum":    "tx-checksum-ip-generic",
+	"rx-checksum":    "rx-checksum",
+	"tso":            "tx-tcp-segmentation",
+	"vxlan":          "tx-udp_tnl-segmentation",
+	"vxlan-checksum": "tx-udp_tnl-csum-segmentation",
+	"esp":            "esp-hw-offload",
+	"esp-checksum":   "esp-tx-csum-hw-offload",
+}
+
+// cpuFlags - cpu flags of /proc/cpuinfo advertised, by label, x86 and arm names alike
+var cpuFlags = map[string][]string{
+	"crypto-aes":    {"aes"},
+	"crypto-sha":    {"sha_ni", "sha2"},
+	"crypto-avx512": {"avx512f"},
+	"crypto-pmull":  {"pclmulqdq", "pmull"},
+}
+
+// Labels - returns the capability labels of the forwarder: whether the nic offloads of the interface holding tunnelIP
+// and the crypto instructions of the cpu are available, and cryptoEngine, the vpp crypto engine configured.
+// Anything that can't be probed is logged and left out.
+func Labels(ctx context.Context, tunnelIP net.IP, cryptoEngine string) map[string]string {
+	labels := make(map[string]string)
+	if ifName, err := interfaceOf(tunnelIP); err != nil {
+		log.Entry(ctx).Warnf("not advertising nic offloads: %+v", err)
+	} else if active, err := features(ifName); err != nil {
+		log.Entry(ctx).Warnf("not advertising nic offloads of %s: %+v", ifName, err)
+	} else {
+		for label, feature := range nicFeatures {
+			if on, ok := active[feature]; ok {
+				labels[LabelPrefix+label] = strconv.FormatBool(on)
+			}
+		}
+	}
+	flags, err := cpuinfoFlags()
+	if err != nil {
+		log.Entry(ctx).Warnf("not advertising cpu crypto capabilities: %+v", err)
+	}
+	for label, names := range cpuFlags {
+		if flags == nil {
+			break
+		}
+		has := false
+		for _, name := range names {
+			has = has || flags[name]
+		}
+		labels[LabelPrefix+label] = strconv.FormatBool(has)
+	}
+	if cryptoEngine != "" {
+		labels[LabelPrefix+"crypto-engine"] = cryptoEngine
+	}
+	return labels
+}
+
+// interfaceOf - returns the name of the interface holding ip
+func interfaceOf(ip net.IP) (string, error) {
+	if ip == nil {
+		return "", errors.New("no tunnel ip")
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, iface := range ifaces {
+		addrs, addrsErr := iface.Addrs()
+		if addrsErr != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name, nil
+			}
+		}
+	}
+	return "", errors.Errorf("no interface holds tunnel ip %s", ip)
+}
+
+// cpuinfoFlags - returns the flags (x86) or features (arm) of the first cpu in /proc/cpuinfo
+func cpuinfoFlags() (map[string]bool, error) {
+	data, err := ioutil.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if key := strings.TrimSpace(kv[0]); key != "flags" && key != "Features" {
+			continue
+		}
+		flags := make(map[string]bool)
+		for _, flag := range strings.Fields(kv[1]) {
+			flags[flag] = true
+		}
+		return flags, nil
+	}
+	return nil, errors.New("no cpu flags in /proc/cpuinfo")
+}
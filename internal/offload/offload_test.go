@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offload
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceOf(t *testing.T) {
+	ifName, err := interfaceOf(net.IPv4(127, 0, 0, 1))
+	require.NoError(t, err)
+	require.NotEmpty(t, ifName)
+
+	_, err = interfaceOf(net.ParseIP("192.0.2.1"))
+	require.Error(t, err)
+	_, err = interfaceOf(nil)
+	require.Error(t, err)
+}
+
+func TestLabels(t *testing.T) {
+	labels := Labels(context.Background(), nil, "native")
+	require.Equal(t, "native", labels[LabelPrefix+"crypto-engine"])
+	for label := range nicFeatures {
+		require.NotContains(t, labels, LabelPrefix+label, "no nic offloads without a tunnel interface")
+	}
+	if _, err := cpuinfoFlags(); err == nil {
+		for label := range cpuFlags {
+			require.Contains(t, labels, LabelPrefix+label)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package orphanclose - chain element making Close idempotent: a Close of a connection unknown to the forwarder,
+// e.g. one it lost track of across a restart or already removed, gets its vpp objects cleaned up best effort by their
+// deterministic names and succeeds, instead of leaving NSMgr retrying it forever.  It runs after authorization, so
+// that only Closes the forwarder would serve anyway get cleaned up, and other failures are returned as they are.
+package orphanclose
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type orphanCloseServer struct {
+	client   configurator.ConfiguratorServiceClient
+	metadata *connmeta.Store
+}
+
+// NewServer - returns a server chain element cleaning up, using vppagentCC, the vpp objects of connections unknown to
+// metadata whose Close fails.  The client side interface name is taken from metadata when the closed connection
+// lacks it.
+func NewServer(vppagentCC grpc.ClientConnInterface, metadata *connmeta.Store) networkservice.NetworkServiceServer {
+	return &orphanCloseServer{
+		client:   configurator.NewConfiguratorServiceClient(vppagentCC),
+		metadata: metadata,
+	}
+}
+
+func (o *orphanCloseServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (o *orphanCloseServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	// Looked up first as the rest of the chain forgets the connection on Close
+	clientInterface := vppnames.ClientInterface(conn)
+	if clientInterface == "" {
+		clientInterface, _ = o.metadata.Get(conn.GetId(), connmeta.ClientInterfaceKey)
+	}
+	_, known := o.metadata.Get(conn.GetId(), connmeta.ServerInterfaceKey)
+	if _, err := next.Server(ctx).Close(ctx, conn); err != nil {
+		if known && status.Code(err) != codes.NotFound {
+			return nil, err
+		}
+		log.Entry(ctx).Warnf("Close of unknown connection %s failed, cleaning up its vpp objects by name: %+v", conn.GetId(), err)
+		if cleanupErr := cleanup(ctx, o.client, vppnames.ServerInterface(conn), clientInterface); cleanupErr != nil {
+			log.Entry(ctx).Warnf("best effort cleanup failed: %+v", cleanupErr)
+		}
+	}
+	return &empty.Empty{}, nil
+}
+
+// Cleanup - deletes, using vppagentCC, the cross connect and interfaces named serverInterface and clientInterface
+// ("" if unknown), objects that don't exist are ignored by vpp-agent
+func Cleanup(ctx context.Context, vppagentCC grpc.ClientConnInterface, serverInterface, clientInterface string) error {
+	return cleanup(ctx, configurator.NewConfiguratorServiceClient(vppagentCC), serverInterface, clientInterface)
+}
+
+func cleanup(ctx context.Context, client configurator.ConfiguratorServiceClient, serverInterface, clientInterface string) error {
+	conf := &vpp.ConfigData{
+		Interfaces: []*vpp.Interface{{Name: serverInterface}},
+	}
+	if clientInterface != "" {
+		conf.Interfaces = append(conf.Interfaces, &vpp.Interface{Name: clientInterface})
+		conf.XconnectPairs = []*vpp.L2XConnect{
+			{ReceiveInterface: serverInterface, TransmitInterface: clientInterface},
+			{ReceiveInterface: clientInterface, TransmitInterface: serverInterface},
+		}
+	}
+	_, err := client.Delete(ctx, &configurator.DeleteRequest{Delete: &configurator.Config{VppConfig: conf}})
+	return errors.Wrapf(err, "failed to delete %s %s", serverInterface, clientInterface)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orphanclose_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/orphanclose"
+)
+
+// deleteCC - records the Deletes it gets, failing them with err
+type deleteCC struct {
+	deletes []*configurator.DeleteRequest
+	err     error
+}
+
+func (c *deleteCC) Invoke(_ context.Context, _ string, args, _ interface{}, _ ...grpc.CallOption) error {
+	request, ok := args.(*configurator.DeleteRequest)
+	if !ok {
+		return errors.Errorf("unexpected %T", args)
+	}
+	c.deletes = append(c.deletes, request)
+	return c.err
+}
+
+func (c *deleteCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+// failingServer - fails Closes with err
+type failingServer struct {
+	err error
+}
+
+func (f *failingServer) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return request.GetConnection(), nil
+}
+
+func (f *failingServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &empty.Empty{}, nil
+}
+
+func newStore(t *testing.T) *connmeta.Store {
+	store, err := connmeta.NewStore(context.Background(), "", time.Hour)
+	require.NoError(t, err)
+	return store
+}
+
+func TestCloseUnknown(t *testing.T) {
+	cc := &deleteCC{}
+	store := newStore(t)
+	store.Set("conn-1", connmeta.ClientInterfaceKey, "client-next")
+	server := chain.NewNetworkServiceServer(
+		orphanclose.NewServer(cc, store),
+		&failingServer{err: errors.New("unknown connection")},
+	)
+
+	_, err := server.Close(context.Background(), &networkservice.Connection{Id: "conn-1"})
+	require.NoError(t, err)
+	require.Len(t, cc.deletes, 1)
+	conf := cc.deletes[0].GetDelete().GetVppConfig()
+	require.Len(t, conf.GetInterfaces(), 2)
+	require.Equal(t, "server-conn-1", conf.GetInterfaces()[0].GetName())
+	require.Equal(t, "client-next", conf.GetInterfaces()[1].GetName())
+	require.Len(t, conf.GetXconnectPairs(), 2)
+
+	// Without a client side interface only the server side one is cleaned up, failures of that are only logged
+	cc.err = errors.New("vpp-agent is gone")
+	_, err = server.Close(context.Background(), &networkservice.Connection{Id: "conn-2"})
+	require.NoError(t, err)
+	require.Len(t, cc.deletes, 2)
+	conf = cc.deletes[1].GetDelete().GetVppConfig()
+	require.Len(t, conf.GetInterfaces(), 1)
+	require.Equal(t, "server-conn-2", conf.GetInterfaces()[0].GetName())
+	require.Empty(t, conf.GetXconnectPairs())
+}
+
+func TestCloseKnown(t *testing.T) {
+	cc := &deleteCC{}
+	store := newStore(t)
+	store.Set("conn-1", connmeta.ServerInterfaceKey, "server-conn-1")
+	next := &failingServer{err: status.Error(codes.PermissionDenied, "denied")}
+	server := chain.NewNetworkServiceServer(orphanclose.NewServer(cc, store), next)
+	conn := &networkservice.Connection{Id: "conn-1"}
+
+	_, err := server.Close(context.Background(), conn)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Empty(t, cc.deletes)
+
+	next.err = status.Error(codes.NotFound, "gone")
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, cc.deletes, 1)
+
+	next.err = nil
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, cc.deletes, 1)
+}
+
+func TestCleanup(t *testing.T) {
+	cc := &deleteCC{}
+	require.NoError(t, orphanclose.Cleanup(context.Background(), cc, "server-conn-1", "client-conn-2"))
+	require.Len(t, cc.deletes, 1)
+	require.Len(t, cc.deletes[0].GetDelete().GetVppConfig().GetXconnectPairs(), 2)
+
+	cc.err = errors.New("vpp-agent is gone")
+	require.Error(t, orphanclose.Cleanup(context.Background(), cc, "server-conn-1", ""))
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package payloadcheck - rejects connections whose payload the vpp interfaces programmed for them cannot carry,
+// instead of leaving a cross connect that drops their traffic.  The forwarder cross connects at layer 2, so every
+// interface of a connection carries ethernet frames, except for memif interfaces in ip mode.
+package payloadcheck
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// Payloads the forwarder carries, a connection without one being treated as IP
+const (
+	Ethernet = "ETHERNET"
+	IP       = "IP"
+)
+
+type payloadKey struct{}
+
+type payloadCheckServer struct{}
+
+// NewServer - returns a server chain element rejecting Requests of unknown payloads and passing the payload of the
+// others on to UnaryClientInterceptor through their ctx
+func NewServer() networkservice.NetworkServiceServer {
+	return &payloadCheckServer{}
+}
+
+func (p *payloadCheckServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	payload := request.GetConnection().GetPayload()
+	switch payload {
+	case "":
+		payload = IP
+	case Ethernet, IP:
+	default:
+		metrics.Int("payload_rejected_requests").Add(1)
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported payload %q, the forwarder carries %s and %s", payload, Ethernet, IP)
+	}
+	return next.Server(ctx).Request(context.WithValue(ctx, payloadKey{}, payload), request)
+}
+
+func (p *payloadCheckServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// UnaryClientInterceptor - returns an interceptor failing, before they reach vpp, the vpp-agent Updates of a Request
+// passed by NewServer that give an ethernet connection an ip framed interface, or cross connect an ip framed
+// interface with an ethernet one
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		payload, ok := ctx.Value(payloadKey{}).(string)
+		update, isUpdate := req.(*configurator.UpdateRequest)
+		if !ok || !isUpdate {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if err := check(payload, update.GetUpdate()); err != nil {
+			metrics.Int("payload_rejected_requests").Add(1)
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// check - returns an error if the interfaces and cross connects of conf cannot carry payload
+func check(payload string, conf *configurator.Config) error {
+	framings := make(map[string]string)
+	for _, iface := range conf.GetVppConfig().GetInterfaces() {
+		framings[iface.GetName()] = framing(iface)
+		if payload == Ethernet && framings[iface.GetName()] != Ethernet {
+			return status.Errorf(codes.InvalidArgument, "vpp interface %s (%s) cannot carry %s payload", iface.GetName(), iface.GetType(), payload)
+		}
+	}
+	for _, xconnect := range conf.GetVppConfig().GetXconnectPairs() {
+		rx, tx := framings[xconnect.GetReceiveInterface()], framings[xconnect.GetTransmitInterface()]
+		if rx != "" && tx != "" && rx != tx {
+			return status.Errorf(codes.InvalidArgument, "cross connect of %s (%s framed) to %s (%s framed) cannot carry %s payload",
+				xconnect.GetReceiveInterface(), rx, xconnect.GetTransmitInterface(), tx, payload)
+		}
+	}
+	return nil
+}
+
+// framing - returns the frames iface carries: IP for memif interfaces in ip mode, Ethernet otherwise
+func framing(iface *vpp_interfaces.Interface) string {
+	if iface.GetType() == vpp_interfaces.Interface_MEMIF && iface.GetMemif().GetMode() == vpp_interfaces.MemifLink_IP {
+		return IP
+	}
+	return Ethernet
+}
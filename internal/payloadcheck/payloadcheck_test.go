@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payloadcheck_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/payloadcheck"
+)
+
+// updateServer - sends its Requests' vpp-agent Update of conf through the payloadcheck interceptor, counting those
+// that reach vpp-agent
+type updateServer struct {
+	conf    *configurator.Config
+	updates int
+}
+
+func (u *updateServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		u.updates++
+		return nil
+	}
+	err := payloadcheck.UnaryClientInterceptor()(ctx, "/vpp-agent", &configurator.UpdateRequest{Update: u.conf}, nil, nil, invoker)
+	if err != nil {
+		return nil, err
+	}
+	return request.GetConnection(), nil
+}
+
+func (u *updateServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+func memif(name string, mode vpp_interfaces.MemifLink_MemifMode) *vpp_interfaces.Interface {
+	return &vpp_interfaces.Interface{
+		Name: name,
+		Type: vpp_interfaces.Interface_MEMIF,
+		Link: &vpp_interfaces.Interface_Memif{Memif: &vpp_interfaces.MemifLink{Mode: mode}},
+	}
+}
+
+func vxlan(name string) *vpp_interfaces.Interface {
+	return &vpp_interfaces.Interface{Name: name, Type: vpp_interfaces.Interface_VXLAN_TUNNEL}
+}
+
+func crossConnect(ifaces ...*vpp_interfaces.Interface) *configurator.Config {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: ifaces}}
+	if len(ifaces) == 2 {
+		conf.GetVppConfig().XconnectPairs = []*vpp.L2XConnect{
+			{ReceiveInterface: ifaces[0].GetName(), TransmitInterface: ifaces[1].GetName()},
+			{ReceiveInterface: ifaces[1].GetName(), TransmitInterface: ifaces[0].GetName()},
+		}
+	}
+	return conf
+}
+
+func TestPayloadCheck(t *testing.T) {
+	for _, sample := range []struct {
+		name    string
+		payload string
+		conf    *configurator.Config
+		code    codes.Code
+	}{
+		{"unknown payload", "MPLS", crossConnect(memif("server-1", vpp_interfaces.MemifLink_ETHERNET)), codes.InvalidArgument},
+		{"ethernet over ethernet", payloadcheck.Ethernet, crossConnect(memif("server-1", vpp_interfaces.MemifLink_ETHERNET), vxlan("client-1")), codes.OK},
+		{"ethernet over an ip memif", payloadcheck.Ethernet, crossConnect(memif("server-1", vpp_interfaces.MemifLink_IP)), codes.InvalidArgument},
+		{"ip by default", "", crossConnect(memif("server-1", vpp_interfaces.MemifLink_IP), memif("client-1", vpp_interfaces.MemifLink_IP)), codes.OK},
+		{"ip memif to ethernet", payloadcheck.IP, crossConnect(memif("server-1", vpp_interfaces.MemifLink_IP), vxlan("client-1")), codes.InvalidArgument},
+		{"ip over ethernet", payloadcheck.IP, crossConnect(vxlan("server-1"), vxlan("client-1")), codes.OK},
+	} {
+		next := &updateServer{conf: sample.conf}
+		server := chain.NewNetworkServiceServer(payloadcheck.NewServer(), next)
+		_, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+			Connection: &networkservice.Connection{Id: "conn-1", Payload: sample.payload},
+		})
+		require.Equal(t, sample.code, status.Code(err), "%s: %v", sample.name, err)
+		if sample.code == codes.OK {
+			require.Equal(t, 1, next.updates, sample.name)
+		} else {
+			require.Equal(t, 0, next.updates, "%s: the update reached vpp-agent", sample.name)
+		}
+	}
+}
+
+func TestInterceptorOutsideOfRequests(t *testing.T) {
+	reached := false
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		reached = true
+		return nil
+	}
+	update := &configurator.UpdateRequest{Update: crossConnect(memif("server-1", vpp_interfaces.MemifLink_IP), vxlan("client-1"))}
+	require.NoError(t, payloadcheck.UnaryClientInterceptor()(context.Background(), "/vpp-agent", update, nil, nil, invoker))
+	require.True(t, reached, "an update outside of a Request was checked")
+}
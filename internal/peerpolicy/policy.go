@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peerpolicy - allow/deny list of peer spiffe ids loaded from a file and reloaded whenever the file changes.
+//
+// Each non empty line of the file that does not start with '#' is a spiffe id.  Ids prefixed with '!' are denied.
+// A peer is denied if its id is denied, or if any id is allowed and its id is not one of them.
+package peerpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/x509"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+)
+
+const reloadInterval = time.Second
+
+type rules struct {
+	allow map[string]struct{}
+	deny  map[string]struct{}
+}
+
+// Policy - peer policy backed by a file, the zero Policy permits every peer
+type Policy struct {
+	filename string
+	rules    atomic.Value
+}
+
+// NewFile - returns a Policy loaded from filename and reloaded on change until ctx is done
+func NewFile(ctx context.Context, filename string) (*Policy, error) {
+	p := &Policy{filename: filename}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	go p.watch(ctx, info)
+	return p, nil
+}
+
+// Authorize - returns an error if id is not permitted by the policy, may be used as a tlsconfig.Authorizer
+func (p *Policy) Authorize(id spiffeid.ID, _ [][]*x509.Certificate) error {
+	return p.Check(id.String())
+}
+
+// Enforced - returns true if the policy allows or denies any peer, and so denies peers without a spiffe id
+func (p *Policy) Enforced() bool {
+	r, ok := p.rules.Load().(*rules)
+	return ok && (len(r.allow) > 0 || len(r.deny) > 0)
+}
+
+// Check - returns an error if the spiffe id is not permitted by the policy
+func (p *Policy) Check(id string) error {
+	r, ok := p.rules.Load().(*rules)
+	if !ok {
+		return nil
+	}
+	var err error
+	if _, ok := r.deny[id]; ok {
+		err = errors.Errorf("peer %q is denied by %s", id, p.filename)
+	} else if _, ok := r.allow[id]; len(r.allow) > 0 && !ok {
+		err = errors.Errorf("peer %q is not allowed by %s", id, p.filename)
+	}
+	if err != nil {
+		events.Emit(events.Warning, "PeerDenied", err.Error())
+	}
+	return err
+}
+
+func (p *Policy) watch(ctx context.Context, last os.FileInfo) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(p.filename)
+		if err != nil || (info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+			continue
+		}
+		last = info
+		if err := p.load(); err != nil {
+			log.Entry(ctx).Errorf("failed to reload peer policy, keeping the previous one: %+v", err)
+			continue
+		}
+		log.Entry(ctx).Infof("reloaded peer policy from %s", p.filename)
+	}
+}
+
+func (p *Policy) load() error {
+	f, err := os.Open(p.filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+	r := &rules{
+		allow: make(map[string]struct{}),
+		deny:  make(map[string]struct{}),
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		target := r.allow
+		if strings.HasPrefix(line, "!") {
+			target = r.deny
+			line = strings.TrimSpace(strings.TrimPrefix(line, "!"))
+		}
+		id, err := spiffeid.FromString(line)
+		if err != nil {
+			return errors.Wrapf(err, "invalid spiffe id %q in %s", line, p.filename)
+		}
+		target[id.String()] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	p.rules.Store(r)
+	return nil
+}
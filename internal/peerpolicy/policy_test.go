@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerpolicy_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/peerpolicy"
+)
+
+const (
+	nsmgr    = "spiffe://example.org/nsmgr"
+	intruder = "spiffe://example.org/intruder"
+)
+
+func writePolicy(t *testing.T, filename, content string) {
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0600))
+}
+
+func TestPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerpolicy")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "allowlist")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writePolicy(t, filename, "# empty allow list permits everyone\n")
+	policy, err := peerpolicy.NewFile(ctx, filename)
+	require.NoError(t, err)
+	require.NoError(t, policy.Check(nsmgr))
+	require.NoError(t, policy.Check(intruder))
+	require.False(t, policy.Enforced())
+
+	writePolicy(t, filename, "!"+intruder+"\n")
+	require.Eventually(t, func() bool { return policy.Check(intruder) != nil }, 5*time.Second, 100*time.Millisecond)
+	require.NoError(t, policy.Check(nsmgr))
+
+	writePolicy(t, filename, nsmgr+"\n")
+	require.Eventually(t, func() bool { return policy.Check(intruder) != nil && policy.Check(nsmgr) == nil }, 5*time.Second, 100*time.Millisecond)
+
+	writePolicy(t, filename, "not a spiffe id\n")
+	time.Sleep(2 * time.Second)
+	require.NoError(t, policy.Check(nsmgr), "an invalid file must not replace the previous policy")
+}
+
+func TestServerDeniesAnonymousPeers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerpolicy")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "allowlist")
+	writePolicy(t, filename, nsmgr+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	policy, err := peerpolicy.NewFile(ctx, filename)
+	require.NoError(t, err)
+	require.True(t, policy.Enforced())
+
+	// No peer in ctx, as for the Requests of an insecure listener
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: "conn-1"}}
+	_, err = peerpolicy.NewServer(policy).Request(ctx, request)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
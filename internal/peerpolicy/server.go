@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerpolicy
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+type peerPolicyServer struct {
+	policy *Policy
+}
+
+// NewServer - returns a server chain element rejecting Requests from peers not permitted by policy, so that peers
+// removed from the policy are cut off even on already established connections.  Peers without a verified spiffe id,
+// e.g. those of insecure listeners, are rejected whenever the policy is enforced.
+func NewServer(policy *Policy) networkservice.NetworkServiceServer {
+	return &peerPolicyServer{policy: policy}
+}
+
+func (p *peerPolicyServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if err := p.check(ctx); err != nil {
+		return nil, err
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (p *peerPolicyServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	// Close is always permitted so that connections of a denied peer can still be torn down
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func (p *peerPolicyServer) check(ctx context.Context) error {
+	var tlsInfo credentials.TLSInfo
+	if pr, ok := peer.FromContext(ctx); ok {
+		tlsInfo, _ = pr.AuthInfo.(credentials.TLSInfo)
+	}
+	if len(tlsInfo.State.PeerCertificates) == 0 {
+		// Peers of insecure listeners have no identity the policy could permit
+		if p.policy.Enforced() {
+			metrics.Int("peer_policy_anonymous_denied").Add(1)
+			return status.Error(codes.PermissionDenied, "peer has no verified spiffe id to check against the peer policy")
+		}
+		return nil
+	}
+	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err := p.policy.Check(id.String()); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package pingcheck
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+// retryInterval - how long to wait for each echo reply before sending another echo request, the first ones are
+// commonly lost to arp or neighbor discovery
+const retryInterval = 100 * time.Millisecond
+
+// Ping - sends echo requests to dst from the netns referenced by netnsURL until one is answered, or timeout passes
+func Ping(netnsURL string, dst net.IP, timeout time.Duration) error {
+	v4 := dst.To4() != nil
+	fd, err := socketAt(netnsURL, v4)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unix.Close(fd) }()
+	var remote unix.Sockaddr
+	if v4 {
+		sa := &unix.SockaddrInet4{}
+		copy(sa.Addr[:], dst.To4())
+		remote = sa
+	} else {
+		sa := &unix.SockaddrInet6{}
+		copy(sa.Addr[:], dst.To16())
+		remote = sa
+	}
+	id := uint16(os.Getpid())
+	deadline := time.Now().Add(timeout)
+	for seq := uint16(1); time.Now().Before(deadline); seq++ {
+		if err = unix.Sendto(fd, probe.EchoRequest(v4, id, seq, 0), 0, remote); err != nil {
+			return errors.Wrapf(err, "failed to send echo request to %s", dst)
+		}
+		wait := retryInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		if awaitReply(fd, v4, id, seq, wait) {
+			return nil
+		}
+	}
+	return errors.Errorf("no echo reply from %s within %s", dst, timeout)
+}
+
+// awaitReply - whether the echo reply to id and seq arrives on fd within wait, other icmp messages are skipped
+func awaitReply(fd int, v4 bool, id, seq uint16, wait time.Duration) bool {
+	end := time.Now().Add(wait)
+	buf := make([]byte, 1500)
+	for {
+		remaining := time.Until(end)
+		if remaining <= 0 {
+			return false
+		}
+		if probe.SetReceiveTimeout(fd, remaining) != nil {
+			return false
+		}
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return false
+		}
+		if probe.IsEchoReply(v4, buf[:n], id, seq) {
+			return true
+		}
+	}
+}
+
+// socketAt - opens a raw icmp (v4) or icmpv6 socket in the netns referenced by netnsURL
+func socketAt(netnsURL string, v4 bool) (int, error) {
+	u, err := url.Parse(netnsURL)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if u.Scheme != "file" {
+		return 0, errors.Errorf("unsupported netns url: %q", netnsURL)
+	}
+	nsHandle, err := netns.GetFromPath(u.Path)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer func() { _ = nsHandle.Close() }()
+
+	// Sockets belong to the netns of the thread opening them, and stay there
+	runtime.LockOSThread()
+	curNetns, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return 0, errors.WithStack(err)
+	}
+	defer func() { _ = curNetns.Close() }()
+	if err = netns.Set(nsHandle); err != nil {
+		runtime.UnlockOSThread()
+		return 0, errors.WithStack(err)
+	}
+	defer func() {
+		if netns.Set(curNetns) == nil {
+			// A thread left in the wrong netns is not handed back, it exits with its goroutine
+			runtime.UnlockOSThread()
+		}
+	}()
+	domain, protocol := unix.AF_INET6, unix.IPPROTO_ICMPV6
+	if v4 {
+		domain, protocol = unix.AF_INET, unix.IPPROTO_ICMP
+	}
+	fd, err := unix.Socket(domain, unix.SOCK_RAW, protocol)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open an icmp socket")
+	}
+	return fd, nil
+}
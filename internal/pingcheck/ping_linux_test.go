@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pingcheck_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pingcheck"
+)
+
+const selfNetns = "file:///proc/self/ns/net"
+
+func TestPing(t *testing.T) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+	if err != nil {
+		t.Skip("icmp sockets need CAP_NET_RAW")
+	}
+	_ = unix.Close(fd)
+
+	require.NoError(t, pingcheck.Ping(selfNetns, net.IPv4(127, 0, 0, 1), time.Second))
+
+	start := time.Now()
+	require.Error(t, pingcheck.Ping(selfNetns, net.ParseIP("192.0.2.1"), 300*time.Millisecond))
+	require.True(t, time.Since(start) < time.Second, "gives up at the timeout")
+}
+
+func TestPingNetns(t *testing.T) {
+	require.Error(t, pingcheck.Ping("file:///nonexistent/netns", net.IPv4(127, 0, 0, 1), time.Second))
+	require.Error(t, pingcheck.Ping("inode://4/4026531992", net.IPv4(127, 0, 0, 1), time.Second))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pingcheck - verifies the dataplane of kernel connections before their Request succeeds, by pinging the
+// endpoint's address from the client's netns, so that misprogrammed connections fail at setup time rather than
+// when the workload first uses them
+package pingcheck
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+type pingCheckServer struct {
+	timeout time.Duration
+}
+
+// NewServer - returns a server chain element failing the Request of kernel connections whose client cannot ping the
+// dst address of their ip context within timeout.  A timeout of 0 disables the check.
+func NewServer(timeout time.Duration) networkservice.NetworkServiceServer {
+	return &pingCheckServer{timeout: timeout}
+}
+
+func (p *pingCheckServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || p.timeout == 0 || conn.GetMechanism().GetType() != kernel.MECHANISM {
+		return conn, err
+	}
+	dst, _, parseErr := net.ParseCIDR(conn.GetContext().GetIpContext().GetDstIpAddr())
+	if parseErr != nil {
+		// Nothing to ping, the connection is plain l2
+		return conn, nil
+	}
+	timeout := p.timeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	start := time.Now()
+	if pingErr := Ping(conn.GetMechanism().GetParameters()[kernel.NetNSURL], dst, timeout); pingErr != nil {
+		metrics.Int("ping_check_failures").Add(1)
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(pingErr, "connection %s failed its ping check", conn.GetId())
+	}
+	log.Entry(ctx).Debugf("connection %s passed its ping check of %s in %s", conn.GetId(), dst, time.Since(start))
+	return conn, nil
+}
+
+func (p *pingCheckServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pingcheck_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/memif"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pingcheck"
+)
+
+// closeCounter - counts the Closes reaching it
+type closeCounter struct {
+	closes int
+}
+
+func (c *closeCounter) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return request.GetConnection(), nil
+}
+
+func (c *closeCounter) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	c.closes++
+	return &empty.Empty{}, nil
+}
+
+func request(mechanism, dstIPAddr string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{
+		Id: "conn-1",
+		Mechanism: &networkservice.Mechanism{
+			Type:       mechanism,
+			Parameters: map[string]string{kernel.NetNSURL: "inode://4/4026531992"},
+		},
+		Context: &networkservice.ConnectionContext{
+			IpContext: &networkservice.IPContext{DstIpAddr: dstIPAddr},
+		},
+	}}
+}
+
+func TestPingCheckFailure(t *testing.T) {
+	counter := &closeCounter{}
+	server := chain.NewNetworkServiceServer(pingcheck.NewServer(time.Second), counter)
+
+	_, err := server.Request(context.Background(), request(kernel.MECHANISM, "10.0.0.1/32"))
+	require.Error(t, err)
+	require.Equal(t, 1, counter.closes, "the failed connection is torn down")
+}
+
+func TestPingCheckSkipped(t *testing.T) {
+	counter := &closeCounter{}
+	for _, sample := range []struct {
+		name      string
+		timeout   time.Duration
+		mechanism string
+		dst       string
+	}{
+		{"disabled", 0, kernel.MECHANISM, "10.0.0.1/32"},
+		{"not kernel", time.Second, memif.MECHANISM, "10.0.0.1/32"},
+		{"l2 only", time.Second, kernel.MECHANISM, ""},
+	} {
+		server := chain.NewNetworkServiceServer(pingcheck.NewServer(sample.timeout), counter)
+		_, err := server.Request(context.Background(), request(sample.mechanism, sample.dst))
+		require.NoError(t, err, sample.name)
+	}
+	require.Zero(t, counter.closes)
+}
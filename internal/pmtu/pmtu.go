@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pmtu - probes the path mtu of the underlay to the remote end of every vxlan tunnel after it is set up and
+// periodically thereafter, lowering the mtu of the tunnels to a peer whose path mtu shrinks so that oversized packets
+// are dropped (and reported) at the tunnel rather than silently lost in the underlay
+package pmtu
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+const (
+	// Overhead - bytes vxlan adds to a packet: outer ipv4, udp, vxlan and inner ethernet headers
+	Overhead = 20 + 8 + 8 + 14
+	// minMTU - path mtu assumed to work, below which a peer is considered unreachable rather than probed further
+	minMTU = 576
+)
+
+// ownUpdate - context key marking the Updates of the Prober itself, which must not be recorded as the tunnels as sent
+type ownUpdate struct{}
+
+// Prober - tracks the vxlan tunnels passing through its interceptor and, once started, probes the path mtu to the
+// remote end of each.  The zero value is ready to use.
+type Prober struct {
+	mu sync.Mutex
+	// peers - the vxlan interfaces to each peer as last sent to vpp-agent, before the mtu is applied
+	peers    probe.Peers
+	pmtus    map[string]int
+	probeCh  chan string
+	handlers []ChangeHandler
+}
+
+// ChangeHandler - called with the vpp-agent names of tunnels and the mtu they are given after the path mtu to their
+// peer changed
+type ChangeHandler func(ctx context.Context, tunnels []string, mtu uint32)
+
+// UnaryClientInterceptor - returns an interceptor recording the vxlan tunnels created and deleted by the vpp-agent
+// calls passing through, and capping their mtu at the path mtu of their peer less Overhead once it is known.  The mtu
+// is capped on a copy of the Update, leaving the caller's as it was.
+func (p *Prober) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if ctx.Value(ownUpdate{}) != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		put, deleted := probe.Tunnels(req)
+		if update, ok := req.(*configurator.UpdateRequest); ok && len(put) > 0 {
+			req = proto.Clone(update)
+			put, _ = probe.Tunnels(req)
+		}
+		for _, iface := range put {
+			p.add(iface)
+		}
+		for _, iface := range deleted {
+			p.remove(iface)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Start - probes the path mtu from localIP to each tunnel peer once it is seen and every interval until ctx is done,
+// up to the mtu of the interface with localIP, and updates the mtu of the tunnels using vppagentCC when it changes
+func (p *Prober) Start(ctx context.Context, vppagentCC grpc.ClientConnInterface, localIP net.IP, interval time.Duration) error {
+	maxMTU, err := linkMTU(localIP)
+	if err != nil {
+		return err
+	}
+	client := configurator.NewConfiguratorServiceClient(vppagentCC)
+	p.mu.Lock()
+	p.probeCh = make(chan string, 16)
+	for _, addr := range p.peers.Addrs() {
+		select {
+		case p.probeCh <- addr:
+		default:
+			// Left to the first tick
+		}
+	}
+	p.mu.Unlock()
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			var addrs []string
+			select {
+			case <-ctx.Done():
+				return
+			case addr := <-p.probeCh:
+				addrs = append(addrs, addr)
+			case <-ticker.C:
+				p.mu.Lock()
+				addrs = p.peers.Addrs()
+				p.mu.Unlock()
+			}
+			for _, addr := range addrs {
+				p.probe(ctx, client, localIP, addr, maxMTU)
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *Prober) probe(ctx context.Context, client configurator.ConfiguratorServiceClient, localIP net.IP, addr string, maxMTU int) {
+	pmtu, err := discover(ctx, localIP, net.ParseIP(addr), minMTU, maxMTU)
+	if err != nil {
+		log.Entry(ctx).Warnf("failed to probe the path mtu to %s: %+v", addr, err)
+		return
+	}
+	metrics.Int("tunnel_pmtu." + addr).Set(int64(pmtu))
+	p.mu.Lock()
+	previous := p.pmtus[addr]
+	if !p.peers.Has(addr) || previous == pmtu {
+		p.mu.Unlock()
+		return
+	}
+	if p.pmtus == nil {
+		p.pmtus = make(map[string]int)
+	}
+	p.pmtus[addr] = pmtu
+	var tunnels []*vpp_interfaces.Interface
+	for _, iface := range p.peers.Tunnels(addr) {
+		tunnel := proto.Clone(iface).(*vpp_interfaces.Interface)
+		capMTU(tunnel, pmtu)
+		tunnels = append(tunnels, tunnel)
+	}
+	names := p.peers.Names(addr)
+	handlers := p.handlers
+	p.mu.Unlock()
+	if previous != 0 && pmtu < previous {
+		metrics.Int("tunnel_pmtu_shrinks").Add(1)
+		log.Entry(ctx).Warnf("path mtu to tunnel peer %s shrank from %d to %d", addr, previous, pmtu)
+	}
+	if len(tunnels) == 0 {
+		return
+	}
+	update := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: tunnels}}
+	if _, err := client.Update(context.WithValue(ctx, ownUpdate{}, true), &configurator.UpdateRequest{Update: update}); err != nil {
+		log.Entry(ctx).Warnf("failed to apply the path mtu %d to the tunnels to %s: %+v", pmtu, addr, err)
+	}
+	for _, handler := range handlers {
+		handler(ctx, names, uint32(pmtu-Overhead))
+	}
+}
+
+// OnChange - registers handler to be called whenever the mtu of tunnels changes with the path mtu to their peer
+func (p *Prober) OnChange(handler ChangeHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// TunnelMTU - returns the mtu the path mtu of its peer allows the tunnel with the vpp-agent name name, 0 if not known
+func (p *Prober) TunnelMTU(name string) uint32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if addr, ok := p.peers.Peer(name); ok && p.pmtus[addr] != 0 {
+		return uint32(p.pmtus[addr] - Overhead)
+	}
+	return 0
+}
+
+func (p *Prober) add(iface *vpp_interfaces.Interface) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.peers.Put(iface) && p.probeCh != nil {
+		select {
+		case p.probeCh <- iface.GetVxlan().GetDstAddress():
+		default:
+		}
+	}
+	capMTU(iface, p.pmtus[iface.GetVxlan().GetDstAddress()])
+}
+
+func (p *Prober) remove(iface *vpp_interfaces.Interface) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.peers.Delete(iface) {
+		delete(p.pmtus, iface.GetVxlan().GetDstAddress())
+	}
+}
+
+// capMTU - lowers the mtu of tunnel to what fits a path mtu of pmtu, if known
+func capMTU(tunnel *vpp_interfaces.Interface, pmtu int) {
+	if pmtu == 0 {
+		return
+	}
+	if mtu := uint32(pmtu - Overhead); tunnel.GetMtu() == 0 || tunnel.GetMtu() > mtu {
+		tunnel.Mtu = mtu
+	}
+}
+
+func linkMTU(ip net.IP) (int, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return ifaces[i].MTU, nil
+			}
+		}
+	}
+	return 0, errors.Errorf("no interface has the tunnel ip %s", ip)
+}
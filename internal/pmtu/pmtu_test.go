@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pmtu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+)
+
+func tunnel(name, dst string, mtu uint32) *vpp_interfaces.Interface {
+	return &vpp_interfaces.Interface{
+		Name: name,
+		Type: vpp_interfaces.Interface_VXLAN_TUNNEL,
+		Mtu:  mtu,
+		Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{DstAddress: dst}},
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	p := &Prober{pmtus: map[string]int{"10.0.0.2": 1500}}
+	var sent interface{}
+	invoker := func(_ context.Context, _ string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		sent = req
+		return nil
+	}
+	interceptor := p.UnaryClientInterceptor()
+	config := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: []*vpp_interfaces.Interface{
+		tunnel("vxlan-1", "10.0.0.2", 9000),
+		tunnel("vxlan-2", "10.0.0.2", 1000),
+		tunnel("vxlan-3", "10.0.0.3", 9000),
+	}}}
+	update := &configurator.UpdateRequest{Update: config}
+	original := proto.Clone(update)
+
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update", update, nil, nil, invoker))
+	ifaces := sent.(*configurator.UpdateRequest).GetUpdate().GetVppConfig().GetInterfaces()
+	require.Equal(t, uint32(1500-Overhead), ifaces[0].GetMtu(), "capped at the path mtu")
+	require.Equal(t, uint32(1000), ifaces[1].GetMtu(), "lower mtus are kept")
+	require.Equal(t, uint32(9000), ifaces[2].GetMtu(), "path mtu not known yet")
+	require.True(t, proto.Equal(original, update), "the caller's Update is left as it was")
+
+	require.Equal(t, uint32(1500-Overhead), p.TunnelMTU("vxlan-1"))
+	require.Zero(t, p.TunnelMTU("vxlan-3"))
+	require.Zero(t, p.TunnelMTU("vxlan-4"))
+
+	// The path mtu is forgotten with the last tunnel to the peer
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Delete",
+		&configurator.DeleteRequest{Delete: config}, nil, nil, invoker))
+	require.Zero(t, p.TunnelMTU("vxlan-1"))
+	require.Empty(t, p.pmtus)
+}
+
+func TestOwnUpdatesNotRecorded(t *testing.T) {
+	p := &Prober{}
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		return nil
+	}
+	update := &configurator.UpdateRequest{Update: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{tunnel("vxlan-1", "10.0.0.2", 1450)},
+	}}}
+	ctx := context.WithValue(context.Background(), ownUpdate{}, true)
+	require.NoError(t, p.UnaryClientInterceptor()(ctx, "/ligato.configurator.ConfiguratorService/Update", update, nil, nil, invoker))
+	require.Empty(t, p.peers.Addrs())
+}
+
+func TestCapMTU(t *testing.T) {
+	for _, sample := range []struct {
+		mtu, want uint32
+		pmtu      int
+	}{
+		{0, 1450, 1500},
+		{9000, 1450, 1500},
+		{1400, 1400, 1500},
+		{9000, 9000, 0},
+	} {
+		iface := &vpp_interfaces.Interface{Mtu: sample.mtu}
+		capMTU(iface, sample.pmtu)
+		require.Equal(t, sample.want, iface.GetMtu(), sample)
+	}
+}
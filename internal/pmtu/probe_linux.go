@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package pmtu
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+const (
+	probeTimeout = time.Second
+	probeTries   = 2
+	ipHeaderLen  = 20
+)
+
+// discover - returns the largest packet size between lo and hi that reaches dst from src unfragmented, found by a
+// binary search of icmp echoes with the don't fragment bit set
+func discover(ctx context.Context, src, dst net.IP, lo, hi int) (int, error) {
+	if dst.To4() == nil || src.To4() == nil {
+		return 0, errors.Errorf("path mtu probing needs ipv4 addresses, got %s to %s", src, dst)
+	}
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open an icmp socket")
+	}
+	defer func() { _ = unix.Close(fd) }()
+	// Set the don't fragment bit, ignoring the path mtu the kernel may have cached
+	if err = unix.SetsockoptInt(fd, unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_PROBE); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if err = probe.SetReceiveTimeout(fd, probeTimeout); err != nil {
+		return 0, err
+	}
+	local := &unix.SockaddrInet4{}
+	copy(local.Addr[:], src.To4())
+	if err = unix.Bind(fd, local); err != nil {
+		return 0, errors.Wrapf(err, "failed to bind to %s", src)
+	}
+	remote := &unix.SockaddrInet4{}
+	copy(remote.Addr[:], dst.To4())
+	id := uint16(os.Getpid())
+	seq := uint16(0)
+	reaches := func(size int) bool {
+		for try := 0; try < probeTries && ctx.Err() == nil; try++ {
+			seq++
+			if echo(fd, remote, id, seq, size) {
+				return true
+			}
+		}
+		return false
+	}
+	if !reaches(lo) {
+		return 0, errors.Errorf("%s does not answer %d byte icmp echoes", dst, lo)
+	}
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if reaches(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, ctx.Err()
+}
+
+// echo - sends an icmp echo making a size byte ip packet and reports whether its reply arrived in time
+func echo(fd int, remote *unix.SockaddrInet4, id, seq uint16, size int) bool {
+	if err := unix.Sendto(fd, probe.EchoRequest(true, id, seq, size-ipHeaderLen), 0, remote); err != nil {
+		// EMSGSIZE: larger than the mtu of the local interface
+		return false
+	}
+	deadline := time.Now().Add(probeTimeout)
+	buf := make([]byte, size+ipHeaderLen)
+	for time.Now().Before(deadline) {
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return false
+		}
+		if addr, ok := from.(*unix.SockaddrInet4); ok && addr.Addr == remote.Addr && probe.IsEchoReply(true, buf[:n], id, seq) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prewarm - warms vpp's state about each other forwarder found in the registry ahead of any connection to it:
+// its underlay neighbor, and the bfd session, path mtu and latency probes kept per peer, so that they are known
+// before the first remote connection to it rather than found out as part of it.
+//
+// The state is set up through a tunnel shell per peer: a plain vxlan tunnel with a vni of its own, carrying no
+// connection.  Connections do not reuse shells, each remote connection still creates its own vxlan tunnel; what
+// carries over is what vpp and the per peer monitors hold for the peer address, the neighbor being resolved by their
+// probes.  Peers are found by the TunnelIP they advertise in the TunnelIPLabel of their registration.  Wireguard
+// peers are not supported: vpp-agent v3.1 has no wireguard.
+package prewarm
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/idalloc"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+const (
+	// TunnelIPLabel - the label of forwarder registrations advertising their TunnelIP
+	TunnelIPLabel = "tunnelIP"
+	// prefix - the prefix of the vpp-agent names of tunnel shells
+	prefix = "prewarm-"
+	// inheritedGrace - how long shells left by a previous forwarder process are kept without their peer being found
+	inheritedGrace = time.Minute
+	pruneInterval  = 10 * time.Second
+	retryPeriod    = 5 * time.Second
+)
+
+// Shell - returns whether name is the vpp-agent name of a tunnel shell
+func Shell(name string) bool {
+	return strings.HasPrefix(name, prefix)
+}
+
+type shell struct {
+	expires map[string]time.Time
+}
+
+type prewarmer struct {
+	client  configurator.ConfiguratorServiceClient
+	ids     *idalloc.Allocator
+	name    string
+	localIP net.IP
+	shells  map[string]*shell
+}
+
+// Watch - until ctx is done, keeps a tunnel shell from localIP, using vppagentCC and a vni from ids, to the TunnelIP
+// of each forwarder other than name that registryCC finds, as long as its registration lasts
+func Watch(ctx context.Context, registryCC, vppagentCC grpc.ClientConnInterface, ids *idalloc.Allocator, name string, localIP net.IP) {
+	p := &prewarmer{
+		client:  configurator.NewConfiguratorServiceClient(vppagentCC),
+		ids:     ids,
+		name:    name,
+		localIP: localIP,
+		shells:  make(map[string]*shell),
+	}
+	p.inherit(ctx)
+	found := make(chan *registry.NetworkServiceEndpoint)
+	go func() {
+		for {
+			if err := find(ctx, registryCC, found); err != nil && ctx.Err() == nil {
+				log.Entry(ctx).Warnf("failed to watch the registry for tunnel peers, retrying in %s: %+v", retryPeriod, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryPeriod):
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case nse := <-found:
+				p.found(ctx, nse)
+			case <-ticker.C:
+			}
+			p.prune(ctx)
+		}
+	}()
+}
+
+// find - sends the forwarders registryCC finds, and their later updates, to found until the watch ends
+func find(ctx context.Context, registryCC grpc.ClientConnInterface, found chan<- *registry.NetworkServiceEndpoint) error {
+	stream, err := registry.NewNetworkServiceEndpointRegistryClient(registryCC).Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"forwarder"}},
+		Watch:                  true,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for {
+		nse, err := stream.Recv()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		select {
+		case found <- nse:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// inherit - adopts the shells a previous forwarder process left in vpp, until their peers are found again
+func (p *prewarmer) inherit(ctx context.Context) {
+	dump, err := p.client.Dump(ctx, &configurator.DumpRequest{})
+	if err != nil {
+		log.Entry(ctx).Warnf("failed to dump the tunnel shells of a previous forwarder process: %+v", err)
+		return
+	}
+	for _, iface := range dump.GetDump().GetVppConfig().GetInterfaces() {
+		if Shell(iface.GetName()) {
+			p.shells[iface.GetVxlan().GetDstAddress()] = &shell{expires: map[string]time.Time{"": time.Now().Add(inheritedGrace)}}
+			metrics.Int("tunnel_shells").Add(1)
+		}
+	}
+}
+
+func (p *prewarmer) found(ctx context.Context, nse *registry.NetworkServiceEndpoint) {
+	if nse.GetName() == p.name {
+		return
+	}
+	peer := net.ParseIP(nse.GetNetworkServiceLabels()["forwarder"].GetLabels()[TunnelIPLabel])
+	if peer == nil || peer.Equal(p.localIP) {
+		return
+	}
+	expires, err := ptypes.Timestamp(nse.GetExpirationTime())
+	if err != nil {
+		expires = time.Now().Add(pruneInterval)
+	}
+	s, ok := p.shells[peer.String()]
+	if !ok {
+		if err = p.create(ctx, peer); err != nil {
+			log.Entry(ctx).Warnf("failed to set up a tunnel shell to forwarder %s at %s: %+v", nse.GetName(), peer, err)
+			return
+		}
+		s = &shell{expires: make(map[string]time.Time)}
+		p.shells[peer.String()] = s
+	}
+	s.expires[nse.GetName()] = expires
+}
+
+// prune - deletes the shells to peers no registration of which lasts
+func (p *prewarmer) prune(ctx context.Context) {
+	now := time.Now()
+	for peer, s := range p.shells {
+		for name, expires := range s.expires {
+			if !expires.After(now) {
+				delete(s.expires, name)
+			}
+		}
+		if len(s.expires) > 0 {
+			continue
+		}
+		if err := p.delete(ctx, peer); err != nil {
+			log.Entry(ctx).Warnf("failed to delete the tunnel shell to %s: %+v", peer, err)
+			continue
+		}
+		delete(p.shells, peer)
+	}
+}
+
+func (p *prewarmer) create(ctx context.Context, peer net.IP) error {
+	vni, err := p.ids.Allocate(idalloc.VNI, prefix+peer.String())
+	if err != nil {
+		return err
+	}
+	if _, err = p.client.Update(ctx, &configurator.UpdateRequest{Update: p.config(peer.String(), vni)}); err != nil {
+		p.ids.Release(prefix + peer.String())
+		return errors.WithStack(err)
+	}
+	metrics.Int("tunnel_shells").Add(1)
+	log.Entry(ctx).Infof("set up a tunnel shell to %s", peer)
+	return nil
+}
+
+func (p *prewarmer) delete(ctx context.Context, peer string) error {
+	if _, err := p.client.Delete(ctx, &configurator.DeleteRequest{Delete: p.config(peer, 0)}); err != nil {
+		return errors.WithStack(err)
+	}
+	p.ids.Release(prefix + peer)
+	metrics.Int("tunnel_shells").Add(-1)
+	log.Entry(ctx).Infof("deleted the tunnel shell to %s", peer)
+	return nil
+}
+
+func (p *prewarmer) config(peer string, vni uint32) *configurator.Config {
+	return &configurator.Config{
+		VppConfig: &vpp.ConfigData{
+			Interfaces: []*vpp_interfaces.Interface{{
+				Name:    prefix + peer,
+				Type:    vpp_interfaces.Interface_VXLAN_TUNNEL,
+				Enabled: true,
+				Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{
+					SrcAddress: p.localIP.String(),
+					DstAddress: peer,
+					Vni:        vni,
+				}},
+			}},
+		},
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package prewarm
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/idalloc"
+)
+
+// agentCC - a vpp-agent dumping dump and recording the shells set up and deleted
+type agentCC struct {
+	dump    *configurator.Config
+	created []*vpp_interfaces.Interface
+	deleted []string
+}
+
+func (c *agentCC) Invoke(_ context.Context, _ string, args, reply interface{}, _ ...grpc.CallOption) error {
+	switch req := args.(type) {
+	case *configurator.DumpRequest:
+		proto.Merge(reply.(proto.Message), &configurator.DumpResponse{Dump: c.dump})
+	case *configurator.UpdateRequest:
+		c.created = append(c.created, req.GetUpdate().GetVppConfig().GetInterfaces()...)
+	case *configurator.DeleteRequest:
+		for _, iface := range req.GetDelete().GetVppConfig().GetInterfaces() {
+			c.deleted = append(c.deleted, iface.GetName())
+		}
+	default:
+		return errors.Errorf("unexpected %T", args)
+	}
+	return nil
+}
+
+func (c *agentCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func newPrewarmer(t *testing.T, cc *agentCC) *prewarmer {
+	ids, err := idalloc.NewAllocator(context.Background(), "", map[string]idalloc.Range{idalloc.VNI: {Min: 100, Max: 199}})
+	require.NoError(t, err)
+	return &prewarmer{
+		client:  configurator.NewConfiguratorServiceClient(cc),
+		ids:     ids,
+		name:    "forwarder-1",
+		localIP: net.ParseIP("10.0.0.1"),
+		shells:  make(map[string]*shell),
+	}
+}
+
+// forwarder - the registration of forwarder name advertising tunnelIP, lasting for ttl
+func forwarder(t *testing.T, name, tunnelIP string, ttl time.Duration) *registry.NetworkServiceEndpoint {
+	expires, err := ptypes.TimestampProto(time.Now().Add(ttl))
+	require.NoError(t, err)
+	return &registry.NetworkServiceEndpoint{
+		Name:                name,
+		NetworkServiceNames: []string{"forwarder"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"forwarder": {Labels: map[string]string{TunnelIPLabel: tunnelIP}},
+		},
+		ExpirationTime: expires,
+	}
+}
+
+func TestShell(t *testing.T) {
+	require.True(t, Shell(prefix+"10.0.0.2"))
+	require.False(t, Shell("vxlan-10.0.0.2"))
+}
+
+func TestFoundSetsUpOneShellPerPeer(t *testing.T) {
+	cc := &agentCC{}
+	p := newPrewarmer(t, cc)
+
+	// Neither this forwarder nor forwarders not advertising another tunnel ip get a shell
+	p.found(context.Background(), forwarder(t, "forwarder-1", "10.0.0.2", time.Minute))
+	p.found(context.Background(), forwarder(t, "forwarder-2", "10.0.0.1", time.Minute))
+	p.found(context.Background(), forwarder(t, "forwarder-3", "", time.Minute))
+	require.Empty(t, cc.created)
+
+	p.found(context.Background(), forwarder(t, "forwarder-4", "10.0.0.2", time.Minute))
+	p.found(context.Background(), forwarder(t, "forwarder-5", "10.0.0.2", time.Minute))
+	require.Len(t, cc.created, 1, "a peer got more than one shell")
+	vxlan := cc.created[0].GetVxlan()
+	require.Equal(t, prefix+"10.0.0.2", cc.created[0].GetName())
+	require.Equal(t, "10.0.0.1", vxlan.GetSrcAddress())
+	require.Equal(t, "10.0.0.2", vxlan.GetDstAddress())
+	vni, ok := p.ids.Lookup(idalloc.VNI, prefix+"10.0.0.2")
+	require.True(t, ok)
+	require.Equal(t, vni, vxlan.GetVni())
+}
+
+func TestPruneWaitsForEveryRegistration(t *testing.T) {
+	cc := &agentCC{}
+	p := newPrewarmer(t, cc)
+	p.found(context.Background(), forwarder(t, "forwarder-2", "10.0.0.2", -time.Second))
+	p.found(context.Background(), forwarder(t, "forwarder-3", "10.0.0.2", time.Minute))
+
+	p.prune(context.Background())
+	require.Empty(t, cc.deleted, "the shell was deleted while a registration of its peer lasts")
+
+	p.shells["10.0.0.2"].expires["forwarder-3"] = time.Now()
+	p.prune(context.Background())
+	require.Equal(t, []string{prefix + "10.0.0.2"}, cc.deleted)
+	require.Empty(t, p.shells)
+	_, ok := p.ids.Lookup(idalloc.VNI, prefix+"10.0.0.2")
+	require.False(t, ok, "the vni of the deleted shell was not released")
+}
+
+func TestInheritKeepsShellsOfPreviousProcess(t *testing.T) {
+	cc := &agentCC{dump: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{
+			{Name: "eth0", Type: vpp_interfaces.Interface_AF_PACKET},
+			{
+				Name: prefix + "10.0.0.2",
+				Type: vpp_interfaces.Interface_VXLAN_TUNNEL,
+				Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{DstAddress: "10.0.0.2", Vni: 150}},
+			},
+		},
+	}}}
+	p := newPrewarmer(t, cc)
+	p.inherit(context.Background())
+	require.Len(t, p.shells, 1)
+
+	// The peer coming back to the registry keeps the inherited shell
+	p.found(context.Background(), forwarder(t, "forwarder-2", "10.0.0.2", time.Minute))
+	require.Empty(t, cc.created, "an inherited shell was set up again")
+	p.prune(context.Background())
+	require.Empty(t, cc.deleted)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package privileges - analysis and reduction of the linux capabilities the forwarder runs with.
+//
+// The forwarder needs:
+//   - NET_ADMIN  to create and configure interfaces (taps, veths, tc) via netlink
+//   - NET_RAW    for the af_packet uplink of vpp
+//   - SYS_ADMIN  to enter the network namespaces of clients (setns)
+//   - SYS_PTRACE to open /proc/<pid>/ns/net of processes of other users
+//   - IPC_LOCK   for vpp to lock its memory and use hugepages
+package privileges
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// droppedEnv - set for the forwarder re-executed by Drop
+const droppedEnv = "NSM_PRIVILEGES_DROPPED"
+
+var capabilities = map[string]uint{
+	"CHOWN":            0,
+	"DAC_OVERRIDE":     1,
+	"DAC_READ_SEARCH":  2,
+	"FOWNER":           3,
+	"FSETID":           4,
+	"KILL":             5,
+	"SETGID":           6,
+	"SETUID":           7,
+	"SETPCAP":          8,
+	"LINUX_IMMUTABLE":  9,
+	"NET_BIND_SERVICE": 10,
+	"NET_BROADCAST":    11,
+	"NET_ADMIN":        12,
+	"NET_RAW":          13,
+	"IPC_LOCK":         14,
+	"IPC_OWNER":        15,
+	"SYS_MODULE":       16,
+	"SYS_RAWIO":        17,
+	"SYS_CHROOT":       18,
+	"SYS_PTRACE":       19,
+	"SYS_PACCT":        20,
+	"SYS_ADMIN":        21,
+	"SYS_BOOT":         22,
+	"SYS_NICE":         23,
+	"SYS_RESOURCE":     24,
+	"SYS_TIME":         25,
+	"SYS_TTY_CONFIG":   26,
+	"MKNOD":            27,
+	"LEASE":            28,
+	"AUDIT_WRITE":      29,
+	"AUDIT_CONTROL":    30,
+	"SETFCAP":          31,
+	"MAC_OVERRIDE":     32,
+	"MAC_ADMIN":        33,
+	"SYSLOG":           34,
+	"WAKE_ALARM":       35,
+	"BLOCK_SUSPEND":    36,
+	"AUDIT_READ":       37,
+}
+
+// mask - returns the bit mask of the capabilities names, with or without CAP_ prefix
+func mask(names []string) (uint64, error) {
+	var rv uint64
+	for _, name := range names {
+		c, ok := capabilities[strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "CAP_")]
+		if !ok {
+			return 0, errors.Errorf("unknown capability %q", name)
+		}
+		rv |= 1 << c
+	}
+	return rv, nil
+}
+
+// names - returns the sorted names of the capabilities in m
+func names(m uint64) []string {
+	var rv []string
+	for name, c := range capabilities {
+		if m&(1<<c) != 0 {
+			rv = append(rv, name)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// dropMask - returns the bit mask of the capabilities up to lastCap that are not in keepMask
+func dropMask(keepMask uint64, lastCap uint) uint64 {
+	// A shift by 64 is 0, so all is all ones for lastCap 63
+	all := uint64(1)<<(lastCap+1) - 1
+	return all &^ keepMask
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMasks(t *testing.T) {
+	keep, err := mask([]string{"NET_ADMIN", "cap_net_raw", " SYS_ADMIN "})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<12|1<<13|1<<21), keep)
+	require.Equal(t, []string{"NET_ADMIN", "NET_RAW", "SYS_ADMIN"}, names(keep))
+
+	_, err = mask([]string{"NET_ADMN"})
+	require.Error(t, err)
+
+	for _, sample := range []struct {
+		name    string
+		lastCap uint
+		keep    uint64
+		drop    uint64
+	}{
+		{"nothing kept", 3, 0, 0xf},
+		{"some kept", 37, keep, (1<<38 - 1) &^ keep},
+		{"all kept", 13, 1<<14 - 1, 0},
+		{"kept beyond the last capability", 1, 1 << 5, 0x3},
+		{"all bits", 63, 1, ^uint64(1)},
+	} {
+		require.Equal(t, sample.drop, dropMask(sample.keep, sample.lastCap), sample.name)
+	}
+	// What remains after the re-exec, eff &^ keep, is zero once the dropped capabilities are gone
+	eff := uint64(1<<38-1) &^ dropMask(keep, 37)
+	require.Zero(t, eff&^keep)
+}
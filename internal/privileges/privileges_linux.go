@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Validate - logs which of the required capabilities the process has and returns an error naming the missing ones
+func Validate(ctx context.Context, required []string) error {
+	eff, err := effective()
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, name := range required {
+		m, err := mask([]string{name})
+		if err != nil {
+			return err
+		}
+		if eff&m == 0 {
+			missing = append(missing, name)
+			continue
+		}
+		log.Entry(ctx).Infof("capability %s: present", name)
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("missing capabilities: %s", strings.Join(missing, ","))
+	}
+	return nil
+}
+
+// Drop - reduces the capabilities of the process to keep.  Capabilities are per thread, so rather than changing them
+// in place, the capabilities beyond keep are dropped from the bounding, inheritable and ambient sets of the current
+// thread which then re-executes the forwarder: the new process, and everything it spawns, is limited to keep.  The
+// re-executed forwarder is marked by droppedEnv, and only verifies that it holds no capabilities beyond keep.
+func Drop(ctx context.Context, keep []string) error {
+	keepMask, err := mask(keep)
+	if err != nil {
+		return err
+	}
+	eff, err := effective()
+	if err != nil {
+		return err
+	}
+	if os.Getenv(droppedEnv) != "" {
+		if extra := eff &^ keepMask; extra != 0 {
+			return errors.Errorf("capabilities %s remain after dropping privileges", strings.Join(names(extra), ","))
+		}
+		return nil
+	}
+	if eff&^keepMask == 0 {
+		return nil
+	}
+	lastCap, err := lastCapability()
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	runtime.LockOSThread()
+	if err := dropThread(keepMask, lastCap); err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	log.Entry(ctx).Infof("re-executing %s with capabilities %s", executable, strings.Join(keep, ","))
+	return errors.WithStack(syscall.Exec(executable, os.Args, append(os.Environ(), droppedEnv+"=true")))
+}
+
+// dropThread - drops the capabilities not in keepMask from the bounding, inheritable and ambient sets of the thread
+func dropThread(keepMask uint64, lastCap uint) error {
+	drop := dropMask(keepMask, lastCap)
+	for c := uint(0); c <= lastCap; c++ {
+		if drop&(1<<c) == 0 {
+			continue
+		}
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil {
+			return errors.Wrapf(err, "failed to drop capability %d from the bounding set", c)
+		}
+		// Ambient capabilities are kept across exec whatever the bounding set
+		if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_LOWER, uintptr(c), 0, 0); err != nil {
+			return errors.Wrapf(err, "failed to drop capability %d from the ambient set", c)
+		}
+	}
+	header := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&header, &data[0]); err != nil {
+		return errors.Wrap(err, "failed to get the capabilities of the thread")
+	}
+	data[0].Inheritable &= uint32(keepMask)
+	data[1].Inheritable &= uint32(keepMask >> 32)
+	if err := unix.Capset(&header, &data[0]); err != nil {
+		return errors.Wrap(err, "failed to drop the inheritable capabilities")
+	}
+	return nil
+}
+
+func effective() (uint64, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		eff, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		return eff, errors.WithStack(err)
+	}
+	return 0, errors.New("CapEff not found in /proc/self/status")
+}
+
+func lastCapability() (uint, error) {
+	content, err := ioutil.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	lastCap, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+	return uint(lastCap), errors.WithStack(err)
+}
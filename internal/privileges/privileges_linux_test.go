@@ -0,0 +1,38 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	eff, err := effective()
+	require.NoError(t, err)
+	require.NoError(t, Validate(context.Background(), names(eff)), "the capabilities held are present")
+	require.NoError(t, Validate(context.Background(), nil))
+
+	require.Error(t, Validate(context.Background(), []string{"NET_ADMN"}))
+	if missing := names(^eff & (1<<38 - 1)); len(missing) > 0 {
+		err = Validate(context.Background(), missing[:1])
+		require.Error(t, err)
+		require.Contains(t, err.Error(), missing[0])
+	}
+}
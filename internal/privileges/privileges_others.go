@@ -0,0 +1,35 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package privileges
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Validate - capabilities are linux specific
+func Validate(ctx context.Context, required []string) error {
+	return errors.New("capability analysis is only supported on linux")
+}
+
+// Drop - capabilities are linux specific
+func Drop(ctx context.Context, keep []string) error {
+	return errors.New("dropping privileges is only supported on linux")
+}
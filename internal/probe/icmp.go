@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"encoding/binary"
+)
+
+// The icmp and icmpv6 message types of echoes
+const (
+	icmpEchoRequest   = 8
+	icmpEchoReply     = 0
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+	echoHeaderLen     = 8
+)
+
+// EchoRequest - returns an icmp (v4) or icmpv6 echo request of size bytes, no less than its 8 byte header, for id and
+// seq.  Only icmp requests are checksummed, the kernel computes the checksums of icmpv6.
+func EchoRequest(v4 bool, id, seq uint16, size int) []byte {
+	if size < echoHeaderLen {
+		size = echoHeaderLen
+	}
+	request := make([]byte, size)
+	request[0] = icmpv6EchoRequest
+	if v4 {
+		request[0] = icmpEchoRequest
+	}
+	binary.BigEndian.PutUint16(request[4:], id)
+	binary.BigEndian.PutUint16(request[6:], seq)
+	if v4 {
+		binary.BigEndian.PutUint16(request[2:], checksum(request))
+	}
+	return request
+}
+
+// IsEchoReply - returns true if msg, as read from a raw icmp (v4) or icmpv6 socket, is the echo reply to id and seq
+func IsEchoReply(v4 bool, msg []byte, id, seq uint16) bool {
+	replyType := byte(icmpv6EchoReply)
+	if v4 {
+		// Raw ipv4 sockets hand back the ip header too
+		if len(msg) == 0 || len(msg) < int(msg[0]&0x0f)*4 {
+			return false
+		}
+		msg = msg[int(msg[0]&0x0f)*4:]
+		replyType = icmpEchoReply
+	}
+	return len(msg) >= echoHeaderLen && msg[0] == replyType &&
+		binary.BigEndian.Uint16(msg[4:]) == id && binary.BigEndian.Uint16(msg[6:]) == seq
+}
+
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
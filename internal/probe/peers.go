@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package probe - what the monitors of the remote ends (peers) of the vxlan tunnels the forwarder programs share:
+// the tunnels to each peer as sent to vpp-agent, and the icmp echoes peers are probed with
+package probe
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+)
+
+// Tunnels - returns the vxlan tunnels put by req if it is a vpp-agent UpdateRequest, deleted if a DeleteRequest
+func Tunnels(req interface{}) (put, deleted []*vpp_interfaces.Interface) {
+	switch r := req.(type) {
+	case *configurator.UpdateRequest:
+		put = vxlanTunnels(r.GetUpdate().GetVppConfig().GetInterfaces())
+	case *configurator.DeleteRequest:
+		deleted = vxlanTunnels(r.GetDelete().GetVppConfig().GetInterfaces())
+	}
+	return put, deleted
+}
+
+func vxlanTunnels(ifaces []*vpp_interfaces.Interface) []*vpp_interfaces.Interface {
+	var tunnels []*vpp_interfaces.Interface
+	for _, iface := range ifaces {
+		if iface.GetType() == vpp_interfaces.Interface_VXLAN_TUNNEL {
+			tunnels = append(tunnels, iface)
+		}
+	}
+	return tunnels
+}
+
+// Peers - the vxlan tunnels to each peer by peer address and vpp-agent name, as last put.  The zero value is ready to
+// use.  Peers is not safe for concurrent use, its users guard it along with the state they keep per peer.
+type Peers struct {
+	tunnels map[string]map[string]*vpp_interfaces.Interface
+}
+
+// Put - records a copy of tunnel, returning true if it is the first tunnel to its peer.  Tunnels without a remote
+// address are ignored.
+func (p *Peers) Put(tunnel *vpp_interfaces.Interface) bool {
+	addr := tunnel.GetVxlan().GetDstAddress()
+	if addr == "" {
+		return false
+	}
+	if p.tunnels == nil {
+		p.tunnels = make(map[string]map[string]*vpp_interfaces.Interface)
+	}
+	tunnels, ok := p.tunnels[addr]
+	if !ok {
+		tunnels = make(map[string]*vpp_interfaces.Interface)
+		p.tunnels[addr] = tunnels
+	}
+	tunnels[tunnel.GetName()] = proto.Clone(tunnel).(*vpp_interfaces.Interface)
+	return !ok
+}
+
+// Delete - forgets tunnel, returning true if it was the last tunnel to its peer
+func (p *Peers) Delete(tunnel *vpp_interfaces.Interface) bool {
+	addr := tunnel.GetVxlan().GetDstAddress()
+	tunnels, ok := p.tunnels[addr]
+	if !ok {
+		return false
+	}
+	delete(tunnels, tunnel.GetName())
+	if len(tunnels) > 0 {
+		return false
+	}
+	delete(p.tunnels, addr)
+	return true
+}
+
+// Has - returns true if there is a tunnel to addr
+func (p *Peers) Has(addr string) bool {
+	_, ok := p.tunnels[addr]
+	return ok
+}
+
+// Addrs - returns the addresses of the peers, sorted
+func (p *Peers) Addrs() []string {
+	addrs := make([]string, 0, len(p.tunnels))
+	for addr := range p.tunnels {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Names - returns the vpp-agent names of the tunnels to addr, sorted
+func (p *Peers) Names(addr string) []string {
+	names := make([]string, 0, len(p.tunnels[addr]))
+	for name := range p.tunnels[addr] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tunnels - returns the tunnels to addr as last put, sorted by name.  They are Peers' own copies, not to be modified.
+func (p *Peers) Tunnels(addr string) []*vpp_interfaces.Interface {
+	tunnels := make([]*vpp_interfaces.Interface, 0, len(p.tunnels[addr]))
+	for _, name := range p.Names(addr) {
+		tunnels = append(tunnels, p.tunnels[addr][name])
+	}
+	return tunnels
+}
+
+// Peer - returns the address of the peer of the tunnel with the vpp-agent name name, false if there is no such tunnel
+func (p *Peers) Peer(name string) (string, bool) {
+	for addr, tunnels := range p.tunnels {
+		if _, ok := tunnels[name]; ok {
+			return addr, true
+		}
+	}
+	return "", false
+}
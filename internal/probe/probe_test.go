@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/probe"
+)
+
+func tunnel(name, dst string) *vpp_interfaces.Interface {
+	return &vpp_interfaces.Interface{
+		Name: name,
+		Type: vpp_interfaces.Interface_VXLAN_TUNNEL,
+		Link: &vpp_interfaces.Interface_Vxlan{Vxlan: &vpp_interfaces.VxlanLink{DstAddress: dst}},
+	}
+}
+
+func TestTunnels(t *testing.T) {
+	ifaces := []*vpp_interfaces.Interface{
+		tunnel("vxlan-1", "10.0.0.2"),
+		{Name: "tap-1", Type: vpp_interfaces.Interface_TAP},
+	}
+	config := &configurator.Config{VppConfig: &vpp.ConfigData{Interfaces: ifaces}}
+
+	put, deleted := probe.Tunnels(&configurator.UpdateRequest{Update: config})
+	require.Equal(t, ifaces[:1], put)
+	require.Empty(t, deleted)
+
+	put, deleted = probe.Tunnels(&configurator.DeleteRequest{Delete: config})
+	require.Empty(t, put)
+	require.Equal(t, ifaces[:1], deleted)
+
+	put, deleted = probe.Tunnels(&configurator.GetRequest{})
+	require.Empty(t, put)
+	require.Empty(t, deleted)
+}
+
+func TestPeers(t *testing.T) {
+	var peers probe.Peers
+	require.True(t, peers.Put(tunnel("vxlan-2", "10.0.0.2")))
+	require.False(t, peers.Put(tunnel("vxlan-1", "10.0.0.2")))
+	require.True(t, peers.Put(tunnel("vxlan-3", "10.0.0.3")))
+	require.False(t, peers.Put(tunnel("vxlan-4", "")))
+
+	require.Equal(t, []string{"10.0.0.2", "10.0.0.3"}, peers.Addrs())
+	require.Equal(t, []string{"vxlan-1", "vxlan-2"}, peers.Names("10.0.0.2"))
+	addr, ok := peers.Peer("vxlan-3")
+	require.True(t, ok)
+	require.Equal(t, "10.0.0.3", addr)
+	_, ok = peers.Peer("vxlan-4")
+	require.False(t, ok)
+
+	// Put keeps a copy
+	iface := tunnel("vxlan-3", "10.0.0.3")
+	iface.Mtu = 1450
+	peers.Put(iface)
+	iface.Mtu = 1400
+	require.Equal(t, uint32(1450), peers.Tunnels("10.0.0.3")[0].GetMtu())
+
+	require.False(t, peers.Delete(tunnel("vxlan-1", "10.0.0.2")))
+	require.True(t, peers.Has("10.0.0.2"))
+	require.True(t, peers.Delete(tunnel("vxlan-2", "10.0.0.2")))
+	require.False(t, peers.Has("10.0.0.2"))
+	require.False(t, peers.Delete(tunnel("vxlan-2", "10.0.0.2")))
+	require.Equal(t, []string{"10.0.0.3"}, peers.Addrs())
+}
+
+// reply - returns the echo reply to request as read from a raw socket: with an ipv4 header of headerLen bytes if v4
+func reply(request []byte, v4 bool, headerLen int) []byte {
+	msg := append(make([]byte, headerLen), request...)
+	msg[headerLen] = 129
+	if v4 {
+		msg[0] = 0x40 | byte(headerLen/4)
+		msg[headerLen] = 0
+	}
+	return msg
+}
+
+func TestEchoRequest(t *testing.T) {
+	request := probe.EchoRequest(true, 0x1234, 7, 64)
+	require.Len(t, request, 64)
+	require.Equal(t, byte(8), request[0])
+	require.Equal(t, uint16(0x1234), binary.BigEndian.Uint16(request[4:]))
+	require.Equal(t, uint16(7), binary.BigEndian.Uint16(request[6:]))
+	// A message with a valid checksum sums to all ones
+	var sum uint32
+	for i := 0; i < len(request); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(request[i:]))
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	require.Equal(t, uint32(0xffff), sum)
+
+	request = probe.EchoRequest(false, 0x1234, 7, 0)
+	require.Len(t, request, 8)
+	require.Equal(t, byte(128), request[0])
+	require.Zero(t, binary.BigEndian.Uint16(request[2:]))
+}
+
+func TestIsEchoReply(t *testing.T) {
+	request := probe.EchoRequest(true, 0x1234, 7, 8)
+	require.True(t, probe.IsEchoReply(true, reply(request, true, 20), 0x1234, 7))
+	require.True(t, probe.IsEchoReply(true, reply(request, true, 24), 0x1234, 7))
+	require.False(t, probe.IsEchoReply(true, reply(request, true, 20), 0x1234, 8))
+	require.False(t, probe.IsEchoReply(true, reply(request, true, 20), 0x4321, 7))
+	msg := reply(request, true, 20)
+	msg[20] = 8
+	require.False(t, probe.IsEchoReply(true, msg, 0x1234, 7))
+	require.False(t, probe.IsEchoReply(true, reply(request, true, 20)[:24], 0x1234, 7))
+	require.False(t, probe.IsEchoReply(true, []byte{0x4f}, 0x1234, 7))
+	require.False(t, probe.IsEchoReply(true, nil, 0x1234, 7))
+
+	request = probe.EchoRequest(false, 0x1234, 7, 8)
+	require.True(t, probe.IsEchoReply(false, reply(request, false, 0), 0x1234, 7))
+	require.False(t, probe.IsEchoReply(false, request, 0x1234, 7))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package probe
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// SetReceiveTimeout - makes reads from the socket fd time out after timeout, which must be positive
+func SetReceiveTimeout(fd int, timeout time.Duration) error {
+	// A zero timeout never times out, as rounded down from less than a microsecond
+	if timeout < time.Microsecond {
+		timeout = time.Microsecond
+	}
+	tv := unix.NsecToTimeval(timeout.Nanoseconds())
+	return errors.WithStack(unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv))
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ra - emits IPv6 Router Advertisements carrying the prefix of their connection's ip context to kernel
+// clients, so that the client stacks can autoconfigure their addresses the standard (SLAAC) way
+package ra
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/hostifs"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type raServer struct {
+	hostIfs  *hostifs.Table
+	interval time.Duration
+	mu       sync.Mutex
+	adverts  map[string]context.CancelFunc
+}
+
+// NewServer - returns a server chain element advertising the ipv6 prefix of kernel clients every interval, and in
+// answer to their Router Solicitations, on the host side of the veth pair of their connection as recorded in hostIfs.
+// It does nothing unless enabled.
+func NewServer(hostIfs *hostifs.Table, enabled bool, interval time.Duration) networkservice.NetworkServiceServer {
+	if !enabled {
+		hostIfs = nil
+	}
+	return &raServer{
+		hostIfs:  hostIfs,
+		interval: interval,
+		adverts:  make(map[string]context.CancelFunc),
+	}
+}
+
+func (r *raServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || r.hostIfs == nil || conn.GetMechanism().GetType() != kernel.MECHANISM {
+		return conn, err
+	}
+	p, ok := newPrefix(conn, r.interval)
+	if !ok {
+		return conn, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, running := r.adverts[conn.GetId()]; running {
+		return conn, nil
+	}
+	hostIf := r.hostIfs.Get(vppnames.ServerInterface(conn))
+	if hostIf == "" {
+		log.Entry(ctx).Debugf("connection %s has no veth pair to advertise on", conn.GetId())
+		return conn, nil
+	}
+	if !p.autonomous() {
+		log.Entry(ctx).Warnf("prefix %s of connection %s is not a /64, advertising it as on-link only", p.net, conn.GetId())
+	}
+	// The advertiser outlives the Request, up to the Close of the connection
+	advertiseCtx, cancel := context.WithCancel(context.Background())
+	if err := advertise(advertiseCtx, hostIf, p, r.interval); err != nil {
+		cancel()
+		log.Entry(ctx).Warnf("failed to advertise %s for connection %s on %s: %+v", p.net, conn.GetId(), hostIf, err)
+		return conn, nil
+	}
+	r.adverts[conn.GetId()] = cancel
+	log.Entry(ctx).Infof("advertising %s for connection %s on %s", p.net, conn.GetId(), hostIf)
+	return conn, nil
+}
+
+func (r *raServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	r.mu.Lock()
+	if cancel, ok := r.adverts[conn.GetId()]; ok {
+		cancel()
+		delete(r.adverts, conn.GetId())
+	}
+	r.mu.Unlock()
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// prefix - what a client is told
+type prefix struct {
+	net      *net.IPNet
+	lifetime time.Duration
+}
+
+// newPrefix - returns the prefix of the client of conn (the network of its src address), false if it has no ipv6
+// address.  The prefix stays valid for three advertisement intervals, so a client only drops it once the
+// connection has been gone for a while.
+func newPrefix(conn *networkservice.Connection, interval time.Duration) (*prefix, bool) {
+	client, clientNet, err := net.ParseCIDR(conn.GetContext().GetIpContext().GetSrcIpAddr())
+	if err != nil || client.To4() != nil {
+		return nil, false
+	}
+	return &prefix{net: clientNet, lifetime: 3 * interval}, true
+}
+
+// autonomous - whether clients can form their addresses in p themselves, which SLAAC only allows for /64s
+func (p *prefix) autonomous() bool {
+	ones, _ := p.net.Mask.Size()
+	return ones == 64
+}
+
+// ICMPv6 types and Neighbor Discovery options of RFC 4861
+const (
+	icmpRouterSolicitation  = 133
+	icmpRouterAdvertisement = 134
+	optSourceLinkAddr       = 1
+	optPrefixInfo           = 3
+	prefixOnLink            = 0x80
+	prefixAutonomous        = 0x40
+	ethIPv6                 = 0x86dd
+	ipv6HeaderLen           = 40
+	protoICMPv6             = 58
+	hopLimit                = 255
+)
+
+var allNodes = net.ParseIP("ff02::1")
+
+// advertisement - returns the ethernet frame of the Router Advertisement of p sent from mac.  The advertisement has
+// a router lifetime of zero: the veth is not the client's way to its peer's addresses, so it must not become its
+// default router.
+func (p *prefix) advertisement(mac net.HardwareAddr) []byte {
+	ra := make([]byte, 16, 16+8+32)
+	ra[0] = icmpRouterAdvertisement
+	ra[4] = 64 // cur hop limit
+	ra = append(ra, optSourceLinkAddr, 1)
+	ra = append(ra, mac...)
+	flags := byte(prefixOnLink)
+	if p.autonomous() {
+		flags |= prefixAutonomous
+	}
+	ones, _ := p.net.Mask.Size()
+	info := make([]byte, 32)
+	info[0], info[1], info[2], info[3] = optPrefixInfo, 4, byte(ones), flags
+	binary.BigEndian.PutUint32(info[4:], uint32(p.lifetime/time.Second))
+	binary.BigEndian.PutUint32(info[8:], uint32(p.lifetime/time.Second))
+	copy(info[16:], p.net.IP.To16())
+	ra = append(ra, info...)
+
+	src := linkLocal(mac)
+	binary.BigEndian.PutUint16(ra[2:], checksum(src, allNodes, ra))
+	ip := make([]byte, ipv6HeaderLen, ipv6HeaderLen+len(ra))
+	ip[0] = 0x60
+	binary.BigEndian.PutUint16(ip[4:], uint16(len(ra)))
+	ip[6], ip[7] = protoICMPv6, hopLimit
+	copy(ip[8:], src)
+	copy(ip[24:], allNodes)
+	ip = append(ip, ra...)
+	return frame(mac, ip)
+}
+
+// frame - returns the ethernet frame carrying the ipv6 packet ip from src to the all-nodes multicast address
+func frame(src net.HardwareAddr, ip []byte) []byte {
+	f := make([]byte, 14, 14+len(ip))
+	copy(f, net.HardwareAddr{0x33, 0x33, 0, 0, 0, 1})
+	copy(f[6:], src)
+	binary.BigEndian.PutUint16(f[12:], ethIPv6)
+	return append(f, ip...)
+}
+
+// isSolicitation - whether the ethernet frame f carries a Router Solicitation
+func isSolicitation(f []byte) bool {
+	if len(f) < 14+ipv6HeaderLen+4 || binary.BigEndian.Uint16(f[12:]) != ethIPv6 {
+		return false
+	}
+	ip := f[14:]
+	return ip[0]>>4 == 6 && ip[6] == protoICMPv6 && ip[7] == hopLimit && ip[ipv6HeaderLen] == icmpRouterSolicitation
+}
+
+// linkLocal - returns the modified EUI-64 link local address of mac
+func linkLocal(mac net.HardwareAddr) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1] = 0xfe, 0x80
+	copy(ip[8:], mac[:3])
+	ip[8] ^= 0x02
+	ip[11], ip[12] = 0xff, 0xfe
+	copy(ip[13:], mac[3:6])
+	return ip
+}
+
+// checksum - the ICMPv6 checksum of msg from src to dst, including the ipv6 pseudo header
+func checksum(src, dst net.IP, msg []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(b[i])<<8 | uint32(b[i+1])
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+	add(src.To16())
+	add(dst.To16())
+	sum += uint32(len(msg)) + protoICMPv6
+	add(msg)
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+)
+
+func connection(srcIPAddr string) *networkservice.Connection {
+	return &networkservice.Connection{
+		Context: &networkservice.ConnectionContext{
+			IpContext: &networkservice.IPContext{SrcIpAddr: srcIPAddr},
+		},
+	}
+}
+
+func TestNewPrefix(t *testing.T) {
+	p, ok := newPrefix(connection("fd00:1:2:3::2/64"), 10*time.Second)
+	require.True(t, ok)
+	require.Equal(t, "fd00:1:2:3::/64", p.net.String())
+	require.Equal(t, 30*time.Second, p.lifetime)
+	require.True(t, p.autonomous())
+
+	p, ok = newPrefix(connection("fd00::2/80"), 10*time.Second)
+	require.True(t, ok)
+	require.False(t, p.autonomous(), "SLAAC needs a /64")
+
+	_, ok = newPrefix(connection("10.0.0.2/30"), 10*time.Second)
+	require.False(t, ok)
+	_, ok = newPrefix(connection(""), 10*time.Second)
+	require.False(t, ok)
+}
+
+func TestLinkLocal(t *testing.T) {
+	mac, err := net.ParseMAC("52:54:00:12:34:56")
+	require.NoError(t, err)
+	require.Equal(t, "fe80::5054:ff:fe12:3456", linkLocal(mac).String())
+}
+
+func TestAdvertisement(t *testing.T) {
+	mac, err := net.ParseMAC("52:54:00:12:34:56")
+	require.NoError(t, err)
+	p, _ := newPrefix(connection("fd00:1:2:3::2/64"), 10*time.Second)
+	f := p.advertisement(mac)
+
+	require.Equal(t, net.HardwareAddr{0x33, 0x33, 0, 0, 0, 1}, net.HardwareAddr(f[:6]))
+	require.Equal(t, mac, net.HardwareAddr(f[6:12]))
+	require.Equal(t, uint16(ethIPv6), binary.BigEndian.Uint16(f[12:]))
+
+	ip := f[14:]
+	require.Equal(t, byte(6), ip[0]>>4)
+	require.Equal(t, byte(protoICMPv6), ip[6])
+	require.Equal(t, byte(hopLimit), ip[7])
+	src, dst := net.IP(ip[8:24]), net.IP(ip[24:40])
+	require.Equal(t, linkLocal(mac), src)
+	require.True(t, allNodes.Equal(dst))
+	ra := ip[ipv6HeaderLen:]
+	require.Equal(t, int(binary.BigEndian.Uint16(ip[4:])), len(ra))
+
+	require.Equal(t, byte(icmpRouterAdvertisement), ra[0])
+	require.Zero(t, checksum(src, dst, ra), "a valid checksum sums up to zero")
+	require.Zero(t, binary.BigEndian.Uint16(ra[6:]), "never a default router")
+
+	// Source link layer address, then prefix information
+	require.Equal(t, []byte{optSourceLinkAddr, 1}, ra[16:18])
+	require.Equal(t, []byte(mac), ra[18:24])
+	info := ra[24:]
+	require.Len(t, info, 32)
+	require.Equal(t, []byte{optPrefixInfo, 4, 64, prefixOnLink | prefixAutonomous}, info[:4])
+	require.Equal(t, uint32(30), binary.BigEndian.Uint32(info[4:]))
+	require.Equal(t, uint32(30), binary.BigEndian.Uint32(info[8:]))
+	require.Equal(t, "fd00:1:2:3::", net.IP(info[16:32]).String())
+}
+
+func TestIsSolicitation(t *testing.T) {
+	mac, err := net.ParseMAC("52:54:00:12:34:56")
+	require.NoError(t, err)
+	ip := make([]byte, ipv6HeaderLen+8)
+	ip[0], ip[6], ip[7] = 0x60, protoICMPv6, hopLimit
+	ip[ipv6HeaderLen] = icmpRouterSolicitation
+	require.True(t, isSolicitation(frame(mac, ip)))
+
+	ip[ipv6HeaderLen] = icmpRouterAdvertisement
+	require.False(t, isSolicitation(frame(mac, ip)))
+	ip[ipv6HeaderLen], ip[7] = icmpRouterSolicitation, 1
+	require.False(t, isSolicitation(frame(mac, ip)), "forwarded solicitations are not from the link")
+	require.False(t, isSolicitation(frame(mac, nil)))
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package ra
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// advertise - advertises p on the host interface ifName every interval, and whenever a Router Solicitation arrives
+// on it, until ctx is done
+func advertise(ctx context.Context, ifName string, p *prefix, interval time.Duration) error {
+	iface, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	protocol := htons(unix.ETH_P_IPV6)
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(protocol))
+	if err != nil {
+		return errors.Wrap(err, "failed to open a packet socket")
+	}
+	addr := &unix.SockaddrLinklayer{Protocol: protocol, Ifindex: iface.Index}
+	if err = unix.Bind(fd, addr); err != nil {
+		_ = unix.Close(fd)
+		return errors.Wrapf(err, "failed to bind to %s", ifName)
+	}
+	advertisement := p.advertisement(iface.HardwareAddr)
+	send := func() {
+		if sendErr := unix.Sendto(fd, advertisement, 0, addr); sendErr != nil {
+			log.Entry(ctx).Warnf("failed to send router advertisement on %s: %+v", ifName, sendErr)
+		}
+	}
+	go func() {
+		defer func() { _ = unix.Close(fd) }()
+		send()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				send()
+			}
+		}
+	}()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, _, recvErr := unix.Recvfrom(fd, buf, 0)
+			if ctx.Err() != nil {
+				return
+			}
+			if recvErr != nil {
+				if recvErr == unix.EINTR {
+					continue
+				}
+				log.Entry(ctx).Errorf("router advertiser on %s failed: %+v", ifName, recvErr)
+				return
+			}
+			if isSolicitation(buf[:n]) {
+				send()
+			}
+		}
+	}()
+	return nil
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit - chain element honoring the bandwidth limit requested for a connection in software, by installing
+// a token bucket (tbf) qdisc on the kernel interface of the connection.  vpp-agent v3.1 has no policer or QoS model to
+// shape the other mechanisms with, so a bandwidth limit requested for any of them is refused rather than ignored.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+// BandwidthLabel - connection label carrying the requested bandwidth limit in bits per second, optionally suffixed
+// with k, M or G (for example "100M")
+const BandwidthLabel = "bandwidth"
+
+type rateLimitServer struct{}
+
+// NewServer - returns a server chain element shaping the kernel interface of connections requesting a bandwidth limit,
+// and refusing connections of other mechanisms requesting one
+func NewServer() networkservice.NetworkServiceServer {
+	return &rateLimitServer{}
+}
+
+func (r *rateLimitServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := conn.GetLabels()[BandwidthLabel]
+	if !ok {
+		return conn, nil
+	}
+	rate, err := parseRate(value)
+	if err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, err
+	}
+	if conn.GetMechanism().GetType() != kernel.MECHANISM {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Errorf("bandwidth limit %q for connection %s can not be enforced for mechanism %s", value, conn.GetId(), conn.GetMechanism().GetType())
+	}
+	params := conn.GetMechanism().GetParameters()
+	if err := shape(params[kernel.NetNSURL], params[kernel.InterfaceNameKey], rate); err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(err, "failed to apply bandwidth limit %q for connection %s", value, conn.GetId())
+	}
+	return conn, nil
+}
+
+func (r *rateLimitServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	// The qdisc is removed together with the interface
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// parseRate - parses a rate in bits per second with an optional k, M or G suffix
+func parseRate(value string) (uint64, error) {
+	digits := strings.TrimSpace(value)
+	multiplier := uint64(1)
+	if digits != "" {
+		switch digits[len(digits)-1] {
+		case 'k', 'K':
+			multiplier = 1000
+		case 'm', 'M':
+			multiplier = 1000 * 1000
+		case 'g', 'G':
+			multiplier = 1000 * 1000 * 1000
+		}
+		if multiplier != 1 {
+			digits = digits[:len(digits)-1]
+		}
+	}
+	rate, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || rate == 0 || rate > math.MaxUint64/multiplier {
+		return 0, errors.Errorf("invalid %s label value: %q", BandwidthLabel, value)
+	}
+	return rate * multiplier, nil
+}
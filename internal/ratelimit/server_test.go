@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/memif"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+)
+
+// closeCounter - last chain element, returning the connection of the Request and counting Closes
+type closeCounter struct {
+	closed int
+}
+
+func (c *closeCounter) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return request.GetConnection(), nil
+}
+
+func (c *closeCounter) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	c.closed++
+	return &empty.Empty{}, nil
+}
+
+func request(mechanism string, labels map[string]string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id:        "conn-1",
+			Mechanism: &networkservice.Mechanism{Type: mechanism},
+			Labels:    labels,
+		},
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for value, want := range map[string]uint64{
+		"1500":                 1500,
+		" 64k ":                64 * 1000,
+		"100M":                 100 * 1000 * 1000,
+		"10g":                  10 * 1000 * 1000 * 1000,
+		"18446744073709551615": 18446744073709551615,
+		"18446744073G":         18446744073 * 1000 * 1000 * 1000,
+	} {
+		rate, err := parseRate(value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, rate, value)
+	}
+	for _, value := range []string{"", "0", "0M", "M", "-1k", "1.5G", "10T", "18446744074G", "18446744073709551616"} {
+		_, err := parseRate(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestTBFParameters(t *testing.T) {
+	bytesPerSecond, buffer, limit, err := tbfParameters(8 * 1000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), bytesPerSecond)
+	require.Equal(t, uint32(minBurst), buffer, "the bucket holds at least a few packets")
+	require.Equal(t, uint32(minBurst+50), limit)
+
+	bytesPerSecond, buffer, limit, err = tbfParameters(8 * 1000 * 1000 * 1000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000*1000*1000), bytesPerSecond)
+	require.Equal(t, uint32(100*1000*1000), buffer)
+	require.Equal(t, uint32(150*1000*1000), limit)
+
+	_, _, _, err = tbfParameters(1000 * 1000 * 1000 * 1000)
+	require.Error(t, err, "a 1T queue does not fit the qdisc")
+}
+
+func TestUnlabeledConnection(t *testing.T) {
+	tail := &closeCounter{}
+	server := chain.NewNetworkServiceServer(NewServer(), tail)
+
+	conn, err := server.Request(context.Background(), request(memif.MECHANISM, map[string]string{"app": "db"}))
+	require.NoError(t, err)
+	require.Equal(t, "conn-1", conn.GetId())
+	require.Zero(t, tail.closed)
+}
+
+func TestUnsupportedMechanism(t *testing.T) {
+	tail := &closeCounter{}
+	server := chain.NewNetworkServiceServer(NewServer(), tail)
+
+	_, err := server.Request(context.Background(), request(memif.MECHANISM, map[string]string{BandwidthLabel: "100M"}))
+	require.Error(t, err, "a limit that can not be enforced is refused")
+	require.Equal(t, 1, tail.closed)
+}
+
+func TestInvalidBandwidth(t *testing.T) {
+	tail := &closeCounter{}
+	server := chain.NewNetworkServiceServer(NewServer(), tail)
+
+	_, err := server.Request(context.Background(), request(kernel.MECHANISM, map[string]string{BandwidthLabel: "fast"}))
+	require.Error(t, err)
+	require.Equal(t, 1, tail.closed)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	latencyMs = 50
+	minBurst  = 16 * 1024
+)
+
+// tbfParameters - returns the rate in bytes per second, the bucket size and the queue limit in bytes of a tbf qdisc
+// limiting to rate bits per second, or an error if they do not fit the qdisc
+func tbfParameters(rate uint64) (bytesPerSecond uint64, buffer, limit uint32, err error) {
+	bytesPerSecond = rate / 8
+	burst := bytesPerSecond / 10
+	if burst < minBurst {
+		burst = minBurst
+	}
+	queue := bytesPerSecond/1000*latencyMs + burst
+	if queue > math.MaxUint32 {
+		return 0, 0, 0, errors.Errorf("bandwidth limit of %d bit/s exceeds the limits of a tbf qdisc", rate)
+	}
+	return bytesPerSecond, uint32(burst), uint32(queue), nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// shape - installs a tbf qdisc limiting ifaceName in the netns referenced by netnsURL to rate bits per second
+func shape(netnsURL, ifaceName string, rate uint64) error {
+	u, err := url.Parse(netnsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if u.Scheme != "file" {
+		return errors.Errorf("unsupported netns url: %q", netnsURL)
+	}
+	nsHandle, err := netns.GetFromPath(u.Path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = nsHandle.Close() }()
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Delete()
+	bytesPerSecond, buffer, limit, err := tbfParameters(rate)
+	if err != nil {
+		return err
+	}
+	link, err := handle.LinkByName(ifaceName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	qdisc := &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   bytesPerSecond,
+		Buffer: buffer,
+		Limit:  limit,
+	}
+	return errors.WithStack(handle.QdiscReplace(qdisc))
+}
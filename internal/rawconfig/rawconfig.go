@@ -0,0 +1,255 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rawconfig - an escape hatch for the corner cases the forwarder doesn't cover, e.g. extra routes: a
+// connection labelled Label=<file> gets the vpp-agent config snippet in <file>, from a directory provided by the
+// operator, merged into the txns creating and deleting its vpp interfaces.
+//
+// Snippets are YAML or JSON vpp-agent configs (see agentconfig) and text/template templates, given the Connection
+// and the vpp-agent names of its ServerInterface and ClientInterface.  Connections come from clients, so every action
+// is substituted as a quoted string and has to make up a whole value:
+//
+//	vppConfig:
+//	  routes:
+//	    - dstNetwork: 10.10.0.0/16
+//	      outgoingInterface: {{.ServerInterface}}
+//
+// The config rendered for a connection is recorded with its metadata, and exactly that is deleted with it.
+package rawconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/agentconfig"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+const (
+	// Label - the connection label naming the snippet file of the connection
+	Label = "vppagent-config"
+	// ConfigKey - the connmeta key of the config rendered for a connection, in the json mapping of configurator.Config
+	ConfigKey = "vpp.config_snippet"
+)
+
+type snippetKey struct{}
+
+type snippet struct {
+	name string
+	tmpl *template.Template
+	conn *networkservice.Connection
+	// rendered - the config merged into the txns of conn, rendered once
+	rendered *configurator.Config
+}
+
+// templateData - what snippets are given
+type templateData struct {
+	Connection      *networkservice.Connection
+	ServerInterface string
+	ClientInterface string
+}
+
+type rawConfigServer struct {
+	dir      string
+	metadata *connmeta.Store
+}
+
+// NewServer - returns a server chain element handing the snippets in dir named by the Label of connections to
+// UnaryClientInterceptor and recording the configs rendered in metadata.  Labels are ignored if dir is empty.
+func NewServer(dir string, metadata *connmeta.Store) networkservice.NetworkServiceServer {
+	return &rawConfigServer{dir: dir, metadata: metadata}
+}
+
+func (r *rawConfigServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s, err := r.load(request.GetConnection())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+	if s == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	conn, err := next.Server(ctx).Request(context.WithValue(ctx, snippetKey{}, s), request)
+	if err != nil {
+		return nil, err
+	}
+	if s.rendered != nil {
+		data, marshalErr := (&jsonpb.Marshaler{}).MarshalToString(s.rendered)
+		if marshalErr != nil {
+			log.Entry(ctx).Warnf("vpp-agent config snippet of connection %s cannot be recorded to be deleted with it: %+v", conn.GetId(), marshalErr)
+			return conn, nil
+		}
+		r.metadata.Set(conn.GetId(), ConfigKey, data)
+	}
+	return conn, nil
+}
+
+func (r *rawConfigServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if data, ok := r.metadata.Get(conn.GetId(), ConfigKey); ok {
+		rendered := &configurator.Config{}
+		if err := jsonpb.UnmarshalString(data, rendered); err != nil {
+			log.Entry(ctx).Warnf("vpp-agent config snippet of connection %s cannot be deleted with it: %+v", conn.GetId(), err)
+		} else {
+			ctx = context.WithValue(ctx, snippetKey{}, &snippet{conn: conn, rendered: rendered})
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// load - returns the snippet of conn, nil if it has none
+func (r *rawConfigServer) load(conn *networkservice.Connection) (*snippet, error) {
+	name := conn.GetLabels()[Label]
+	if r.dir == "" || name == "" {
+		return nil, nil
+	}
+	if filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+		return nil, errors.Errorf("invalid vpp-agent config snippet name %q", name)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read vpp-agent config snippet %s", name)
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{quoteFunc: quote}).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid vpp-agent config snippet %s", name)
+	}
+	for _, t := range tmpl.Templates() {
+		quoteActions(t.Tree, t.Tree.Root)
+	}
+	return &snippet{name: name, tmpl: tmpl, conn: conn}, nil
+}
+
+const quoteFunc = "rawconfigQuote"
+
+// quote - returns v as a double quoted string, which YAML and JSON alike take as a single string value
+func quote(v interface{}) (string, error) {
+	data, err := json.Marshal(fmt.Sprint(v))
+	return string(data), err
+}
+
+// quoteActions - makes every action of node that outputs anything, as templates of tree, pipe its output into quote
+func quoteActions(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			quoteActions(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(quoteFunc).SetTree(tree).SetPos(n.Pos)},
+			})
+		}
+	case *parse.IfNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.RangeNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	case *parse.WithNode:
+		quoteActions(tree, n.List)
+		quoteActions(tree, n.ElseList)
+	}
+}
+
+// render - returns the config of s for its connection, rendered on first use
+func (s *snippet) render() (*configurator.Config, error) {
+	if s.rendered != nil {
+		return s.rendered, nil
+	}
+	var buf bytes.Buffer
+	if err := s.tmpl.Execute(&buf, &templateData{
+		Connection:      s.conn,
+		ServerInterface: vppnames.ServerInterface(s.conn),
+		ClientInterface: vppnames.ClientInterface(s.conn),
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to render vpp-agent config snippet %s", s.name)
+	}
+	conf, err := agentconfig.Parse(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "vpp-agent config snippet %s", s.name)
+	}
+	s.rendered = conf
+	return conf, nil
+}
+
+// UnaryClientInterceptor - returns an interceptor merging the snippet of the connection into the vpp-agent Updates
+// of its server interface, and the config recorded for it into the Deletes
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		s, ok := ctx.Value(snippetKey{}).(*snippet)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			if has(r.GetUpdate().GetVppConfig().GetInterfaces(), vppnames.ServerInterface(s.conn)) {
+				conf, err := s.render()
+				if err != nil {
+					metrics.Int("vppagent_config_snippet_errors").Add(1)
+					return status.Errorf(codes.InvalidArgument, "%s", err)
+				}
+				r = proto.Clone(r).(*configurator.UpdateRequest)
+				proto.Merge(r.Update, conf)
+				req = r
+			}
+		case *configurator.DeleteRequest:
+			// What was merged into the Update, or recorded for Close, never rendered again
+			if s.rendered != nil && has(r.GetDelete().GetVppConfig().GetInterfaces(), vppnames.ServerInterface(s.conn)) {
+				r = proto.Clone(r).(*configurator.DeleteRequest)
+				proto.Merge(r.Delete, s.rendered)
+				req = r
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func has(ifaces []*vpp_interfaces.Interface, name string) bool {
+	for _, iface := range ifaces {
+		if iface.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rawconfig_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/rawconfig"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+const snippet = `vppConfig:
+  routes:
+    - dstNetwork: 10.10.0.0/16
+      outgoingInterface: {{index .Connection.Labels "route-via"}}
+`
+
+// vppServer - sends the Update and Delete of the server interface of the connection through rawconfig's
+// interceptor, keeping the requests reaching vpp-agent
+type vppServer struct {
+	updates []*configurator.UpdateRequest
+	deletes []*configurator.DeleteRequest
+}
+
+func (v *vppServer) invoke(ctx context.Context, req interface{}) error {
+	invoker := func(_ context.Context, _ string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			v.updates = append(v.updates, r)
+		case *configurator.DeleteRequest:
+			v.deletes = append(v.deletes, r)
+		}
+		return nil
+	}
+	return rawconfig.UnaryClientInterceptor()(ctx, "", req, nil, nil, invoker)
+}
+
+func config(conn *networkservice.Connection) *configurator.Config {
+	return &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: vppnames.ServerInterface(conn)}},
+	}}
+}
+
+func (v *vppServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn := request.GetConnection()
+	if err := v.invoke(ctx, &configurator.UpdateRequest{Update: config(conn)}); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (v *vppServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, v.invoke(ctx, &configurator.DeleteRequest{Delete: config(conn)})
+}
+
+type fixture struct {
+	dir      string
+	cancel   context.CancelFunc
+	metadata *connmeta.Store
+	vppagent *vppServer
+	server   networkservice.NetworkServiceServer
+}
+
+func setup(t *testing.T) *fixture {
+	dir, err := ioutil.TempDir("", "rawconfig")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "routes.yaml"), []byte(snippet), 0600))
+	ctx, cancel := context.WithCancel(context.Background())
+	metadata, err := connmeta.NewStore(ctx, "", time.Minute)
+	require.NoError(t, err)
+	f := &fixture{dir: dir, cancel: cancel, metadata: metadata, vppagent: &vppServer{}}
+	f.server = chain.NewNetworkServiceServer(rawconfig.NewServer(dir, metadata), f.vppagent)
+	return f
+}
+
+func (f *fixture) cleanup() {
+	f.cancel()
+	_ = os.RemoveAll(f.dir)
+}
+
+func connection(routeVia string) *networkservice.Connection {
+	return &networkservice.Connection{
+		Id:     "conn-1",
+		Labels: map[string]string{rawconfig.Label: "routes.yaml", "route-via": routeVia},
+	}
+}
+
+func TestValuesQuoted(t *testing.T) {
+	f := setup(t)
+	defer f.cleanup()
+
+	_, err := f.server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection: connection("memif0\n    - dstNetwork: 0.0.0.0/0"),
+	})
+	require.NoError(t, err)
+	require.Len(t, f.vppagent.updates, 1)
+	routes := f.vppagent.updates[0].GetUpdate().GetVppConfig().GetRoutes()
+	require.Len(t, routes, 1, "the label added a route of its own")
+	require.Equal(t, "memif0\n    - dstNetwork: 0.0.0.0/0", routes[0].GetOutgoingInterface())
+}
+
+func TestCloseDeletesRecordedConfig(t *testing.T) {
+	f := setup(t)
+	defer f.cleanup()
+
+	conn, err := f.server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: connection("memif0")})
+	require.NoError(t, err)
+	_, ok := f.metadata.Get(conn.GetId(), rawconfig.ConfigKey)
+	require.True(t, ok)
+
+	// Neither the snippet nor the labels changing since may change what is deleted
+	require.NoError(t, ioutil.WriteFile(filepath.Join(f.dir, "routes.yaml"), []byte("vppConfig: {}\n"), 0600))
+	conn.Labels["route-via"] = "memif1"
+	_, err = f.server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, f.vppagent.deletes, 1)
+	routes := f.vppagent.deletes[0].GetDelete().GetVppConfig().GetRoutes()
+	require.Len(t, routes, 1)
+	require.Equal(t, "memif0", routes[0].GetOutgoingInterface())
+	require.Equal(t, "10.10.0.0/16", routes[0].GetDstNetwork())
+}
+
+func TestInvalidSnippetName(t *testing.T) {
+	f := setup(t)
+	defer f.cleanup()
+
+	conn := connection("memif0")
+	conn.Labels[rawconfig.Label] = "../routes.yaml"
+	_, err := f.server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: conn})
+	require.Error(t, err)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package readiness - process wide readiness of the forwarder's components, reflected in the grpc health service
+package readiness
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	mu       sync.RWMutex
+	problems = make(map[string]error)
+)
+
+// Set - records the readiness of component, a nil err marks it ready
+func Set(component string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err == nil {
+		delete(problems, component)
+		return
+	}
+	problems[component] = err
+}
+
+// Err - returns an error describing every component that is not ready, or nil if all of them are
+func Err() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(problems) == 0 {
+		return nil
+	}
+	var msgs []string
+	for component, err := range problems {
+		msgs = append(msgs, component+": "+err.Error())
+	}
+	sort.Strings(msgs)
+	return errors.New(strings.Join(msgs, "; "))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package readiness_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+func TestSetAndErr(t *testing.T) {
+	require.NoError(t, readiness.Err())
+
+	readiness.Set("vpp", errors.New("not connected"))
+	readiness.Set("spire", errors.New("no svid"))
+	require.EqualError(t, readiness.Err(), "spire: no svid; vpp: not connected")
+
+	readiness.Set("vpp", nil)
+	require.EqualError(t, readiness.Err(), "spire: no svid")
+
+	readiness.Set("spire", nil)
+	require.NoError(t, readiness.Err())
+}
+
+func TestInterceptorReportsNotServing(t *testing.T) {
+	interceptor := readiness.UnaryServerInterceptor()
+	check := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	serving := func(context.Context, interface{}) (interface{}, error) {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+	}
+	status := func(info *grpc.UnaryServerInfo) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := interceptor(context.Background(), &grpc_health_v1.HealthCheckRequest{}, info, serving)
+		require.NoError(t, err)
+		return resp.(*grpc_health_v1.HealthCheckResponse).GetStatus()
+	}
+
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, status(check))
+
+	readiness.Set("vpp", errors.New("not connected"))
+	defer readiness.Set("vpp", nil)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, status(check))
+
+	// Only health checks are answered on behalf of the handler
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, status(&grpc.UnaryServerInfo{FullMethod: "/connection.NetworkService/Request"}))
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registration - registers the forwarder with the NSM registry, refreshing the registration before it
+// expires and unregistering it on shutdown so stale forwarder entries don't linger
+package registration
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	// refreshDivisor - registrations are refreshed after 1/refreshDivisor of their lifetime
+	refreshDivisor = 3
+	// jitterDivisor - refreshes are jittered by up to +-1/jitterDivisor of the refresh period
+	jitterDivisor = 10
+	// minRefreshPeriod - floor of the refresh period, however short the lifetime
+	minRefreshPeriod  = time.Second
+	retryPeriod       = 5 * time.Second
+	unregisterTimeout = 5 * time.Second
+)
+
+// Register - registers nse via cc to expire after lifetime and keeps refreshing it, jittered so that forwarders
+// started together don't refresh together, until ctx is done, when it is unregistered.  The initial registration is
+// synchronous and its error returned.
+func Register(ctx context.Context, cc grpc.ClientConnInterface, nse *registry.NetworkServiceEndpoint, lifetime time.Duration) error {
+	if lifetime <= 0 {
+		return errors.Errorf("registration lifetime must be positive, got %s", lifetime)
+	}
+	client := registry.NewNetworkServiceEndpointRegistryClient(cc)
+	registered, err := register(ctx, client, nse, lifetime)
+	if err != nil {
+		return err
+	}
+	log.Entry(ctx).Infof("registered %s at %s", registered.GetName(), registered.GetUrl())
+	go func() {
+		timer := time.NewTimer(refreshPeriod(lifetime))
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				unregister(ctx, client, registered)
+				return
+			case <-timer.C:
+			}
+			refreshed, refreshErr := register(ctx, client, registered, lifetime)
+			if refreshErr != nil {
+				log.Entry(ctx).Warnf("failed to refresh registration of %s, retrying in %s: %+v", registered.GetName(), retryPeriod, refreshErr)
+				timer.Reset(retryPeriod)
+				continue
+			}
+			registered = refreshed
+			timer.Reset(refreshPeriod(lifetime))
+		}
+	}()
+	return nil
+}
+
+func register(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient, nse *registry.NetworkServiceEndpoint, lifetime time.Duration) (*registry.NetworkServiceEndpoint, error) {
+	expirationTime, err := ptypes.TimestampProto(time.Now().Add(lifetime))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nse.ExpirationTime = expirationTime
+	registered, err := client.Register(ctx, nse, grpc.WaitForReady(true))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to register %s", nse.GetName())
+	}
+	return registered, nil
+}
+
+// unregister - unregisters nse on shutdown, when ctx is already done
+func unregister(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient, nse *registry.NetworkServiceEndpoint) {
+	unregisterCtx, cancel := context.WithTimeout(context.Background(), unregisterTimeout)
+	defer cancel()
+	if _, err := client.Unregister(unregisterCtx, nse); err != nil {
+		log.Entry(ctx).Warnf("failed to unregister %s, it expires at %s: %+v", nse.GetName(), ptypes.TimestampString(nse.GetExpirationTime()), err)
+		return
+	}
+	log.Entry(ctx).Infof("unregistered %s", nse.GetName())
+}
+
+func refreshPeriod(lifetime time.Duration) time.Duration {
+	period := lifetime / refreshDivisor
+	if period < minRefreshPeriod {
+		period = minRefreshPeriod
+	}
+	jitter := period / jitterDivisor
+	if jitter <= 0 {
+		return period
+	}
+	// #nosec
+	return period - jitter + time.Duration(rand.Int63n(int64(2*jitter)))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registration_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/registration"
+)
+
+// nsmgr - keeps the registrations and unregistrations made through it, failing Registers with registerErr
+type nsmgr struct {
+	mu           sync.Mutex
+	registerErr  error
+	registered   []*registry.NetworkServiceEndpoint
+	unregistered []*registry.NetworkServiceEndpoint
+}
+
+func (n *nsmgr) Invoke(_ context.Context, method string, args, reply interface{}, _ ...grpc.CallOption) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	nse := proto.Clone(args.(proto.Message)).(*registry.NetworkServiceEndpoint)
+	switch {
+	case strings.HasSuffix(method, "/Register"):
+		if n.registerErr != nil {
+			return n.registerErr
+		}
+		n.registered = append(n.registered, nse)
+		proto.Merge(reply.(proto.Message), nse)
+	case strings.HasSuffix(method, "/Unregister"):
+		n.unregistered = append(n.unregistered, nse)
+	}
+	return nil
+}
+
+func (n *nsmgr) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func (n *nsmgr) counts() (registered, unregistered int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.registered), len(n.unregistered)
+}
+
+func (n *nsmgr) firstRegistered() *registry.NetworkServiceEndpoint {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.registered[0]
+}
+
+func TestRegisterRefreshesAndUnregisters(t *testing.T) {
+	cc := &nsmgr{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nse := &registry.NetworkServiceEndpoint{Name: "forwarder-1", Url: "unix:///listen.on.sock"}
+
+	// The shortest refresh period is a second, however short the lifetime
+	start := time.Now()
+	require.NoError(t, registration.Register(ctx, cc, nse, 3*time.Second))
+	registered, _ := cc.counts()
+	require.Equal(t, 1, registered, "the initial registration is synchronous")
+	expirationTime, err := ptypes.Timestamp(cc.firstRegistered().GetExpirationTime())
+	require.NoError(t, err)
+	require.True(t, expirationTime.After(start.Add(3*time.Second-time.Millisecond)))
+	require.True(t, expirationTime.Before(time.Now().Add(3*time.Second)))
+
+	require.Eventually(t, func() bool {
+		registered, _ := cc.counts()
+		return registered >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+	require.True(t, time.Since(start) > 800*time.Millisecond, "the registration is refreshed after a third of its lifetime")
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, unregistered := cc.counts()
+		return unregistered == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "forwarder-1", cc.unregistered[0].GetName())
+}
+
+func TestRegisterErrors(t *testing.T) {
+	nse := &registry.NetworkServiceEndpoint{Name: "forwarder-1"}
+	require.Error(t, registration.Register(context.Background(), &nsmgr{}, nse, 0))
+
+	cc := &nsmgr{registerErr: errors.New("registry unavailable")}
+	err := registration.Register(context.Background(), cc, nse, time.Minute)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "registry unavailable")
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remoteswap - chain element supporting heals that move the remote side of a connection, e.g. to another
+// mechanism or a remote endpoint with a different TunnelIP, without tearing down the client side.
+//
+// Such a heal reaches the forwarder as a Request for the existing connection.  The vpp-agent Update it results in
+// leaves the server side interface untouched, as its name and values are unchanged, and reprograms the remote side
+// and the cross connect.  What vpp-agent doesn't know is that the previous remote side interface, if it had another
+// name, is gone: this element deletes it.
+package remoteswap
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+type remoteSwapServer struct {
+	client   configurator.ConfiguratorServiceClient
+	metadata *connmeta.Store
+}
+
+// NewServer - returns a server chain element deleting, using vppagentCC, the previous remote side interface of
+// connections whose remote side was swapped, as recorded in metadata.  It has to precede connmeta.NewServer in the
+// chain.
+func NewServer(vppagentCC grpc.ClientConnInterface, metadata *connmeta.Store) networkservice.NetworkServiceServer {
+	return &remoteSwapServer{
+		client:   configurator.NewConfiguratorServiceClient(vppagentCC),
+		metadata: metadata,
+	}
+}
+
+func (r *remoteSwapServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	previous, _ := r.metadata.Get(request.GetConnection().GetId(), connmeta.ClientInterfaceKey)
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	current := vppnames.ClientInterface(conn)
+	if previous == "" || current == "" || previous == current {
+		return conn, nil
+	}
+	log.Entry(ctx).Infof("remote side of connection %s moved from %s to %s, deleting %s", conn.GetId(), previous, current, previous)
+	metrics.Int("remote_swaps").Add(1)
+	serverInterface := vppnames.ServerInterface(conn)
+	conf := &vpp.ConfigData{
+		Interfaces:    []*vpp.Interface{{Name: previous}},
+		XconnectPairs: []*vpp.L2XConnect{{ReceiveInterface: previous, TransmitInterface: serverInterface}},
+	}
+	if _, err = r.client.Delete(ctx, &configurator.DeleteRequest{Delete: &configurator.Config{VppConfig: conf}}); err != nil {
+		log.Entry(ctx).Warnf("failed to delete previous remote side interface %s of connection %s: %+v", previous, conn.GetId(), err)
+	}
+	return conn, nil
+}
+
+func (r *remoteSwapServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
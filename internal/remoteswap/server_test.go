@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remoteswap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/connmeta"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/remoteswap"
+)
+
+// deleteCC - records the Deletes it gets
+type deleteCC struct {
+	deletes []*configurator.DeleteRequest
+}
+
+func (c *deleteCC) Invoke(_ context.Context, _ string, args, _ interface{}, _ ...grpc.CallOption) error {
+	request, ok := args.(*configurator.DeleteRequest)
+	if !ok {
+		return errors.Errorf("unexpected %T", args)
+	}
+	c.deletes = append(c.deletes, request)
+	return nil
+}
+
+func (c *deleteCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func request(nextSegment string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{
+			Id: "conn-1",
+			Path: &networkservice.Path{
+				PathSegments: []*networkservice.PathSegment{{Id: "conn-1"}, {Id: nextSegment}},
+			},
+		},
+	}
+}
+
+func TestRemoteSwap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := connmeta.NewStore(ctx, "", time.Hour)
+	require.NoError(t, err)
+	cc := &deleteCC{}
+	server := chain.NewNetworkServiceServer(remoteswap.NewServer(cc, store), connmeta.NewServer(store))
+
+	_, err = server.Request(ctx, request("endpoint-a"))
+	require.NoError(t, err)
+	require.Empty(t, cc.deletes, "nothing to swap on the first Request")
+
+	_, err = server.Request(ctx, request("endpoint-a"))
+	require.NoError(t, err)
+	require.Empty(t, cc.deletes, "a refresh keeps the remote side")
+
+	_, err = server.Request(ctx, request("endpoint-b"))
+	require.NoError(t, err)
+	require.Len(t, cc.deletes, 1)
+	conf := cc.deletes[0].GetDelete().GetVppConfig()
+	require.Len(t, conf.GetInterfaces(), 1)
+	require.Equal(t, "client-endpoint-a", conf.GetInterfaces()[0].GetName())
+	require.Len(t, conf.GetXconnectPairs(), 1)
+	require.Equal(t, "client-endpoint-a", conf.GetXconnectPairs()[0].GetReceiveInterface())
+	require.Equal(t, "server-conn-1", conf.GetXconnectPairs()[0].GetTransmitInterface())
+
+	value, _ := store.Get("conn-1", connmeta.ClientInterfaceKey)
+	require.Equal(t, "client-endpoint-b", value)
+}
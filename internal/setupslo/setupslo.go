@@ -0,0 +1,256 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package setupslo - measures how long Requests take and how much of it each chain element accounts for, against a
+// target latency at a quantile, e.g. 250ms at the 0.99 quantile, so the chain can be optimized where it actually
+// spends its time.
+//
+// Probes placed in front of each element record when a Request enters and leaves them.  The time between two
+// consecutive records is attributed to the element whose probe is innermost at that point, what follows the last
+// element (the mechanisms, the Request to the endpoint and the vpp-agent txns) to "endpoint".
+package setupslo
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+const (
+	// total - the name the latencies of whole Requests are recorded under
+	total = "total"
+	// rest - the name of what follows the last instrumented element
+	rest = "endpoint"
+	// window - the number of latest latencies the quantiles are computed over
+	window         = 1024
+	reportInterval = 10 * time.Second
+)
+
+// Tracker - the latest Request latencies, of whole Requests and of each element
+type Tracker struct {
+	target   time.Duration
+	quantile float64
+
+	mu        sync.Mutex
+	latencies map[string]*samples
+	breached  bool
+}
+
+type samples struct {
+	values []time.Duration
+	next   int
+}
+
+func (s *samples) add(d time.Duration) {
+	if len(s.values) < window {
+		s.values = append(s.values, d)
+		return
+	}
+	s.values[s.next] = d
+	s.next = (s.next + 1) % window
+}
+
+func (s *samples) at(quantile float64) time.Duration {
+	sorted := append([]time.Duration(nil), s.values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(quantile*float64(len(sorted)-1))]
+}
+
+// NewTracker - returns a Tracker of Requests against target at quantile, exporting the quantile of the latency of
+// Requests and of each element as setup_latency_us.<element> until ctx is done.  A target of 0 disables it.
+func NewTracker(ctx context.Context, target time.Duration, quantile float64) *Tracker {
+	t := &Tracker{
+		target:    target,
+		quantile:  quantile,
+		latencies: make(map[string]*samples),
+	}
+	if target <= 0 {
+		return t
+	}
+	go func() {
+		ticker := time.NewTicker(reportInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			t.report(ctx)
+		}
+	}()
+	return t
+}
+
+// Instrument - returns elements with a probe in front of each and one after the last, elements as is if t is
+// disabled
+func (t *Tracker) Instrument(elements ...networkservice.NetworkServiceServer) []networkservice.NetworkServiceServer {
+	if t.target <= 0 {
+		return elements
+	}
+	rv := make([]networkservice.NetworkServiceServer, 0, 2*len(elements)+1)
+	for _, element := range elements {
+		rv = append(rv, &probeServer{tracker: t, name: elementName(element)}, element)
+	}
+	return append(rv, &probeServer{tracker: t, name: rest})
+}
+
+// elementName - returns the name of the package of element, e.g. "peerpolicy"
+func elementName(element networkservice.NetworkServiceServer) string {
+	elementType := reflect.TypeOf(element)
+	for elementType.Kind() == reflect.Ptr {
+		elementType = elementType.Elem()
+	}
+	if elementType.PkgPath() == "" {
+		return strings.TrimPrefix(fmt.Sprintf("%T", element), "*")
+	}
+	return path.Base(elementType.PkgPath())
+}
+
+func (t *Tracker) observe(ctx context.Context, r *recorder) {
+	elapsed, elements := r.attribute()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sample(total, elapsed)
+	for name, d := range elements {
+		t.sample(name, d)
+	}
+	if elapsed <= t.target {
+		return
+	}
+	slowest := rest
+	for name, d := range elements {
+		if d > elements[slowest] {
+			slowest = name
+		}
+	}
+	metrics.Int("setup_slo_violations").Add(1)
+	metrics.Int("setup_slo_violations." + slowest).Add(1)
+	log.Entry(ctx).Debugf("Request took %s, over the setup latency target of %s, %s of it in %s", elapsed, t.target, elements[slowest], slowest)
+}
+
+func (t *Tracker) sample(name string, d time.Duration) {
+	s, ok := t.latencies[name]
+	if !ok {
+		s = &samples{}
+		t.latencies[name] = s
+	}
+	s.add(d)
+}
+
+func (t *Tracker) report(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.latencies[total]; !ok {
+		return
+	}
+	for name, s := range t.latencies {
+		metrics.Int("setup_latency_us." + name).Set(int64(s.at(t.quantile) / time.Microsecond))
+	}
+	latency := t.latencies[total].at(t.quantile)
+	breached := latency > t.target
+	if breached == t.breached {
+		return
+	}
+	t.breached = breached
+	if breached {
+		metrics.Int("setup_slo_breached").Set(1)
+		log.Entry(ctx).Warnf("%v quantile of the Request latency is %s, over the target of %s", t.quantile, latency, t.target)
+		return
+	}
+	metrics.Int("setup_slo_breached").Set(0)
+	log.Entry(ctx).Infof("%v quantile of the Request latency is %s, back under the target of %s", t.quantile, latency, t.target)
+}
+
+type recorderKey struct{}
+
+type record struct {
+	name  string
+	enter bool
+	at    time.Time
+}
+
+// recorder - the records of the probes a Request passed
+type recorder struct {
+	mu      sync.Mutex
+	records []record
+}
+
+func (r *recorder) add(name string, enter bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, record{name: name, enter: enter, at: time.Now()})
+}
+
+// attribute - returns the time between the first and the last record, and the part of it each element accounts for
+func (r *recorder) attribute() (time.Duration, map[string]time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	elements := make(map[string]time.Duration)
+	var stack []string
+	for i, rec := range r.records {
+		if i > 0 && len(stack) > 0 {
+			elements[stack[len(stack)-1]] += rec.at.Sub(r.records[i-1].at)
+		}
+		if rec.enter {
+			stack = append(stack, rec.name)
+		} else if len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(r.records) == 0 {
+		return 0, elements
+	}
+	return r.records[len(r.records)-1].at.Sub(r.records[0].at), elements
+}
+
+type probeServer struct {
+	tracker *Tracker
+	name    string
+}
+
+func (p *probeServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	r, ok := ctx.Value(recorderKey{}).(*recorder)
+	if !ok {
+		r = &recorder{}
+		ctx = context.WithValue(ctx, recorderKey{}, r)
+	}
+	r.add(p.name, true)
+	conn, err := next.Server(ctx).Request(ctx, request)
+	r.add(p.name, false)
+	// The outermost probe accounts for the Request, failed ones are not setups
+	if !ok && err == nil {
+		p.tracker.observe(ctx, r)
+	}
+	return conn, err
+}
+
+func (p *probeServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package setupslo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// slowServer - chain element taking delay over each Request, failing it with err
+type slowServer struct {
+	delay time.Duration
+	err   error
+}
+
+func (s *slowServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *slowServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func TestSamplesAt(t *testing.T) {
+	s := &samples{}
+	for i := 1; i <= 100; i++ {
+		s.add(time.Duration(i) * time.Millisecond)
+	}
+	require.Equal(t, 100*time.Millisecond, s.at(1))
+	require.Equal(t, 50*time.Millisecond, s.at(0.5))
+	require.Equal(t, time.Millisecond, s.at(0))
+
+	// Only the latest window latencies count
+	for i := 0; i < window; i++ {
+		s.add(time.Second)
+	}
+	require.Len(t, s.values, window)
+	require.Equal(t, time.Second, s.at(0))
+}
+
+func TestAttribute(t *testing.T) {
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	r := &recorder{records: []record{
+		{name: "a", enter: true, at: at(0)},
+		{name: "b", enter: true, at: at(1)},
+		{name: rest, enter: true, at: at(4)},
+		{name: rest, enter: false, at: at(10)},
+		{name: "b", enter: false, at: at(12)},
+		{name: "a", enter: false, at: at(13)},
+	}}
+	elapsed, elements := r.attribute()
+	require.Equal(t, 13*time.Millisecond, elapsed)
+	require.Equal(t, map[string]time.Duration{
+		"a":  2 * time.Millisecond,
+		"b":  5 * time.Millisecond,
+		rest: 6 * time.Millisecond,
+	}, elements)
+
+	elapsed, elements = (&recorder{}).attribute()
+	require.Zero(t, elapsed)
+	require.Empty(t, elements)
+}
+
+func TestInstrumentDisabled(t *testing.T) {
+	element := &slowServer{}
+	tracker := NewTracker(context.Background(), 0, 0.99)
+	require.Equal(t, []networkservice.NetworkServiceServer{element}, tracker.Instrument(element))
+}
+
+func TestTrackRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker := NewTracker(ctx, 10*time.Millisecond, 0.99)
+	// Elements are named after their package
+	require.Equal(t, "setupslo", elementName(&slowServer{}))
+	violations := metrics.Int("setup_slo_violations.setupslo").Value()
+
+	server := chain.NewNetworkServiceServer(tracker.Instrument(&slowServer{delay: 20 * time.Millisecond})...)
+	_, err := server.Request(ctx, &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: "conn-1"}})
+	require.NoError(t, err)
+	require.Equal(t, violations+1, metrics.Int("setup_slo_violations.setupslo").Value(),
+		"the slow element was not blamed for the violation")
+
+	// Failed Requests are not setups
+	failing := chain.NewNetworkServiceServer(tracker.Instrument(&slowServer{err: errors.New("failed")})...)
+	_, err = failing.Request(ctx, &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: "conn-2"}})
+	require.Error(t, err)
+
+	tracker.mu.Lock()
+	require.Len(t, tracker.latencies[total].values, 1)
+	require.True(t, tracker.latencies[total].values[0] >= 20*time.Millisecond)
+	require.True(t, tracker.latencies["setupslo"].values[0] >= 20*time.Millisecond)
+	require.Contains(t, tracker.latencies, rest)
+	latency := tracker.latencies[total].values[0]
+	tracker.mu.Unlock()
+
+	tracker.report(ctx)
+	require.Equal(t, int64(1), metrics.Int("setup_slo_breached").Value())
+	require.Equal(t, int64(latency/time.Microsecond), metrics.Int("setup_latency_us.total").Value())
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package sflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const (
+	linkPollPeriod = 100 * time.Millisecond
+	linkTimeout    = 10 * time.Second
+	// skfAdRandom - offset of the random number ancillary data of classic bpf (SKF_AD_OFF + SKF_AD_RANDOM)
+	skfAdRandom = 0xfffff000 + 56
+)
+
+// capture - brings up the host interface ifName and calls handle with the first snapLen bytes and the length of 1 in
+// rate of the frames it receives until ctx is done, the sampling done by a socket filter in the kernel
+func capture(ctx context.Context, ifName string, rate uint32, snapLen int, handle func(header []byte, frameLength int)) error {
+	link, err := waitForLink(ctx, ifName)
+	if err != nil {
+		return err
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		return errors.Wrapf(err, "failed to bring up %s", ifName)
+	}
+	// Opened for no protocol so that it receives nothing, from any interface, before the filter is attached and it is
+	// bound to ifName for all protocols
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return errors.Wrap(err, "failed to open a packet socket")
+	}
+	filter := []unix.SockFilter{{Code: unix.BPF_RET | unix.BPF_K, K: 0xffff}}
+	if rate > 1 {
+		filter = []unix.SockFilter{
+			{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: skfAdRandom},
+			{Code: unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K, Jt: 0, Jf: 1, K: uint32((1 << 32) / uint64(rate))},
+			{Code: unix.BPF_RET | unix.BPF_K, K: 0},
+			{Code: unix.BPF_RET | unix.BPF_K, K: 0xffff},
+		}
+	}
+	prog := &unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err = unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, prog); err != nil {
+		_ = unix.Close(fd)
+		return errors.Wrap(err, "failed to attach the sampling filter")
+	}
+	if err = unix.Bind(fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: link.Attrs().Index}); err != nil {
+		_ = unix.Close(fd)
+		return errors.Wrapf(err, "failed to bind to %s", ifName)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = unix.Close(fd)
+	}()
+	go func() {
+		buf := make([]byte, snapLen)
+		for {
+			// MSG_TRUNC returns the length of the frame rather than what fit in buf
+			n, _, recvErr := unix.Recvfrom(fd, buf, unix.MSG_TRUNC)
+			if ctx.Err() != nil {
+				return
+			}
+			if recvErr != nil {
+				if recvErr == unix.EINTR {
+					continue
+				}
+				log.Entry(ctx).Errorf("sflow capture on %s failed: %+v", ifName, recvErr)
+				return
+			}
+			captured := n
+			if captured > len(buf) {
+				captured = len(buf)
+			}
+			handle(buf[:captured], n)
+		}
+	}()
+	return nil
+}
+
+func waitForLink(ctx context.Context, ifName string) (netlink.Link, error) {
+	ctx, cancel := context.WithTimeout(ctx, linkTimeout)
+	defer cancel()
+	ticker := time.NewTicker(linkPollPeriod)
+	defer ticker.Stop()
+	for {
+		link, err := netlink.LinkByName(ifName)
+		if err == nil {
+			return link, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "host interface %s of the sflow tap did not appear", ifName)
+		case <-ticker.C:
+		}
+	}
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
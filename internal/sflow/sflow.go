@@ -0,0 +1,222 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sflow - exports 1 in N packets of the forwarder's connections to an sFlow collector, as a lightweight
+// alternative to ipfix on constrained nodes.  The connection interfaces are mirrored (SPAN) to a tap interface whose
+// host side the forwarder samples with a kernel socket filter, so only sampled packets reach userspace.
+package sflow
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+const (
+	defaultPort = "6343"
+	// tapName - vpp-agent name of the tap sampled packets are mirrored to
+	tapName = "sflow-tap"
+	// hostIfName - name of the host side of tapName, suffixed by the instance of the forwarder if not 0
+	hostIfName = "nsm-sflow"
+	// headerBytes - bytes of each sampled packet sent to the collector
+	headerBytes = 128
+)
+
+// Sampler - samples the packets mirrored to its tap and sends them to an sFlow collector
+type Sampler struct {
+	client     configurator.ConfiguratorServiceClient
+	conn       net.Conn
+	agent      net.IP
+	rate       uint32
+	hostIfName string
+	started    time.Time
+	mu         sync.Mutex
+	sequence   uint32
+	samples    uint32
+}
+
+// NewSampler - creates the tap connections are mirrored to using vppagentCC and starts sending 1 in rate of the
+// packets on it to collector (host[:port]) until ctx is done, agent being the address the samples are reported from
+// and instance the one of the forwarder on its node
+func NewSampler(ctx context.Context, vppagentCC grpc.ClientConnInterface, collector string, rate uint32, agent net.IP, instance int) (*Sampler, error) {
+	if rate == 0 {
+		return nil, errors.New("sampling rate must be at least 1")
+	}
+	if agent.To4() == nil {
+		return nil, errors.Errorf("sflow requires an ipv4 agent address, got %s", agent)
+	}
+	if _, _, err := net.SplitHostPort(collector); err != nil {
+		collector = net.JoinHostPort(collector, defaultPort)
+	}
+	conn, err := net.Dial("udp", collector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial sflow collector %s", collector)
+	}
+	s := &Sampler{
+		client:     configurator.NewConfiguratorServiceClient(vppagentCC),
+		conn:       conn,
+		agent:      agent.To4(),
+		rate:       rate,
+		hostIfName: hostIfName,
+		started:    time.Now(),
+	}
+	if instance > 0 {
+		s.hostIfName = fmt.Sprintf("%s%d", hostIfName, instance)
+	}
+	tap := &vpp_interfaces.Interface{
+		Name:    tapName,
+		Type:    vpp_interfaces.Interface_TAP,
+		Enabled: true,
+		Link: &vpp_interfaces.Interface_Tap{
+			Tap: &vpp_interfaces.TapLink{
+				Version:    2,
+				HostIfName: s.hostIfName,
+			},
+		},
+	}
+	if _, err = s.client.Update(ctx, &configurator.UpdateRequest{Update: &configurator.Config{
+		VppConfig: &vpp.ConfigData{Interfaces: []*vpp_interfaces.Interface{tap}},
+	}}); err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "failed to create the sflow tap")
+	}
+	if err = capture(ctx, s.hostIfName, rate, headerBytes, s.send); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	log.Entry(ctx).Infof("sending 1 in %d packets of the connections to sflow collector %s", rate, collector)
+	return s, nil
+}
+
+// send - sends a datagram with a single flow sample of header, copied from a frame of frameLength bytes
+func (s *Sampler) send(header []byte, frameLength int) {
+	s.mu.Lock()
+	s.sequence++
+	s.samples++
+	datagram := encode(s.agent, s.sequence, s.samples, uint32(time.Since(s.started)/time.Millisecond), s.rate, header, frameLength)
+	s.mu.Unlock()
+	if _, err := s.conn.Write(datagram); err != nil {
+		metrics.Int("sflow_send_errors").Add(1)
+		return
+	}
+	metrics.Int("sflow_samples").Add(1)
+}
+
+// encode - returns an sFlow v5 datagram carrying one flow sample with a raw ethernet packet header record
+func encode(agent net.IP, sequence, sampleSequence, uptime, rate uint32, header []byte, frameLength int) []byte {
+	padded := (len(header) + 3) &^ 3
+	record := make([]byte, 16+padded)
+	binary.BigEndian.PutUint32(record[0:], 1) // header protocol: ethernet
+	binary.BigEndian.PutUint32(record[4:], uint32(frameLength))
+	binary.BigEndian.PutUint32(record[8:], 0) // stripped
+	binary.BigEndian.PutUint32(record[12:], uint32(len(header)))
+	copy(record[16:], header)
+
+	sample := make([]byte, 32, 32+8+len(record))
+	binary.BigEndian.PutUint32(sample[0:], sampleSequence)
+	binary.BigEndian.PutUint32(sample[4:], 0) // source id: unknown interface
+	binary.BigEndian.PutUint32(sample[8:], rate)
+	binary.BigEndian.PutUint32(sample[12:], sampleSequence*rate)
+	binary.BigEndian.PutUint32(sample[16:], 0) // drops
+	binary.BigEndian.PutUint32(sample[20:], 0) // input: unknown
+	binary.BigEndian.PutUint32(sample[24:], 0) // output: unknown
+	binary.BigEndian.PutUint32(sample[28:], 1) // records
+	sample = appendUint32(sample, 1)           // record format: raw packet header
+	sample = appendUint32(sample, uint32(len(record)))
+	sample = append(sample, record...)
+
+	datagram := make([]byte, 0, 28+8+len(sample))
+	datagram = appendUint32(datagram, 5) // version
+	datagram = appendUint32(datagram, 1) // agent address type: ipv4
+	datagram = append(datagram, agent.To4()...)
+	datagram = appendUint32(datagram, 0) // sub agent id
+	datagram = appendUint32(datagram, sequence)
+	datagram = appendUint32(datagram, uptime)
+	datagram = appendUint32(datagram, 1) // samples
+	datagram = appendUint32(datagram, 1) // sample format: flow sample
+	datagram = appendUint32(datagram, uint32(len(sample)))
+	return append(datagram, sample...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+type sflowServer struct {
+	sampler *Sampler
+}
+
+// NewServer - returns a server chain element mirroring the client facing interface of every connection to the tap of
+// sampler, doing nothing if sampler is nil
+func NewServer(sampler *Sampler) networkservice.NetworkServiceServer {
+	return &sflowServer{sampler: sampler}
+}
+
+func (s *sflowServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || s.sampler == nil {
+		return conn, err
+	}
+	if _, err := s.sampler.client.Update(ctx, &configurator.UpdateRequest{Update: spanConfig(conn)}); err != nil {
+		_, _ = next.Server(ctx).Close(ctx, conn)
+		return nil, errors.Wrapf(err, "failed to sample connection %s", conn.GetId())
+	}
+	return conn, nil
+}
+
+func (s *sflowServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if s.sampler != nil {
+		if _, err := s.sampler.client.Delete(ctx, &configurator.DeleteRequest{Delete: spanConfig(conn)}); err != nil {
+			log.Entry(ctx).Warnf("failed to stop sampling connection %s: %+v", conn.GetId(), err)
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func spanConfig(conn *networkservice.Connection) *configurator.Config {
+	return &configurator.Config{
+		VppConfig: &vpp.ConfigData{
+			Spans: []*vpp_interfaces.Span{{
+				InterfaceFrom: vppnames.ServerInterface(conn),
+				InterfaceTo:   tapName,
+				Direction:     vpp_interfaces.Span_BOTH,
+			}},
+		},
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sflow
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	header := []byte{1, 2, 3, 4, 5}
+	datagram := encode(net.ParseIP("10.0.0.1"), 7, 3, 1000, 100, header, 1514)
+	word := func(offset int) uint32 {
+		return binary.BigEndian.Uint32(datagram[offset:])
+	}
+	// 28 bytes of datagram header, 8 of sample header, 40 of flow sample and 16 of record header before the header,
+	// padded to 8 bytes
+	require.Len(t, datagram, 28+8+40+16+8)
+
+	require.Equal(t, uint32(5), word(0), "version")
+	require.Equal(t, uint32(1), word(4), "agent address type")
+	require.Equal(t, []byte{10, 0, 0, 1}, datagram[8:12], "agent address")
+	require.Equal(t, uint32(7), word(16), "sequence")
+	require.Equal(t, uint32(1000), word(20), "uptime")
+	require.Equal(t, uint32(1), word(24), "samples")
+
+	require.Equal(t, uint32(1), word(28), "sample format")
+	require.Equal(t, uint32(len(datagram)-36), word(32), "sample length")
+	require.Equal(t, uint32(3), word(36), "sample sequence")
+	require.Equal(t, uint32(100), word(44), "sampling rate")
+	require.Equal(t, uint32(300), word(48), "sample pool")
+	require.Equal(t, uint32(1), word(64), "records")
+
+	require.Equal(t, uint32(1), word(68), "record format")
+	require.Equal(t, uint32(16+8), word(72), "record length")
+	require.Equal(t, uint32(1), word(76), "header protocol")
+	require.Equal(t, uint32(1514), word(80), "frame length")
+	require.Equal(t, uint32(len(header)), word(88), "header length")
+	require.Equal(t, []byte{1, 2, 3, 4, 5, 0, 0, 0}, datagram[92:])
+}
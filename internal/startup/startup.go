@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package startup - bounds the phases of the forwarder's startup by timeouts, taking a configurable action when one
+// expires so that a wedged dependency leads to a deterministic restart rather than a hang
+package startup
+
+import (
+	"context"
+	"time"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+)
+
+// Action - taken when a startup phase does not complete within its timeout
+type Action string
+
+// Actions
+const (
+	// Fatal - exits the forwarder, leaving the restart to its supervisor
+	Fatal Action = "fatal"
+	// Retry - cancels the phase and runs it again, up to Retries times, then exits
+	Retry Action = "retry"
+	// Skip - cancels the phase and carries on without it, supported by phases that are optional only
+	Skip Action = "skip"
+)
+
+// cancelGrace - time a cancelled phase has to return before the forwarder exits regardless of the action
+const cancelGrace = 5 * time.Second
+
+// PhaseConfig - timeout of a startup phase and the action taken when it expires, intended to be embedded in the
+// forwarder's Config once per phase
+type PhaseConfig struct {
+	Timeout time.Duration `default:"0" desc:"time the startup phase may take, 0 waits forever"`
+	Action  string        `default:"fatal" desc:"action when the startup phase times out: fatal, retry or skip, phases not supporting it exit"`
+	Retries int           `default:"3" desc:"number of times a timed out startup phase is retried if Action is retry"`
+}
+
+// Bound - bounds phase name, which supports the Fatal action only, by config.Timeout.  The returned func has to be
+// called once the phase completed.
+func Bound(ctx context.Context, name string, config *PhaseConfig) (done func()) {
+	if config.Timeout <= 0 {
+		return func() {}
+	}
+	action(ctx, name, config, Fatal)
+	timer := time.AfterFunc(config.Timeout, func() {
+		expired(ctx, name, config, 1)
+		log.Entry(ctx).Fatalf("startup phase %s did not complete within %s", name, config.Timeout)
+	})
+	return func() { timer.Stop() }
+}
+
+// Run - runs phase name as f, bounded by config.Timeout.  When it expires the context passed to f is cancelled and
+// config.Action taken, falling back to Fatal unless it is supported, the one action besides Fatal the phase supports.
+// The context passed to f stays valid once f returned in time, so f may start things outliving the phase.  Run
+// returns the error f returned in time, or ctx.Err().
+func Run(ctx context.Context, name string, config *PhaseConfig, supported Action, f func(ctx context.Context) error) error {
+	if config.Timeout <= 0 {
+		return f(ctx)
+	}
+	a := action(ctx, name, config, supported)
+	for attempt := 1; ; attempt++ {
+		phaseCtx, cancel := context.WithCancel(ctx)
+		timer := time.AfterFunc(config.Timeout, cancel)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- f(phaseCtx)
+		}()
+		returned := false
+		select {
+		case err := <-errCh:
+			if timer.Stop() {
+				return err
+			}
+			returned = true
+		case <-phaseCtx.Done():
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		expired(ctx, name, config, attempt)
+		if a == Fatal || (a == Retry && attempt > config.Retries) {
+			log.Entry(ctx).Fatalf("startup phase %s did not complete within %s in %d attempt(s)", name, config.Timeout, attempt)
+		}
+		// Let f give up on its cancelled context before running it again or moving on
+		if !returned {
+			select {
+			case <-errCh:
+			case <-time.After(cancelGrace):
+				log.Entry(ctx).Fatalf("startup phase %s did not return within %s of being cancelled", name, cancelGrace)
+			}
+		}
+		if a == Skip {
+			log.Entry(ctx).Warnf("skipping startup phase %s", name)
+			return nil
+		}
+		log.Entry(ctx).Warnf("retrying startup phase %s (retry %d of %d)", name, attempt, config.Retries)
+	}
+}
+
+// action - returns the action configured for phase name, Fatal if it is neither Fatal nor supported
+func action(ctx context.Context, name string, config *PhaseConfig, supported Action) Action {
+	a := Action(config.Action)
+	if a != Fatal && a != supported {
+		log.Entry(ctx).Warnf("startup phase %s does not support the %q action, it will exit on timeout", name, config.Action)
+		return Fatal
+	}
+	return a
+}
+
+func expired(ctx context.Context, name string, config *PhaseConfig, attempt int) {
+	log.Entry(ctx).Errorf("startup phase %s timed out after %s (attempt %d)", name, config.Timeout, attempt)
+	events.Emitf(events.Warning, "StartupPhaseTimeout", "startup phase %s timed out after %s", name, config.Timeout)
+}
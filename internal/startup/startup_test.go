@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+)
+
+// hangOnce - returns a phase hanging until cancelled on its first attempt and succeeding on later ones, counting
+// the attempts
+func hangOnce(attempts *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*attempts++
+		if *attempts == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+}
+
+func TestRunInTime(t *testing.T) {
+	config := &startup.PhaseConfig{Timeout: time.Minute, Action: string(startup.Fatal)}
+	var phaseCtx context.Context
+	err := startup.Run(context.Background(), "phase", config, startup.Retry, func(ctx context.Context) error {
+		phaseCtx = ctx
+		return errors.New("failed in time")
+	})
+	require.EqualError(t, err, "failed in time")
+	require.NoError(t, phaseCtx.Err(), "the phase context outlives the phase")
+
+	require.NoError(t, startup.Run(context.Background(), "phase", &startup.PhaseConfig{}, startup.Retry, func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+		return nil
+	}))
+}
+
+func TestRunRetry(t *testing.T) {
+	attempts := 0
+	config := &startup.PhaseConfig{Timeout: 50 * time.Millisecond, Action: string(startup.Retry), Retries: 1}
+	require.NoError(t, startup.Run(context.Background(), "phase", config, startup.Retry, hangOnce(&attempts)))
+	require.Equal(t, 2, attempts)
+}
+
+func TestRunSkip(t *testing.T) {
+	attempts := 0
+	config := &startup.PhaseConfig{Timeout: 50 * time.Millisecond, Action: string(startup.Skip)}
+	require.NoError(t, startup.Run(context.Background(), "phase", config, startup.Skip, hangOnce(&attempts)))
+	require.Equal(t, 1, attempts)
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := &startup.PhaseConfig{Timeout: time.Minute, Action: string(startup.Fatal)}
+	err := startup.Run(ctx, "phase", config, startup.Retry, func(phaseCtx context.Context) error {
+		cancel()
+		<-phaseCtx.Done()
+		return phaseCtx.Err()
+	})
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestBound(t *testing.T) {
+	done := startup.Bound(context.Background(), "phase", &startup.PhaseConfig{Timeout: 50 * time.Millisecond})
+	done()
+	// The stopped timer doesn't fire
+	time.Sleep(100 * time.Millisecond)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// Timer - times the startup phases, each one ending when the next one begins, publishing their durations as the
+// startup_phase_seconds.<phase> metrics and logging them as fields, e.g. for the json logs of the prod profile
+type Timer struct {
+	started    time.Time
+	phase      string
+	phaseStart time.Time
+}
+
+// NewTimer - returns a Timer of a startup that began at started
+func NewTimer(started time.Time) *Timer {
+	return &Timer{started: started}
+}
+
+// Begin - ends the current phase, if any, and begins phase name
+func (t *Timer) Begin(ctx context.Context, name string) {
+	now := time.Now()
+	t.end(ctx, now)
+	t.phase, t.phaseStart = name, now
+}
+
+// Done - ends the current phase and records the duration of the whole startup as the startup_seconds metric
+func (t *Timer) Done(ctx context.Context) {
+	now := time.Now()
+	t.end(ctx, now)
+	t.phase = ""
+	total := now.Sub(t.started)
+	metrics.Float("startup_seconds").Set(total.Seconds())
+	log.Entry(ctx).WithFields(logrus.Fields{
+		"startup_seconds": total.Seconds(),
+	}).Info("startup completed")
+}
+
+func (t *Timer) end(ctx context.Context, now time.Time) {
+	if t.phase == "" {
+		return
+	}
+	duration := now.Sub(t.phaseStart)
+	metrics.Float("startup_phase_seconds." + t.phase).Set(duration.Seconds())
+	log.Entry(ctx).WithFields(logrus.Fields{
+		"startup_phase":         t.phase,
+		"startup_phase_seconds": duration.Seconds(),
+	}).Info("startup phase completed")
+}
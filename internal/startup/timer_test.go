@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+)
+
+func TestTimer(t *testing.T) {
+	started := time.Now()
+	timer := startup.NewTimer(started.Add(-time.Second))
+	timer.Begin(context.Background(), "test-1")
+	time.Sleep(20 * time.Millisecond)
+	timer.Begin(context.Background(), "test-2")
+	require.Zero(t, metrics.Float("startup_phase_seconds.test-2").Value(), "a phase was recorded before it ended")
+	timer.Done(context.Background())
+
+	first := metrics.Float("startup_phase_seconds.test-1").Value()
+	second := metrics.Float("startup_phase_seconds.test-2").Value()
+	require.True(t, first >= 0.02, "phase test-1 took %vs", first)
+	require.True(t, second > 0 && second < first, "phase test-2 took %vs", second)
+	total := metrics.Float("startup_seconds").Value()
+	require.True(t, total >= 1+first && total <= time.Since(started).Seconds()+1, "startup took %vs", total)
+
+	// Done ends the last phase only once
+	timer.Done(context.Background())
+	require.Equal(t, second, metrics.Float("startup_phase_seconds.test-2").Value())
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steering
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+const requestMethod = "/networkservice.NetworkService/Request"
+
+type steeringServer struct {
+	policy *Policy
+}
+
+// NewServer - returns a server chain element restricting the mechanisms Requests offer toward their client to those
+// policy allows them
+func NewServer(policy *Policy) networkservice.NetworkServiceServer {
+	return &steeringServer{policy: policy}
+}
+
+func (s *steeringServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	steered, err := s.policy.steer(ctx, request, Client)
+	if err != nil {
+		return nil, err
+	}
+	return next.Server(ctx).Request(ctx, steered)
+}
+
+func (s *steeringServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// UnaryClientInterceptor - returns an interceptor restricting the mechanisms the Requests forwarded toward their
+// endpoint offer to those p allows them
+func (p *Policy) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		request, ok := req.(*networkservice.NetworkServiceRequest)
+		if !ok || method != requestMethod {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		steered, err := p.steer(ctx, request, Endpoint)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, steered, reply, cc, opts...)
+	}
+}
+
+// steer - returns request offering only the mechanisms p allows it toward side, failing if none of the offered ones
+// is.  Requests offering none are left alone.
+func (p *Policy) steer(ctx context.Context, request *networkservice.NetworkServiceRequest, side Side) (*networkservice.NetworkServiceRequest, error) {
+	preferences := request.GetMechanismPreferences()
+	if len(preferences) == 0 {
+		return request, nil
+	}
+	allowed, types := p.Allowed(request.GetConnection(), side, preferences)
+	if len(allowed) == len(preferences) {
+		return request, nil
+	}
+	if len(allowed) == 0 {
+		metrics.Int("steering_rejected_requests").Add(1)
+		return nil, status.Errorf(codes.FailedPrecondition, "steering rules allow connection %s only %v toward its %s, none of which is offered",
+			request.GetConnection().GetId(), types, side)
+	}
+	log.Entry(ctx).Debugf("steering connection %s to %v toward its %s", request.GetConnection().GetId(), types, side)
+	steered := *request
+	steered.MechanismPreferences = allowed
+	return &steered, nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package steering - mechanism steering rules loaded from a yaml file and reloaded whenever the file changes:
+//
+//	rules:
+//	  - labels: {encryption: required}
+//	    mechanisms: [WIREGUARD, IPSEC]
+//	  - networkService: storage
+//	    clientMechanisms: [KERNEL_INTERFACE]
+//	    endpointMechanisms: [VXLAN, MEMIF]
+//
+// A connection matches a rule if it has all the labels of the rule and, if the rule names one, is of its network
+// service.  The connections matching rules may only use, toward their client, the mechanisms (by type, case
+// insensitive) all of these rules list in clientMechanisms and, toward their endpoint, those they list in
+// endpointMechanisms.  mechanisms stands for both sides where a rule sets no list of its own, a rule without a list
+// for a side leaves that side alone.  The endpoint side of a remote connection is the tunnel to the other forwarder.
+package steering
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+const reloadInterval = time.Second
+
+type rule struct {
+	Labels             map[string]string `yaml:"labels"`
+	NetworkService     string            `yaml:"networkService"`
+	Mechanisms         []string          `yaml:"mechanisms"`
+	ClientMechanisms   []string          `yaml:"clientMechanisms"`
+	EndpointMechanisms []string          `yaml:"endpointMechanisms"`
+}
+
+// Side - the side of the forwarder a mechanism connects it to
+type Side string
+
+// Sides of the forwarder
+const (
+	Client   Side = "client"
+	Endpoint Side = "endpoint"
+)
+
+type rules struct {
+	Rules []*rule `yaml:"rules"`
+}
+
+// Policy - steering rules backed by a file, the zero Policy lets every connection use any mechanism
+type Policy struct {
+	filename string
+	rules    atomic.Value
+}
+
+// NewFile - returns a Policy loaded from filename and reloaded on change until ctx is done
+func NewFile(ctx context.Context, filename string) (*Policy, error) {
+	p := &Policy{filename: filename}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	go p.watch(ctx, info)
+	return p, nil
+}
+
+// Allowed - returns the mechanisms of preferences conn may use toward side, all of them if it matches no rule
+// restricting that side, along with the mechanism types allowed to it (nil if any)
+func (p *Policy) Allowed(conn *networkservice.Connection, side Side, preferences []*networkservice.Mechanism) (allowed []*networkservice.Mechanism, types []string) {
+	r, ok := p.rules.Load().(*rules)
+	if !ok {
+		return preferences, nil
+	}
+	var matched [][]string
+	for _, candidate := range r.Rules {
+		if mechanisms := candidate.mechanisms(side); len(mechanisms) > 0 && candidate.matches(conn) {
+			matched = append(matched, mechanisms)
+		}
+	}
+	if len(matched) == 0 {
+		return preferences, nil
+	}
+	for _, mechanism := range preferences {
+		permitted := true
+		for _, m := range matched {
+			permitted = permitted && permits(m, mechanism.GetType())
+		}
+		if permitted {
+			allowed = append(allowed, mechanism)
+		}
+	}
+	for _, mechanismType := range matched[0] {
+		permitted := true
+		for _, m := range matched[1:] {
+			permitted = permitted && permits(m, mechanismType)
+		}
+		if permitted {
+			types = append(types, strings.ToUpper(mechanismType))
+		}
+	}
+	return allowed, types
+}
+
+// mechanisms - returns the mechanisms r allows toward side, nil if it does not restrict side
+func (r *rule) mechanisms(side Side) []string {
+	switch {
+	case side == Client && r.ClientMechanisms != nil:
+		return r.ClientMechanisms
+	case side == Endpoint && r.EndpointMechanisms != nil:
+		return r.EndpointMechanisms
+	}
+	return r.Mechanisms
+}
+
+func (r *rule) matches(conn *networkservice.Connection) bool {
+	if r.NetworkService != "" && r.NetworkService != conn.GetNetworkService() {
+		return false
+	}
+	for key, value := range r.Labels {
+		if conn.GetLabels()[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func permits(mechanisms []string, mechanismType string) bool {
+	for _, m := range mechanisms {
+		if strings.EqualFold(m, mechanismType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) watch(ctx context.Context, last os.FileInfo) {
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(p.filename)
+		if err != nil || (info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+			continue
+		}
+		last = info
+		if err := p.load(); err != nil {
+			log.Entry(ctx).Errorf("failed to reload steering rules, keeping the previous ones: %+v", err)
+			continue
+		}
+		log.Entry(ctx).Infof("reloaded steering rules from %s", p.filename)
+	}
+}
+
+func (p *Policy) load() error {
+	data, err := ioutil.ReadFile(p.filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r := &rules{}
+	if err := yaml.UnmarshalStrict(data, r); err != nil {
+		return errors.Wrapf(err, "invalid steering rules in %s", p.filename)
+	}
+	for i, candidate := range r.Rules {
+		if len(candidate.Mechanisms) == 0 && len(candidate.ClientMechanisms) == 0 && len(candidate.EndpointMechanisms) == 0 {
+			return errors.Errorf("steering rule %d in %s lists no mechanisms", i, p.filename)
+		}
+	}
+	p.rules.Store(r)
+	return nil
+}
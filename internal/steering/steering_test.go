@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package steering_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/memif"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/steering"
+)
+
+const rules = `
+rules:
+  - labels: {encryption: required}
+    mechanisms: [wireguard, ipsec]
+  - networkService: storage
+    mechanisms: [KERNEL_INTERFACE, MEMIF]
+  - networkService: storage
+    labels: {app: db}
+    mechanisms: [MEMIF]
+`
+
+// load - returns the policy of the rules in data, and a func to clean up after it
+func load(t *testing.T, data string) (policy *steering.Policy, cleanup func()) {
+	dir, err := ioutil.TempDir("", "steering")
+	require.NoError(t, err)
+	filename := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(data), 0600))
+	ctx, cancel := context.WithCancel(context.Background())
+	policy, err = steering.NewFile(ctx, filename)
+	require.NoError(t, err)
+	return policy, func() {
+		cancel()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	policy, cleanup := load(t, rules)
+	defer cleanup()
+
+	preferences := []*networkservice.Mechanism{{Type: kernel.MECHANISM}, {Type: memif.MECHANISM}, {Type: vxlan.MECHANISM}}
+
+	allowed, types := policy.Allowed(&networkservice.Connection{NetworkService: "web"}, steering.Client, preferences)
+	require.Equal(t, preferences, allowed)
+	require.Nil(t, types)
+
+	allowed, types = policy.Allowed(&networkservice.Connection{NetworkService: "storage"}, steering.Client, preferences)
+	require.Equal(t, preferences[:2], allowed)
+	require.Equal(t, []string{kernel.MECHANISM, memif.MECHANISM}, types)
+
+	allowed, _ = policy.Allowed(&networkservice.Connection{NetworkService: "storage", Labels: map[string]string{"app": "db"}}, steering.Endpoint, preferences)
+	require.Equal(t, preferences[1:2], allowed)
+
+	allowed, types = policy.Allowed(&networkservice.Connection{NetworkService: "web", Labels: map[string]string{"encryption": "required"}}, steering.Client, preferences)
+	require.Empty(t, allowed)
+	require.Equal(t, []string{"WIREGUARD", "IPSEC"}, types)
+
+	allowed, types = (&steering.Policy{}).Allowed(&networkservice.Connection{NetworkService: "storage"}, steering.Client, preferences)
+	require.Equal(t, preferences, allowed)
+	require.Nil(t, types)
+}
+
+const remoteRules = `
+rules:
+  - networkService: storage
+    clientMechanisms: [KERNEL_INTERFACE]
+    endpointMechanisms: [VXLAN]
+`
+
+// TestRemoteConnection - a kernel client of a remote endpoint gets a kernel interface toward the client and a vxlan
+// tunnel to the other forwarder toward the endpoint
+func TestRemoteConnection(t *testing.T) {
+	policy, cleanup := load(t, remoteRules)
+	defer cleanup()
+
+	conn := &networkservice.Connection{Id: "conn-1", NetworkService: "storage"}
+	var forwarded *networkservice.NetworkServiceRequest
+	invoker := func(_ context.Context, _ string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		forwarded = req.(*networkservice.NetworkServiceRequest)
+		return nil
+	}
+	// The client side of the forwarder, which forwards the Request toward the endpoint through the interceptor
+	forwarder := &forwardingServer{
+		forward: func(ctx context.Context, request *networkservice.NetworkServiceRequest) error {
+			return policy.UnaryClientInterceptor()(ctx, "/networkservice.NetworkService/Request", &networkservice.NetworkServiceRequest{
+				Connection:           request.GetConnection(),
+				MechanismPreferences: []*networkservice.Mechanism{{Type: memif.MECHANISM}, {Type: vxlan.MECHANISM}},
+			}, &networkservice.Connection{}, nil, invoker)
+		},
+	}
+	server := chain.NewNetworkServiceServer(steering.NewServer(policy), forwarder)
+	_, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection:           conn,
+		MechanismPreferences: []*networkservice.Mechanism{{Type: memif.MECHANISM}, {Type: kernel.MECHANISM}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*networkservice.Mechanism{{Type: kernel.MECHANISM}}, forwarder.preferences)
+	require.Equal(t, []*networkservice.Mechanism{{Type: vxlan.MECHANISM}}, forwarded.GetMechanismPreferences())
+
+	_, err = server.Request(context.Background(), &networkservice.NetworkServiceRequest{
+		Connection:           conn,
+		MechanismPreferences: []*networkservice.Mechanism{{Type: memif.MECHANISM}},
+	})
+	require.Error(t, err)
+}
+
+// forwardingServer - records the mechanism preferences reaching it and forwards the Request with forward
+type forwardingServer struct {
+	preferences []*networkservice.Mechanism
+	forward     func(ctx context.Context, request *networkservice.NetworkServiceRequest) error
+}
+
+func (f *forwardingServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	f.preferences = request.GetMechanismPreferences()
+	if err := f.forward(ctx, request); err != nil {
+		return nil, err
+	}
+	return request.GetConnection(), nil
+}
+
+func (f *forwardingServer) Close(context.Context, *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package svidrotation - makes rotations of the forwarder's x509 svid visible.
+//
+// The mTLS configs handed to grpc resolve the svid on every handshake, so a rotated svid is picked up by new
+// connections without rebinding listeners, while established streams keep the certificate they were set up with.
+package svidrotation
+
+import (
+	"context"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+const checkInterval = time.Second
+
+// Watch - checks source until ctx is done, logging and counting each svid rotation
+func Watch(ctx context.Context, source x509svid.Source) {
+	rotations := metrics.Int("svid_rotations")
+	expiry := metrics.Int("svid_expiry_seconds")
+	var serial string
+	ticker := time.NewTicker(checkInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			if svid, err := source.GetX509SVID(); err == nil && len(svid.Certificates) > 0 {
+				cert := svid.Certificates[0]
+				expiry.Set(cert.NotAfter.Unix())
+				if cert.SerialNumber.String() != serial {
+					if serial != "" {
+						rotations.Add(1)
+						log.Entry(ctx).Infof("SVID %q rotated: serial %s, expires %s", svid.ID, cert.SerialNumber, cert.NotAfter)
+						events.Emitf(events.Normal, "SVIDRotated", "SVID %q rotated, expires %s", svid.ID, cert.NotAfter)
+					}
+					serial = cert.SerialNumber.String()
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svidrotation_test
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/svidrotation"
+)
+
+// source - returns an svid with the current serial
+type source struct {
+	mu       sync.Mutex
+	serial   int64
+	notAfter time.Time
+}
+
+func (s *source) GetX509SVID() (*x509svid.SVID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &x509svid.SVID{Certificates: []*x509.Certificate{{SerialNumber: big.NewInt(s.serial), NotAfter: s.notAfter}}}, nil
+}
+
+func (s *source) rotate(notAfter time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serial++
+	s.notAfter = notAfter
+}
+
+func TestRotationCounted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second)
+	s := &source{serial: 1, notAfter: notAfter}
+	rotations := metrics.Int("svid_rotations").Value()
+
+	svidrotation.Watch(ctx, s)
+	require.Eventually(t, func() bool {
+		return metrics.Int("svid_expiry_seconds").Value() == notAfter.Unix()
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, rotations, metrics.Int("svid_rotations").Value(), "the first svid is no rotation")
+
+	s.rotate(notAfter.Add(time.Hour))
+	require.Eventually(t, func() bool {
+		return metrics.Int("svid_rotations").Value() == rotations+1
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Equal(t, notAfter.Add(time.Hour).Unix(), metrics.Int("svid_expiry_seconds").Value())
+}
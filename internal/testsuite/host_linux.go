@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package testsuite
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	underlayLinkA = "nsm-suite-a"
+	underlayLinkB = "nsm-suite-b"
+	netnsDir      = "/var/run/netns"
+)
+
+// setupUnderlay - creates the veth pair the vpps of the forwarders attach to, addressed with a and b, and returns
+// the func deleting it
+func setupUnderlay(a, b *net.IPNet) (func(), error) {
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: underlayLinkA}, PeerName: underlayLinkB}
+	if err := netlink.LinkAdd(veth); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the underlay veth pair %s", underlayLinkA)
+	}
+	cleanup := func() {
+		if link, err := netlink.LinkByName(underlayLinkA); err == nil {
+			_ = netlink.LinkDel(link)
+		}
+	}
+	for name, addr := range map[string]*net.IPNet{underlayLinkA: a, underlayLinkB: b} {
+		link, err := netlink.LinkByName(name)
+		if err != nil {
+			cleanup()
+			return nil, errors.WithStack(err)
+		}
+		if err = netlink.AddrAdd(link, &netlink.Addr{IPNet: addr}); err != nil {
+			cleanup()
+			return nil, errors.Wrapf(err, "failed to address %s with %s", name, addr)
+		}
+		if err = netlink.LinkSetUp(link); err != nil {
+			cleanup()
+			return nil, errors.Wrapf(err, "failed to set %s up", name)
+		}
+	}
+	return cleanup, nil
+}
+
+// newNamedNetNS - creates the netns name, leaving the calling thread in its own netns, and returns the func deleting it
+func newNamedNetNS(name string) (netns.NsHandle, func(), error) {
+	// Creating the netns moves the creating thread into it
+	runtime.LockOSThread()
+	curNetns, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return 0, nil, errors.WithStack(err)
+	}
+	defer func() { _ = curNetns.Close() }()
+	handle, err := netns.NewNamed(name)
+	if err != nil {
+		// A thread left in the wrong netns is not handed back, it exits with its goroutine
+		if netns.Set(curNetns) == nil {
+			runtime.UnlockOSThread()
+		}
+		return 0, nil, errors.Wrapf(err, "failed to create netns %s", name)
+	}
+	if err = netns.Set(curNetns); err != nil {
+		return 0, nil, errors.WithStack(err)
+	}
+	runtime.UnlockOSThread()
+	return handle, func() {
+		_ = handle.Close()
+		path := filepath.Join(netnsDir, name)
+		_ = unix.Unmount(path, unix.MNT_DETACH)
+		_ = os.Remove(path)
+	}, nil
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux,!windows
+
+package testsuite
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netns"
+)
+
+func setupUnderlay(*net.IPNet, *net.IPNet) (func(), error) {
+	return nil, errors.New("the test suite underlay is only supported on linux")
+}
+
+func newNamedNetNS(string) (netns.NsHandle, func(), error) {
+	return 0, nil, errors.New("the test suite netns is only supported on linux")
+}
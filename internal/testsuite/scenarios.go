@@ -0,0 +1,251 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package testsuite
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pingcheck"
+)
+
+const (
+	pingTimeout         = 5 * time.Second
+	linkStatePollPeriod = 100 * time.Millisecond
+)
+
+// local - a connection to the endpoint through forwarder-a only
+func local(ctx context.Context, s *suite) error {
+	conn, err := s.request(ctx, localService)
+	if err != nil {
+		return err
+	}
+	return s.closeAfter(ctx, conn, s.check(ctx, conn))
+}
+
+// remote - a connection to the endpoint through forwarder-a and forwarder-b, over a vxlan tunnel between them
+func remote(ctx context.Context, s *suite) error {
+	conn, err := s.request(ctx, remoteService)
+	if err != nil {
+		return err
+	}
+	return s.closeAfter(ctx, conn, s.checkRemote(ctx, conn))
+}
+
+// refresh - a connection keeps its id and addresses, and its connectivity, when refreshed
+func refresh(ctx context.Context, s *suite) error {
+	conn, err := s.request(ctx, localService)
+	if err != nil {
+		return err
+	}
+	refreshed, err := s.client.Request(ctx, &networkservice.NetworkServiceRequest{Connection: conn})
+	if err != nil {
+		return s.closeAfter(ctx, conn, errors.Wrap(err, "refresh failed"))
+	}
+	if refreshed.GetId() != conn.GetId() ||
+		refreshed.GetContext().GetIpContext().GetSrcIpAddr() != conn.GetContext().GetIpContext().GetSrcIpAddr() ||
+		refreshed.GetContext().GetIpContext().GetDstIpAddr() != conn.GetContext().GetIpContext().GetDstIpAddr() {
+		return s.closeAfter(ctx, refreshed, errors.Errorf("refresh changed connection %s %s -> %s into %s %s -> %s",
+			conn.GetId(), conn.GetContext().GetIpContext().GetSrcIpAddr(), conn.GetContext().GetIpContext().GetDstIpAddr(),
+			refreshed.GetId(), refreshed.GetContext().GetIpContext().GetSrcIpAddr(), refreshed.GetContext().GetIpContext().GetDstIpAddr()))
+	}
+	return s.closeAfter(ctx, refreshed, s.check(ctx, refreshed))
+}
+
+// heal - a remote connection whose vpp interfaces were lost on both forwarders, as to restarts of their vpps, is
+// restored by the refresh of the client once the forwarders have seen the interfaces go
+func heal(ctx context.Context, s *suite) error {
+	conn, err := s.request(ctx, remoteService)
+	if err != nil {
+		return err
+	}
+	if err = s.checkRemote(ctx, conn); err != nil {
+		return s.closeAfter(ctx, conn, err)
+	}
+	for _, f := range []*forwarder{s.a, s.b} {
+		if err = f.loseInterfaces(ctx); err != nil {
+			return s.closeAfter(ctx, conn, err)
+		}
+	}
+	refreshed, err := s.client.Request(ctx, &networkservice.NetworkServiceRequest{Connection: conn})
+	if err != nil {
+		return s.closeAfter(ctx, conn, errors.Wrap(err, "refresh after losing the vpp interfaces failed"))
+	}
+	return s.closeAfter(ctx, refreshed, s.checkRemote(ctx, refreshed))
+}
+
+// loseInterfaces - deletes the vpp interfaces of the only connection of f behind its back and waits for f to see
+// them deleted
+func (f *forwarder) loseInterfaces(ctx context.Context) error {
+	entries := f.connections.List()
+	if len(entries) != 1 {
+		return errors.Errorf("%s has %d connections, expected 1", f.name, len(entries))
+	}
+	deleted := &vpp.ConfigData{}
+	for _, name := range []string{entries[0].ServerInterface, entries[0].ClientInterface} {
+		if name != "" {
+			deleted.Interfaces = append(deleted.Interfaces, &vpp.Interface{Name: name})
+		}
+	}
+	if _, err := configurator.NewConfiguratorServiceClient(f.vppagentCC).Delete(ctx, &configurator.DeleteRequest{
+		Delete: &configurator.Config{VppConfig: deleted},
+	}); err != nil {
+		return errors.Wrapf(err, "failed to delete the vpp interfaces of %s", f.name)
+	}
+	ticker := time.NewTicker(linkStatePollPeriod)
+	defer ticker.Stop()
+	for {
+		entries = f.connections.List()
+		if len(entries) == 1 && entries[0].LinkState == "DELETED" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("%s did not see the vpp interfaces of its connection deleted", f.name)
+		case <-ticker.C:
+		}
+	}
+}
+
+// request - requests a connection of networkService, the client asking for the kernel mechanism
+func (s *suite) request(ctx context.Context, networkService string) (*networkservice.Connection, error) {
+	conn, err := s.client.Request(ctx, &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{NetworkService: networkService},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "request of %s failed", networkService)
+	}
+	return conn, nil
+}
+
+// closeAfter - closes conn, returning err if set or else any error closing it or left behind by the Close
+func (s *suite) closeAfter(ctx context.Context, conn *networkservice.Connection, err error) error {
+	entries := append(s.a.connections.List(), s.b.connections.List()...)
+	if _, closeErr := s.client.Close(ctx, conn); closeErr != nil && err == nil {
+		err = errors.Wrapf(closeErr, "close of connection %s failed", conn.GetId())
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range []*forwarder{s.a, s.b} {
+		if n := f.connections.Len(); n != 0 {
+			return errors.Errorf("%s still has %d connections after close", f.name, n)
+		}
+		ifaces, ifacesErr := interfaces(ctx, f.vppagentCC)
+		if ifacesErr != nil {
+			return ifacesErr
+		}
+		for i := range entries {
+			if _, ok := ifaces[entries[i].ServerInterface]; ok {
+				return errors.Errorf("vpp interface %s of %s left behind by close", entries[i].ServerInterface, f.name)
+			}
+		}
+	}
+	return nil
+}
+
+// check - checks that forwarder-a has programmed conn and, unless vpp is fake, that the client and the endpoint
+// reach each other over it
+func (s *suite) check(ctx context.Context, conn *networkservice.Connection) error {
+	entries := s.a.connections.List()
+	if len(entries) != 1 {
+		return errors.Errorf("forwarder-a has %d connections, expected 1", len(entries))
+	}
+	ifaces, err := interfaces(ctx, s.a.vppagentCC)
+	if err != nil {
+		return err
+	}
+	if _, ok := ifaces[entries[0].ServerInterface]; !ok {
+		return errors.Errorf("vpp interface %s of connection %s not configured", entries[0].ServerInterface, conn.GetId())
+	}
+	if s.fake {
+		return nil
+	}
+	src, _, err := net.ParseCIDR(conn.GetContext().GetIpContext().GetSrcIpAddr())
+	if err != nil {
+		return errors.Wrapf(err, "connection %s has no client address", conn.GetId())
+	}
+	dst, _, err := net.ParseCIDR(conn.GetContext().GetIpContext().GetDstIpAddr())
+	if err != nil {
+		return errors.Wrapf(err, "connection %s has no endpoint address", conn.GetId())
+	}
+	if err = pingcheck.Ping(s.clientNS, dst, pingTimeout); err != nil {
+		return errors.Wrap(err, "the client cannot reach the endpoint")
+	}
+	if err = pingcheck.Ping(hostNetNSURL, src, pingTimeout); err != nil {
+		return errors.Wrap(err, "the endpoint cannot reach the client")
+	}
+	return nil
+}
+
+// checkRemote - like check, also checking that forwarder-b has the connection and that forwarder-a has a vxlan
+// tunnel to it
+func (s *suite) checkRemote(ctx context.Context, conn *networkservice.Connection) error {
+	if n := s.b.connections.Len(); n != 1 {
+		return errors.Errorf("forwarder-b has %d connections, expected 1", n)
+	}
+	ifaces, err := interfaces(ctx, s.a.vppagentCC)
+	if err != nil {
+		return err
+	}
+	tunnel := false
+	for _, iface := range ifaces {
+		tunnel = tunnel || iface.GetType() == vpp_interfaces.Interface_VXLAN_TUNNEL
+	}
+	if !tunnel {
+		return errors.Errorf("forwarder-a has no vxlan tunnel for connection %s", conn.GetId())
+	}
+	return s.check(ctx, conn)
+}
+
+// interfaces - returns the vpp interfaces configured through vppagentCC by name
+func interfaces(ctx context.Context, vppagentCC grpc.ClientConnInterface) (map[string]*vpp.Interface, error) {
+	rv, err := configurator.NewConfiguratorServiceClient(vppagentCC).Get(ctx, &configurator.GetRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the vpp-agent config")
+	}
+	ifaces := make(map[string]*vpp.Interface)
+	for _, iface := range rv.GetConfig().GetVppConfig().GetInterfaces() {
+		ifaces[iface.GetName()] = iface
+	}
+	return ifaces, nil
+}
+
+// checkCleanup - checks that the vpps of the forwarders are back to their state after vppinit
+func (s *suite) checkCleanup(ctx context.Context) error {
+	for _, f := range []*forwarder{s.a, s.b} {
+		leaked, err := f.baseline.Leaked(ctx, f.vppagentCC)
+		if err != nil {
+			return err
+		}
+		if len(leaked) > 0 {
+			return errors.Errorf("%d vpp objects left behind on %s: %s", len(leaked), f.name, strings.Join(leaked, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package testsuite - self-contained end-to-end test of the forwarder: brings up two forwarders in process, each
+// with a vpp of its own, connected by a veth underlay, along with a client and an endpoint, and runs cross connect
+// scenarios through them.  Meant to validate a build of the forwarder and vpp on the hardware it is to run on, the
+// forwarders are built by the forwarder binary as it builds the one it serves, only with the suite's own tokens and
+// authorization.
+package testsuite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/dataplane"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// Config - configuration of the test suite
+type Config struct {
+	// VPP - configuration of the vpp of both forwarders, each running it as an instance of its own.  With VPP.Fake
+	// the scenarios only check what is sent to vpp-agent, not the connectivity it results in.
+	VPP vppagent.Config
+	// NewForwarder - returns the forwarder named name, with tunnelIP as its tunnel ip and its state kept in dir
+	NewForwarder func(ctx context.Context, name string, tunnelIP net.IP, dir string) (Forwarder, error)
+	// Dir - directory of the sockets and memif sockets of the suite
+	Dir string
+	// Scenarios - names of the scenarios to run, in order
+	Scenarios []string
+	// Timeout - time each scenario may take
+	Timeout time.Duration
+}
+
+// Forwarder - a forwarder of the suite, built the way the forwarder binary builds the one it serves
+type Forwarder interface {
+	// VPPAgentDialOptions - returns the options to dial the vpp-agent of the forwarder with
+	VPPAgentDialOptions() []grpc.DialOption
+	// InitFunc - returns the func adding the initial vpp config of the forwarder to the first vpp-agent Update
+	InitFunc() func(conf *configurator.Config) error
+	// Connections - returns the table of the connections of the forwarder
+	Connections() *conntable.Table
+	// Endpoint - returns the endpoint of the forwarder on vppagentCC, authorizing Requests with authzServer and
+	// reaching the endpoints of its connections through connectTo.  The link states of its connections follow the
+	// vpp interface events of vppagentCC.
+	Endpoint(ctx context.Context, vppagentCC *grpc.ClientConn, authzServer networkservice.NetworkServiceServer, tokenGenerator token.GeneratorFunc, connectTo *url.URL, clientDialOptions ...grpc.DialOption) dataplane.Endpoint
+}
+
+// Result - outcome of a scenario
+type Result struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// scenario - a cross connect scenario, closing the connections it requests
+type scenario func(ctx context.Context, s *suite) error
+
+var scenarios = map[string]scenario{
+	"local":   local,
+	"remote":  remote,
+	"refresh": refresh,
+	"heal":    heal,
+}
+
+// Run - brings up the suite and runs the scenarios of config, returning their results followed by that of checking
+// that the vpps are back to their state after vppinit.  It fails without results if
+// the suite cannot be brought up or a scenario is unknown.
+func Run(ctx context.Context, config *Config) ([]*Result, error) {
+	for _, name := range config.Scenarios {
+		if _, ok := scenarios[name]; !ok {
+			return nil, errors.Errorf("unknown test suite scenario %q", name)
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s, err := start(ctx, config)
+	defer func() {
+		cancel()
+		s.wait()
+	}()
+	if err != nil {
+		return nil, err
+	}
+	var results []*Result
+	for _, name := range config.Scenarios {
+		log.Entry(ctx).Infof("running test suite scenario %s", name)
+		scenarioCtx, cancelScenario := context.WithTimeout(ctx, config.Timeout)
+		started := time.Now()
+		scenarioErr := scenarios[name](scenarioCtx, s)
+		cancelScenario()
+		results = append(results, &Result{Name: name, Err: scenarioErr, Duration: time.Since(started)})
+	}
+	started := time.Now()
+	cleanupErr := s.checkCleanup(ctx)
+	results = append(results, &Result{Name: "cleanup", Err: cleanupErr, Duration: time.Since(started)})
+	return results, nil
+}
+
+// Report - writes a line per result to w and returns whether all of them passed
+func Report(w io.Writer, results []*Result) bool {
+	passed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			_, _ = fmt.Fprintf(w, "FAIL %s (%s): %+v\n", result.Name, result.Duration.Round(time.Millisecond), result.Err)
+			continue
+		}
+		passed++
+		_, _ = fmt.Fprintf(w, "PASS %s (%s)\n", result.Name, result.Duration.Round(time.Millisecond))
+	}
+	_, _ = fmt.Fprintf(w, "%d of %d scenarios passed\n", passed, len(results))
+	return passed == len(results)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package testsuite_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/testsuite"
+)
+
+func TestReport(t *testing.T) {
+	var b bytes.Buffer
+	require.True(t, testsuite.Report(&b, []*testsuite.Result{
+		{Name: "local", Duration: 1234 * time.Microsecond},
+		{Name: "cleanup", Duration: time.Second},
+	}))
+	require.Equal(t, "PASS local (1ms)\nPASS cleanup (1s)\n2 of 2 scenarios passed\n", b.String())
+
+	b.Reset()
+	require.False(t, testsuite.Report(&b, []*testsuite.Result{
+		{Name: "local"},
+		{Name: "remote", Err: errors.New("no reply to ping")},
+	}))
+	require.True(t, strings.HasPrefix(b.String(), "PASS local (0s)\nFAIL remote (0s): no reply to ping\n"), b.String())
+	require.True(t, strings.HasSuffix(b.String(), "\n1 of 2 scenarios passed\n"), b.String())
+}
+
+func TestRunUnknownScenario(t *testing.T) {
+	results, err := testsuite.Run(context.Background(), &testsuite.Config{Scenarios: []string{"local", "teleport"}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "teleport")
+	require.Nil(t, results)
+}
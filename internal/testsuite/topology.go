@@ -0,0 +1,271 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package testsuite
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edwarnicke/grpcfd"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netns"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	localcredentials "google.golang.org/grpc/credentials/local"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/client"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/endpoint"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms"
+	kernelmechanism "github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/sendfd"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/ipam/point2pointipam"
+	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/cleanupcheck"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/conntable"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/ns"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+const (
+	// localService - network service of the endpoint reached through forwarder-a only
+	localService = "test-suite-local"
+	// remoteService - network service of the endpoint reached through forwarder-a, a vxlan tunnel and forwarder-b
+	remoteService = "test-suite-remote"
+	// clientNetNS - named netns of the client
+	clientNetNS  = "nsm-test-suite"
+	hostNetNSURL = "file:///proc/self/ns/net"
+)
+
+// Addresses of the underlay between the forwarders and of the connections
+var (
+	underlayA      = &net.IPNet{IP: net.IPv4(10, 255, 0, 1), Mask: net.CIDRMask(30, 32)}
+	underlayB      = &net.IPNet{IP: net.IPv4(10, 255, 0, 2), Mask: net.CIDRMask(30, 32)}
+	endpointPrefix = &net.IPNet{IP: net.IPv4(10, 255, 1, 0), Mask: net.CIDRMask(24, 32)}
+)
+
+// forwarder - a forwarder of the suite
+type forwarder struct {
+	name          string
+	vppagentCC    *grpc.ClientConn
+	vppagentErrCh <-chan error
+	connections   *conntable.Table
+	baseline      cleanupcheck.Baseline
+}
+
+// suite - the client, forwarders and endpoint of the test suite:
+//
+//	client -> forwarder-a -> nsmgr -> endpoint               (localService)
+//	client -> forwarder-a -> nsmgr -> forwarder-b -> endpoint (remoteService, over vxlan)
+type suite struct {
+	fake     bool
+	a, b     *forwarder
+	client   networkservice.NetworkServiceClient
+	clientNS string
+	cleanups []func()
+}
+
+// start - brings up the suite, whatever it brought up before failing being torn down by wait
+func start(ctx context.Context, config *Config) (*suite, error) {
+	s := &suite{fake: config.VPP.Fake, clientNS: hostNetNSURL}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return s, errors.WithStack(err)
+	}
+	hostNS, err := netns.Get()
+	if err != nil {
+		return s, errors.WithStack(err)
+	}
+	clientNS := hostNS
+	if !s.fake {
+		cleanup, underlayErr := setupUnderlay(underlayA, underlayB)
+		if underlayErr != nil {
+			return s, underlayErr
+		}
+		s.cleanups = append(s.cleanups, cleanup)
+		if clientNS, cleanup, err = newNamedNetNS(clientNetNS); err != nil {
+			return s, err
+		}
+		s.cleanups = append(s.cleanups, cleanup)
+		s.clientNS = "file:///var/run/netns/" + clientNetNS
+	}
+
+	nse := endpoint.NewServer(ctx, "endpoint", &allowServer{}, generateToken,
+		mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
+			kernel.MECHANISM: kernelmechanism.NewServer(),
+		}),
+		sendfd.NewServer(),
+		point2pointipam.NewServer(endpointPrefix),
+	)
+	nseURL := socket(config.Dir, "endpoint.sock")
+	if err = serve(ctx, nseURL, nse.Register); err != nil {
+		return s, err
+	}
+	if s.b, err = s.startForwarder(ctx, config, "forwarder-b", 2, underlayB.IP, nseURL); err != nil {
+		return s, err
+	}
+	nsmgrURL := socket(config.Dir, "nsmgr.sock")
+	if s.a, err = s.startForwarder(ctx, config, "forwarder-a", 1, underlayA.IP, nsmgrURL); err != nil {
+		return s, err
+	}
+	ccB, err := dial(ctx, socket(config.Dir, "forwarder-b.sock"))
+	if err != nil {
+		return s, err
+	}
+	nsmgr := chain.NewNetworkServiceServer(&routeServer{remote: networkservice.NewNetworkServiceClient(ccB)}, nse)
+	if err = serve(ctx, nsmgrURL, func(server *grpc.Server) {
+		networkservice.RegisterNetworkServiceServer(server, nsmgr)
+	}); err != nil {
+		return s, err
+	}
+	ccA, err := dial(ctx, socket(config.Dir, "forwarder-a.sock"))
+	if err != nil {
+		return s, err
+	}
+	s.client = client.NewClient(ctx, "client", nil, generateToken, ccA,
+		ns.NewClient(clientNS),
+		kernelmechanism.NewClient(),
+		sendfd.NewClient(),
+		ns.NewClient(hostNS),
+	)
+	return s, nil
+}
+
+// startForwarder - starts the forwarder name on vpp instance instance, built by config.NewForwarder, serving on
+// Dir/<name>.sock and reaching its endpoints through connectTo
+func (s *suite) startForwarder(ctx context.Context, config *Config, name string, instance int, tunnelIP net.IP, connectTo *url.URL) (*forwarder, error) {
+	built, err := config.NewForwarder(ctx, name, tunnelIP, filepath.Join(config.Dir, name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build %s", name)
+	}
+	vppConfig := config.VPP
+	vppConfig.Instance = instance
+	vppConfig.PinCPUs = false
+	vppConfig.AgentRESTPort = 0
+	vppConfig.ForwarderID = name
+	f := &forwarder{name: name, connections: built.Connections()}
+	if f.vppagentCC, f.vppagentErrCh = vppagent.StartAndDialContext(ctx, &vppConfig, built.VPPAgentDialOptions()...); f.vppagentCC == nil {
+		return nil, errors.Wrapf(<-f.vppagentErrCh, "failed to start the vpp of %s", name)
+	}
+	s.cleanups = append(s.cleanups, func() {
+		for range f.vppagentErrCh {
+		}
+	})
+	if f.baseline, err = cleanupcheck.Snapshot(ctx, f.vppagentCC, built.InitFunc()); err != nil {
+		return nil, err
+	}
+	ep := built.Endpoint(ctx, f.vppagentCC, &allowServer{}, generateToken, connectTo, dialOptions()...)
+	if err = serve(ctx, socket(config.Dir, name+".sock"), ep.Register); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// wait - waits for the forwarders to exit once the ctx of start is done and tears down the rest of the suite
+func (s *suite) wait() {
+	for i := len(s.cleanups) - 1; i >= 0; i-- {
+		s.cleanups[i]()
+	}
+}
+
+func socket(dir, name string) *url.URL {
+	return &url.URL{Scheme: "unix", Path: filepath.Join(dir, name)}
+}
+
+// serve - serves the services register registers on listenOn until ctx is done
+func serve(ctx context.Context, listenOn *url.URL, register func(server *grpc.Server)) error {
+	_ = os.Remove(listenOn.Path)
+	server := grpc.NewServer(grpc.Creds(grpcfd.TransportCredentials(localcredentials.NewCredentials())))
+	register(server)
+	select {
+	case err := <-grpcutils.ListenAndServe(ctx, listenOn, server):
+		return errors.Wrapf(err, "failed to serve on %s", listenOn)
+	default:
+		return nil
+	}
+}
+
+func dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(grpcfd.TransportCredentials(localcredentials.NewCredentials())),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+	}
+}
+
+func dial(ctx context.Context, target *url.URL) (*grpc.ClientConn, error) {
+	cc, err := grpc.DialContext(ctx, target.String(), dialOptions()...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial %s", target)
+	}
+	return cc, nil
+}
+
+// generateToken - hands out tokens to everyone in the suite, it has no spire
+func generateToken(credentials.AuthInfo) (string, time.Time, error) {
+	return "test-suite", time.Now().Add(time.Hour), nil
+}
+
+// allowServer - authorizes every Request, the suite's peers being trusted
+type allowServer struct{}
+
+func (a *allowServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (a *allowServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// routeServer - stands in for the nsmgr of forwarder-a, passing the connections of remoteService on to forwarder-b
+// with vxlan as their only mechanism and the others to the endpoint
+type routeServer struct {
+	remote networkservice.NetworkServiceClient
+}
+
+func (r *routeServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if request.GetConnection().GetNetworkService() != remoteService {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	request = proto.Clone(request).(*networkservice.NetworkServiceRequest)
+	var preferences []*networkservice.Mechanism
+	for _, mechanism := range request.GetMechanismPreferences() {
+		if mechanism.GetType() == vxlan.MECHANISM {
+			preferences = append(preferences, mechanism)
+		}
+	}
+	request.MechanismPreferences = preferences
+	return r.remote.Request(ctx, request)
+}
+
+func (r *routeServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if conn.GetNetworkService() != remoteService {
+		return next.Server(ctx).Close(ctx, conn)
+	}
+	return r.remote.Close(ctx, conn)
+}
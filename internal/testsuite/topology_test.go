@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package testsuite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+)
+
+// recordingServer - records the Requests and Closes it gets
+type recordingServer struct {
+	requests []*networkservice.NetworkServiceRequest
+	closes   []*networkservice.Connection
+}
+
+func (r *recordingServer) Request(_ context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	r.requests = append(r.requests, request)
+	return request.GetConnection(), nil
+}
+
+func (r *recordingServer) Close(_ context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	r.closes = append(r.closes, conn)
+	return &empty.Empty{}, nil
+}
+
+// recordingClient - records the Requests and Closes it gets
+type recordingClient struct {
+	recordingServer
+}
+
+func (r *recordingClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, _ ...grpc.CallOption) (*networkservice.Connection, error) {
+	return r.recordingServer.Request(ctx, request)
+}
+
+func (r *recordingClient) Close(ctx context.Context, conn *networkservice.Connection, _ ...grpc.CallOption) (*empty.Empty, error) {
+	return r.recordingServer.Close(ctx, conn)
+}
+
+func TestRouteServer(t *testing.T) {
+	endpoint, remote := &recordingServer{}, &recordingClient{}
+	server := chain.NewNetworkServiceServer(&routeServer{remote: remote}, endpoint)
+	request := func(networkService string) *networkservice.NetworkServiceRequest {
+		return &networkservice.NetworkServiceRequest{
+			Connection:           &networkservice.Connection{Id: "conn-1", NetworkService: networkService},
+			MechanismPreferences: []*networkservice.Mechanism{{Type: kernel.MECHANISM}, {Type: vxlan.MECHANISM}},
+		}
+	}
+
+	conn, err := server.Request(context.Background(), request(localService))
+	require.NoError(t, err)
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, endpoint.requests, 1)
+	require.Len(t, endpoint.requests[0].GetMechanismPreferences(), 2)
+	require.Len(t, endpoint.closes, 1)
+	require.Empty(t, remote.requests)
+
+	remoteRequest := request(remoteService)
+	conn, err = server.Request(context.Background(), remoteRequest)
+	require.NoError(t, err)
+	_, err = server.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Len(t, remote.requests, 1)
+	require.Len(t, remote.requests[0].GetMechanismPreferences(), 1, "mechanisms other than vxlan reached forwarder-b")
+	require.Equal(t, vxlan.MECHANISM, remote.requests[0].GetMechanismPreferences()[0].GetType())
+	require.Len(t, remoteRequest.GetMechanismPreferences(), 2, "the caller's request was changed")
+	require.Len(t, remote.closes, 1)
+	require.Len(t, endpoint.requests, 1)
+}
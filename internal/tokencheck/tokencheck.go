@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokencheck - authorize policies validating the tokens of incoming Requests like the sdk's default ones,
+// but tolerating a clock skew between the forwarder and the nodes the tokens were minted on
+package tokencheck
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"expvar"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// signingMethods - the methods tokens may be signed with, those of the ecdsa and rsa keys of svids
+var signingMethods = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512"}
+
+type tokensValid struct {
+	skew      time.Duration
+	tolerated *expvar.Int
+}
+
+type currentTokenSigned struct{}
+
+// Policies - returns the policies replacing the sdk's defaults: the tokens of all path segments must be valid,
+// up to skew past their exp or ahead of their nbf and iat, and the token of the current segment must be signed by
+// the peer's certificate
+func Policies(skew time.Duration) []authorize.Policy {
+	return []authorize.Policy{
+		&tokensValid{
+			skew:      skew,
+			tolerated: metrics.Int("clock_skew_tolerated.tokens"),
+		},
+		&currentTokenSigned{},
+	}
+}
+
+func (t *tokensValid) Check(_ context.Context, input interface{}) error {
+	path, ok := input.(*networkservice.Path)
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "unexpected authorize input %T", input)
+	}
+	now := time.Now()
+	for i, segment := range path.GetPathSegments() {
+		claims := jwt.MapClaims{}
+		if _, _, err := new(jwt.Parser).ParseUnverified(segment.GetToken(), claims); err != nil {
+			return status.Errorf(codes.PermissionDenied, "token of path segment %d is invalid: %s", i, err.Error())
+		}
+		if !claims.VerifyExpiresAt(now.Add(-t.skew).Unix(), true) {
+			return status.Errorf(codes.PermissionDenied, "token of path segment %d expired", i)
+		}
+		if !claims.VerifyNotBefore(now.Add(t.skew).Unix(), false) || !claims.VerifyIssuedAt(now.Add(t.skew).Unix(), false) {
+			return status.Errorf(codes.PermissionDenied, "token of path segment %d is not valid yet", i)
+		}
+		if !claims.VerifyExpiresAt(now.Unix(), true) || !claims.VerifyNotBefore(now.Unix(), false) || !claims.VerifyIssuedAt(now.Unix(), false) {
+			t.tolerated.Add(1)
+		}
+	}
+	return nil
+}
+
+func (c *currentTokenSigned) Check(ctx context.Context, input interface{}) error {
+	path, ok := input.(*networkservice.Path)
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "unexpected authorize input %T", input)
+	}
+	cert := peerCertificate(ctx)
+	if cert == nil {
+		return status.Error(codes.PermissionDenied, "peer has no certificate the token could be signed by")
+	}
+	if int(path.GetIndex()) >= len(path.GetPathSegments()) {
+		return status.Errorf(codes.PermissionDenied, "path index %d out of range", path.GetIndex())
+	}
+	tok := path.GetPathSegments()[path.GetIndex()].GetToken()
+	// The validity window has been checked, skew included, by tokensValid
+	parser := &jwt.Parser{ValidMethods: signingMethods, SkipClaimsValidation: true}
+	if _, err := parser.Parse(tok, keyFunc(cert)); err != nil {
+		return status.Errorf(codes.PermissionDenied, "token of path segment %d is not signed by the peer: %s", path.GetIndex(), err.Error())
+	}
+	return nil
+}
+
+// keyFunc - returns a jwt.Keyfunc returning the public key of cert for tokens signed with a method of its key type
+func keyFunc(cert *x509.Certificate) jwt.Keyfunc {
+	return func(tok *jwt.Token) (interface{}, error) {
+		switch cert.PublicKey.(type) {
+		case *ecdsa.PublicKey:
+			if _, ok := tok.Method.(*jwt.SigningMethodECDSA); ok {
+				return cert.PublicKey, nil
+			}
+		case *rsa.PublicKey:
+			if _, ok := tok.Method.(*jwt.SigningMethodRSA); ok {
+				return cert.PublicKey, nil
+			}
+		}
+		return nil, errors.Errorf("signing method %s does not match the %T of the peer certificate", tok.Method.Alg(), cert.PublicKey)
+	}
+}
+
+// peerCertificate - returns the certificate the peer of ctx authenticated with, nil if it used none
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return tlsInfo.State.PeerCertificates[0]
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokencheck_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokencheck"
+)
+
+const skew = 5 * time.Minute
+
+func newPeer(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func withPeer(cert *x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	tok, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return tok
+}
+
+func path(tok string) *networkservice.Path {
+	return &networkservice.Path{PathSegments: []*networkservice.PathSegment{{Name: "nsmgr", Token: tok}}}
+}
+
+func TestTokensValid(t *testing.T) {
+	cert, key := newPeer(t)
+	tokensValid := tokencheck.Policies(skew)[0]
+	now := time.Now()
+	for _, sample := range []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"valid", jwt.MapClaims{"exp": now.Add(time.Hour).Unix()}, true},
+		{"no exp", jwt.MapClaims{"sub": "nsmgr"}, false},
+		{"expired within skew", jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}, true},
+		{"expired outside skew", jwt.MapClaims{"exp": now.Add(-10 * time.Minute).Unix()}, false},
+		{"not yet valid within skew", jwt.MapClaims{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}, true},
+		{"not yet valid outside skew", jwt.MapClaims{"exp": now.Add(time.Hour).Unix(), "nbf": now.Add(10 * time.Minute).Unix()}, false},
+		{"issued within skew", jwt.MapClaims{"exp": now.Add(time.Hour).Unix(), "iat": now.Add(time.Minute).Unix()}, true},
+		{"issued outside skew", jwt.MapClaims{"exp": now.Add(time.Hour).Unix(), "iat": now.Add(10 * time.Minute).Unix()}, false},
+	} {
+		err := tokensValid.Check(withPeer(cert), path(sign(t, jwt.SigningMethodES256, key, sample.claims)))
+		if sample.valid {
+			require.NoError(t, err, sample.name)
+		} else {
+			require.Error(t, err, sample.name)
+		}
+	}
+}
+
+func TestCurrentTokenSigned(t *testing.T) {
+	cert, key := newPeer(t)
+	other, otherKey := newPeer(t)
+	currentTokenSigned := tokencheck.Policies(skew)[1]
+	claims := jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}
+
+	require.NoError(t, currentTokenSigned.Check(withPeer(cert), path(sign(t, jwt.SigningMethodES256, key, claims))))
+	require.Error(t, currentTokenSigned.Check(withPeer(other), path(sign(t, jwt.SigningMethodES256, key, claims))), "signed by another key")
+	require.Error(t, currentTokenSigned.Check(withPeer(other), path(sign(t, jwt.SigningMethodES256, otherKey, claims))[:10]), "malformed")
+	require.Error(t, currentTokenSigned.Check(context.Background(), path(sign(t, jwt.SigningMethodES256, key, claims))), "no peer certificate")
+	// A token claiming hmac, keyed with the bytes of the peer's public key, must not be accepted
+	hmacToken := sign(t, jwt.SigningMethodHS256, cert.RawSubjectPublicKeyInfo, claims)
+	require.Error(t, currentTokenSigned.Check(withPeer(cert), path(hmacToken)), "hmac signed")
+}
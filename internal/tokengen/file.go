@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+// StaticFile - returns a token.GeneratorFunc handing out the token read from filename, e.g. one provisioned by an
+// external identity system, reread whenever the file changes.  Its expiry is the exp claim if the token is a jwt, but
+// never later than maxTokenLifetime from now.
+func StaticFile(filename string, maxTokenLifetime time.Duration) token.GeneratorFunc {
+	var mu sync.Mutex
+	var modTime time.Time
+	var tok string
+	var exp time.Time
+	return func(credentials.AuthInfo) (string, time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		info, err := os.Stat(filename)
+		if err != nil {
+			return "", time.Time{}, errors.Wrapf(err, "error reading token file %s", filename)
+		}
+		if !info.ModTime().Equal(modTime) {
+			data, readErr := ioutil.ReadFile(filename) // #nosec
+			if readErr != nil {
+				return "", time.Time{}, errors.Wrapf(readErr, "error reading token file %s", filename)
+			}
+			tok = strings.TrimSpace(string(data))
+			exp = expiry(tok)
+			modTime = info.ModTime()
+		}
+		if tok == "" {
+			return "", time.Time{}, errors.Errorf("token file %s is empty", filename)
+		}
+		expireTime := time.Now().Add(maxTokenLifetime)
+		if !exp.IsZero() && exp.Before(expireTime) {
+			expireTime = exp
+		}
+		return tok, expireTime, nil
+	}
+}
+
+// expiry - returns the exp claim of tok, without verifying it, or the zero time if tok is no jwt or has none
+func expiry(tok string) time.Time {
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(tok, claims); err != nil {
+		return time.Time{}
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Time{}
+}
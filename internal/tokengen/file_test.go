@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokengen"
+)
+
+// writeToken - writes tok to filename with modification time modTime, for the change to be seen whatever the
+// resolution of the file system's timestamps
+func writeToken(t *testing.T, filename, tok string, modTime time.Time) {
+	require.NoError(t, ioutil.WriteFile(filename, []byte(tok+"\n"), 0600))
+	require.NoError(t, os.Chtimes(filename, modTime, modTime))
+}
+
+func TestStaticFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokengen")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "token")
+	generator := tokengen.StaticFile(filename, time.Hour)
+
+	_, _, err = generator(nil)
+	require.Error(t, err, "the file does not exist yet")
+
+	writeToken(t, filename, "opaque-token", time.Now().Add(-time.Minute))
+	tok, expireTime, err := generator(nil)
+	require.NoError(t, err)
+	require.Equal(t, "opaque-token", tok)
+	require.True(t, expireTime.After(time.Now().Add(59*time.Minute)), "a token that is no jwt lasts the max lifetime")
+
+	// A changed file is read again, the exp claim of a jwt is its expiry
+	exp := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": exp.Unix()}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	writeToken(t, filename, signed, time.Now())
+	tok, expireTime, err = generator(nil)
+	require.NoError(t, err)
+	require.Equal(t, signed, tok)
+	require.True(t, expireTime.Equal(exp))
+
+	writeToken(t, filename, "", time.Now().Add(time.Minute))
+	_, _, err = generator(nil)
+	require.Error(t, err, "an empty token file is an error")
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokengen - token generators for the tokens the forwarder mints for its path segments
+package tokengen
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+// SpiffeJWT - returns a token.GeneratorFunc minting jwts signed by the svid from source, like spiffejwt does, but
+// restricted to audiences.  If no audiences are given the token is restricted to the spiffe id of the peer it is
+// generated for.  The lifetime of a token never exceeds maxTokenLifetime nor the validity of the peer certificate.
+func SpiffeJWT(source x509svid.Source, maxTokenLifetime time.Duration, audiences ...string) token.GeneratorFunc {
+	return func(authInfo credentials.AuthInfo) (string, time.Time, error) {
+		ownSVID, err := source.GetX509SVID()
+		if err != nil {
+			return "", time.Time{}, errors.Wrap(err, "error creating token")
+		}
+		expireTime := time.Now().Add(maxTokenLifetime)
+		aud := audiences
+		if tlsInfo, ok := authInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			peerCert := tlsInfo.State.PeerCertificates[0]
+			if peerCert.NotAfter.Before(expireTime) {
+				expireTime = peerCert.NotAfter
+			}
+			if peerID, err := x509svid.IDFromCert(peerCert); err == nil && len(aud) == 0 {
+				aud = []string{peerID.String()}
+			}
+		}
+		claims := jwt.MapClaims{
+			"sub": ownSVID.ID.String(),
+			"exp": expireTime.Unix(),
+		}
+		if len(aud) > 0 {
+			claims["aud"] = aud
+		}
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ownSVID.PrivateKey)
+		if err != nil {
+			return "", time.Time{}, errors.Wrap(err, "error signing token")
+		}
+		return tok, expireTime, nil
+	}
+}
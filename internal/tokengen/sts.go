@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+const (
+	stsTimeout       = 10 * time.Second
+	stsRefreshMargin = time.Minute
+
+	tokenExchangeGrant = "urn:ietf:params:oauth:grant-type:token-exchange"
+	jwtTokenType       = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+type stsResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type stsToken struct {
+	token      string
+	expireTime time.Time
+}
+
+// stsExchange - an exchange in flight, waited for by every generation for the same peer meanwhile
+type stsExchange struct {
+	done       chan struct{}
+	token      string
+	expireTime time.Time
+	err        error
+}
+
+// STS - returns a token.GeneratorFunc exchanging the tokens minted by subject at the oauth token exchange (RFC 8693)
+// endpoint stsURL, for clusters federating identity through something other than jwt svids.  Exchanged tokens are
+// cached per peer until shortly before they expire, which is never later than maxTokenLifetime from the exchange, and
+// concurrent generations for a peer share one exchange.  Tokens for peers without a spiffe id are neither cached nor
+// shared.  Exchanges time out after stsTimeout and are cancelled with ctx.
+func STS(ctx context.Context, stsURL *url.URL, subject token.GeneratorFunc, maxTokenLifetime time.Duration) token.GeneratorFunc {
+	client := &http.Client{}
+	var mu sync.Mutex
+	cache := make(map[string]*stsToken)
+	inflight := make(map[string]*stsExchange)
+	return func(authInfo credentials.AuthInfo) (string, time.Time, error) {
+		peer := peerKey(authInfo)
+		if peer == "" {
+			return exchange(ctx, client, stsURL, subject, authInfo, maxTokenLifetime)
+		}
+		mu.Lock()
+		if cached, ok := cache[peer]; ok && time.Until(cached.expireTime) > stsRefreshMargin {
+			mu.Unlock()
+			return cached.token, cached.expireTime, nil
+		}
+		e, ok := inflight[peer]
+		if !ok {
+			e = &stsExchange{done: make(chan struct{})}
+			inflight[peer] = e
+		}
+		mu.Unlock()
+		if !ok {
+			e.token, e.expireTime, e.err = exchange(ctx, client, stsURL, subject, authInfo, maxTokenLifetime)
+			mu.Lock()
+			delete(inflight, peer)
+			if e.err == nil {
+				cache[peer] = &stsToken{token: e.token, expireTime: e.expireTime}
+			}
+			mu.Unlock()
+			close(e.done)
+		}
+		select {
+		case <-e.done:
+			return e.token, e.expireTime, e.err
+		case <-ctx.Done():
+			return "", time.Time{}, errors.WithStack(ctx.Err())
+		}
+	}
+}
+
+// exchange - exchanges a token minted by subject for authInfo at stsURL
+func exchange(ctx context.Context, client *http.Client, stsURL *url.URL, subject token.GeneratorFunc, authInfo credentials.AuthInfo, maxTokenLifetime time.Duration) (string, time.Time, error) {
+	subjectToken, subjectExpireTime, err := subject(authInfo)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrant},
+		"subject_token":        {subjectToken},
+		"subject_token_type":   {jwtTokenType},
+		"requested_token_type": {jwtTokenType},
+	}
+	ctx, cancel := context.WithTimeout(ctx, stsTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsURL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, errors.Wrapf(err, "error exchanging token at %s", stsURL.String())
+	}
+	defer func() { _ = resp.Body.Close() }()
+	exchanged := &stsResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(exchanged); err != nil && resp.StatusCode == http.StatusOK {
+		return "", time.Time{}, errors.Wrapf(err, "error decoding token exchange response of %s", stsURL.String())
+	}
+	if resp.StatusCode != http.StatusOK || exchanged.AccessToken == "" {
+		return "", time.Time{}, errors.Errorf("token exchange at %s failed: %s %s %s",
+			stsURL.String(), resp.Status, exchanged.Error, exchanged.ErrorDescription)
+	}
+	expireTime := time.Now().Add(maxTokenLifetime)
+	if exchanged.ExpiresIn > 0 {
+		if exp := time.Now().Add(time.Duration(exchanged.ExpiresIn) * time.Second); exp.Before(expireTime) {
+			expireTime = exp
+		}
+	} else if subjectExpireTime.Before(expireTime) {
+		expireTime = subjectExpireTime
+	}
+	return exchanged.AccessToken, expireTime, nil
+}
+
+// peerKey - returns the spiffe id of the peer of authInfo, or "" if it has none
+func peerKey(authInfo credentials.AuthInfo) string {
+	tlsInfo, ok := authInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	if err != nil {
+		return ""
+	}
+	return id.String()
+}
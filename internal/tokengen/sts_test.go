@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokengen"
+)
+
+func subject(credentials.AuthInfo) (string, time.Time, error) {
+	return "subject-token", time.Now().Add(time.Hour), nil
+}
+
+// newSTS - returns an sts server exchanging subject-token for exchanged-token valid for expiresIn seconds, failing
+// the exchange while fail is set, and the number of exchanges it served
+func newSTS(t *testing.T, expiresIn int64, fail *int32) (*httptest.Server, *int32) {
+	var exchanges int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchanges, 1)
+		// Leave concurrent generations the time to wait for this exchange
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.LoadInt32(fail) != 0 || r.FormValue("subject_token") != "subject-token" ||
+			r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:token-exchange" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "exchanged-token", "expires_in": expiresIn})
+	}))
+	return server, &exchanges
+}
+
+func stsURL(t *testing.T, server *httptest.Server) *url.URL {
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return u
+}
+
+func TestSTSSharedAndCached(t *testing.T) {
+	var fail int32
+	server, exchanges := newSTS(t, 600, &fail)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	generator := tokengen.STS(ctx, stsURL(t, server), subject, time.Hour)
+	peerCert, _ := newCertificate(t, "spiffe://example.org/nsmgr", time.Now().Add(time.Hour))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, expireTime, err := generator(peerAuthInfo(peerCert))
+			require.NoError(t, err)
+			require.Equal(t, "exchanged-token", tok)
+			require.True(t, expireTime.Before(time.Now().Add(601*time.Second)), "expires_in caps the expiry")
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(exchanges), "concurrent generations for a peer share one exchange")
+
+	_, _, err := generator(peerAuthInfo(peerCert))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(exchanges), "the exchanged token is cached")
+
+	// Tokens for peers without a spiffe id are exchanged every time
+	for i := 0; i < 2; i++ {
+		_, _, err = generator(nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(exchanges))
+}
+
+func TestSTSErrorNotCached(t *testing.T) {
+	fail := int32(1)
+	server, exchanges := newSTS(t, 600, &fail)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	generator := tokengen.STS(ctx, stsURL(t, server), subject, time.Hour)
+	peerCert, _ := newCertificate(t, "spiffe://example.org/nsmgr", time.Now().Add(time.Hour))
+
+	_, _, err := generator(peerAuthInfo(peerCert))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid_grant")
+
+	atomic.StoreInt32(&fail, 0)
+	tok, _, err := generator(peerAuthInfo(peerCert))
+	require.NoError(t, err)
+	require.Equal(t, "exchanged-token", tok)
+	require.Equal(t, int32(2), atomic.LoadInt32(exchanges))
+}
+
+func TestSTSMaxLifetime(t *testing.T) {
+	var fail int32
+	server, _ := newSTS(t, 0, &fail)
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without expires_in the exchanged token expires with the subject token, capped by the max lifetime
+	_, expireTime, err := tokengen.STS(ctx, stsURL(t, server), subject, time.Minute)(nil)
+	require.NoError(t, err)
+	require.True(t, expireTime.Before(time.Now().Add(time.Minute+time.Second)))
+}
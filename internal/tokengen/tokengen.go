@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/token"
+)
+
+// Config - selects the token generator the forwarder mints its tokens with, intended to be embedded in the
+// forwarder's Config
+type Config struct {
+	Type   string  `default:"spiffejwt" desc:"token generator: spiffejwt (signed by the svid), file (read from File) or sts (exchanged at STSURL)"`
+	File   string  `desc:"file a ready made token is read from, reread on change, if Type is file"`
+	STSURL url.URL `envconfig:"STS_URL" desc:"url of the oauth token exchange (RFC 8693) endpoint spiffejwt tokens are exchanged at, if Type is sts"`
+}
+
+// New - returns the token generator selected by config.  Tokens never outlive maxTokenLifetime and, but for file,
+// are restricted to audiences, or the spiffe id of the peer if none are given.  Token exchanges are cancelled with ctx.
+func New(ctx context.Context, config *Config, source x509svid.Source, maxTokenLifetime time.Duration, audiences ...string) (token.GeneratorFunc, error) {
+	switch config.Type {
+	case "", "spiffejwt":
+		return SpiffeJWT(source, maxTokenLifetime, audiences...), nil
+	case "file":
+		if config.File == "" {
+			return nil, errors.New("token generator file requires a File")
+		}
+		return StaticFile(config.File, maxTokenLifetime), nil
+	case "sts":
+		if config.STSURL.String() == "" {
+			return nil, errors.New("token generator sts requires an STSURL")
+		}
+		return STS(ctx, &config.STSURL, SpiffeJWT(source, maxTokenLifetime, audiences...), maxTokenLifetime), nil
+	default:
+		return nil, errors.Errorf("unknown token generator %q, expected spiffejwt, file or sts", config.Type)
+	}
+}
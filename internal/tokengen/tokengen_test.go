@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokengen_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/tokengen"
+)
+
+// newCertificate - returns a self signed certificate of spiffeID valid until notAfter, and its key
+func newCertificate(t *testing.T, spiffeID string, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		URIs:         []*url.URL{id},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// peerAuthInfo - returns the auth info of a tls peer presenting cert
+func peerAuthInfo(cert *x509.Certificate) credentials.AuthInfo {
+	return credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+}
+
+// svidSource - always returns svid
+type svidSource struct {
+	svid *x509svid.SVID
+}
+
+func (s *svidSource) GetX509SVID() (*x509svid.SVID, error) {
+	return s.svid, nil
+}
+
+func newSource(t *testing.T) (*svidSource, *ecdsa.PrivateKey) {
+	cert, key := newCertificate(t, "spiffe://example.org/forwarder", time.Now().Add(24*time.Hour))
+	id, err := x509svid.IDFromCert(cert)
+	require.NoError(t, err)
+	return &svidSource{svid: &x509svid.SVID{ID: id, Certificates: []*x509.Certificate{cert}, PrivateKey: key}}, key
+}
+
+// parse - returns the claims of tok, verified with the public key of key
+func parse(t *testing.T, tok string, key *ecdsa.PrivateKey) jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tok, claims, func(*jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	require.NoError(t, err)
+	return claims
+}
+
+func TestSpiffeJWTRestrictedToPeer(t *testing.T) {
+	source, key := newSource(t)
+	peerNotAfter := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	peerCert, _ := newCertificate(t, "spiffe://example.org/nsmgr", peerNotAfter)
+
+	tok, expireTime, err := tokengen.SpiffeJWT(source, time.Hour)(peerAuthInfo(peerCert))
+	require.NoError(t, err)
+	require.True(t, expireTime.Equal(peerNotAfter), "the token does not outlive the peer certificate")
+	claims := parse(t, tok, key)
+	require.Equal(t, "spiffe://example.org/forwarder", claims["sub"])
+	require.Equal(t, []interface{}{"spiffe://example.org/nsmgr"}, claims["aud"])
+	require.Equal(t, float64(peerNotAfter.Unix()), claims["exp"])
+}
+
+func TestSpiffeJWTAudiences(t *testing.T) {
+	source, key := newSource(t)
+	peerCert, _ := newCertificate(t, "spiffe://example.org/nsmgr", time.Now().Add(24*time.Hour))
+
+	tok, expireTime, err := tokengen.SpiffeJWT(source, time.Minute, "nsmgr", "registry")(peerAuthInfo(peerCert))
+	require.NoError(t, err)
+	require.True(t, expireTime.Before(time.Now().Add(time.Minute+time.Second)), "the token does not outlive the max lifetime")
+	require.Equal(t, []interface{}{"nsmgr", "registry"}, parse(t, tok, key)["aud"])
+
+	// Without a peer there is no audience but those given
+	tok, _, err = tokengen.SpiffeJWT(source, time.Minute)(nil)
+	require.NoError(t, err)
+	_, ok := parse(t, tok, key)["aud"]
+	require.False(t, ok)
+}
+
+func TestNew(t *testing.T) {
+	source, _ := newSource(t)
+	for _, config := range []*tokengen.Config{
+		{},
+		{Type: "spiffejwt"},
+		{Type: "file", File: "/var/run/token"},
+		{Type: "sts", STSURL: url.URL{Scheme: "https", Host: "sts.example.org"}},
+	} {
+		generator, err := tokengen.New(context.Background(), config, source, time.Hour)
+		require.NoError(t, err, config.Type)
+		require.NotNil(t, generator, config.Type)
+	}
+	for _, config := range []*tokengen.Config{
+		{Type: "file"},
+		{Type: "sts"},
+		{Type: "kerberos"},
+	} {
+		_, err := tokengen.New(context.Background(), config, source, time.Hour)
+		require.Error(t, err, config.Type)
+	}
+}
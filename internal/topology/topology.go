@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package topology - node name and zone/region labels the forwarder advertises so NSMgr can prefer same-zone
+// forwarders for remote connections
+package topology
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/k8s"
+)
+
+// Labels advertised to NSMgr
+const (
+	NodeNameLabel = "nodeName"
+	ZoneLabel     = "topology.kubernetes.io/zone"
+	RegionLabel   = "topology.kubernetes.io/region"
+)
+
+// Labels - returns the topology labels of the forwarder.  Zone and region not given are looked up in the labels of
+// node nodeName when running in a kubernetes cluster, failures to do so are logged and leave them out.
+func Labels(ctx context.Context, nodeName, zone, region string) map[string]string {
+	labels := make(map[string]string)
+	if nodeName != "" && (zone == "" || region == "") {
+		nodeLabels, err := nodeLabels(ctx, nodeName)
+		if err != nil {
+			log.Entry(ctx).Warnf("failed to look up the topology labels of node %s: %+v", nodeName, err)
+		}
+		if zone == "" {
+			zone = nodeLabels[ZoneLabel]
+		}
+		if region == "" {
+			region = nodeLabels[RegionLabel]
+		}
+	}
+	for key, value := range map[string]string{NodeNameLabel: nodeName, ZoneLabel: zone, RegionLabel: region} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// nodeLabels - gets the labels of node nodeName from the kubernetes api server
+func nodeLabels(ctx context.Context, nodeName string) (map[string]string, error) {
+	client, err := k8s.InCluster()
+	if err != nil {
+		return nil, err
+	}
+	var node struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err = client.Do(ctx, http.MethodGet, "/api/v1/nodes/"+nodeName, nil, &node); err != nil {
+		return nil, err
+	}
+	return node.Metadata.Labels, nil
+}
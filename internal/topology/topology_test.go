@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/topology"
+)
+
+// outsideCluster - unsets the environment of a pod of a kubernetes cluster, returning the func restoring it
+func outsideCluster(t *testing.T) func() {
+	host, hostOK := os.LookupEnv("KUBERNETES_SERVICE_HOST")
+	require.NoError(t, os.Unsetenv("KUBERNETES_SERVICE_HOST"))
+	return func() {
+		if hostOK {
+			_ = os.Setenv("KUBERNETES_SERVICE_HOST", host)
+		}
+	}
+}
+
+func TestLabelsGiven(t *testing.T) {
+	defer outsideCluster(t)()
+
+	require.Equal(t, map[string]string{
+		topology.NodeNameLabel: "node-1",
+		topology.ZoneLabel:     "zone-a",
+		topology.RegionLabel:   "region-1",
+	}, topology.Labels(context.Background(), "node-1", "zone-a", "region-1"))
+
+	require.Equal(t, map[string]string{
+		topology.ZoneLabel: "zone-a",
+	}, topology.Labels(context.Background(), "", "zone-a", ""))
+
+	require.Empty(t, topology.Labels(context.Background(), "", "", ""))
+}
+
+func TestLabelsLookupFailureLeavesThemOut(t *testing.T) {
+	defer outsideCluster(t)()
+
+	// Outside a cluster the node labels can't be looked up, only those known are advertised
+	require.Equal(t, map[string]string{
+		topology.NodeNameLabel: "node-1",
+		topology.RegionLabel:   "region-1",
+	}, topology.Labels(context.Background(), "node-1", "", "region-1"))
+}
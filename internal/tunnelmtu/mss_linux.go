@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnelmtu
+
+import (
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netns"
+)
+
+// clampMSS - replaces the iptables rules clamping the mss of tcp syns leaving or entering ifaceName in the netns
+// referenced by netnsURL to previous (if not 0) by rules clamping it to mss (if not 0)
+func clampMSS(netnsURL, ifaceName string, mss, previous int) error {
+	u, err := url.Parse(netnsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if u.Scheme != "file" {
+		return errors.Errorf("unsupported netns url: %q", netnsURL)
+	}
+	nsHandle, err := netns.GetFromPath(u.Path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = nsHandle.Close() }()
+
+	// iptables runs in the netns of the thread forking it
+	runtime.LockOSThread()
+	curNetns, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return errors.WithStack(err)
+	}
+	defer func() { _ = curNetns.Close() }()
+	if err = netns.Set(nsHandle); err != nil {
+		runtime.UnlockOSThread()
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if netns.Set(curNetns) == nil {
+			// A thread left in the wrong netns is not handed back, it exits with its goroutine
+			runtime.UnlockOSThread()
+		}
+	}()
+	for _, rule := range [][]string{
+		{"POSTROUTING", "-o", ifaceName},
+		{"PREROUTING", "-i", ifaceName},
+	} {
+		if previous != 0 {
+			// Missing rules are fine, the interface may have been replaced
+			_ = iptables(append([]string{"-D"}, rule...), previous)
+		}
+		if mss != 0 {
+			if err = iptables(append([]string{"-A"}, rule...), mss); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func iptables(rule []string, mss int) error {
+	args := append(append([]string{"-w", "-t", "mangle"}, rule...),
+		"-p", "tcp", "--tcp-flags", "SYN,RST", "SYN", "-j", "TCPMSS", "--set-mss", strconv.Itoa(mss))
+	if output, err := exec.Command("iptables", args...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "iptables %v: %s", args, output)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnelmtu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampMSSNetns(t *testing.T) {
+	// Nothing to add or remove, only entering and leaving the netns
+	require.NoError(t, clampMSS("file:///proc/self/ns/net", "nsm-1", 0, 0))
+
+	require.Error(t, clampMSS("file:///nonexistent/netns", "nsm-1", 1410, 0))
+	require.Error(t, clampMSS("inode://4/4026531992", "nsm-1", 1410, 0))
+}
+
+func TestApplyKeepsFailedClamp(t *testing.T) {
+	server := &tunnelMTUServer{clampMSS: true}
+	tgt := &target{connID: "conn-1", netnsURL: "file:///nonexistent/netns", ifaceName: "nsm-1"}
+	server.apply(context.Background(), tgt, 1450)
+	require.Zero(t, tgt.mss, "only clamps applied are to be removed")
+
+	tgt = &target{connID: "conn-2", netnsURL: "file:///proc/self/ns/net", ifaceName: "nsm-1", mss: 1410, closed: true}
+	server.apply(context.Background(), tgt, 1300)
+	require.Equal(t, 1410, tgt.mss, "closed connections are left alone")
+}
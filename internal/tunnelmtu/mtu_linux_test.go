@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnelmtu
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetMTU(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	require.NoError(t, err)
+
+	// Setting the mtu an interface already has changes nothing
+	require.NoError(t, setMTU("file:///proc/self/ns/net", "lo", lo.MTU))
+
+	require.Error(t, setMTU("file:///proc/self/ns/net", "does-not-exist", 1450))
+	require.Error(t, setMTU("file:///nonexistent/netns", "lo", 1450))
+	require.Error(t, setMTU("inode://4/4026531992", "lo", 1450))
+}
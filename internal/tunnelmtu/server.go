@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tunnelmtu - chain element handing the mtu of the tunnel of a connection on to its kernel interface, so
+// that packets too large for the tunnel are answered by the client's kernel with icmp frag needed (or refused
+// locally) instead of being silently dropped by vpp at the tunnel, and clamping the tcp mss of the connection to
+// what fits the tunnel for clients that ignore the interface mtu
+package tunnelmtu
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pmtu"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+// Handling of packets too large for the tunnel of their connection
+const (
+	PolicyDrop = "drop"
+	PolicyICMP = "icmp"
+)
+
+// tcpHeaders - bytes of the ipv4 and tcp headers not counted by the mss
+const tcpHeaders = 20 + 20
+
+// target - the kernel interface of a connection, its mu serializing what is applied to it
+type target struct {
+	mu        sync.Mutex
+	closed    bool
+	connID    string
+	netnsURL  string
+	ifaceName string
+	mss       int
+}
+
+type tunnelMTUServer struct {
+	prober   *pmtu.Prober
+	setMTU   bool
+	clampMSS bool
+	mu       sync.Mutex
+	targets  map[string]*target
+}
+
+// NewServer - returns a server chain element setting the mtu of the kernel interface of each connection to the mtu
+// prober found for its tunnel if policy is PolicyICMP, and clamping the tcp mss on that interface to match if
+// clampMSS, following later changes.  It does nothing for PolicyDrop without clampMSS.
+func NewServer(prober *pmtu.Prober, policy string, clampMSS bool) (networkservice.NetworkServiceServer, error) {
+	switch policy {
+	case PolicyDrop, PolicyICMP:
+	default:
+		return nil, errors.Errorf("unknown oversize policy %q, expected %s or %s", policy, PolicyDrop, PolicyICMP)
+	}
+	t := &tunnelMTUServer{
+		setMTU:   policy == PolicyICMP,
+		clampMSS: clampMSS,
+		targets:  make(map[string]*target),
+	}
+	if t.setMTU || t.clampMSS {
+		t.prober = prober
+		prober.OnChange(t.changed)
+	}
+	return t, nil
+}
+
+func (t *tunnelMTUServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil || t.prober == nil {
+		return conn, err
+	}
+	tunnel := vppnames.ClientInterface(conn)
+	if tunnel == "" || conn.GetMechanism().GetType() != kernel.MECHANISM {
+		return conn, nil
+	}
+	params := conn.GetMechanism().GetParameters()
+	t.mu.Lock()
+	tgt, ok := t.targets[tunnel]
+	if !ok {
+		tgt = &target{connID: conn.GetId(), netnsURL: params[kernel.NetNSURL], ifaceName: params[kernel.InterfaceNameKey]}
+		t.targets[tunnel] = tgt
+	}
+	t.mu.Unlock()
+	if mtu := t.prober.TunnelMTU(tunnel); mtu != 0 {
+		t.apply(ctx, tgt, mtu)
+	}
+	return conn, nil
+}
+
+func (t *tunnelMTUServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if t.prober != nil {
+		tunnel := vppnames.ClientInterface(conn)
+		t.mu.Lock()
+		tgt, ok := t.targets[tunnel]
+		delete(t.targets, tunnel)
+		t.mu.Unlock()
+		if ok {
+			tgt.mu.Lock()
+			tgt.closed = true
+			if tgt.mss != 0 {
+				// The rules match the interface by name and would outlive it
+				if err := clampMSS(tgt.netnsURL, tgt.ifaceName, 0, tgt.mss); err != nil {
+					log.Entry(ctx).Debugf("failed to remove the mss clamping of connection %s: %+v", tgt.connID, err)
+				}
+			}
+			tgt.mu.Unlock()
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func (t *tunnelMTUServer) changed(ctx context.Context, tunnels []string, mtu uint32) {
+	var tgts []*target
+	t.mu.Lock()
+	for _, tunnel := range tunnels {
+		if tgt, ok := t.targets[tunnel]; ok {
+			tgts = append(tgts, tgt)
+		}
+	}
+	t.mu.Unlock()
+	for _, tgt := range tgts {
+		t.apply(ctx, tgt, mtu)
+	}
+}
+
+// apply - applies the tunnel mtu mtu to tgt, unless its connection has closed meanwhile.  Only tgt is locked while
+// the netns and iptables are changed, the targets of other connections are applied to concurrently.
+func (t *tunnelMTUServer) apply(ctx context.Context, tgt *target, mtu uint32) {
+	tgt.mu.Lock()
+	defer tgt.mu.Unlock()
+	if tgt.closed {
+		return
+	}
+	if t.setMTU {
+		if err := setMTU(tgt.netnsURL, tgt.ifaceName, int(mtu)); err != nil {
+			log.Entry(ctx).Warnf("failed to set the mtu of %s of connection %s to %d: %+v", tgt.ifaceName, tgt.connID, mtu, err)
+		} else {
+			log.Entry(ctx).Infof("mtu of %s of connection %s set to %d, the mtu of its tunnel", tgt.ifaceName, tgt.connID, mtu)
+		}
+	}
+	if mss := int(mtu) - tcpHeaders; t.clampMSS && mss != tgt.mss {
+		if err := clampMSS(tgt.netnsURL, tgt.ifaceName, mss, tgt.mss); err != nil {
+			log.Entry(ctx).Warnf("failed to clamp the tcp mss of connection %s to %d: %+v", tgt.connID, mss, err)
+			return
+		}
+		tgt.mss = mss
+		log.Entry(ctx).Infof("tcp mss on %s of connection %s clamped to %d", tgt.ifaceName, tgt.connID, mss)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunnelmtu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/memif"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/pmtu"
+)
+
+func connection(id, mechanism string) *networkservice.Connection {
+	return &networkservice.Connection{
+		Id: id,
+		Mechanism: &networkservice.Mechanism{
+			Type: mechanism,
+			Parameters: map[string]string{
+				kernel.NetNSURL:         "file:///proc/self/ns/net",
+				kernel.InterfaceNameKey: "nsm-1",
+			},
+		},
+		Path: &networkservice.Path{
+			PathSegments: []*networkservice.PathSegment{{Id: id}, {Id: id + "-next"}},
+		},
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := NewServer(&pmtu.Prober{}, "fragment", false)
+	require.Error(t, err)
+
+	server, err := NewServer(&pmtu.Prober{}, PolicyDrop, false)
+	require.NoError(t, err)
+	require.Nil(t, server.(*tunnelMTUServer).prober, "nothing to follow")
+
+	server, err = NewServer(&pmtu.Prober{}, PolicyDrop, true)
+	require.NoError(t, err)
+	require.NotNil(t, server.(*tunnelMTUServer).prober)
+}
+
+func TestTargets(t *testing.T) {
+	server, err := NewServer(&pmtu.Prober{}, PolicyICMP, false)
+	require.NoError(t, err)
+	targets := server.(*tunnelMTUServer).targets
+	elements := chain.NewNetworkServiceServer(server)
+
+	conn, err := elements.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: connection("conn-1", kernel.MECHANISM)})
+	require.NoError(t, err)
+	_, err = elements.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: connection("conn-2", memif.MECHANISM)})
+	require.NoError(t, err)
+	require.Len(t, targets, 1, "memif clients have no kernel interface")
+	tgt := targets["client-conn-1-next"]
+	require.NotNil(t, tgt)
+	require.Equal(t, "conn-1", tgt.connID)
+	require.Equal(t, "nsm-1", tgt.ifaceName)
+
+	_, err = elements.Close(context.Background(), conn)
+	require.NoError(t, err)
+	require.Empty(t, targets)
+	require.True(t, tgt.closed, "changes racing the Close are not applied")
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txndedup - skips vpp-agent txns that would not change anything.
+//
+// Refresh Requests with unchanged parameters make the chain send vpp-agent the very same Update as before.  Those
+// are skipped entirely.  Updates with changed parameters (new ips, mtu...) are passed on, and vpp-agent's scheduler,
+// being declarative, reprograms only the objects whose values changed rather than deleting and recreating them.
+//
+// Skipping is only safe as long as vpp still has what was applied, so applied Updates are remembered for the current
+// vpp epoch only: the epoch ends with every Delete and full resync, whenever the connection to vpp-agent is lost and
+// whenever Invalidate is called, e.g. for an interface deleted behind the forwarder's back that a heal is to restore.
+package txndedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+const (
+	updateMethod = "/ligato.configurator.ConfiguratorService/Update"
+	deleteMethod = "/ligato.configurator.ConfiguratorService/Delete"
+)
+
+// Cache - the Updates applied successfully in the current vpp epoch.  The zero value skips nothing.
+type Cache struct {
+	// TTL - how long an applied Update is skipped for, 0 disables skipping
+	TTL time.Duration
+
+	mu      sync.Mutex
+	epoch   uint64
+	applied map[[sha256.Size]byte]time.Time
+}
+
+// Invalidate - ends the current vpp epoch, forgetting all applied Updates
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epoch++
+	c.applied = nil
+}
+
+// WatchConnection - ends the vpp epoch whenever cc is no longer ready, vpp-agent and possibly vpp having restarted,
+// until ctx is done
+func (c *Cache) WatchConnection(ctx context.Context, cc *grpc.ClientConn) {
+	go func() {
+		state := cc.GetState()
+		for cc.WaitForStateChange(ctx, state) {
+			if state = cc.GetState(); state != connectivity.Ready {
+				c.Invalidate()
+			}
+		}
+	}()
+}
+
+// UnaryClientInterceptor - returns an interceptor skipping Updates identical to one applied successfully less than
+// c.TTL ago in the current vpp epoch.  Full resyncs are never skipped.
+func (c *Cache) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	skipped := metrics.Int("vppagent_txns_skipped")
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		update, ok := req.(*configurator.UpdateRequest)
+		if method == deleteMethod || (ok && update.GetFullResync()) {
+			c.Invalidate()
+		}
+		if method != updateMethod || c.TTL <= 0 || !ok || update.GetFullResync() {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		if err := buf.Marshal(update); err != nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		key := sha256.Sum256(buf.Bytes())
+		c.mu.Lock()
+		epoch := c.epoch
+		at, ok := c.applied[key]
+		c.mu.Unlock()
+		if ok && time.Since(at) < c.TTL {
+			skipped.Add(1)
+			return nil
+		}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// An epoch ended during the Update may have undone it
+		if c.epoch != epoch {
+			return nil
+		}
+		if c.applied == nil {
+			c.applied = make(map[[sha256.Size]byte]time.Time)
+		}
+		c.applied[key] = time.Now()
+		for k, t := range c.applied {
+			if time.Since(t) >= c.TTL {
+				delete(c.applied, k)
+			}
+		}
+		return nil
+	}
+}
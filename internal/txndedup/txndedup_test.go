@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txndedup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/txndedup"
+)
+
+const updateMethod = "/ligato.configurator.ConfiguratorService/Update"
+
+func update(fullResync bool) *configurator.UpdateRequest {
+	return &configurator.UpdateRequest{
+		Update: &configurator.Config{VppConfig: &vpp.ConfigData{
+			Interfaces: []*vpp_interfaces.Interface{{Name: "memif0", Type: vpp_interfaces.Interface_MEMIF}},
+		}},
+		FullResync: fullResync,
+	}
+}
+
+// send - sends req through c twice, with Invalidate called in between if invalidate, and returns the number of
+// Updates reaching vpp-agent
+func send(c *txndedup.Cache, req *configurator.UpdateRequest, invalidate bool) int {
+	interceptor := c.UnaryClientInterceptor()
+	invoked := 0
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked++
+		return nil
+	}
+	_ = interceptor(context.Background(), updateMethod, req, &configurator.UpdateResponse{}, nil, invoker)
+	if invalidate {
+		c.Invalidate()
+	}
+	_ = interceptor(context.Background(), updateMethod, req, &configurator.UpdateResponse{}, nil, invoker)
+	return invoked
+}
+
+func TestSkipsRepeatedUpdate(t *testing.T) {
+	require.Equal(t, 1, send(&txndedup.Cache{TTL: time.Minute}, update(false), false))
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	require.Equal(t, 2, send(&txndedup.Cache{}, update(false), false))
+}
+
+func TestFullResyncNotSkipped(t *testing.T) {
+	require.Equal(t, 2, send(&txndedup.Cache{TTL: time.Minute}, update(true), false))
+}
+
+func TestInvalidateEndsEpoch(t *testing.T) {
+	require.Equal(t, 2, send(&txndedup.Cache{TTL: time.Minute}, update(false), true))
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txnstats - metrics of the vpp-agent txns the forwarder makes, for capacity planning of the vpp api
+// throughput: histograms of their item counts and sizes, and counters of txns and of the items of each model.
+//
+//	vppagent_txns.<update|delete>                 txns made
+//	vppagent_txn_items.<update|delete>            histogram of the items per txn
+//	vppagent_txn_bytes.<update|delete>            histogram of the encoded size of txns
+//	vppagent_txn_model_items.<section>.<model>    items sent, e.g. vpp.interfaces or linux.routes
+package txnstats
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+var (
+	itemBounds = []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512}
+	byteBounds = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576}
+)
+
+// UnaryClientInterceptor - returns an interceptor recording the metrics of the vpp-agent Updates and Deletes passing
+// through
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		switch r := req.(type) {
+		case *configurator.UpdateRequest:
+			record("update", r, r.GetUpdate())
+		case *configurator.DeleteRequest:
+			record("delete", r, r.GetDelete())
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func record(kind string, req proto.Message, conf *configurator.Config) {
+	metrics.Int("vppagent_txns." + kind).Add(1)
+	metrics.Histogram("vppagent_txn_bytes."+kind, byteBounds...).Observe(float64(proto.Size(req)))
+	total := 0
+	for model, items := range models(conf) {
+		metrics.Int("vppagent_txn_model_items." + model).Add(int64(items))
+		total += items
+	}
+	metrics.Histogram("vppagent_txn_items."+kind, itemBounds...).Observe(float64(total))
+}
+
+// models - returns the number of items of each model of conf, by <section>.<model> named after their proto fields
+func models(conf *configurator.Config) map[string]int {
+	rv := make(map[string]int)
+	if conf == nil {
+		return rv
+	}
+	c := reflect.ValueOf(conf).Elem()
+	for i := 0; i < c.NumField(); i++ {
+		section := c.Field(i)
+		if c.Type().Field(i).PkgPath != "" || section.Kind() != reflect.Ptr || section.IsNil() || section.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		sectionName := strings.TrimSuffix(protoName(c.Type().Field(i)), "_config")
+		s := section.Elem()
+		for j := 0; j < s.NumField(); j++ {
+			items := s.Field(j)
+			if s.Type().Field(j).PkgPath != "" || items.Kind() != reflect.Slice || items.Len() == 0 {
+				continue
+			}
+			rv[sectionName+"."+protoName(s.Type().Field(j))] += items.Len()
+		}
+	}
+	return rv
+}
+
+// protoName - returns the proto name of the generated field, e.g. vpp_config for VppConfig
+func protoName(field reflect.StructField) string {
+	for _, part := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
+		}
+	}
+	return strings.ToLower(field.Name)
+}
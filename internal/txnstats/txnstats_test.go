@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package txnstats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/linux"
+	linux_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/linux/interfaces"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+func config() *configurator.Config {
+	return &configurator.Config{
+		VppConfig: &vpp.ConfigData{
+			Interfaces: []*vpp_interfaces.Interface{{Name: "server-1"}, {Name: "client-1"}},
+			Routes:     []*vpp_l3.Route{{DstNetwork: "10.0.0.0/24", OutgoingInterface: "server-1"}},
+		},
+		LinuxConfig: &linux.ConfigData{
+			Interfaces: []*linux_interfaces.Interface{{Name: "client-1"}},
+		},
+	}
+}
+
+func TestModels(t *testing.T) {
+	require.Equal(t, map[string]int{
+		"vpp.interfaces":   2,
+		"vpp.routes":       1,
+		"linux.interfaces": 1,
+	}, models(config()))
+	require.Empty(t, models(nil))
+	require.Empty(t, models(&configurator.Config{VppConfig: &vpp.ConfigData{}}))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	txns := metrics.Int("vppagent_txns.delete").Value()
+	routes := metrics.Int("vppagent_txn_model_items.vpp.routes").Value()
+
+	invoked := false
+	invoker := func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		invoked = true
+		return nil
+	}
+	interceptor := UnaryClientInterceptor()
+	req := &configurator.DeleteRequest{Delete: config()}
+	require.NoError(t, interceptor(context.Background(), "/Delete", req, &configurator.DeleteResponse{}, nil, invoker))
+	require.True(t, invoked)
+	require.Equal(t, txns+1, metrics.Int("vppagent_txns.delete").Value())
+	require.Equal(t, routes+1, metrics.Int("vppagent_txn_model_items.vpp.routes").Value())
+
+	// Other calls are not txns
+	require.NoError(t, interceptor(context.Background(), "/Dump", &configurator.DumpRequest{}, &configurator.DumpResponse{}, nil, invoker))
+	require.Equal(t, txns+1, metrics.Int("vppagent_txns.delete").Value())
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Architecture profiles besides those of archProfiles
+const (
+	// autoProfile - the profile of the architecture the forwarder runs on, if there is one
+	autoProfile = "auto"
+	// noProfile - leaves vpp at its defaults
+	noProfile = "none"
+)
+
+// archProfile - vpp tuning of an architecture
+type archProfile struct {
+	// buffersPerNuma - buffers vpp allocates per numa node, the vpp default if 0
+	buffersPerNuma int
+	// disabledPlugins - plugins not working on the architecture, unless explicitly enabled
+	disabledPlugins []string
+	// cpuFeatures - flags (features on arm64) of /proc/cpuinfo the vpp builds for the architecture require
+	cpuFeatures []string
+	// cryptoEngines - the crypto engines vpp has on the architecture
+	cryptoEngines []string
+}
+
+var archProfiles = map[string]*archProfile{
+	"amd64": {
+		cpuFeatures:   []string{"sse4_2"},
+		cryptoEngines: []string{"native", "ipsecmb", "openssl"},
+	},
+	// arm64 edge nodes are short of memory and intel's ipsec multi-buffer library is x86 only
+	"arm64": {
+		buffersPerNuma:  8192,
+		disabledPlugins: []string{"crypto_ipsecmb"},
+		cpuFeatures:     []string{"asimd"},
+		cryptoEngines:   []string{"native", "openssl"},
+	},
+}
+
+// archProfile - returns the profile ArchProfile selects, nil for none
+func (c *Config) archProfile() (*archProfile, error) {
+	switch c.ArchProfile {
+	case noProfile:
+		return nil, nil
+	case autoProfile:
+		return archProfiles[runtime.GOARCH], nil
+	}
+	profile, ok := archProfiles[c.ArchProfile]
+	if !ok {
+		return nil, errors.Errorf("unknown vpp architecture profile %q, use auto, none, amd64 or arm64", c.ArchProfile)
+	}
+	return profile, nil
+}
+
+// validateArch - checks that the cpu has the features vpp requires on its architecture and that the crypto engine
+// is available on it
+func validateArch(config *Config) error {
+	profile, err := config.archProfile()
+	if err != nil || profile == nil {
+		return err
+	}
+	if config.CryptoEngine != "" && !contains(profile.cryptoEngines, config.CryptoEngine) {
+		return errors.Errorf("crypto engine %s is not available on %s, use one of %s",
+			config.CryptoEngine, runtime.GOARCH, strings.Join(profile.cryptoEngines, ", "))
+	}
+	cpuinfo, err := ioutil.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		// Nothing to check against
+		return nil
+	}
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(cpuinfo), "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if key := strings.TrimSpace(kv[0]); len(kv) == 2 && (key == "flags" || key == "Features") {
+			for _, feature := range strings.Fields(kv[1]) {
+				present[feature] = true
+			}
+		}
+	}
+	for _, feature := range profile.cpuFeatures {
+		if !present[feature] {
+			return errors.Errorf("the cpu lacks the %s feature vpp requires on %s", feature, runtime.GOARCH)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
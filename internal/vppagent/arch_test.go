@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchProfile(t *testing.T) {
+	profile, err := (&Config{ArchProfile: noProfile}).archProfile()
+	require.NoError(t, err)
+	require.Nil(t, profile)
+
+	profile, err = (&Config{ArchProfile: autoProfile}).archProfile()
+	require.NoError(t, err)
+	require.True(t, profile == archProfiles[runtime.GOARCH])
+
+	profile, err = (&Config{ArchProfile: "arm64"}).archProfile()
+	require.NoError(t, err)
+	require.Equal(t, 8192, profile.buffersPerNuma)
+
+	_, err = (&Config{ArchProfile: "riscv64"}).archProfile()
+	require.Error(t, err)
+}
+
+func TestValidateArch(t *testing.T) {
+	require.NoError(t, validateArch(&Config{ArchProfile: noProfile, CryptoEngine: "ipsecmb"}))
+	require.Error(t, validateArch(&Config{ArchProfile: "riscv64"}))
+	require.Error(t, validateArch(&Config{ArchProfile: "arm64", CryptoEngine: "ipsecmb"}), "ipsecmb is x86 only")
+
+	archProfiles["test"] = &archProfile{cpuFeatures: []string{"no_such_feature"}}
+	defer delete(archProfiles, "test")
+	err := validateArch(&Config{ArchProfile: "test"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the cpu lacks the no_such_feature feature")
+}
+
+func TestArm64Profile(t *testing.T) {
+	read, remove := render(t, &Config{ArchProfile: "arm64"})
+	defer remove()
+	vppConf := read(vppConfFile)
+	require.Contains(t, vppConf, "buffers {\n  buffers-per-numa 8192\n}")
+	require.Contains(t, vppConf, "  plugin crypto_ipsecmb_plugin.so { disable }\n")
+
+	read, remove = render(t, &Config{ArchProfile: "arm64", BuffersPerNuma: 4096, PluginsEnable: []string{"crypto_ipsecmb"}})
+	defer remove()
+	vppConf = read(vppConfFile)
+	require.Contains(t, vppConf, "buffers {\n  buffers-per-numa 4096\n}")
+	require.Contains(t, vppConf, "  plugin crypto_ipsecmb_plugin.so { enable }\n")
+	require.NotContains(t, vppConf, "crypto_ipsecmb_plugin.so { disable }")
+
+	read, remove = render(t, &Config{ArchProfile: noProfile})
+	defer remove()
+	require.NotContains(t, read(vppConfFile), "buffers {")
+}
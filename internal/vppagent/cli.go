@@ -0,0 +1,46 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cliErrors - prefixes of the output vppctl prints, with exit status 0, for failed commands
+var cliErrors = []string{"unknown input", "unknown interface", "error", "failed", "invalid"}
+
+// CLI - runs the vpp cli command through vppctl and vpp's cli socket, returning its output
+func (c *Config) CLI(ctx context.Context, command ...string) (string, error) {
+	args := append([]string{"-s", c.path(cliSocket)}, command...)
+	output, err := exec.CommandContext(ctx, c.CtlPath, args...).CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "vpp cli %q failed: %s", strings.Join(command, " "), output)
+	}
+	trimmed := strings.ToLower(strings.TrimSpace(string(output)))
+	for _, prefix := range cliErrors {
+		if strings.HasPrefix(trimmed, prefix) {
+			return "", errors.Errorf("vpp cli %q failed: %s", strings.Join(command, " "), strings.TrimSpace(string(output)))
+		}
+	}
+	return string(output), nil
+}
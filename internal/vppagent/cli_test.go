@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVPPCtl - a vppctl echoing the socket and command it is given, failing the commands bad and fail the two ways
+// vppctl does
+const fakeVPPCtl = `#!/bin/sh
+case "$3" in
+bad) echo "unknown input: bad";;
+fail) echo "cli socket gone"; exit 1;;
+*) echo "$2 $3 $4";;
+esac
+`
+
+func TestCLI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppctl")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	ctlPath := filepath.Join(dir, "vppctl")
+	require.NoError(t, ioutil.WriteFile(ctlPath, []byte(fakeVPPCtl), 0700))
+	config := &Config{CtlPath: ctlPath, RootDir: dir}
+
+	output, err := config.CLI(context.Background(), "show", "runtime")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, cliSocket)+" show runtime\n", output)
+
+	_, err = config.CLI(context.Background(), "bad")
+	require.Error(t, err, "vppctl exits 0 for unknown commands")
+	require.Contains(t, err.Error(), "unknown input")
+
+	_, err = config.CLI(context.Background(), "fail")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cli socket gone")
+}
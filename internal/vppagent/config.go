@@ -0,0 +1,322 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	vppConfFile  = "etc/vpp/vpp.conf"
+	agentConfDir = "etc/vpp-agent"
+	apiSocket    = "var/run/vpp/api.sock"
+	statsSocket  = "var/run/vpp/stats.sock"
+	cliSocket    = "var/run/vpp/cli.sock"
+	startupCLI   = "etc/vpp/startup.cli"
+	vppLogFile   = "var/log/vpp/vpp.log"
+)
+
+var vppConfTemplate = template.Must(template.New(vppConfFile).Parse(`unix {
+  nodaemon
+  log {{ .Path "` + vppLogFile + `" }}
+  full-coredump
+  cli-listen {{ .Path "` + cliSocket + `" }}
+{{- if .CPULimit }}
+  poll-sleep-usec 100
+{{- end }}
+{{- if .StartupCommands }}
+  startup-config {{ .Path "` + startupCLI + `" }}
+{{- end }}
+}
+{{- if or .Workers .PinCPUs }}
+cpu {
+{{- if .PinCPUs }}
+  main-core {{ .MainCore }}
+{{- if .Workers }}
+  corelist-workers {{ .WorkerCores }}
+{{- end }}
+{{- else }}
+  workers {{ .Workers }}
+{{- end }}
+}
+{{- end }}
+api-trace {
+  on
+}
+{{- if .Buffers }}
+buffers {
+  buffers-per-numa {{ .Buffers }}
+}
+{{- end }}
+{{- if or .APISegmentGlobalSize .APISegmentAPISize .Instance }}
+api-segment {
+{{- if .Instance }}
+  prefix nsm{{ .Instance }}
+{{- end }}
+{{- if .APISegmentGlobalSize }}
+  global-size {{ .APISegmentGlobalSize }}
+{{- end }}
+{{- if .APISegmentAPISize }}
+  api-size {{ .APISegmentAPISize }}
+{{- end }}
+}
+{{- end }}
+socksvr {
+  socket-name {{ .Path "` + apiSocket + `" }}
+}
+statseg {
+  socket-name {{ .Path "` + statsSocket + `" }}
+  per-node-counters on
+{{- if .StatsegSize }}
+  size {{ .StatsegSize }}
+{{- end }}
+}
+plugins {
+{{- range .Plugins }}
+  plugin {{ .Name }} { {{ .Action }} }
+{{- end }}
+}
+`))
+
+var startupCLITemplate = template.Must(template.New(startupCLI).Parse(`{{ range .StartupCommands }}{{ . }}
+{{ end }}`))
+
+var agentConfTemplates = map[string]*template.Template{
+	"grpc.conf": template.Must(template.New("grpc.conf").Parse(`endpoint: {{ .AgentEndpoint }}
+`)),
+	// vpp-agent's REST api accepts changes too, the read-only one is served by the forwarder
+	"http.conf": template.Must(template.New("http.conf").Parse(`disabled: true
+`)),
+	"telemetry.conf": template.Must(template.New("telemetry.conf").Parse(`disabled: true
+`)),
+	"etcd.conf": template.Must(template.New("etcd.conf").Parse(`endpoints:
+{{- range .AgentEtcdEndpoints }}
+  - "{{ . }}"
+{{- end }}
+allow-delayed-start: true
+reconnect-resync: true
+`)),
+	"govpp.conf": template.Must(template.New("govpp.conf").Parse(`binapi-socket-path: {{ .Path "` + apiSocket + `" }}
+stats-socket-path: {{ .Path "` + statsSocket + `" }}
+`)),
+}
+
+// mainCore - returns the cpu the vpp main thread is pinned to if PinCPUs is set, cpu 0 being left to the system
+// and each instance getting as many cpus as it has threads
+func (c *Config) mainCore() int {
+	return 1 + c.Instance*(c.Workers+1)
+}
+
+// PinnedCPUs - returns the cpus the vpp threads are pinned to, none unless PinCPUs is set
+func (c *Config) PinnedCPUs() []int {
+	if !c.PinCPUs {
+		return nil
+	}
+	var rv []int
+	for cpu := c.mainCore(); cpu <= c.mainCore()+c.Workers; cpu++ {
+		rv = append(rv, cpu)
+	}
+	return rv
+}
+
+type templateData struct {
+	*Config
+}
+
+// Path - returns relative rooted at the configured RootDir
+func (t *templateData) Path(relative string) string {
+	return t.path(relative)
+}
+
+// AgentEndpoint - returns the endpoint vpp-agent serves grpc on
+func (t *templateData) AgentEndpoint() string {
+	return t.agentEndpoint()
+}
+
+// MainCore - returns the cpu of the vpp main thread
+func (t *templateData) MainCore() int {
+	return t.mainCore()
+}
+
+// WorkerCores - returns the cpus of the vpp workers, following MainCore
+func (t *templateData) WorkerCores() string {
+	main := t.MainCore()
+	if t.Workers == 1 {
+		return strconv.Itoa(main + 1)
+	}
+	return fmt.Sprintf("%d-%d", main+1, main+t.Workers)
+}
+
+// Buffers - returns the buffers vpp allocates per numa node, 0 for the vpp default
+func (t *templateData) Buffers() int {
+	if t.BuffersPerNuma > 0 {
+		return t.BuffersPerNuma
+	}
+	if profile, _ := t.archProfile(); profile != nil {
+		return profile.buffersPerNuma
+	}
+	return 0
+}
+
+type plugin struct {
+	Name   string
+	Action string
+}
+
+// Plugins - returns the vpp plugins to enable or disable
+func (t *templateData) Plugins() []plugin {
+	var rv []plugin
+	if t.CryptoEngine != "" {
+		rv = append(rv, plugin{Name: pluginFileName("crypto_" + t.CryptoEngine), Action: "enable"})
+	}
+	if t.CryptoAsync {
+		rv = append(rv, plugin{Name: pluginFileName("crypto_sw_scheduler"), Action: "enable"})
+	}
+	for _, name := range t.PluginsEnable {
+		rv = append(rv, plugin{Name: pluginFileName(name), Action: "enable"})
+	}
+	for _, name := range t.PluginsDisable {
+		rv = append(rv, plugin{Name: pluginFileName(name), Action: "disable"})
+	}
+	if profile, _ := t.archProfile(); profile != nil {
+		for _, name := range profile.disabledPlugins {
+			if !contains(t.PluginsEnable, name) && !contains(t.PluginsEnable, pluginFileName(name)) &&
+				!contains(t.PluginsDisable, name) && !contains(t.PluginsDisable, pluginFileName(name)) {
+				rv = append(rv, plugin{Name: pluginFileName(name), Action: "disable"})
+			}
+		}
+	}
+	return rv
+}
+
+// StartupCommands - returns the vpp cli commands run once vpp has started
+func (t *templateData) StartupCommands() []string {
+	var rv []string
+	if t.CryptoEngine != "" {
+		rv = append(rv, "set crypto handler all "+t.CryptoEngine)
+	}
+	if t.CryptoAsync {
+		rv = append(rv, "set crypto async handler all crypto_sw_scheduler")
+		for _, worker := range t.CryptoAsyncWorkers {
+			rv = append(rv, fmt.Sprintf("set sw_scheduler worker %d crypto on", worker))
+		}
+	}
+	if t.ForwarderID != "" {
+		// vpp-agent keeps its own names in the tags of the interfaces it configures, local0 it leaves alone
+		rv = append(rv, "set interface tag local0 nsm-forwarder-"+t.ForwarderID)
+	}
+	return rv
+}
+
+func pluginFileName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, ".so") {
+		return name
+	}
+	return name + "_plugin.so"
+}
+
+func validateCrypto(config *Config) error {
+	switch config.CryptoEngine {
+	case "", "native", "ipsecmb", "openssl":
+		return nil
+	default:
+		return errors.Errorf("unknown crypto engine %q", config.CryptoEngine)
+	}
+}
+
+// cpuLimit - returns CPULimit in cores, 0 if unlimited
+func (c *Config) cpuLimit() (float64, error) {
+	if c.CPULimit == "" {
+		return 0, nil
+	}
+	if c.Cgroup == "" {
+		return 0, errors.New("a cpu limit requires a Cgroup to apply it to")
+	}
+	value, scale := c.CPULimit, 1.0
+	if strings.HasSuffix(value, "m") {
+		value, scale = strings.TrimSuffix(value, "m"), 0.001
+	}
+	cores, err := strconv.ParseFloat(value, 64)
+	if err != nil || cores <= 0 {
+		return 0, errors.Errorf("invalid cpu limit %q, expected cores (0.5) or millicores (500m)", c.CPULimit)
+	}
+	return cores * scale, nil
+}
+
+func validatePlugins(config *Config) error {
+	enabled := make(map[string]bool)
+	for _, name := range config.PluginsEnable {
+		enabled[pluginFileName(name)] = true
+	}
+	for _, name := range config.PluginsDisable {
+		if enabled[pluginFileName(name)] {
+			return errors.Errorf("vpp plugin %s is both enabled and disabled", pluginFileName(name))
+		}
+	}
+	return nil
+}
+
+func writeConfigs(config *Config) error {
+	data := &templateData{Config: config}
+	for _, dir := range []string{filepath.Dir(vppLogFile), filepath.Dir(apiSocket)} {
+		if err := os.MkdirAll(config.path(dir), 0700); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := writeTemplate(config.path(vppConfFile), vppConfTemplate, data); err != nil {
+		return err
+	}
+	if err := writeTemplate(config.path(startupCLI), startupCLITemplate, data); err != nil {
+		return err
+	}
+	for name, tmpl := range agentConfTemplates {
+		filename := filepath.Join(config.path(agentConfDir), name)
+		if name == "etcd.conf" && len(config.AgentEtcdEndpoints) == 0 {
+			// vpp-agent only loads its etcd plugin if etcd.conf exists
+			if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if err := writeTemplate(filename, tmpl, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTemplate(filename string, tmpl *template.Template, data *templateData) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return errors.WithStack(err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return errors.Wrapf(err, "failed to render %s", filename)
+	}
+	return errors.WithStack(ioutil.WriteFile(filename, []byte(b.String()), 0600))
+}
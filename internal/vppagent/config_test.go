@@ -0,0 +1,194 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// render - writes the configs of config rooted at a temporary directory, returning the func reading them back
+// by their path relative to it and the func removing it
+func render(t *testing.T, config *Config) (read func(relative string) string, remove func()) {
+	dir, err := ioutil.TempDir("", "vppagent")
+	require.NoError(t, err)
+	config.RootDir = dir
+	require.NoError(t, writeConfigs(config))
+	return func(relative string) string {
+			data, readErr := ioutil.ReadFile(filepath.Clean(filepath.Join(dir, relative)))
+			require.NoError(t, readErr)
+			return string(data)
+		}, func() {
+			_ = os.RemoveAll(dir)
+		}
+}
+
+func TestSegmentSizes(t *testing.T) {
+	read, remove := render(t, &Config{ArchProfile: noProfile})
+	defer remove()
+	vppConf := read(vppConfFile)
+	require.NotContains(t, vppConf, "api-segment")
+	require.NotContains(t, vppConf, "  size ")
+
+	read, remove = render(t, &Config{
+		ArchProfile:          noProfile,
+		StatsegSize:          "128M",
+		APISegmentGlobalSize: "64M",
+		APISegmentAPISize:    "16M",
+	})
+	defer remove()
+	vppConf = read(vppConfFile)
+	require.Contains(t, vppConf, "  per-node-counters on\n  size 128M\n}")
+	require.Contains(t, vppConf, "api-segment {\n  global-size 64M\n  api-size 16M\n}")
+}
+
+func TestPlugins(t *testing.T) {
+	read, remove := render(t, &Config{
+		ArchProfile:    noProfile,
+		PluginsEnable:  []string{"acl", "wireguard_plugin.so"},
+		PluginsDisable: []string{"dpdk_plugin.so", " nat "},
+	})
+	defer remove()
+	require.Contains(t, read(vppConfFile), `plugins {
+  plugin acl_plugin.so { enable }
+  plugin wireguard_plugin.so { enable }
+  plugin dpdk_plugin.so { disable }
+  plugin nat_plugin.so { disable }
+}`)
+
+	require.NoError(t, validatePlugins(&Config{PluginsEnable: []string{"acl"}, PluginsDisable: []string{"dpdk"}}))
+	require.Error(t, validatePlugins(&Config{PluginsEnable: []string{"dpdk"}, PluginsDisable: []string{"dpdk_plugin.so"}}))
+}
+
+func TestCryptoEngine(t *testing.T) {
+	config := &Config{
+		ArchProfile:        noProfile,
+		CryptoEngine:       "ipsecmb",
+		CryptoAsync:        true,
+		CryptoAsyncWorkers: []int{0, 2},
+	}
+	read, remove := render(t, config)
+	defer remove()
+	vppConf := read(vppConfFile)
+	require.Contains(t, vppConf, "  plugin crypto_ipsecmb_plugin.so { enable }\n  plugin crypto_sw_scheduler_plugin.so { enable }\n")
+	require.Contains(t, vppConf, "  startup-config "+config.path(startupCLI)+"\n")
+	require.Equal(t, `set crypto handler all ipsecmb
+set crypto async handler all crypto_sw_scheduler
+set sw_scheduler worker 0 crypto on
+set sw_scheduler worker 2 crypto on
+`, read(startupCLI))
+
+	read, remove = render(t, &Config{ArchProfile: noProfile})
+	defer remove()
+	require.NotContains(t, read(vppConfFile), "startup-config")
+	require.Empty(t, read(startupCLI))
+
+	require.NoError(t, validateCrypto(&Config{CryptoEngine: "openssl"}))
+	require.NoError(t, validateCrypto(&Config{}))
+	require.Error(t, validateCrypto(&Config{CryptoEngine: "qat"}))
+}
+
+func TestEtcdConf(t *testing.T) {
+	config := &Config{ArchProfile: noProfile, AgentEtcdEndpoints: []string{"etcd-0:2379", "etcd-1:2379"}}
+	read, remove := render(t, config)
+	defer remove()
+	require.Equal(t, `endpoints:
+  - "etcd-0:2379"
+  - "etcd-1:2379"
+allow-delayed-start: true
+reconnect-resync: true
+`, read(filepath.Join(agentConfDir, "etcd.conf")))
+
+	// vpp-agent loads its etcd plugin whenever etcd.conf exists, so it is removed once no endpoints are configured
+	config.AgentEtcdEndpoints = nil
+	require.NoError(t, writeConfigs(config))
+	_, err := os.Stat(config.path(filepath.Join(agentConfDir, "etcd.conf")))
+	require.True(t, os.IsNotExist(err))
+	require.Equal(t, "endpoint: localhost:9111\n", read(filepath.Join(agentConfDir, "grpc.conf")))
+}
+
+func TestCPULimit(t *testing.T) {
+	for _, sample := range []struct {
+		limit string
+		cores float64
+	}{
+		{"", 0},
+		{"2", 2},
+		{"0.5", 0.5},
+		{"250m", 0.25},
+	} {
+		cores, err := (&Config{CPULimit: sample.limit, Cgroup: "/sys/fs/cgroup/vpp"}).cpuLimit()
+		require.NoError(t, err, sample.limit)
+		require.Equal(t, sample.cores, cores, sample.limit)
+	}
+	for _, limit := range []string{"two", "0", "-1", "m"} {
+		_, err := (&Config{CPULimit: limit, Cgroup: "/sys/fs/cgroup/vpp"}).cpuLimit()
+		require.Error(t, err, limit)
+	}
+	_, err := (&Config{CPULimit: "2"}).cpuLimit()
+	require.Error(t, err, "a cpu limit needs a cgroup")
+}
+
+func TestInstance(t *testing.T) {
+	read, remove := render(t, &Config{ArchProfile: noProfile, Instance: 2})
+	defer remove()
+	require.Contains(t, read(filepath.Join("instance-2", vppConfFile)), "api-segment {\n  prefix nsm2\n}")
+	require.Equal(t, "endpoint: localhost:9113\n", read(filepath.Join("instance-2", agentConfDir, "grpc.conf")))
+
+	read, remove = render(t, &Config{ArchProfile: noProfile})
+	defer remove()
+	require.NotContains(t, read(vppConfFile), "prefix")
+}
+
+func TestPinCPUs(t *testing.T) {
+	config := &Config{ArchProfile: noProfile, PinCPUs: true}
+	read, remove := render(t, config)
+	defer remove()
+	require.Contains(t, read(vppConfFile), "cpu {\n  main-core 1\n}")
+	require.Equal(t, []int{1}, config.PinnedCPUs())
+
+	config = &Config{ArchProfile: noProfile, PinCPUs: true, Workers: 1}
+	read, remove = render(t, config)
+	defer remove()
+	require.Contains(t, read(vppConfFile), "cpu {\n  main-core 1\n  corelist-workers 2\n}")
+	require.Equal(t, []int{1, 2}, config.PinnedCPUs())
+
+	// Each instance gets the cpus following those of the instances before it
+	config = &Config{ArchProfile: noProfile, PinCPUs: true, Workers: 2, Instance: 1}
+	read, remove = render(t, config)
+	defer remove()
+	require.Contains(t, read(filepath.Join("instance-1", vppConfFile)), "cpu {\n  main-core 4\n  corelist-workers 5-6\n}")
+	require.Equal(t, []int{4, 5, 6}, config.PinnedCPUs())
+
+	config = &Config{ArchProfile: noProfile, Workers: 2}
+	read, remove = render(t, config)
+	defer remove()
+	require.Contains(t, read(vppConfFile), "cpu {\n  workers 2\n}")
+	require.Empty(t, config.PinnedCPUs())
+}
+
+func TestForwarderIDTag(t *testing.T) {
+	read, remove := render(t, &Config{ArchProfile: noProfile, ForwarderID: "5b7e1d0c-5d6e-4f1a-9a43-3c2f8f0a6b11"})
+	defer remove()
+	require.Equal(t, "set interface tag local0 nsm-forwarder-5b7e1d0c-5d6e-4f1a-9a43-3c2f8f0a6b11\n", read(startupCLI))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build fakevpp,!windows
+
+package vppagent
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/fakevppagent"
+)
+
+// fakeBuilt - whether a Fake config can be run, the fake vpp-agent being linked into this build
+const fakeBuilt = true
+
+// dialFake - dials a new fake vpp-agent in place of vpp-agent.  The returned channel receives any error dialing and
+// is closed once ctx is done.
+func dialFake(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, <-chan error) {
+	rvErrCh := make(chan error, 1)
+	vppagentCC, err := (&fakevppagent.Server{}).Dial(ctx, opts...)
+	if err != nil {
+		rvErrCh <- errors.Wrap(err, "failed to dial the fake vpp-agent")
+		close(rvErrCh)
+		return nil, rvErrCh
+	}
+	log.Entry(ctx).Warn("connected to a fake vpp-agent, recording txns instead of programming vpp")
+	go func() {
+		<-ctx.Done()
+		close(rvErrCh)
+	}()
+	return vppagentCC, rvErrCh
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+// Actions taken when vpp exceeds its memory limit
+const (
+	MemoryActionLog       = "log"
+	MemoryActionRestart   = "restart"
+	MemoryActionReadiness = "readiness"
+)
+
+const readinessComponent = "vpp-memory"
+
+// validateMemory - checks the memory watchdog settings of config
+func validateMemory(config *Config) error {
+	switch config.MemoryAction {
+	case MemoryActionLog, MemoryActionRestart, MemoryActionReadiness:
+	default:
+		return errors.Errorf("invalid vpp memory action %q, expected %s, %s or %s",
+			config.MemoryAction, MemoryActionLog, MemoryActionRestart, MemoryActionReadiness)
+	}
+	if config.MemoryCheckInterval < 0 {
+		return errors.Errorf("invalid vpp memory check interval %s", config.MemoryCheckInterval)
+	}
+	return nil
+}
+
+// watchMemory - checks the memory (rss and hugepages) of the vpp process pid every config.MemoryCheckInterval until
+// ctx is done, taking config.MemoryAction whenever it exceeds config.MemoryLimit.  Either being 0 disables the checks.
+func watchMemory(ctx context.Context, config *Config, pid int) {
+	if config.MemoryLimit == 0 || config.MemoryCheckInterval <= 0 {
+		return
+	}
+	usage := metrics.Int("vpp_memory_bytes")
+	exceeded := metrics.Int("vpp_memory_limit_exceeded")
+	ticker := time.NewTicker(config.MemoryCheckInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			bytes, err := memoryUsage(pid)
+			if err != nil {
+				log.Entry(ctx).Warnf("failed to read memory usage of vpp: %+v", err)
+				continue
+			}
+			usage.Set(int64(bytes))
+			if bytes <= config.MemoryLimit {
+				if config.MemoryAction == MemoryActionReadiness {
+					readiness.Set(readinessComponent, nil)
+				}
+				continue
+			}
+			exceeded.Add(1)
+			err = errors.Errorf("vpp uses %d bytes of memory, exceeding the limit of %d bytes", bytes, config.MemoryLimit)
+			log.Entry(ctx).Warn(err)
+			events.Emit(events.Warning, "VPPMemoryLimitExceeded", err.Error())
+			switch config.MemoryAction {
+			case MemoryActionRestart:
+				log.Entry(ctx).Warnf("killing vpp (pid %d) to get it restarted", pid)
+				_ = syscall.Kill(pid, syscall.SIGKILL)
+				return
+			case MemoryActionReadiness:
+				readiness.Set(readinessComponent, err)
+			}
+		}
+	}()
+}
+
+// memoryUsage - returns the resident and hugepage memory in bytes of the process pid
+func memoryUsage(pid int) (uint64, error) {
+	f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer func() { _ = f.Close() }()
+	var total uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (fields[0] != "VmRSS:" && fields[0] != "HugetlbPages:") {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		total += kb * 1024
+	}
+	return total, errors.WithStack(scanner.Err())
+}
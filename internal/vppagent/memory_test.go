@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/readiness"
+)
+
+func TestValidateMemory(t *testing.T) {
+	for _, action := range []string{MemoryActionLog, MemoryActionRestart, MemoryActionReadiness} {
+		require.NoError(t, validateMemory(&Config{MemoryAction: action}), action)
+	}
+	require.Error(t, validateMemory(&Config{MemoryAction: "oom"}))
+	require.Error(t, validateMemory(&Config{MemoryAction: MemoryActionLog, MemoryCheckInterval: -time.Second}))
+}
+
+func TestMemoryUsage(t *testing.T) {
+	bytes, err := memoryUsage(os.Getpid())
+	require.NoError(t, err)
+	require.Greater(t, bytes, uint64(0))
+
+	_, err = memoryUsage(-1)
+	require.Error(t, err)
+}
+
+func TestWatchMemoryReadiness(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer readiness.Set(readinessComponent, nil)
+	config := &Config{MemoryLimit: 1, MemoryAction: MemoryActionReadiness, MemoryCheckInterval: 10 * time.Millisecond}
+
+	// The test process uses more than a byte, taking the forwarder out of readiness
+	watchMemory(ctx, config, os.Getpid())
+	require.Eventually(t, func() bool {
+		return readiness.Err() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// and back in once it is under the limit again
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	watchMemory(ctx, &Config{MemoryLimit: 1 << 50, MemoryAction: MemoryActionReadiness, MemoryCheckInterval: 10 * time.Millisecond}, os.Getpid())
+	require.Eventually(t, func() bool {
+		return readiness.Err() == nil
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !fakevpp,!windows
+
+package vppagent
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// fakeBuilt - whether a Fake config can be run, the fake vpp-agent being linked into this build
+const fakeBuilt = false
+
+func dialFake(context.Context, ...grpc.DialOption) (*grpc.ClientConn, <-chan error) {
+	rvErrCh := make(chan error, 1)
+	rvErrCh <- errors.New("a fake vpp-agent requires a forwarder built with the fakevpp tag")
+	close(rvErrCh)
+	return nil, rvErrCh
+}
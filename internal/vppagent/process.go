@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/events"
+)
+
+const (
+	// execEnv - environment variable carrying the process attributes to apply and the process to exec, to this
+	// executable started as the wrapper of vpp or vpp-agent
+	execEnv = "NSM_VPPAGENT_EXEC"
+	// execErrorFD - descriptor the wrapper reports failing to exec on
+	execErrorFD = 3
+)
+
+var processes sync.Map
+
+// Exec - if this process was started as the wrapper of vpp or vpp-agent, applies their process attributes and execs
+// them, never returning.  Does nothing otherwise, to be called first thing by main.
+func Exec() {
+	value, ok := os.LookupEnv(execEnv)
+	if !ok {
+		return
+	}
+	err := execWithAttributes(value)
+	_, _ = fmt.Fprintf(os.NewFile(execErrorFD, "exec error"), "%v", err)
+	os.Exit(127)
+}
+
+// Processes - returns the pids of the vpp and vpp-agent processes started by this forwarder process that are running
+func Processes() []int {
+	var pids []int
+	processes.Range(func(key, _ interface{}) bool {
+		pids = append(pids, key.(int))
+		return true
+	})
+	sort.Ints(pids)
+	return pids
+}
+
+// start - starts name with args, env in addition to the forwarder's environment and the process attributes from config.  Returns the pid of the process and a channel
+// receiving an error if the process exits before ctx is done, closed once it has exited.
+func start(ctx context.Context, config *Config, env []string, name string, args ...string) (int, <-chan error, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = config.WorkingDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := startProcess(cmd, config); err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to start %s", name)
+	}
+	log.Entry(ctx).Infof("started %s (pid %d)", name, cmd.Process.Pid)
+
+	processes.Store(cmd.Process.Pid, name)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		err := cmd.Wait()
+		processes.Delete(cmd.Process.Pid)
+		if ctx.Err() != nil {
+			return
+		}
+		events.Emitf(events.Warning, "ProcessExited", "%s exited unexpectedly: %v", name, err)
+		errCh <- errors.Errorf("%s exited unexpectedly: %v", name, err)
+	}()
+	return cmd.Process.Pid, errCh, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppagent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forkthread"
+)
+
+// attributes - process attributes the wrapper applies to the process it execs, and what it execs
+type attributes struct {
+	Path          string `json:"path"`
+	Parent        int    `json:"parent"`
+	UID           int    `json:"uid"`
+	GID           int    `json:"gid"`
+	NoNewPrivs    bool   `json:"noNewPrivs"`
+	OOMScoreAdj   int    `json:"oomScoreAdj"`
+	RlimitNofile  uint64 `json:"rlimitNofile"`
+	RlimitMemlock uint64 `json:"rlimitMemlock"`
+	Cgroup        string `json:"cgroup"`
+}
+
+// startProcess - starts cmd through this executable as a wrapper, which applies the process attributes from config and
+// then execs cmd, so that vpp and vpp-agent are constrained from their first instruction on and killed with the
+// forwarder.  Returns once cmd has been exec'd, or with the error it failed with.
+func startProcess(cmd *exec.Cmd, config *Config) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	value, err := json.Marshal(&attributes{
+		Path:          cmd.Path,
+		Parent:        os.Getpid(),
+		UID:           config.UID,
+		GID:           config.GID,
+		NoNewPrivs:    config.NoNewPrivs,
+		OOMScoreAdj:   config.OOMScoreAdj,
+		RlimitNofile:  config.RlimitNofile,
+		RlimitMemlock: config.RlimitMemlock,
+		Cgroup:        config.Cgroup,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = errR.Close() }()
+	cmd.Path = executable
+	cmd.Env = append(cmd.Env, execEnv+"="+string(value))
+	cmd.ExtraFiles = []*os.File{errW}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	forkthread.Run(func() { err = cmd.Start() })
+	_ = errW.Close()
+	if err != nil {
+		return err
+	}
+	// errW is closed on exec, the wrapper writes to it only before exiting on failure
+	message, _ := ioutil.ReadAll(errR)
+	if len(message) > 0 {
+		_ = cmd.Wait()
+		return errors.New(string(message))
+	}
+	return nil
+}
+
+// execWithAttributes - applies the process attributes in value and execs the process they name.  Returns only on
+// failure.
+func execWithAttributes(value string) error {
+	// Credentials, no_new_privs and the parent death signal are set for this thread, the one exec'ing
+	runtime.LockOSThread()
+	syscall.CloseOnExec(execErrorFD)
+	attrs := &attributes{}
+	if err := json.Unmarshal([]byte(value), attrs); err != nil {
+		return errors.Wrapf(err, "invalid %s", execEnv)
+	}
+	if attrs.OOMScoreAdj != 0 {
+		if err := ioutil.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(attrs.OOMScoreAdj)), 0); err != nil {
+			return errors.Wrap(err, "failed to set oom_score_adj")
+		}
+	}
+	for resource, limit := range map[int]uint64{
+		unix.RLIMIT_NOFILE:  attrs.RlimitNofile,
+		unix.RLIMIT_MEMLOCK: attrs.RlimitMemlock,
+	} {
+		if limit == 0 {
+			continue
+		}
+		if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+			return errors.Wrapf(err, "failed to set rlimit %d", resource)
+		}
+	}
+	if attrs.Cgroup != "" {
+		filename := filepath.Join(attrs.Cgroup, "cgroup.procs")
+		if err := ioutil.WriteFile(filename, []byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+			return errors.Wrapf(err, "failed to move to cgroup %s", attrs.Cgroup)
+		}
+	}
+	if attrs.UID >= 0 || attrs.GID >= 0 {
+		if err := setCredentials(attrs.UID, attrs.GID); err != nil {
+			return err
+		}
+	}
+	if attrs.NoNewPrivs {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return errors.Wrap(err, "failed to set no_new_privs")
+		}
+	}
+	// Changing credentials clears the parent death signal, and the forwarder may have exited before it was set
+	if err := unix.Prctl(unix.PR_SET_PDEATHSIG, uintptr(syscall.SIGKILL), 0, 0, 0); err != nil {
+		return errors.Wrap(err, "failed to set the parent death signal")
+	}
+	if os.Getppid() != attrs.Parent {
+		return errors.New("the forwarder exited")
+	}
+	var env []string
+	for _, entry := range os.Environ() {
+		if !strings.HasPrefix(entry, execEnv+"=") {
+			env = append(env, entry)
+		}
+	}
+	return errors.Wrapf(syscall.Exec(attrs.Path, os.Args, env), "failed to exec %s", attrs.Path) // #nosec
+}
+
+// setCredentials - sets the uid and gid of this thread to uid and gid, those of the process if negative, and drops
+// the supplementary groups, as syscall.Credential would have done on fork
+func setCredentials(uid, gid int) error {
+	if uid < 0 {
+		uid = os.Getuid()
+	}
+	if gid < 0 {
+		gid = os.Getgid()
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETGROUPS, 0, 0, 0); errno != 0 {
+		return errors.Wrap(errno, "failed to drop the supplementary groups")
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESGID, uintptr(gid), uintptr(gid), uintptr(gid)); errno != 0 {
+		return errors.Wrapf(errno, "failed to set gid %d", gid)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESUID, uintptr(uid), uintptr(uid), uintptr(uid)); errno != 0 {
+		return errors.Wrapf(errno, "failed to set uid %d", uid)
+	}
+	return nil
+}
+
+// cfsPeriod - cfs period in microseconds the cpu quota is given for
+const cfsPeriod = 100000
+
+// applyCPULimit - sets the cpu quota of config.Cgroup to config.CPULimit, through cpu.max on cgroup v2 and
+// cpu.cfs_quota_us on v1
+func applyCPULimit(config *Config) error {
+	cores, err := config.cpuLimit()
+	if err != nil || cores == 0 {
+		return err
+	}
+	quota := int64(cores * cfsPeriod)
+	if _, err = os.Stat(filepath.Join(config.Cgroup, "cpu.max")); err == nil {
+		err = ioutil.WriteFile(filepath.Join(config.Cgroup, "cpu.max"), []byte(fmt.Sprintf("%d %d", quota, cfsPeriod)), 0)
+		return errors.Wrapf(err, "failed to limit the cpu of cgroup %s", config.Cgroup)
+	}
+	if err = ioutil.WriteFile(filepath.Join(config.Cgroup, "cpu.cfs_period_us"), []byte(strconv.Itoa(cfsPeriod)), 0); err != nil {
+		return errors.Wrapf(err, "failed to limit the cpu of cgroup %s", config.Cgroup)
+	}
+	err = ioutil.WriteFile(filepath.Join(config.Cgroup, "cpu.cfs_quota_us"), []byte(strconv.FormatInt(quota, 10)), 0)
+	return errors.Wrapf(err, "failed to limit the cpu of cgroup %s", config.Cgroup)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppagent
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain - runs the test binary as the wrapper applying process attributes when it is started by startProcess
+func TestMain(m *testing.M) {
+	Exec()
+	os.Exit(m.Run())
+}
+
+func TestStartProcessAppliesAttributes(t *testing.T) {
+	// The attributes are those of the shell from its first instruction on, then inherited by cat
+	cmd := exec.Command("sh", "-c", "ulimit -n; cat /proc/self/oom_score_adj; echo exec=$"+execEnv)
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	config := &Config{UID: -1, GID: -1, NoNewPrivs: true, OOMScoreAdj: 500, RlimitNofile: 256}
+	require.NoError(t, startProcess(cmd, config))
+	require.NoError(t, cmd.Wait())
+	require.Equal(t, []string{"256", "500", "exec="}, strings.Split(strings.TrimSpace(output.String()), "\n"))
+}
+
+func TestStartProcessReportsFailure(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "true")
+	config := &Config{UID: -1, GID: -1, Cgroup: "/nonexistent"}
+	err := startProcess(cmd, config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cgroup /nonexistent")
+}
+
+func TestStartProcessNotFound(t *testing.T) {
+	cmd := exec.Command("/nonexistent/vpp")
+	require.Error(t, startProcess(cmd, &Config{UID: -1, GID: -1}))
+}
+
+func TestApplyCPULimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	read := func(name string) string {
+		data, readErr := ioutil.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, readErr)
+		return string(data)
+	}
+
+	// cgroup v1
+	for _, name := range []string{"cpu.cfs_period_us", "cpu.cfs_quota_us"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("-1"), 0600))
+	}
+	require.NoError(t, applyCPULimit(&Config{CPULimit: "1500m", Cgroup: dir}))
+	require.Equal(t, "100000", read("cpu.cfs_period_us"))
+	require.Equal(t, "150000", read("cpu.cfs_quota_us"))
+
+	// cgroup v2
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cpu.max"), []byte("max 100000"), 0600))
+	require.NoError(t, applyCPULimit(&Config{CPULimit: "0.5", Cgroup: dir}))
+	require.Equal(t, "50000 100000", read("cpu.max"))
+
+	require.NoError(t, applyCPULimit(&Config{Cgroup: dir}), "no limit, nothing to apply")
+	require.Error(t, applyCPULimit(&Config{CPULimit: "1", Cgroup: filepath.Join(dir, "nonexistent")}))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux,!windows
+
+package vppagent
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+func startProcess(cmd *exec.Cmd, config *Config) error {
+	if config.UID >= 0 || config.GID >= 0 {
+		credential := &syscall.Credential{Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+		if config.UID >= 0 {
+			credential.Uid = uint32(config.UID)
+		}
+		if config.GID >= 0 {
+			credential.Gid = uint32(config.GID)
+		}
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	}
+	return cmd.Start()
+}
+
+func execWithAttributes(_ string) error {
+	return errors.New("process attributes are supported on linux only")
+}
+
+func applyCPULimit(config *Config) error {
+	if config.CPULimit != "" {
+		return errors.New("cpu limits are supported on linux only")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppagent")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	out := filepath.Join(dir, "env")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errCh, err := start(ctx, &Config{UID: -1, GID: -1}, []string{"MICROSERVICE_LABEL=forwarder-1"}, "sh", "-c", "echo $MICROSERVICE_LABEL > "+out)
+	require.NoError(t, err)
+	require.Error(t, <-errCh, "exiting before ctx is done is unexpected")
+	data, err := ioutil.ReadFile(filepath.Clean(out))
+	require.NoError(t, err)
+	require.Equal(t, "forwarder-1\n", string(data))
+}
+
+func TestStartWorkingDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppagent")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	_, errCh, err := start(context.Background(), &Config{UID: -1, GID: -1, WorkingDir: dir}, nil, "sh", "-c", "pwd > pwd")
+	require.NoError(t, err)
+	<-errCh
+	data, err := ioutil.ReadFile(filepath.Join(dir, "pwd"))
+	require.NoError(t, err)
+	wd, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	require.Equal(t, wd+"\n", string(data))
+}
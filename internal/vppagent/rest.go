@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// ServeReadOnlyREST - serves, on ln until ctx is done, the config of vpp-agent at /configurator/config and the state
+// of vpp at /configurator/dump as json, fetched through vppagentCC.  vpp-agent's own REST api, which also accepts
+// changes, is disabled.
+func ServeReadOnlyREST(ctx context.Context, ln net.Listener, vppagentCC grpc.ClientConnInterface) {
+	client := configurator.NewConfiguratorServiceClient(vppagentCC)
+	mux := http.NewServeMux()
+	mux.Handle("/configurator/config", readOnly(func(r *http.Request) (proto.Message, error) {
+		rv, getErr := client.Get(r.Context(), &configurator.GetRequest{})
+		return rv.GetConfig(), getErr
+	}))
+	mux.Handle("/configurator/dump", readOnly(func(r *http.Request) (proto.Message, error) {
+		rv, dumpErr := client.Dump(r.Context(), &configurator.DumpRequest{})
+		return rv.GetDump(), dumpErr
+	}))
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if serveErr := server.Serve(ln); serveErr != nil && serveErr != http.ErrServerClosed {
+			log.Entry(ctx).Errorf("vpp-agent REST api failed: %+v", serveErr)
+		}
+	}()
+	log.Entry(ctx).Infof("serving read-only vpp-agent REST api on %s", ln.Addr())
+}
+
+// readOnly - returns a handler answering GET and HEAD requests with the message get returns as json
+func readOnly(get func(r *http.Request) (proto.Message, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "the vpp-agent REST api is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		msg, err := get(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err = (&jsonpb.Marshaler{Indent: "  "}).Marshal(w, msg); err != nil {
+			log.Entry(r.Context()).Warnf("failed to write the vpp-agent REST response: %+v", err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// configuratorCC - answers Gets with the config of an interface and fails Dumps
+type configuratorCC struct{}
+
+func (c *configuratorCC) Invoke(_ context.Context, _ string, args, reply interface{}, _ ...grpc.CallOption) error {
+	switch args.(type) {
+	case *configurator.GetRequest:
+		proto.Merge(reply.(proto.Message), &configurator.GetResponse{
+			Config: &configurator.Config{VppConfig: &vpp.ConfigData{
+				Interfaces: []*vpp_interfaces.Interface{{Name: "memif-1", Type: vpp_interfaces.Interface_MEMIF}},
+			}},
+		})
+		return nil
+	case *configurator.DumpRequest:
+		return errors.New("vpp is gone")
+	}
+	return errors.Errorf("unexpected %T", args)
+}
+
+func (c *configuratorCC) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func TestReadOnlyREST(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vppagent.ServeReadOnlyREST(ctx, ln, &configuratorCC{})
+	base := "http://" + ln.Addr().String()
+
+	resp, err := http.Get(base + "/configurator/config")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Contains(t, string(body), `"name": "memif-1"`)
+
+	resp, err = http.Post(base+"/configurator/config", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode, "changes are refused")
+
+	resp, err = http.Get(base + "/configurator/dump")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+)
+
+var rxModes = map[string]vpp_interfaces.Interface_RxMode_Type{
+	"polling":   vpp_interfaces.Interface_RxMode_POLLING,
+	"interrupt": vpp_interfaces.Interface_RxMode_INTERRUPT,
+	"adaptive":  vpp_interfaces.Interface_RxMode_ADAPTIVE,
+}
+
+var rxModeInterfaceTypes = map[string]vpp_interfaces.Interface_Type{
+	"memif":    vpp_interfaces.Interface_MEMIF,
+	"tap":      vpp_interfaces.Interface_TAP,
+	"afpacket": vpp_interfaces.Interface_AF_PACKET,
+	"dpdk":     vpp_interfaces.Interface_DPDK,
+}
+
+// RxModeInterceptor - returns an interceptor setting the rx mode of the interfaces of the vpp-agent Updates passing
+// through that have none, per RxModes and RxMode.  It sends on a copy of the Update, leaving the caller's as it was.
+func (c *Config) RxModeInterceptor() grpc.UnaryClientInterceptor {
+	modes, _ := c.rxModes()
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if update, ok := req.(*configurator.UpdateRequest); ok && len(modes) > 0 {
+			update = proto.Clone(update).(*configurator.UpdateRequest)
+			req = update
+			for _, iface := range update.GetUpdate().GetVppConfig().GetInterfaces() {
+				if mode, ok := modes[iface.GetType()]; ok && len(iface.GetRxModes()) == 0 {
+					iface.RxModes = []*vpp_interfaces.Interface_RxMode{{DefaultMode: true, Mode: mode}}
+				}
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// rxModes - returns the rx mode of each interface type, RxModes overriding RxMode
+func (c *Config) rxModes() (map[vpp_interfaces.Interface_Type]vpp_interfaces.Interface_RxMode_Type, error) {
+	modes := make(map[vpp_interfaces.Interface_Type]vpp_interfaces.Interface_RxMode_Type)
+	rxMode := c.RxMode
+	if rxMode == "" && c.CPULimit != "" {
+		// Leave polling when idle to stay within the cpu limit
+		rxMode = "adaptive"
+	}
+	if rxMode != "" {
+		mode, ok := rxModes[rxMode]
+		if !ok {
+			return nil, errors.Errorf("unknown rx mode %q, expected polling, interrupt or adaptive", rxMode)
+		}
+		for _, ifType := range rxModeInterfaceTypes {
+			modes[ifType] = mode
+		}
+	}
+	for _, typeMode := range c.RxModes {
+		kv := strings.SplitN(typeMode, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid rx mode %q, expected type=mode", typeMode)
+		}
+		ifType, ok := rxModeInterfaceTypes[kv[0]]
+		if !ok {
+			return nil, errors.Errorf("unknown interface type %q of rx mode %q, expected memif, tap, afpacket or dpdk", kv[0], typeMode)
+		}
+		mode, ok := rxModes[kv[1]]
+		if !ok {
+			return nil, errors.Errorf("unknown rx mode %q, expected polling, interrupt or adaptive", kv[1])
+		}
+		modes[ifType] = mode
+	}
+	return modes, nil
+}
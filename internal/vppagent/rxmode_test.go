@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	"google.golang.org/grpc"
+)
+
+func TestRxModes(t *testing.T) {
+	modes, err := (&Config{RxMode: "interrupt", RxModes: []string{"memif=polling"}}).rxModes()
+	require.NoError(t, err)
+	require.Equal(t, vpp_interfaces.Interface_RxMode_POLLING, modes[vpp_interfaces.Interface_MEMIF])
+	require.Equal(t, vpp_interfaces.Interface_RxMode_INTERRUPT, modes[vpp_interfaces.Interface_TAP])
+	require.Equal(t, vpp_interfaces.Interface_RxMode_INTERRUPT, modes[vpp_interfaces.Interface_DPDK])
+
+	modes, err = (&Config{CPULimit: "2"}).rxModes()
+	require.NoError(t, err)
+	require.Equal(t, vpp_interfaces.Interface_RxMode_ADAPTIVE, modes[vpp_interfaces.Interface_MEMIF], "a cpu limit leaves polling when idle")
+
+	modes, err = (&Config{}).rxModes()
+	require.NoError(t, err)
+	require.Empty(t, modes)
+
+	for _, config := range []*Config{
+		{RxMode: "busy"},
+		{RxModes: []string{"memif"}},
+		{RxModes: []string{"vxlan=polling"}},
+		{RxModes: []string{"memif=busy"}},
+	} {
+		_, err = config.rxModes()
+		require.Error(t, err, config)
+	}
+}
+
+func TestRxModeInterceptor(t *testing.T) {
+	update := &configurator.UpdateRequest{Update: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{
+			{Name: "memif-1", Type: vpp_interfaces.Interface_MEMIF},
+			{Name: "tap-1", Type: vpp_interfaces.Interface_TAP, RxModes: []*vpp_interfaces.Interface_RxMode{
+				{DefaultMode: true, Mode: vpp_interfaces.Interface_RxMode_POLLING},
+			}},
+			{Name: "vxlan-1", Type: vpp_interfaces.Interface_VXLAN_TUNNEL},
+		},
+	}}}
+	original := proto.Clone(update)
+	var sent *configurator.UpdateRequest
+	invoker := func(_ context.Context, _ string, req, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		sent = req.(*configurator.UpdateRequest)
+		return nil
+	}
+	interceptor := (&Config{RxMode: "interrupt"}).RxModeInterceptor()
+	require.NoError(t, interceptor(context.Background(), "/ligato.configurator.ConfiguratorService/Update", update, nil, nil, invoker))
+
+	ifaces := sent.GetUpdate().GetVppConfig().GetInterfaces()
+	require.Equal(t, vpp_interfaces.Interface_RxMode_INTERRUPT, ifaces[0].GetRxModes()[0].GetMode())
+	require.Equal(t, vpp_interfaces.Interface_RxMode_POLLING, ifaces[1].GetRxModes()[0].GetMode(), "rx modes set are kept")
+	require.Empty(t, ifaces[2].GetRxModes(), "interfaces without rx queues are left alone")
+	require.True(t, proto.Equal(original, update), "the caller's Update is left as it was")
+}
@@ -0,0 +1,352 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+// Package vppagent - runs vpp and vpp-agent as child processes of the forwarder and dials vpp-agent's grpc server
+package vppagent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Config - configuration of the vpp and vpp-agent processes, intended to be embedded in the forwarder's Config
+type Config struct {
+	RootDir string `desc:"directory vpp and vpp-agent configs, sockets and logs are rooted at" split_words:"true"`
+
+	// Binaries
+	Path           string   `default:"vpp" desc:"path of the vpp binary" split_words:"true"`
+	ExtraArgs      []string `desc:"additional arguments for vpp" split_words:"true"`
+	AgentPath      string   `default:"vpp-agent" desc:"path of the vpp-agent binary" split_words:"true"`
+	AgentExtraArgs []string `desc:"additional arguments for vpp-agent" split_words:"true"`
+	WorkingDir     string   `desc:"working directory of vpp and vpp-agent, the forwarder's if empty" split_words:"true"`
+	CtlPath        string   `default:"vppctl" desc:"path of the vppctl binary, used to run vpp cli commands" split_words:"true"`
+
+	// Attributes of the spawned processes
+	UID           int    `default:"-1" desc:"uid to run vpp and vpp-agent as, -1 to inherit" split_words:"true"`
+	GID           int    `default:"-1" desc:"gid to run vpp and vpp-agent as, -1 to inherit" split_words:"true"`
+	NoNewPrivs    bool   `default:"true" desc:"prevent vpp and vpp-agent from gaining privileges on exec" split_words:"true"`
+	OOMScoreAdj   int    `default:"0" desc:"oom_score_adj of vpp and vpp-agent, 0 to inherit" split_words:"true"`
+	RlimitNofile  uint64 `default:"0" desc:"open files limit of vpp and vpp-agent, 0 to inherit" split_words:"true"`
+	RlimitMemlock uint64 `default:"0" desc:"locked memory limit in bytes of vpp and vpp-agent, 0 to inherit" split_words:"true"`
+	Cgroup        string `desc:"cgroup directory vpp and vpp-agent are moved to, inherited if empty" split_words:"true"`
+	CPULimit      string `desc:"cpu quota of Cgroup in cores, e.g. 0.5 or 500m, also making vpp sleep and interfaces leave polling when idle, unlimited if empty" split_words:"true"`
+
+	// Shared memory segments of vpp, left at the vpp defaults if empty
+	StatsegSize          string `desc:"size of the vpp stats segment, e.g. 128M" split_words:"true"`
+	APISegmentGlobalSize string `desc:"global size of the vpp api segment, e.g. 64M" split_words:"true"`
+	APISegmentAPISize    string `desc:"api size of the vpp api segment, e.g. 16M" split_words:"true"`
+
+	// Plugins of vpp, given as file names (dpdk_plugin.so) or short names (dpdk)
+	PluginsEnable  []string `desc:"vpp plugins to enable" split_words:"true"`
+	PluginsDisable []string `default:"dpdk_plugin.so" desc:"vpp plugins to disable" split_words:"true"`
+
+	// Tuning of vpp for the architecture it runs on
+	ArchProfile    string `default:"auto" desc:"vpp tuning profile: auto (by the forwarder's architecture), amd64, arm64 or none" split_words:"true"`
+	BuffersPerNuma int    `default:"0" desc:"buffers vpp allocates per numa node, by ArchProfile (arm64 8192) or the vpp default if 0" split_words:"true"`
+
+	// Worker threads of vpp, each memif connection getting a queue per worker so its flows spread over all of them
+	Workers             int           `default:"0" desc:"number of vpp worker threads, packets are processed on the main thread if 0" split_words:"true"`
+	WorkerStatsInterval time.Duration `default:"10s" desc:"interval of the per vpp thread utilization metrics, 0 disables" split_words:"true"`
+
+	// Crypto used by encrypted tunnels (ipsec, wireguard)
+	CryptoEngine       string `desc:"crypto engine handling all algorithms: native, ipsecmb or openssl, vpp default if empty" split_words:"true"`
+	CryptoAsync        bool   `default:"false" desc:"offload crypto to the async software scheduler" split_words:"true"`
+	CryptoAsyncWorkers []int  `desc:"vpp workers running async crypto, all workers if empty" split_words:"true"`
+
+	// Key value store of vpp-agent
+	AgentEtcdEndpoints     []string `desc:"etcd endpoints vpp-agent stores and watches its configuration in, disabled if empty" split_words:"true"`
+	AgentMicroserviceLabel string   `default:"forwarder" desc:"microservice label vpp-agent keys its configuration by in etcd" split_words:"true"`
+
+	// Read-only inspection of vpp-agent
+	AgentRESTPort int `default:"0" desc:"localhost port serving the vpp-agent config and the vpp state read-only as json, at /configurator/config and /configurator/dump, disabled if 0" split_words:"true"`
+
+	// Rx mode of the interfaces configured through vpp-agent, polling on busy nodes, interrupt or adaptive to save cpu
+	RxMode  string   `desc:"rx mode of memif, tap, afpacket and dpdk interfaces: polling, interrupt or adaptive, vpp default if empty" split_words:"true"`
+	RxModes []string `desc:"rx mode per interface type as type=mode, e.g. memif=interrupt, overriding RxMode" split_words:"true"`
+
+	// Startup
+	StartupTimeout time.Duration `default:"2m" desc:"time vpp and vpp-agent may take to become ready, 0 waits forever" split_words:"true"`
+
+	// Memory watchdog of vpp
+	MemoryLimit         uint64        `default:"0" desc:"memory (rss and hugepages) in bytes vpp may use before MemoryAction is taken, 0 disables" split_words:"true"`
+	MemoryAction        string        `default:"log" desc:"action when vpp exceeds MemoryLimit: log, restart or readiness" split_words:"true"`
+	MemoryCheckInterval time.Duration `default:"10s" desc:"interval of vpp memory checks, 0 disables" split_words:"true"`
+
+	// Partitioning of the node between several forwarders
+	PinCPUs  bool `default:"false" desc:"pin the vpp main thread and workers to cpus of their own, picked by Instance" split_words:"true"`
+	Instance int  `ignored:"true"`
+
+	// ForwarderID - persistent id of the forwarder, vpp's local0 interface is tagged with
+	ForwarderID string `ignored:"true"`
+
+	// Testing without vpp
+	Fake bool `default:"false" desc:"record vpp-agent txns in process instead of running vpp and vpp-agent, for tests, in forwarders built with the fakevpp tag" split_words:"true"`
+}
+
+const (
+	vppagentPort     = 9111
+	socketPollPeriod = 100 * time.Millisecond
+	readyPollPeriod  = 500 * time.Millisecond
+)
+
+// Hints logged when a startup stage does not complete within the startup timeout
+const (
+	apiSocketHint = "vpp did not create its api socket: check that hugepages are available " +
+		"(grep Huge /proc/meminfo) and read the vpp log"
+	dialHint  = "vpp-agent did not open its grpc server: check the vpp-agent output above"
+	readyHint = "vpp-agent did not connect to vpp's binary api: check that vpp is running " +
+		"and that govpp.conf points at its api socket"
+)
+
+// StartAndDialContext - writes the configs for and starts vpp and vpp-agent, then dials vpp-agent with opts in
+// addition to its own.  The returned channel receives any error of either process and is closed once both have
+// exited.  If either fails to start or vpp-agent cannot be dialed, both are killed: the channel is closed once they
+// are gone.
+func StartAndDialContext(ctx context.Context, config *Config, opts ...grpc.DialOption) (vppagentCC *grpc.ClientConn, errCh <-chan error) {
+	if config.Fake {
+		return dialFake(ctx, opts...)
+	}
+	rvErrCh := make(chan error, 4)
+	var wg sync.WaitGroup
+	processCtx, cancelProcesses := context.WithCancel(ctx)
+	defer func() {
+		if vppagentCC == nil {
+			cancelProcesses()
+		}
+		go func() {
+			wg.Wait()
+			cancelProcesses()
+			close(rvErrCh)
+		}()
+	}()
+	if err := writeConfigs(config); err != nil {
+		rvErrCh <- err
+		return nil, rvErrCh
+	}
+	if err := applyCPULimit(config); err != nil {
+		rvErrCh <- err
+		return nil, rvErrCh
+	}
+	startupCtx, cancelStartup := context.WithCancel(ctx)
+	if config.StartupTimeout > 0 {
+		startupCtx, cancelStartup = context.WithTimeout(ctx, config.StartupTimeout)
+	}
+	defer cancelStartup()
+
+	vppArgs := append([]string{"-c", config.path(vppConfFile)}, config.ExtraArgs...)
+	vppPid, vppErrCh, err := start(processCtx, config, nil, config.Path, vppArgs...)
+	if err != nil {
+		rvErrCh <- err
+		return nil, rvErrCh
+	}
+	forward(&wg, vppErrCh, rvErrCh)
+	watchMemory(processCtx, config, vppPid)
+	if err = waitForFile(startupCtx, config.path(apiSocket)); err != nil {
+		rvErrCh <- errors.Wrap(err, apiSocketHint)
+		return nil, rvErrCh
+	}
+	log.Entry(ctx).Infof("vpp api socket %s created", config.path(apiSocket))
+
+	agentEnv := []string{"MICROSERVICE_LABEL=" + config.AgentMicroserviceLabel}
+	agentArgs := append([]string{"-config-dir", config.path(agentConfDir)}, config.AgentExtraArgs...)
+	_, agentErrCh, err := start(processCtx, config, agentEnv, config.AgentPath, agentArgs...)
+	if err != nil {
+		rvErrCh <- err
+		return nil, rvErrCh
+	}
+	forward(&wg, agentErrCh, rvErrCh)
+
+	vppagentCC, err = dial(ctx, startupCtx, config, opts...)
+	if err != nil {
+		rvErrCh <- err
+		return nil, rvErrCh
+	}
+	watchWorkers(ctx, config)
+	return vppagentCC, rvErrCh
+}
+
+// DialContext - dials an already running vpp-agent, such as one left running by a previous forwarder process.  The
+// returned channel receives any error dialing and is closed once ctx is done.
+func DialContext(ctx context.Context, config *Config, opts ...grpc.DialOption) (vppagentCC *grpc.ClientConn, errCh <-chan error) {
+	if config.Fake {
+		return dialFake(ctx, opts...)
+	}
+	rvErrCh := make(chan error, 1)
+	vppagentCC, err := Dial(ctx, config, opts...)
+	if err != nil {
+		rvErrCh <- err
+		close(rvErrCh)
+		return nil, rvErrCh
+	}
+	watchWorkers(ctx, config)
+	go func() {
+		<-ctx.Done()
+		close(rvErrCh)
+	}()
+	return vppagentCC, rvErrCh
+}
+
+// Dial - dials an already running vpp-agent and waits for it to be ready, within config.StartupTimeout.  Unlike
+// DialContext it starts nothing watching vpp, for one-off uses of vpp-agent such as applying a configuration.
+func Dial(ctx context.Context, config *Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	startupCtx, cancelStartup := context.WithCancel(ctx)
+	if config.StartupTimeout > 0 {
+		startupCtx, cancelStartup = context.WithTimeout(ctx, config.StartupTimeout)
+	}
+	defer cancelStartup()
+	return dial(ctx, startupCtx, config, opts...)
+}
+
+// dial - dials vpp-agent within startupCtx and waits for it to be ready
+func dial(ctx, startupCtx context.Context, config *Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{grpc.WithInsecure(), grpc.WithBlock()}, opts...)
+	vppagentCC, err := grpc.DialContext(startupCtx, config.agentEndpoint(), opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial vpp-agent at %s: %s", config.agentEndpoint(), dialHint)
+	}
+	log.Entry(ctx).Infof("connected to vpp-agent at %s", config.agentEndpoint())
+	if err = waitForAgent(startupCtx, vppagentCC); err != nil {
+		_ = vppagentCC.Close()
+		return nil, errors.Wrap(err, readyHint)
+	}
+	log.Entry(ctx).Infof("vpp-agent is connected to vpp and resynced")
+	return vppagentCC, nil
+}
+
+// waitForAgent - waits until vpp-agent can dump the state of vpp, meaning it is connected to vpp's binary api and
+// done with its initial resync
+func waitForAgent(ctx context.Context, cc grpc.ClientConnInterface) error {
+	client := configurator.NewConfiguratorServiceClient(cc)
+	ticker := time.NewTicker(readyPollPeriod)
+	defer ticker.Stop()
+	for {
+		_, err := client.Dump(ctx, &configurator.DumpRequest{})
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "waiting for vpp-agent")
+		case <-ticker.C:
+		}
+	}
+}
+
+func forward(wg *sync.WaitGroup, from <-chan error, to chan<- error) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for err := range from {
+			to <- err
+		}
+	}()
+}
+
+// Validate - checks config for consistency and the existence of the vpp and vpp-agent binaries, which a Fake config
+// does not need
+func (c *Config) Validate() error {
+	binaries := []string{c.Path, c.AgentPath}
+	if c.Fake {
+		if !fakeBuilt {
+			return errors.New("a fake vpp-agent requires a forwarder built with the fakevpp tag")
+		}
+		binaries = nil
+	}
+	for _, binary := range binaries {
+		if _, err := exec.LookPath(binary); err != nil {
+			return errors.Wrapf(err, "binary %s not found", binary)
+		}
+	}
+	if c.WorkingDir != "" {
+		if info, err := os.Stat(c.WorkingDir); err != nil || !info.IsDir() {
+			return errors.Errorf("working directory %s does not exist", c.WorkingDir)
+		}
+	}
+	if err := validateCrypto(c); err != nil {
+		return err
+	}
+	if err := validateArch(c); err != nil {
+		return err
+	}
+	if _, err := c.rxModes(); err != nil {
+		return err
+	}
+	if _, err := c.cpuLimit(); err != nil {
+		return err
+	}
+	if err := validateMemory(c); err != nil {
+		return err
+	}
+	if c.Workers < 0 {
+		return errors.Errorf("invalid number of vpp workers %d", c.Workers)
+	}
+	if c.Instance < 0 {
+		return errors.Errorf("invalid instance %d", c.Instance)
+	}
+	if last := (c.Instance + 1) * (c.Workers + 1); c.PinCPUs && last >= runtime.NumCPU() {
+		return errors.Errorf("cannot pin the vpp threads of instance %d to cpus up to %d, the node has %d", c.Instance, last, runtime.NumCPU())
+	}
+	if c.Workers > 0 && c.WorkerStatsInterval > 0 {
+		if _, err := exec.LookPath(c.CtlPath); err != nil {
+			return errors.Wrapf(err, "binary %s not found, needed for the vpp worker metrics", c.CtlPath)
+		}
+	}
+	return validatePlugins(c)
+}
+
+// path - returns relative rooted at RootDir, in a directory of its own for instances other than 0
+func (c *Config) path(relative string) string {
+	if c.Instance > 0 {
+		return filepath.Join("/", c.RootDir, fmt.Sprintf("instance-%d", c.Instance), relative)
+	}
+	return filepath.Join("/", c.RootDir, relative)
+}
+
+// agentEndpoint - returns the endpoint of vpp-agent's grpc server, offset by Instance
+func (c *Config) agentEndpoint() string {
+	return net.JoinHostPort("localhost", strconv.Itoa(vppagentPort+c.Instance))
+}
+
+func waitForFile(ctx context.Context, filename string) error {
+	ticker := time.NewTicker(socketPollPeriod)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(filename); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "waiting for %s", filename)
+		case <-ticker.C:
+		}
+	}
+}
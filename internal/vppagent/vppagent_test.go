@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
+)
+
+// validConfig - returns a config passing Validate, with sh standing in for the binaries
+func validConfig() *vppagent.Config {
+	return &vppagent.Config{
+		Path:         "sh",
+		AgentPath:    "sh",
+		ArchProfile:  "none",
+		MemoryAction: vppagent.MemoryActionLog,
+	}
+}
+
+func TestValidateBinaries(t *testing.T) {
+	require.NoError(t, validConfig().Validate())
+
+	config := validConfig()
+	config.AgentPath = "/nonexistent/vpp-agent"
+	require.Error(t, config.Validate())
+}
+
+func TestValidateWorkingDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppagent")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	config := validConfig()
+	config.WorkingDir = dir
+	require.NoError(t, config.Validate())
+
+	config.WorkingDir = dir + "/nonexistent"
+	require.Error(t, config.Validate())
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// resyncingAgent - fails the Dumps until it has resynced, after failures of them
+type resyncingAgent struct {
+	failures int32
+}
+
+func (a *resyncingAgent) Invoke(context.Context, string, interface{}, interface{}, ...grpc.CallOption) error {
+	if atomic.AddInt32(&a.failures, -1) >= 0 {
+		return errors.New("not connected to vpp")
+	}
+	return nil
+}
+
+func (a *resyncingAgent) NewStream(context.Context, *grpc.StreamDesc, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+func TestWaitForAgent(t *testing.T) {
+	require.NoError(t, waitForAgent(context.Background(), &resyncingAgent{failures: 2}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := waitForAgent(ctx, &resyncingAgent{failures: 100})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not connected to vpp", "the last failure tells why vpp-agent is not ready")
+}
+
+func TestWaitForFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppagent")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	filename := filepath.Join(dir, "api.sock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Error(t, waitForFile(ctx, filename))
+
+	go func() {
+		time.Sleep(2 * socketPollPeriod)
+		_ = ioutil.WriteFile(filename, nil, 0600)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, waitForFile(ctx, filename))
+}
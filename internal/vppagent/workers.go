@@ -0,0 +1,147 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/metrics"
+)
+
+// MemifQueuesInterceptor - returns an interceptor giving the memif interfaces of the vpp-agent Updates passing
+// through that have no queue counts one rx and tx queue per vpp worker, so that vpp spreads the queues of a single
+// connection over its workers.  It sends on a copy of the Update, leaving the caller's as it was.
+func (c *Config) MemifQueuesInterceptor() grpc.UnaryClientInterceptor {
+	queues := uint32(c.Workers)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if update, ok := req.(*configurator.UpdateRequest); ok && queues > 1 {
+			update = proto.Clone(update).(*configurator.UpdateRequest)
+			req = update
+			for _, iface := range update.GetUpdate().GetVppConfig().GetInterfaces() {
+				memif := iface.GetMemif()
+				if memif == nil {
+					continue
+				}
+				if memif.GetRxQueues() == 0 {
+					memif.RxQueues = queues
+				}
+				if memif.GetTxQueues() == 0 {
+					memif.TxQueues = queues
+				}
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+var (
+	threadRegexp = regexp.MustCompile(`^Thread \d+ (\S+)`)
+	loopsRegexp  = regexp.MustCompile(`vector rate ([0-9.eE+-]+) loops/sec ([0-9.eE+-]+)`)
+	ratesRegexp  = regexp.MustCompile(`vector rates in ([0-9.eE+-]+),`)
+)
+
+// threadRuntime - utilization of a vpp thread as reported by 'show runtime'
+type threadRuntime struct {
+	Name        string
+	VectorRate  float64
+	LoopsPerSec float64
+	InputRate   float64
+}
+
+// watchWorkers - publishes the utilization of each vpp thread every config.WorkerStatsInterval until ctx is done
+func watchWorkers(ctx context.Context, config *Config) {
+	if config.Workers == 0 || config.WorkerStatsInterval == 0 {
+		return
+	}
+	ticker := time.NewTicker(config.WorkerStatsInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			output, err := config.CLI(ctx, "show", "runtime")
+			if err != nil {
+				log.Entry(ctx).Warnf("failed to read the runtime of the vpp threads: %+v", err)
+				continue
+			}
+			threads, err := parseRuntime(strings.NewReader(output))
+			if err != nil {
+				log.Entry(ctx).Warnf("failed to parse the runtime of the vpp threads: %+v", err)
+				continue
+			}
+			for _, thread := range threads {
+				metrics.Float("vpp_thread_vector_rate." + thread.Name).Set(thread.VectorRate)
+				metrics.Float("vpp_thread_loops_per_sec." + thread.Name).Set(thread.LoopsPerSec)
+				metrics.Float("vpp_thread_input_rate." + thread.Name).Set(thread.InputRate)
+			}
+		}
+	}()
+}
+
+// parseRuntime - parses the per thread summaries of the output of 'show runtime', which names the single thread
+// vpp_main when vpp runs without workers
+func parseRuntime(r io.Reader) ([]*threadRuntime, error) {
+	var threads []*threadRuntime
+	current := &threadRuntime{Name: "vpp_main"}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := threadRegexp.FindStringSubmatch(line); match != nil {
+			current = &threadRuntime{Name: match[1]}
+			continue
+		}
+		if match := loopsRegexp.FindStringSubmatch(line); match != nil {
+			vectorRate, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			loops, err := strconv.ParseFloat(match[2], 64)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			current.VectorRate, current.LoopsPerSec = vectorRate, loops
+			threads = append(threads, current)
+			continue
+		}
+		if match := ratesRegexp.FindStringSubmatch(line); match != nil {
+			rate, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			current.InputRate = rate
+		}
+	}
+	return threads, errors.WithStack(scanner.Err())
+}
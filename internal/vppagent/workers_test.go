@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppagent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const runtimeOutput = `Thread 0 vpp_main (lcore 0)
+Time 12.3, 10 sec internal node vector rate 0.00 loops/sec 3661296.52
+  vector rates in 0.0000e0, out 0.0000e0, drop 0.0000e0, punt 0.0000e0
+             Name                 State         Calls          Vectors        Suspends         Clocks       Vectors/Call
+api-rx-from-ring                any wait                 0               0               0          8.21e3            0.00
+---------------
+Thread 1 vpp_wk_0 (lcore 2)
+Time 12.3, 10 sec internal node vector rate 17.52 loops/sec 48211.07
+  vector rates in 8.4521e5, out 8.4520e5, drop 0.0000e0, punt 0.0000e0
+             Name                 State         Calls          Vectors        Suspends         Clocks       Vectors/Call
+memif-input                      polling          1225002        10400466               0          1.02e2            8.49
+`
+
+func TestParseRuntime(t *testing.T) {
+	threads, err := parseRuntime(strings.NewReader(runtimeOutput))
+	require.NoError(t, err)
+	require.Len(t, threads, 2)
+	require.Equal(t, &threadRuntime{Name: "vpp_main", VectorRate: 0, LoopsPerSec: 3661296.52}, threads[0])
+	require.Equal(t, &threadRuntime{Name: "vpp_wk_0", VectorRate: 17.52, LoopsPerSec: 48211.07, InputRate: 8.4521e5}, threads[1])
+}
+
+func TestParseRuntimeWithoutWorkers(t *testing.T) {
+	threads, err := parseRuntime(strings.NewReader(`Time 3.5, 10 sec internal node vector rate 0.00 loops/sec 1030417.82
+  vector rates in 1.2000e1, out 1.2000e1, drop 0.0000e0, punt 0.0000e0
+`))
+	require.NoError(t, err)
+	require.Equal(t, []*threadRuntime{{Name: "vpp_main", LoopsPerSec: 1030417.82, InputRate: 12}}, threads)
+}
@@ -79,6 +79,6 @@ func initArpTable(srcIP net.IP, conf *configurator.Config) error {
 	if err != nil {
 		return err
 	}
-	conf.GetVppConfig().Arps = append(conf.GetVppConfig().GetArps(), entries...)
+	putArps(conf, entries)
 	return nil
 }
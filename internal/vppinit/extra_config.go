@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/agentconfig"
+)
+
+// LoadExtraConfig - returns the vpp-agent configs in the .yaml, .yml and .json files of dir merged in the order of
+// their names, nil if dir is empty.  To be loaded and validated at startup and given to WithExtraConfig.
+func LoadExtraConfig(dir string) (*configurator.Config, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the extra vpp-agent config dir")
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+	conf := &configurator.Config{}
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if file.IsDir() {
+			continue
+		}
+		data, readErr := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if readErr != nil {
+			return nil, errors.Wrapf(readErr, "failed to read extra vpp-agent config %s", file.Name())
+		}
+		extra, parseErr := agentconfig.Parse(data)
+		if parseErr != nil {
+			return nil, errors.Wrapf(parseErr, "extra vpp-agent config %s", file.Name())
+		}
+		proto.Merge(conf, extra)
+	}
+	dedupe(conf)
+	return conf, nil
+}
+
+// initExtraConfig - merges extra, if any, into conf after the rest of the initial configuration.  Like the rest of
+// it, merging it again into the same conf doesn't duplicate it.
+func initExtraConfig(extra, conf *configurator.Config) {
+	if extra == nil {
+		return
+	}
+	proto.Merge(conf, extra)
+	dedupe(conf)
+}
+
+// dedupe - drops the items of the sections of conf, e.g. its vpp routes, equal to an earlier item of theirs
+func dedupe(conf *configurator.Config) {
+	c := reflect.ValueOf(conf).Elem()
+	for i := 0; i < c.NumField(); i++ {
+		section := c.Field(i)
+		if c.Type().Field(i).PkgPath != "" || section.Kind() != reflect.Ptr || section.IsNil() || section.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		s := section.Elem()
+		for j := 0; j < s.NumField(); j++ {
+			items := s.Field(j)
+			if s.Type().Field(j).PkgPath != "" || items.Kind() != reflect.Slice {
+				continue
+			}
+			kept := reflect.MakeSlice(items.Type(), 0, items.Len())
+			for k := 0; k < items.Len(); k++ {
+				item, ok := items.Index(k).Interface().(proto.Message)
+				if !ok {
+					kept = items
+					break
+				}
+				if !containsEqual(kept, item) {
+					kept = reflect.Append(kept, items.Index(k))
+				}
+			}
+			items.Set(kept)
+		}
+	}
+}
+
+func containsEqual(items reflect.Value, item proto.Message) bool {
+	for i := 0; i < items.Len(); i++ {
+		if proto.Equal(items.Index(i).Interface().(proto.Message), item) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+)
+
+func TestExtraConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vppinit")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+	for name, content := range map[string]string{
+		"10-loopback.yaml": "vppConfig:\n  interfaces:\n    - name: loop0\n      type: SOFTWARE_LOOPBACK\n      enabled: true\n",
+		"20-routes.json":   `{"vppConfig": {"routes": [{"dstNetwork": "10.10.0.0/16", "outgoingInterface": "loop0"}]}}`,
+		"README":           "not a config",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	extra, err := LoadExtraConfig(dir)
+	require.NoError(t, err)
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	initConfig(conf, "10.0.0.2/24")
+	initExtraConfig(extra, conf)
+	initExtraConfig(extra, conf)
+
+	interfaces := conf.GetVppConfig().GetInterfaces()
+	require.Len(t, interfaces, 2)
+	require.Equal(t, "eth0", interfaces[0].GetName())
+	require.Equal(t, "loop0", interfaces[1].GetName())
+	require.Len(t, conf.GetVppConfig().GetRoutes(), 2)
+	require.Equal(t, "10.10.0.0/16", conf.GetVppConfig().GetRoutes()[1].GetDstNetwork())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "30-invalid.yml"), []byte("vppConfig: {nosuchsection: []}\n"), 0600))
+	_, err = LoadExtraConfig(dir)
+	require.Error(t, err)
+}
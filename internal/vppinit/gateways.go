@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"net"
+
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+)
+
+// initAttachedGateways - adds an attached host route to each ipv4 next hop of the uplink's routes that is outside of
+// the uplink's subnets, as with the /32 addresses and off subnet gateways of several clouds.  vpp only answers the
+// arp requests of addresses it has an attached route to, so without these the gateway never learns the mac of the
+// TunnelIP and remote vxlan packets stop arriving once its arp cache expires.
+func initAttachedGateways(srcIP net.IP, vrf uint32, conf *configurator.Config) error {
+	iface, err := interfaceFromSrcIP(srcIP)
+	if err != nil {
+		return err
+	}
+	nets, err := ipNetsFromInterface(iface)
+	if err != nil {
+		return err
+	}
+	var attached []*vpp.Route
+	for _, route := range conf.GetVppConfig().GetRoutes() {
+		gateway := net.ParseIP(route.GetNextHopAddr())
+		if gateway == nil || gateway.To4() == nil || route.GetOutgoingInterface() != iface.Name || onLink(gateway, nets) {
+			continue
+		}
+		attached = append(attached, &vpp.Route{
+			Type:              vpp_l3.Route_INTRA_VRF,
+			VrfId:             vrf,
+			OutgoingInterface: iface.Name,
+			DstNetwork:        (&net.IPNet{IP: gateway.To4(), Mask: net.CIDRMask(32, 32)}).String(),
+			Weight:            1,
+		})
+	}
+	putRoutes(conf, attached)
+	return nil
+}
+
+func onLink(ip net.IP, nets []*net.IPNet) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
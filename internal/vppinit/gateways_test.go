@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+)
+
+var loopback = net.ParseIP("127.0.0.1")
+
+func TestInitAttachedGateways(t *testing.T) {
+	lo, err := interfaceFromSrcIP(loopback)
+	require.NoError(t, err)
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	putRoutes(conf, []*vpp.Route{
+		{DstNetwork: defaultIPv4NetworkString, NextHopAddr: "192.0.2.1", OutgoingInterface: lo.Name},
+		{DstNetwork: "198.51.100.0/24", NextHopAddr: "127.0.0.254", OutgoingInterface: lo.Name},
+		{DstNetwork: "203.0.113.0/24", NextHopAddr: "192.0.2.2", OutgoingInterface: "eth1"},
+		{DstNetwork: defaultIPv6NetworkString, NextHopAddr: "2001:db8::1", OutgoingInterface: lo.Name},
+	})
+	require.NoError(t, initAttachedGateways(loopback, 10, conf))
+	routes := conf.GetVppConfig().GetRoutes()
+	require.Len(t, routes, 5, "only the off link ipv4 gateway of the uplink needs an attached route: %v", routes)
+	require.Equal(t, &vpp.Route{
+		Type:              vpp_l3.Route_INTRA_VRF,
+		VrfId:             10,
+		OutgoingInterface: lo.Name,
+		DstNetwork:        "192.0.2.1/32",
+		Weight:            1,
+	}, routes[4])
+
+	require.NoError(t, initAttachedGateways(loopback, 10, conf))
+	require.Len(t, conf.GetVppConfig().GetRoutes(), 5, "the attached route was added again")
+}
+
+func TestOnLink(t *testing.T) {
+	_, nets, err := net.ParseCIDR("10.0.0.0/24")
+	require.NoError(t, err)
+	require.True(t, onLink(net.ParseIP("10.0.0.1"), []*net.IPNet{nets}))
+	require.False(t, onLink(net.ParseIP("10.0.1.1"), []*net.IPNet{nets}))
+	require.False(t, onLink(net.ParseIP("10.0.0.1"), nil))
+}
@@ -109,7 +109,7 @@ func excludedCIDRs() []*net.IPNet {
 	return excludedCIDRs
 }
 
-func initInterface(srcIP net.IP, conf *configurator.Config) error {
+func initInterface(srcIP net.IP, vrf uint32, conf *configurator.Config) error {
 	iface, err := interfaceFromSrcIP(srcIP)
 	if err != nil {
 		return err
@@ -123,6 +123,7 @@ func initInterface(srcIP net.IP, conf *configurator.Config) error {
 		PhysAddress: iface.HardwareAddr.String(),
 		Type:        vpp_interfaces.Interface_AF_PACKET,
 		Enabled:     true,
+		Vrf:         vrf,
 		Link: &vpp_interfaces.Interface_Afpacket{
 			Afpacket: &vpp_interfaces.AfpacketLink{
 				HostIfName: iface.Name,
@@ -132,6 +133,6 @@ func initInterface(srcIP net.IP, conf *configurator.Config) error {
 	for _, ip := range nets {
 		vppIface.IpAddresses = append(vppIface.IpAddresses, ip.String())
 	}
-	conf.GetVppConfig().Interfaces = append([]*vpp_interfaces.Interface{vppIface}, conf.GetVppConfig().GetInterfaces()...)
+	putInterface(conf, vppIface)
 	return nil
 }
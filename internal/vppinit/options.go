@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+)
+
+type options struct {
+	tunnelVRF     uint32
+	managementVRF uint32
+	extraConfig   *configurator.Config
+	bfd           bool
+}
+
+// Option - option for Func and Apply
+type Option func(o *options)
+
+// WithTunnelVRF - puts the uplink, its routes and the vxlan tunnels in the vrf id instead of the default table, so
+// the underlay routing of tunnels is isolated from other uses of vpp
+func WithTunnelVRF(id uint32) Option {
+	return func(o *options) {
+		o.tunnelVRF = id
+	}
+}
+
+// WithManagementVRF - creates the vrf id for management and monitoring traffic
+func WithManagementVRF(id uint32) Option {
+	return func(o *options) {
+		o.managementVRF = id
+	}
+}
+
+// WithExtraConfig - merges extra, vpp-agent configs such as base acls, spans or loopbacks loaded by LoadExtraConfig,
+// into the initial configuration, after the rest of it
+func WithExtraConfig(extra *configurator.Config) Option {
+	return func(o *options) {
+		o.extraConfig = extra
+	}
+}
+
+// WithBFD - permits the BFD control and echo packets of the sessions to the remote ends of vxlan tunnels on the uplink
+func WithBFD() Option {
+	return func(o *options) {
+		o.bfd = true
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
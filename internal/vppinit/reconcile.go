@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+)
+
+// The put* functions reconcile rather than append, replacing any entry of conf with the same key as the new one, so
+// that running the initial configuration again on the same conf (a retried startup phase, or a forwarder restarted
+// against a persistent vpp) neither duplicates nor conflicts with what is already there
+
+// putInterface - puts iface at the front of the interfaces of conf, in place of any interface of the same name
+func putInterface(conf *configurator.Config, iface *vpp_interfaces.Interface) {
+	ifaces := []*vpp_interfaces.Interface{iface}
+	for _, existing := range conf.GetVppConfig().GetInterfaces() {
+		if existing.GetName() != iface.GetName() {
+			ifaces = append(ifaces, existing)
+		}
+	}
+	conf.GetVppConfig().Interfaces = ifaces
+}
+
+// putArps - puts arps into conf, in place of any arp entry for the same ip address on the same interface
+func putArps(conf *configurator.Config, arps []*vpp.ARPEntry) {
+	for _, arp := range arps {
+		if i := findArp(conf.GetVppConfig().GetArps(), arp); i >= 0 {
+			conf.GetVppConfig().Arps[i] = arp
+			continue
+		}
+		conf.GetVppConfig().Arps = append(conf.GetVppConfig().GetArps(), arp)
+	}
+}
+
+// putRoutes - puts routes into conf, in place of any route to the same network through the same next hop
+func putRoutes(conf *configurator.Config, routes []*vpp.Route) {
+	for _, route := range routes {
+		if i := findRoute(conf.GetVppConfig().GetRoutes(), route); i >= 0 {
+			conf.GetVppConfig().Routes[i] = route
+			continue
+		}
+		conf.GetVppConfig().Routes = append(conf.GetVppConfig().GetRoutes(), route)
+	}
+}
+
+// putACL - puts acl into conf, in place of any acl of the same name
+func putACL(conf *configurator.Config, acl *vpp.ACL) {
+	if i := findACL(conf.GetVppConfig().GetAcls(), acl); i >= 0 {
+		conf.GetVppConfig().Acls[i] = acl
+		return
+	}
+	conf.GetVppConfig().Acls = append(conf.GetVppConfig().GetAcls(), acl)
+}
+
+// putVrf - puts vrf into conf, in place of any table of the same id and protocol
+func putVrf(conf *configurator.Config, vrf *vpp_l3.VrfTable) {
+	if i := findVrf(conf.GetVppConfig().GetVrfs(), vrf); i >= 0 {
+		conf.GetVppConfig().Vrfs[i] = vrf
+		return
+	}
+	conf.GetVppConfig().Vrfs = append(conf.GetVppConfig().GetVrfs(), vrf)
+}
+
+// missing - returns the part of desired that current lacks or has with other values, nil if current has it all
+func missing(current, desired *configurator.Config) *configurator.Config {
+	have, want := current.GetVppConfig(), desired.GetVppConfig()
+	rv := &vpp.ConfigData{}
+	for _, vrf := range want.GetVrfs() {
+		if i := findVrf(have.GetVrfs(), vrf); i < 0 || !proto.Equal(have.GetVrfs()[i], vrf) {
+			rv.Vrfs = append(rv.Vrfs, vrf)
+		}
+	}
+	for _, iface := range want.GetInterfaces() {
+		if i := findInterface(have.GetInterfaces(), iface); i < 0 || !proto.Equal(have.GetInterfaces()[i], iface) {
+			rv.Interfaces = append(rv.Interfaces, iface)
+		}
+	}
+	for _, arp := range want.GetArps() {
+		if i := findArp(have.GetArps(), arp); i < 0 || !proto.Equal(have.GetArps()[i], arp) {
+			rv.Arps = append(rv.Arps, arp)
+		}
+	}
+	for _, route := range want.GetRoutes() {
+		if i := findRoute(have.GetRoutes(), route); i < 0 || !proto.Equal(have.GetRoutes()[i], route) {
+			rv.Routes = append(rv.Routes, route)
+		}
+	}
+	for _, acl := range want.GetAcls() {
+		if i := findACL(have.GetAcls(), acl); i < 0 || !proto.Equal(have.GetAcls()[i], acl) {
+			rv.Acls = append(rv.Acls, acl)
+		}
+	}
+	if len(rv.Vrfs)+len(rv.Interfaces)+len(rv.Arps)+len(rv.Routes)+len(rv.Acls) == 0 {
+		return nil
+	}
+	return &configurator.Config{VppConfig: rv}
+}
+
+func findInterface(ifaces []*vpp_interfaces.Interface, iface *vpp_interfaces.Interface) int {
+	for i, existing := range ifaces {
+		if existing.GetName() == iface.GetName() {
+			return i
+		}
+	}
+	return -1
+}
+
+func findArp(arps []*vpp.ARPEntry, arp *vpp.ARPEntry) int {
+	for i, existing := range arps {
+		if existing.GetInterface() == arp.GetInterface() && existing.GetIpAddress() == arp.GetIpAddress() {
+			return i
+		}
+	}
+	return -1
+}
+
+func findRoute(routes []*vpp.Route, route *vpp.Route) int {
+	for i, existing := range routes {
+		if existing.GetVrfId() == route.GetVrfId() && existing.GetDstNetwork() == route.GetDstNetwork() &&
+			existing.GetNextHopAddr() == route.GetNextHopAddr() && existing.GetOutgoingInterface() == route.GetOutgoingInterface() {
+			return i
+		}
+	}
+	return -1
+}
+
+func findACL(acls []*vpp.ACL, acl *vpp.ACL) int {
+	for i, existing := range acls {
+		if existing.GetName() == acl.GetName() {
+			return i
+		}
+	}
+	return -1
+}
+
+func findVrf(vrfs []*vpp_l3.VrfTable, vrf *vpp_l3.VrfTable) int {
+	for i, existing := range vrfs {
+		if existing.GetId() == vrf.GetId() && existing.GetProtocol() == vrf.GetProtocol() {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+)
+
+// initConfig - runs the put* functions the way Func does, with the uplink eth0 of address addr
+func initConfig(conf *configurator.Config, addr string) {
+	putInterface(conf, &vpp_interfaces.Interface{
+		Name:        "eth0",
+		Type:        vpp_interfaces.Interface_AF_PACKET,
+		Enabled:     true,
+		IpAddresses: []string{addr},
+	})
+	putArps(conf, []*vpp.ARPEntry{{Interface: "eth0", IpAddress: "10.0.0.1", PhysAddress: "aa:bb:cc:dd:ee:ff"}})
+	putRoutes(conf, []*vpp.Route{{DstNetwork: "0.0.0.0/0", NextHopAddr: "10.0.0.1", OutgoingInterface: "eth0"}})
+	putACL(conf, &vpp.ACL{Name: "eth0"})
+}
+
+func TestReentryDoesNotDuplicate(t *testing.T) {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	initConfig(conf, "10.0.0.2/24")
+	once := proto.Clone(conf)
+	initConfig(conf, "10.0.0.2/24")
+	require.True(t, proto.Equal(once, conf), "running the initial configuration again changed it: %v", conf)
+	require.Len(t, conf.GetVppConfig().GetInterfaces(), 1)
+	require.Len(t, conf.GetVppConfig().GetArps(), 1)
+	require.Len(t, conf.GetVppConfig().GetRoutes(), 1)
+	require.Len(t, conf.GetVppConfig().GetAcls(), 1)
+}
+
+func TestReentryReplacesChangedEntries(t *testing.T) {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{{Name: "memif0"}},
+	}}
+	initConfig(conf, "10.0.0.2/24")
+	initConfig(conf, "10.0.0.3/24")
+	ifaces := conf.GetVppConfig().GetInterfaces()
+	require.Len(t, ifaces, 2)
+	require.Equal(t, "eth0", ifaces[0].GetName(), "the uplink is not kept first")
+	require.Equal(t, []string{"10.0.0.3/24"}, ifaces[0].GetIpAddresses())
+	require.Equal(t, "memif0", ifaces[1].GetName())
+}
+
+func TestMissingSkipsWhatIsPresent(t *testing.T) {
+	desired := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	initConfig(desired, "10.0.0.2/24")
+	require.True(t, proto.Equal(desired, missing(&configurator.Config{}, desired)))
+
+	current := proto.Clone(desired).(*configurator.Config)
+	current.GetVppConfig().Interfaces = append(current.GetVppConfig().Interfaces, &vpp_interfaces.Interface{Name: "memif0"})
+	require.Nil(t, missing(current, desired))
+
+	current.GetVppConfig().GetRoutes()[0].NextHopAddr = "10.0.0.254"
+	rv := missing(current, desired)
+	require.NotNil(t, rv)
+	require.Empty(t, rv.GetVppConfig().GetInterfaces())
+	require.Empty(t, rv.GetVppConfig().GetArps())
+	require.Empty(t, rv.GetVppConfig().GetAcls())
+	require.Equal(t, desired.GetVppConfig().GetRoutes(), rv.GetVppConfig().GetRoutes())
+}
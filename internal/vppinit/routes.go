@@ -143,11 +143,15 @@ func parseGatewayIP(defaultGateway string) (net.IP, error) {
 	return ip, nil
 }
 
-func initRoutes(conf *configurator.Config) error {
+func initRoutes(vrf uint32, conf *configurator.Config) error {
 	routes, err := defaultRoutes()
 	if err != nil {
 		return err
 	}
-	conf.GetVppConfig().Routes = append(conf.GetVppConfig().GetRoutes(), routes...)
+	for _, route := range routes {
+		// The next hops are looked up in the same vrf, through the uplink
+		route.VrfId, route.ViaVrfId = vrf, vrf
+	}
+	putRoutes(conf, routes)
 	return nil
 }
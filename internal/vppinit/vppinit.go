@@ -20,14 +20,20 @@
 package vppinit
 
 import (
+	"context"
 	"net"
 
 	"github.com/pkg/errors"
 	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
 )
 
 // Func - returns the a function to create an initial vpp configuration
-func Func(srcIP net.IP) func(conf *configurator.Config) error {
+func Func(srcIP net.IP, opts ...Option) func(conf *configurator.Config) error {
+	o := newOptions(opts...)
 	var err error
 	if srcIP == nil || srcIP.IsUnspecified() {
 		srcIP, err = defaultTunnelIP()
@@ -36,18 +42,47 @@ func Func(srcIP net.IP) func(conf *configurator.Config) error {
 		if err != nil {
 			return errors.Wrap(err, "No tunnel IP provided")
 		}
-		if err := initInterface(srcIP, conf); err != nil {
+		initVRFs(o, conf)
+		if err := initInterface(srcIP, o.tunnelVRF, conf); err != nil {
 			return err
 		}
 		if err := initArpTable(srcIP, conf); err != nil {
 			return err
 		}
-		if err := initRoutes(conf); err != nil {
+		if err := initRoutes(o.tunnelVRF, conf); err != nil {
+			return err
+		}
+		if err := initAttachedGateways(srcIP, o.tunnelVRF, conf); err != nil {
 			return err
 		}
-		if err := initVxlanACL(srcIP, conf); err != nil {
+		if err := initVxlanACL(srcIP, o.bfd, conf); err != nil {
 			return err
 		}
+		initExtraConfig(o.extraConfig, conf)
+		return nil
+	}
+}
+
+// Apply - applies the initial vpp configuration of Func(srcIP) through the vpp-agent at vppagentCC, outside of the
+// forwarder's startup, such as from an initContainer provisioning vpp ahead of the forwarder.  Only what the vpp-agent
+// does not already have is applied, so Apply can run again against a persistent vpp.
+func Apply(ctx context.Context, vppagentCC grpc.ClientConnInterface, srcIP net.IP, opts ...Option) error {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	if err := Func(srcIP, opts...)(conf); err != nil {
+		return err
+	}
+	client := configurator.NewConfiguratorServiceClient(vppagentCC)
+	current, err := client.Get(ctx, &configurator.GetRequest{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get the vpp configuration already present")
+	}
+	conf = missing(current.GetConfig(), conf)
+	if conf == nil {
+		log.Entry(ctx).Infof("the initial vpp configuration is already present")
 		return nil
 	}
+	if _, err = client.Update(ctx, &configurator.UpdateRequest{Update: conf}); err != nil {
+		return errors.Wrap(err, "failed to apply the initial vpp configuration")
+	}
+	return nil
 }
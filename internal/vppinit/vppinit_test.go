@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppinit"
+)
+
+var loopback = net.ParseIP("127.0.0.1")
+
+// configuratorCC - a vpp-agent answering Gets with current and recording the Updates it is sent
+type configuratorCC struct {
+	current   *configurator.Config
+	getErr    error
+	updateErr error
+	updates   []*configurator.Config
+}
+
+func (c *configuratorCC) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	switch req := args.(type) {
+	case *configurator.GetRequest:
+		if c.getErr != nil {
+			return c.getErr
+		}
+		proto.Merge(reply.(proto.Message), &configurator.GetResponse{Config: c.current})
+	case *configurator.UpdateRequest:
+		c.updates = append(c.updates, req.GetUpdate())
+		return c.updateErr
+	}
+	return nil
+}
+
+func (c *configuratorCC) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("no streams")
+}
+
+// desired - returns the initial configuration Apply is expected to send for the loopback, skipping t when the host
+// has no default route to build it from
+func desired(t *testing.T) *configurator.Config {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	if err := vppinit.Func(loopback)(conf); err != nil {
+		t.Skipf("no initial configuration for the loopback on this host: %+v", err)
+	}
+	return conf
+}
+
+func TestApplySendsWhatIsMissing(t *testing.T) {
+	want := desired(t)
+	cc := &configuratorCC{current: &configurator.Config{}}
+	require.NoError(t, vppinit.Apply(context.Background(), cc, loopback))
+	require.Len(t, cc.updates, 1)
+	require.True(t, proto.Equal(want, cc.updates[0]), "unexpected update: %v", cc.updates[0])
+}
+
+func TestApplySkipsWhatIsPresent(t *testing.T) {
+	cc := &configuratorCC{current: desired(t)}
+	require.NoError(t, vppinit.Apply(context.Background(), cc, loopback))
+	require.Empty(t, cc.updates, "the configuration already present was sent again")
+}
+
+func TestApplyErrors(t *testing.T) {
+	desired(t)
+	cc := &configuratorCC{getErr: errors.New("vpp-agent get failed")}
+	err := vppinit.Apply(context.Background(), cc, loopback)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "vpp-agent get failed")
+	require.Empty(t, cc.updates)
+
+	cc = &configuratorCC{current: &configurator.Config{}, updateErr: errors.New("vpp-agent update failed")}
+	err = vppinit.Apply(context.Background(), cc, loopback)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to apply the initial vpp configuration")
+	require.Contains(t, err.Error(), "vpp-agent update failed")
+}
+
+func TestApplyUnknownTunnelIP(t *testing.T) {
+	cc := &configuratorCC{current: &configurator.Config{}}
+	require.Error(t, vppinit.Apply(context.Background(), cc, net.ParseIP("192.0.2.123")))
+	require.Empty(t, cc.updates, "an update was sent without an initial configuration")
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+	"google.golang.org/grpc"
+)
+
+const (
+	tunnelVRFLabel     = "nsm-tunnel"
+	managementVRFLabel = "nsm-management"
+)
+
+// initVRFs - creates the ipv4 and ipv6 tables of the vrfs of o other than the default one
+func initVRFs(o *options, conf *configurator.Config) {
+	for _, vrf := range []struct {
+		id    uint32
+		label string
+	}{
+		{o.tunnelVRF, tunnelVRFLabel},
+		{o.managementVRF, managementVRFLabel},
+	} {
+		if vrf.id == 0 {
+			continue
+		}
+		for _, protocol := range []vpp_l3.VrfTable_Protocol{vpp_l3.VrfTable_IPV4, vpp_l3.VrfTable_IPV6} {
+			putVrf(conf, &vpp_l3.VrfTable{Id: vrf.id, Protocol: protocol, Label: vrf.label})
+		}
+	}
+}
+
+// TunnelVRFInterceptor - returns an interceptor putting the vxlan tunnels of the vpp-agent Updates passing through
+// that have no vrf in the vrf id, which vpp-agent uses as their encap vrf, so that they are sent and received through
+// the uplink in the tunnel vrf.  It sends on a copy of the Update, leaving the caller's as it was, and does nothing
+// for the default vrf.
+func TunnelVRFInterceptor(id uint32) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if update, ok := req.(*configurator.UpdateRequest); ok && id != 0 {
+			update = proto.Clone(update).(*configurator.UpdateRequest)
+			req = update
+			for _, iface := range update.GetUpdate().GetVppConfig().GetInterfaces() {
+				if iface.GetType() == vpp_interfaces.Interface_VXLAN_TUNNEL && iface.GetVrf() == 0 {
+					iface.Vrf = id
+				}
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_interfaces "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/interfaces"
+	vpp_l3 "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/l3"
+	"google.golang.org/grpc"
+)
+
+func TestInitVRFs(t *testing.T) {
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	initVRFs(newOptions(), conf)
+	require.Empty(t, conf.GetVppConfig().GetVrfs(), "tables were created for the default vrf")
+
+	initVRFs(newOptions(WithTunnelVRF(10), WithManagementVRF(20)), conf)
+	initVRFs(newOptions(WithTunnelVRF(10), WithManagementVRF(20)), conf)
+	require.ElementsMatch(t, []*vpp_l3.VrfTable{
+		{Id: 10, Protocol: vpp_l3.VrfTable_IPV4, Label: tunnelVRFLabel},
+		{Id: 10, Protocol: vpp_l3.VrfTable_IPV6, Label: tunnelVRFLabel},
+		{Id: 20, Protocol: vpp_l3.VrfTable_IPV4, Label: managementVRFLabel},
+		{Id: 20, Protocol: vpp_l3.VrfTable_IPV6, Label: managementVRFLabel},
+	}, conf.GetVppConfig().GetVrfs())
+}
+
+func TestTunnelVRFInterceptor(t *testing.T) {
+	update := &configurator.UpdateRequest{Update: &configurator.Config{VppConfig: &vpp.ConfigData{
+		Interfaces: []*vpp_interfaces.Interface{
+			{Name: "vxlan0", Type: vpp_interfaces.Interface_VXLAN_TUNNEL},
+			{Name: "vxlan1", Type: vpp_interfaces.Interface_VXLAN_TUNNEL, Vrf: 5},
+			{Name: "memif0", Type: vpp_interfaces.Interface_MEMIF},
+		},
+	}}}
+	before := proto.Clone(update)
+
+	var sent interface{}
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent = req
+		return nil
+	}
+	require.NoError(t, TunnelVRFInterceptor(10)(context.Background(), "Update", update, nil, nil, invoker))
+	ifaces := sent.(*configurator.UpdateRequest).GetUpdate().GetVppConfig().GetInterfaces()
+	require.Equal(t, uint32(10), ifaces[0].GetVrf(), "the vxlan tunnel without a vrf was not put in the tunnel vrf")
+	require.Equal(t, uint32(5), ifaces[1].GetVrf(), "the vrf of a vxlan tunnel was replaced")
+	require.Equal(t, uint32(0), ifaces[2].GetVrf(), "an interface other than a vxlan tunnel was put in the tunnel vrf")
+	require.True(t, proto.Equal(before, update), "the caller's update was changed: %v", update)
+
+	require.NoError(t, TunnelVRFInterceptor(0)(context.Background(), "Update", update, nil, nil, invoker))
+	require.True(t, sent == update, "the update was copied for the default vrf")
+}
@@ -27,9 +27,13 @@ import (
 const (
 	defaultIPv4NetworkString = "0.0.0.0/0"
 	defaultIPv6NetworkString = "::/0"
+
+	vxlanPort      = 4789
+	bfdControlPort = 3784
+	bfdEchoPort    = 3785
 )
 
-func initVxlanACL(srcIP net.IP, conf *configurator.Config) error {
+func initVxlanACL(srcIP net.IP, bfd bool, conf *configurator.Config) error {
 	iface, err := interfaceFromSrcIP(srcIP)
 	if err != nil {
 		return err
@@ -44,7 +48,9 @@ func initVxlanACL(srcIP net.IP, conf *configurator.Config) error {
 	if err != nil {
 		return err
 	}
+	ipv6 := false
 	for _, ipnet := range nets {
+		ipv6 = ipv6 || ipnet.IP.To4() == nil
 		for i := range ipnet.Mask {
 			ipnet.Mask[i] = 0xff
 		}
@@ -58,29 +64,55 @@ func initVxlanACL(srcIP net.IP, conf *configurator.Config) error {
 				return err
 			}
 		}
+		// Permit traffic to VXLAN Destination Ports, and to the BFD control and echo ports of the sessions to the
+		// remote ends of the tunnels
+		vxlanACL.Rules = append(vxlanACL.Rules, udpRule(ipnet, srcNet, vxlanPort))
+		if bfd {
+			vxlanACL.Rules = append(vxlanACL.Rules, udpRule(ipnet, srcNet, bfdControlPort), udpRule(ipnet, srcNet, bfdEchoPort))
+		}
+	}
+	if ipv6 {
+		// Permit neighbor discovery, ipv6's arp, without which vpp never answers for the TunnelIP
 		vxlanACL.Rules = append(vxlanACL.Rules, &vpp_acl.ACL_Rule{
 			Action: vpp_acl.ACL_Rule_PERMIT,
 			IpRule: &vpp_acl.ACL_Rule_IpRule{
-				// Permit arp traffic
 				Ip: &vpp_acl.ACL_Rule_IpRule_Ip{
-					DestinationNetwork: ipnet.String(),
-					SourceNetwork:      srcNet.String(),
+					DestinationNetwork: defaultIPv6NetworkString,
+					SourceNetwork:      defaultIPv6NetworkString,
 				},
-				Udp: &vpp_acl.ACL_Rule_IpRule_Udp{
-					// Permit traffic to VXLAN Destination Ports
-					DestinationPortRange: &vpp_acl.ACL_Rule_IpRule_PortRange{
-						LowerPort: 4789,
-						UpperPort: 4789,
-					},
-					// Permit traffic from all ports
-					SourcePortRange: &vpp_acl.ACL_Rule_IpRule_PortRange{
-						LowerPort: 0,
-						UpperPort: 65535,
-					},
+				Icmp: &vpp_acl.ACL_Rule_IpRule_Icmp{
+					Icmpv6: true,
+					// Router solicitation and advertisement, neighbor solicitation and advertisement, redirect
+					IcmpTypeRange: &vpp_acl.ACL_Rule_IpRule_Icmp_Range{First: 133, Last: 137},
+					IcmpCodeRange: &vpp_acl.ACL_Rule_IpRule_Icmp_Range{First: 0, Last: 255},
 				},
 			},
 		})
 	}
-	conf.GetVppConfig().Acls = append(conf.GetVppConfig().GetAcls(), vxlanACL)
+	putACL(conf, vxlanACL)
 	return nil
 }
+
+// udpRule - returns the rule permitting udp traffic from any port of srcNet to port of dstNet
+func udpRule(dstNet, srcNet *net.IPNet, port uint32) *vpp_acl.ACL_Rule {
+	return &vpp_acl.ACL_Rule{
+		Action: vpp_acl.ACL_Rule_PERMIT,
+		IpRule: &vpp_acl.ACL_Rule_IpRule{
+			Ip: &vpp_acl.ACL_Rule_IpRule_Ip{
+				DestinationNetwork: dstNet.String(),
+				SourceNetwork:      srcNet.String(),
+			},
+			Udp: &vpp_acl.ACL_Rule_IpRule_Udp{
+				DestinationPortRange: &vpp_acl.ACL_Rule_IpRule_PortRange{
+					LowerPort: port,
+					UpperPort: port,
+				},
+				// Permit traffic from all ports
+				SourcePortRange: &vpp_acl.ACL_Rule_IpRule_PortRange{
+					LowerPort: 0,
+					UpperPort: 65535,
+				},
+			},
+		},
+	}
+}
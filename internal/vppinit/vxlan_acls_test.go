@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !windows
+
+package vppinit
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/configurator"
+	"go.ligato.io/vpp-agent/v3/proto/ligato/vpp"
+	vpp_acl "go.ligato.io/vpp-agent/v3/proto/ligato/vpp/acl"
+)
+
+// ports - returns the udp destination ports the rules permit to dstNetwork, and whether they permit neighbor discovery
+func ports(rules []*vpp_acl.ACL_Rule, dstNetwork string) (rv []uint32, nd bool) {
+	for _, rule := range rules {
+		if rule.GetIpRule().GetIcmp().GetIcmpv6() {
+			nd = true
+			continue
+		}
+		if rule.GetIpRule().GetIp().GetDestinationNetwork() == dstNetwork {
+			rv = append(rv, rule.GetIpRule().GetUdp().GetDestinationPortRange().GetLowerPort())
+		}
+	}
+	return rv, nd
+}
+
+func TestInitVxlanACL(t *testing.T) {
+	lo, err := interfaceFromSrcIP(loopback)
+	require.NoError(t, err)
+	nets, err := ipNetsFromInterface(lo)
+	require.NoError(t, err)
+	ipv6 := false
+	for _, ipNet := range nets {
+		ipv6 = ipv6 || ipNet.IP.To4() == nil
+	}
+
+	conf := &configurator.Config{VppConfig: &vpp.ConfigData{}}
+	require.NoError(t, initVxlanACL(loopback, false, conf))
+	require.Len(t, conf.GetVppConfig().GetAcls(), 1)
+	acl := conf.GetVppConfig().GetAcls()[0]
+	require.Equal(t, lo.Name, acl.GetName())
+	require.Equal(t, []string{lo.Name}, acl.GetInterfaces().GetIngress())
+	rv, nd := ports(acl.GetRules(), "127.0.0.1/32")
+	require.Equal(t, []uint32{vxlanPort}, rv)
+	require.Equal(t, ipv6, nd, "neighbor discovery is permitted only with an ipv6 address")
+
+	require.NoError(t, initVxlanACL(loopback, true, conf))
+	require.Len(t, conf.GetVppConfig().GetAcls(), 1)
+	rv, _ = ports(conf.GetVppConfig().GetAcls()[0].GetRules(), "127.0.0.1/32")
+	require.Equal(t, []uint32{vxlanPort, bfdControlPort, bfdEchoPort}, rv)
+}
+
+func TestUDPRule(t *testing.T) {
+	_, dst, err := net.ParseCIDR("10.0.0.2/32")
+	require.NoError(t, err)
+	_, src, err := net.ParseCIDR(defaultIPv4NetworkString)
+	require.NoError(t, err)
+	rule := udpRule(dst, src, vxlanPort)
+	require.Equal(t, vpp_acl.ACL_Rule_PERMIT, rule.GetAction())
+	require.Equal(t, "10.0.0.2/32", rule.GetIpRule().GetIp().GetDestinationNetwork())
+	require.Equal(t, defaultIPv4NetworkString, rule.GetIpRule().GetIp().GetSourceNetwork())
+	require.Equal(t, &vpp_acl.ACL_Rule_IpRule_PortRange{LowerPort: vxlanPort, UpperPort: vxlanPort}, rule.GetIpRule().GetUdp().GetDestinationPortRange())
+	require.Equal(t, &vpp_acl.ACL_Rule_IpRule_PortRange{LowerPort: 0, UpperPort: 65535}, rule.GetIpRule().GetUdp().GetSourcePortRange())
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vppnames - derives the names sdk-vppagent gives to the vpp objects it programs for a connection, so that
+// other chain elements can reference (or clean up) those objects without having to inspect the transaction
+package vppnames
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+)
+
+const (
+	serverPrefix = "server-"
+	clientPrefix = "client-"
+)
+
+// ServerInterface - name of the vpp interface facing the incoming (client) side of conn
+func ServerInterface(conn *networkservice.Connection) string {
+	return serverPrefix + conn.GetId()
+}
+
+// ClientInterface - name of the vpp interface facing the outgoing (endpoint) side of conn, or "" if conn has no
+// next path segment yet
+func ClientInterface(conn *networkservice.Connection) string {
+	path := conn.GetPath()
+	if path == nil || int(path.GetIndex())+1 >= len(path.GetPathSegments()) {
+		return ""
+	}
+	return clientPrefix + path.GetPathSegments()[path.GetIndex()+1].GetId()
+}
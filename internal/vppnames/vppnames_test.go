@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vppnames_test
+
+import (
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppnames"
+)
+
+func TestInterfaceNames(t *testing.T) {
+	conn := &networkservice.Connection{
+		Id: "conn-1",
+		Path: &networkservice.Path{
+			Index:        1,
+			PathSegments: []*networkservice.PathSegment{{Id: "nsc"}, {Id: "conn-1"}, {Id: "next-1"}},
+		},
+	}
+	require.Equal(t, "server-conn-1", vppnames.ServerInterface(conn))
+	require.Equal(t, "client-next-1", vppnames.ClientInterface(conn))
+}
+
+func TestNoNextPathSegment(t *testing.T) {
+	conn := &networkservice.Connection{
+		Id: "conn-1",
+		Path: &networkservice.Path{
+			Index:        1,
+			PathSegments: []*networkservice.PathSegment{{Id: "nsc"}, {Id: "conn-1"}},
+		},
+	}
+	require.Equal(t, "", vppnames.ClientInterface(conn))
+	require.Equal(t, "", vppnames.ClientInterface(&networkservice.Connection{Id: "conn-1"}))
+}
@@ -20,46 +20,30 @@ package main
 
 import (
 	"context"
-	"net"
-	"net/url"
 	"os"
 	"time"
 
 	nested "github.com/antonfisher/nested-logrus-formatter"
-	"github.com/edwarnicke/grpcfd"
 	"github.com/kelseyhightower/envconfig"
-	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
-	"github.com/spiffe/go-spiffe/v2/workloadapi"
-	"google.golang.org/grpc/credentials"
-
-	"github.com/networkservicemesh/sdk-vppagent/pkg/networkservice/chains/xconnectns"
-	"github.com/networkservicemesh/sdk-vppagent/pkg/tools/vppagent"
-
-	"github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
-	"github.com/networkservicemesh/sdk/pkg/tools/spiffejwt"
-
 	"github.com/sirupsen/logrus"
-	"google.golang.org/grpc"
 
 	"github.com/networkservicemesh/sdk/pkg/tools/debug"
-	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
 	"github.com/networkservicemesh/sdk/pkg/tools/signalctx"
 
-	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppinit"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarder"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/handoff"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/startup"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/vppagent"
 )
 
-// Config - configuration for cmd-forwarder-vppagent
-type Config struct {
-	Name             string        `default:"forwarder" desc:"Name of Endpoint"`
-	BaseDir          string        `default:"./" desc:"base directory" split_words:"true"`
-	TunnelIP         net.IP        `desc:"IP to use for tunnels" split_words:"true"`
-	ListenOn         url.URL       `default:"unix:///listen.on.socket" desc:"url to listen on" split_words:"true"`
-	ConnectTo        url.URL       `default:"unix:///connect.to.socket" desc:"url to connect to" split_words:"true"`
-	MaxTokenLifetime time.Duration `default:"24h" desc:"maximum lifetime of tokens" split_words:"true"`
-}
-
 func main() {
+	// vpp and vpp-agent are started through this executable, which applies their process attributes and execs them
+	vppagent.Exec()
+
+	// A first forwarder process that has handed off stays on as the supervisor of vpp and the forwarder it handed to
+	handoff.Supervise(context.Background())
+
 	// ********************************************************************************
 	// setup context to catch signals
 	// ********************************************************************************
@@ -81,6 +65,7 @@ func main() {
 	}
 
 	starttime := time.Now()
+	phases := startup.NewTimer(starttime)
 
 	// enumerating phases
 	log.Entry(ctx).Infof("there are 6 phases which will be executed followed by a success message:")
@@ -94,79 +79,75 @@ func main() {
 
 	// ********************************************************************************
 	log.Entry(ctx).Infof("executing phase 1: get config from environment (time since start: %s)", time.Since(starttime))
+	phases.Begin(ctx, "1")
 	// ********************************************************************************
-	config := &Config{}
+	config := &forwarder.Config{}
 	if err := envconfig.Usage("nsm", config); err != nil {
 		logrus.Fatal(err)
 	}
 	if err := envconfig.Process("nsm", config); err != nil {
 		logrus.Fatalf("error processing config from env: %+v", err)
 	}
-
-	log.Entry(ctx).Infof("Config: %#v", config)
+	if len(os.Args) > 1 && os.Args[1] == forwarder.VPPInitCommand {
+		err := forwarder.VPPInit(ctx, config)
+		cancel()
+		if err != nil {
+			logrus.Fatalf("error running %s: %+v", forwarder.VPPInitCommand, err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == forwarder.TestSuiteCommand {
+		passed, err := forwarder.TestSuite(ctx, config)
+		cancel()
+		if err != nil {
+			logrus.Fatalf("error running %s: %+v", forwarder.TestSuiteCommand, err)
+		}
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+	ctx, f, err := forwarder.New(ctx, cancel, config)
+	if err != nil {
+		logrus.Fatalf("error setting up the forwarder: %+v", err)
+	}
 
 	// ********************************************************************************
 	log.Entry(ctx).Infof("executing phase 2: run vppagent and get a connection to it (time since start: %s)", time.Since(starttime))
+	phases.Begin(ctx, "2")
 	// ********************************************************************************
-	// Run vppagent and get a connection to it
-	vppagentCC, vppagentErrCh := vppagent.StartAndDialContext(ctx)
-	exitOnErr(ctx, cancel, vppagentErrCh)
+	if err = f.StartVPP(ctx); err != nil {
+		logrus.Fatalf("%+v", err)
+	}
 
 	// ********************************************************************************
 	log.Entry(ctx).Infof("executing phase 3: retrieving svid, check spire agent logs if this is the last line you see (time since start: %s)", time.Since(starttime))
+	phases.Begin(ctx, "3")
 	// ********************************************************************************
-	source, err := workloadapi.NewX509Source(ctx)
-	if err != nil {
-		logrus.Fatalf("error getting x509 source: %+v", err)
-	}
-	svid, err := source.GetX509SVID()
-	if err != nil {
-		logrus.Fatalf("error getting x509 svid: %+v", err)
+	if err = f.RetrieveSVID(ctx); err != nil {
+		logrus.Fatalf("%+v", err)
 	}
-	logrus.Infof("SVID: %q", svid.ID)
 
 	// ********************************************************************************
 	log.Entry(ctx).Infof("executing phase 4: create xconnect network service endpoint (time since start: %s)", time.Since(starttime))
+	phases.Begin(ctx, "4")
 	// ********************************************************************************
-	endpoint := xconnectns.NewServer(
-		ctx,
-		config.Name,
-		authorize.NewServer(),
-		spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime),
-		vppagentCC,
-		config.BaseDir,
-		config.TunnelIP,
-		vppinit.Func(config.TunnelIP),
-		&config.ConnectTo,
-		grpc.WithTransportCredentials(grpcfd.TransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeAny())))),
-		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
-	)
+	if err = f.CreateEndpoint(ctx); err != nil {
+		logrus.Fatalf("%+v", err)
+	}
 
 	// ********************************************************************************
 	log.Entry(ctx).Infof("executing phase 5: create grpc server and register xconnect (time since start: %s)", time.Since(starttime))
+	phases.Begin(ctx, "5")
 	// TODO add serveroptions for tracing
 	// ********************************************************************************
-	server := grpc.NewServer(grpc.Creds(grpcfd.TransportCredentials(credentials.NewTLS(tlsconfig.MTLSServerConfig(source, source, tlsconfig.AuthorizeAny())))))
-	endpoint.Register(server)
-	srvErrCh := grpcutils.ListenAndServe(ctx, &config.ListenOn, server)
-	exitOnErr(ctx, cancel, srvErrCh)
+	if err = f.Serve(ctx); err != nil {
+		logrus.Fatalf("%+v", err)
+	}
+	phases.Done(ctx)
 	log.Entry(ctx).Infof("Startup completed in %v", time.Since(starttime))
 
-	<-ctx.Done()
-	<-vppagentErrCh
-}
-
-func exitOnErr(ctx context.Context, cancel context.CancelFunc, errCh <-chan error) {
-	// If we already have an error, log it and exit
-	select {
-	case err := <-errCh:
-		log.Entry(ctx).Fatal(err)
-	default:
+	if err = f.Wait(ctx); err != nil {
+		logrus.Fatalf("cleanup check failed: %+v", err)
 	}
-	// Otherwise wait for an error in the background to log and cancel
-	go func(ctx context.Context, errCh <-chan error) {
-		err := <-errCh
-		log.Entry(ctx).Error(err)
-		cancel()
-	}(ctx, errCh)
 }
@@ -62,7 +62,7 @@ import (
 
 	"github.com/vishvananda/netns"
 
-	main "github.com/networkservicemesh/cmd-forwarder-vppagent"
+	"github.com/networkservicemesh/cmd-forwarder-vppagent/internal/forwarder"
 )
 
 type ForwarderTestSuite struct {
@@ -71,7 +71,7 @@ type ForwarderTestSuite struct {
 	cancel     context.CancelFunc
 	x509source x509svid.Source
 	x509bundle x509bundle.Source
-	config     main.Config
+	config     forwarder.Config
 	spireErrCh <-chan error
 	sutErrCh   <-chan error
 	cc         grpc.ClientConnInterface
@@ -121,7 +121,7 @@ func (f *ForwarderTestSuite) SetupSuite() {
 	clientCreds := credentials.NewTLS(tlsconfig.MTLSClientConfig(f.x509source, f.x509bundle, tlsconfig.AuthorizeAny()))
 	clientCreds = grpcfd.TransportCredentials(clientCreds)
 	f.cc, err = grpc.DialContext(f.ctx,
-		f.config.ListenOn.String(),
+		f.config.ListenOn[0].String(),
 		grpc.WithTransportCredentials(clientCreds),
 		grpc.WithBlock(),
 	)